@@ -0,0 +1,274 @@
+// Package agent runs the tool-calling loop on top of uniai.Client: it calls
+// Chat, executes the requested tools with registered Go handlers, feeds the
+// results back and repeats until the model answers without tool calls.
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/quailyquaily/uniai"
+	"github.com/quailyquaily/uniai/chat"
+)
+
+const (
+	DefaultMaxRounds   = 10
+	DefaultConcurrency = 4
+)
+
+var (
+	// ErrMaxRounds is returned, with the partial Result, when the model still
+	// requests tools after Config.MaxRounds chat rounds. The calls of the last
+	// round are not run; each gets an error tool result instead, so the
+	// partial Result.Messages can still be sent back to continue the run.
+	ErrMaxRounds = errors.New("agent: max rounds reached")
+	// ErrDenied is returned by an ApproveFunc to skip a tool call. The model
+	// receives the denial as the tool result.
+	ErrDenied = errors.New("tool call denied")
+)
+
+// ApproveFunc is called before each tool execution, in call order. Returning
+// nil runs the tool; returning an error wrapping ErrDenied skips it and
+// reports the error to the model; any other error aborts the run.
+type ApproveFunc func(ctx context.Context, call chat.ToolCall) error
+
+// EventType identifies an intermediate Runner event.
+type EventType string
+
+const (
+	// EventResponse is emitted after every chat round with its response.
+	EventResponse EventType = "response"
+	// EventToolCall is emitted when an approved tool starts running.
+	EventToolCall EventType = "tool_call"
+	// EventToolResult is emitted when a tool finishes, with its result
+	// message and handler error, if any.
+	EventToolResult EventType = "tool_result"
+	// EventToolDenied is emitted when the ApproveFunc denies a tool call.
+	EventToolDenied EventType = "tool_denied"
+)
+
+// Event is an intermediate step of Runner.Run.
+type Event struct {
+	Type  EventType
+	Round int
+	// Response is set on EventResponse.
+	Response *chat.Result
+	// ToolCall is set on tool events.
+	ToolCall chat.ToolCall
+	// Message is the tool result message sent to the model, set on
+	// EventToolResult and EventToolDenied.
+	Message *chat.Message
+	Err     error
+}
+
+// EventFunc receives Runner events. Calls are serialized. Returning an error
+// aborts the run.
+type EventFunc func(Event) error
+
+// Config configures a Runner.
+type Config struct {
+	Tools *Registry
+	// MaxRounds caps the number of chat rounds. Defaults to DefaultMaxRounds.
+	MaxRounds int
+	// Concurrency caps the number of tool calls of one round running at
+	// once. Defaults to DefaultConcurrency.
+	Concurrency int
+	Approve     ApproveFunc
+	OnEvent     EventFunc
+}
+
+// Runner runs the tool loop. It is safe for concurrent use.
+type Runner struct {
+	client *uniai.Client
+	cfg    Config
+}
+
+// New returns a Runner using client for chat rounds.
+func New(client *uniai.Client, cfg Config) *Runner {
+	if cfg.MaxRounds <= 0 {
+		cfg.MaxRounds = DefaultMaxRounds
+	}
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = DefaultConcurrency
+	}
+	return &Runner{client: client, cfg: cfg}
+}
+
+// Result is the outcome of Runner.Run.
+type Result struct {
+	// Response is the last chat response; its Text is the final answer.
+	Response *chat.Result
+	// Messages are the turns added by the run, in order: assistant replies,
+	// tool results and the final assistant reply. Every tool call has a
+	// result, also after ErrMaxRounds, so they can be appended to the
+	// conversation to continue it.
+	Messages []chat.Message
+	Rounds   int
+	// Usage is aggregated across rounds. Cost is set only when every round
+	// was priced.
+	Usage chat.Usage
+}
+
+// Run calls Chat with opts and the registered tools until the model replies
+// without tool calls. Options passed to every round are opts followed by the
+// messages added so far; WithTools in opts replaces the registry tools, and
+// WithOnStream streams every round.
+func (r *Runner) Run(ctx context.Context, opts ...chat.Option) (*Result, error) {
+	if r == nil || r.client == nil {
+		return nil, fmt.Errorf("agent: client is nil")
+	}
+	var base []chat.Option
+	if tools := r.cfg.Tools.Tools(); len(tools) > 0 {
+		base = append(base, chat.WithTools(tools))
+	}
+
+	emitter := &emitter{fn: r.cfg.OnEvent}
+	result := &Result{}
+	for round := 1; ; round++ {
+		callOpts := make([]chat.Option, 0, len(base)+len(opts)+1)
+		callOpts = append(callOpts, base...)
+		callOpts = append(callOpts, opts...)
+		callOpts = append(callOpts, chat.WithMessages(result.Messages...))
+		resp, err := r.client.Chat(ctx, callOpts...)
+		if err != nil {
+			return result, err
+		}
+		result.Rounds = round
+		result.Response = resp
+		result.Usage = result.Usage.Add(resp.Usage)
+		result.Messages = append(result.Messages, chat.AssistantReplayMessages(resp)...)
+		if err := emitter.emit(Event{Type: EventResponse, Round: round, Response: resp}); err != nil {
+			return result, err
+		}
+
+		if len(resp.ToolCalls) == 0 {
+			return result, nil
+		}
+		if round >= r.cfg.MaxRounds {
+			for _, call := range resp.ToolCalls {
+				msg, err := errorResult(call, ErrMaxRounds)
+				if err != nil {
+					return result, err
+				}
+				result.Messages = append(result.Messages, msg)
+			}
+			return result, fmt.Errorf("%w (%d)", ErrMaxRounds, r.cfg.MaxRounds)
+		}
+		toolMessages, err := r.runTools(ctx, round, resp.ToolCalls, emitter)
+		if err != nil {
+			return result, err
+		}
+		result.Messages = append(result.Messages, toolMessages...)
+	}
+}
+
+// runTools approves the calls in order, then runs the approved ones
+// concurrently. The returned tool messages follow the call order.
+func (r *Runner) runTools(ctx context.Context, round int, calls []chat.ToolCall, emitter *emitter) ([]chat.Message, error) {
+	messages := make([]chat.Message, len(calls))
+	approved := make([]bool, len(calls))
+	for i, call := range calls {
+		if r.cfg.Approve == nil {
+			approved[i] = true
+			continue
+		}
+		err := r.cfg.Approve(ctx, call)
+		switch {
+		case err == nil:
+			approved[i] = true
+		case errors.Is(err, ErrDenied):
+			msg, encodeErr := errorResult(call, err)
+			if encodeErr != nil {
+				return nil, encodeErr
+			}
+			messages[i] = msg
+			if err := emitter.emit(Event{Type: EventToolDenied, Round: round, ToolCall: call, Message: &msg, Err: err}); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("approve tool %q: %w", call.Function.Name, err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	fail := func(err error) {
+		errOnce.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+	slots := make(chan struct{}, r.cfg.Concurrency)
+	for i, call := range calls {
+		if !approved[i] {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				fail(ctx.Err())
+				return
+			}
+			defer func() { <-slots }()
+			msg, err := r.runTool(ctx, round, call, emitter)
+			if err != nil {
+				fail(err)
+				return
+			}
+			messages[i] = msg
+		}()
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return messages, nil
+}
+
+// runTool runs one approved call. Dispatch errors become the tool result;
+// only event errors are returned.
+func (r *Runner) runTool(ctx context.Context, round int, call chat.ToolCall, emitter *emitter) (chat.Message, error) {
+	if err := emitter.emit(Event{Type: EventToolCall, Round: round, ToolCall: call}); err != nil {
+		return chat.Message{}, err
+	}
+	msg, handleErr := r.cfg.Tools.Dispatch(ctx, call)
+	if handleErr != nil {
+		var err error
+		msg, err = errorResult(call, handleErr)
+		if err != nil {
+			return chat.Message{}, err
+		}
+	}
+	if err := emitter.emit(Event{Type: EventToolResult, Round: round, ToolCall: call, Message: &msg, Err: handleErr}); err != nil {
+		return chat.Message{}, err
+	}
+	return msg, nil
+}
+
+func errorResult(call chat.ToolCall, err error) (chat.Message, error) {
+	return chat.ToolResultValue(call.ID, map[string]string{"error": err.Error()})
+}
+
+type emitter struct {
+	mu sync.Mutex
+	fn EventFunc
+}
+
+func (e *emitter) emit(ev Event) error {
+	if e.fn == nil {
+		return nil
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.fn(ev)
+}
@@ -0,0 +1,204 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/quailyquaily/uniai"
+	"github.com/quailyquaily/uniai/chat"
+)
+
+const toolCallsReply = `{"id":"c1","object":"chat.completion","model":"gpt-test","choices":[{"index":0,"finish_reason":"tool_calls","message":{"role":"assistant","content":null,"tool_calls":[` +
+	`{"id":"call_1","type":"function","function":{"name":"weather","arguments":"{\"city\":\"Paris\"}"}},` +
+	`{"id":"call_2","type":"function","function":{"name":"weather","arguments":"{\"city\":\"Oslo\"}"}},` +
+	`{"id":"call_3","type":"function","function":{"name":"delete_all","arguments":"{}"}}]}}],"usage":{"prompt_tokens":10,"completion_tokens":5,"total_tokens":15}}`
+
+const finalReply = `{"id":"c2","object":"chat.completion","model":"gpt-test","choices":[{"index":0,"finish_reason":"stop","message":{"role":"assistant","content":"Sunny in both."}}],"usage":{"prompt_tokens":30,"completion_tokens":4,"total_tokens":34}}`
+
+func newTestClient(t *testing.T, replies ...string) (*uniai.Client, *[]map[string]any) {
+	t.Helper()
+	var (
+		mu     sync.Mutex
+		bodies []map[string]any
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		bodies = append(bodies, body)
+		reply := replies[min(len(bodies), len(replies))-1]
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, reply)
+	}))
+	t.Cleanup(server.Close)
+	client := uniai.New(uniai.Config{Provider: "openai", OpenAIAPIKey: "test-key", OpenAIAPIBase: server.URL + "/v1", OpenAIModel: "gpt-test"})
+	return client, &bodies
+}
+
+func newWeatherRegistry(t *testing.T, running, peak *atomic.Int32) *Registry {
+	t.Helper()
+	tools := NewRegistry()
+	weather := func(ctx context.Context, args json.RawMessage) (any, error) {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			old := peak.Load()
+			if n <= old || peak.CompareAndSwap(old, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		var in struct {
+			City string `json:"city"`
+		}
+		if err := json.Unmarshal(args, &in); err != nil {
+			return nil, err
+		}
+		return map[string]string{"city": in.City, "sky": "sunny"}, nil
+	}
+	tools.MustRegister(chat.FunctionTool("weather", "Current weather", []byte(`{"type":"object","properties":{"city":{"type":"string"}}}`)), weather)
+	tools.MustRegister(chat.FunctionTool("delete_all", "Delete everything", []byte(`{"type":"object"}`)), func(context.Context, json.RawMessage) (any, error) {
+		return nil, errors.New("refused")
+	})
+	return tools
+}
+
+func TestRunExecutesToolsAndAggregatesUsage(t *testing.T) {
+	client, bodies := newTestClient(t, toolCallsReply, finalReply)
+	var running, peak atomic.Int32
+	var events []EventType
+	runner := New(client, Config{
+		Tools:       newWeatherRegistry(t, &running, &peak),
+		Concurrency: 2,
+		Approve: func(ctx context.Context, call chat.ToolCall) error {
+			if call.Function.Name == "delete_all" {
+				return fmt.Errorf("%w: destructive", ErrDenied)
+			}
+			return nil
+		},
+		OnEvent: func(ev Event) error {
+			events = append(events, ev.Type)
+			return nil
+		},
+	})
+
+	result, err := runner.Run(context.Background(), chat.WithMessages(chat.User("weather in Paris and Oslo?")))
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if result.Response.Text != "Sunny in both." || result.Rounds != 2 {
+		t.Fatalf("unexpected result: %q after %d rounds", result.Response.Text, result.Rounds)
+	}
+	if result.Usage.InputTokens != 40 || result.Usage.OutputTokens != 9 || result.Usage.TotalTokens != 49 {
+		t.Fatalf("unexpected aggregated usage: %#v", result.Usage)
+	}
+	if peak.Load() != 2 {
+		t.Fatalf("expected both weather calls to run concurrently, peak %d", peak.Load())
+	}
+
+	// assistant tool calls, three tool results, final answer
+	if len(result.Messages) != 5 {
+		t.Fatalf("unexpected messages: %#v", result.Messages)
+	}
+	wantContent := []string{
+		`{"city":"Paris","sky":"sunny"}`,
+		`{"city":"Oslo","sky":"sunny"}`,
+		`{"error":"tool call denied: destructive"}`,
+	}
+	for i, want := range wantContent {
+		msg := result.Messages[i+1]
+		if msg.Role != chat.RoleTool || msg.ToolCallID != fmt.Sprintf("call_%d", i+1) || msg.Content != want {
+			t.Fatalf("tool message %d: %#v", i, msg)
+		}
+	}
+
+	if len(*bodies) != 2 {
+		t.Fatalf("expected two chat rounds, got %d", len(*bodies))
+	}
+	if tools := (*bodies)[0]["tools"].([]any); len(tools) != 2 {
+		t.Fatalf("expected registry tools in the request, got %#v", tools)
+	}
+	if messages := (*bodies)[1]["messages"].([]any); len(messages) != 5 {
+		t.Fatalf("expected the replayed turn and tool results, got %#v", messages)
+	}
+
+	counts := map[EventType]int{}
+	for _, ev := range events {
+		counts[ev]++
+	}
+	if counts[EventResponse] != 2 || counts[EventToolCall] != 2 || counts[EventToolResult] != 2 || counts[EventToolDenied] != 1 {
+		t.Fatalf("unexpected events: %v", events)
+	}
+}
+
+func TestRunStopsAtMaxRounds(t *testing.T) {
+	client, bodies := newTestClient(t, toolCallsReply)
+	var running, peak atomic.Int32
+	runner := New(client, Config{Tools: newWeatherRegistry(t, &running, &peak), MaxRounds: 2, Concurrency: 1})
+
+	result, err := runner.Run(context.Background(),
+		chat.WithMessages(chat.User("loop")),
+		chat.WithTools([]chat.Tool{chat.FunctionTool("weather", "Current weather", []byte(`{"type":"object"}`))}),
+	)
+	if !errors.Is(err, ErrMaxRounds) {
+		t.Fatalf("expected max rounds error, got %v", err)
+	}
+	if result.Rounds != 2 || len(*bodies) != 2 {
+		t.Fatalf("expected two rounds, got %d (%d requests)", result.Rounds, len(*bodies))
+	}
+	if peak.Load() != 1 {
+		t.Fatalf("expected tool calls to run one at a time, peak %d", peak.Load())
+	}
+	if got := result.Messages[3].Content; got != `{"error":"refused"}` {
+		t.Fatalf("expected the handler error as tool result, got %s", got)
+	}
+	if tools := (*bodies)[0]["tools"].([]any); len(tools) != 1 {
+		t.Fatalf("expected WithTools to replace the registry tools, got %#v", tools)
+	}
+}
+
+func TestRunContinuesAfterMaxRounds(t *testing.T) {
+	client, bodies := newTestClient(t, toolCallsReply, finalReply)
+	var running, peak atomic.Int32
+	runner := New(client, Config{Tools: newWeatherRegistry(t, &running, &peak), MaxRounds: 1})
+
+	conversation := []chat.Message{chat.User("weather in Paris and Oslo?")}
+	result, err := runner.Run(context.Background(), chat.WithMessages(conversation...))
+	if !errors.Is(err, ErrMaxRounds) {
+		t.Fatalf("expected max rounds error, got %v", err)
+	}
+	if running.Load() != 0 || peak.Load() != 0 {
+		t.Fatalf("expected the last round's tools not to run, peak %d", peak.Load())
+	}
+	// assistant tool calls and one stub result per call
+	if len(result.Messages) != 4 {
+		t.Fatalf("unexpected messages: %#v", result.Messages)
+	}
+	for i, msg := range result.Messages[1:] {
+		if msg.Role != chat.RoleTool || msg.ToolCallID != fmt.Sprintf("call_%d", i+1) || msg.Content != `{"error":"agent: max rounds reached"}` {
+			t.Fatalf("stub tool message %d: %#v", i, msg)
+		}
+	}
+
+	conversation = append(conversation, result.Messages...)
+	result, err = runner.Run(context.Background(), chat.WithMessages(conversation...))
+	if err != nil {
+		t.Fatalf("continue: %v", err)
+	}
+	if result.Response.Text != "Sunny in both." {
+		t.Fatalf("unexpected continued result: %q", result.Response.Text)
+	}
+	if messages := (*bodies)[1]["messages"].([]any); len(messages) != 5 {
+		t.Fatalf("expected the continued request to carry every tool result, got %#v", messages)
+	}
+}
@@ -0,0 +1,84 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/quailyquaily/uniai/chat"
+	"github.com/quailyquaily/uniai/internal/jsonschema"
+)
+
+// Func builds a tool definition and its handler from a typed Go function.
+//
+// The parameters schema is reflected from Args, which must be a struct:
+// fields are named by their json tags, nested structs, slices and maps are
+// described recursively, omitempty and pointer fields are optional and
+// nullable, and `description:"..."`, `enum:"a,b"`, `min:"..."` and
+// `max:"..."` tags document and restrict values. When Args fits the OpenAI
+// strict subset (no maps or interface fields) the tool is marked strict and
+// every property is listed as required, optional ones accepting null;
+// otherwise Strict is false. Strict mode rejects string length bounds, so
+// those are left out of a strict schema and enforced by the handler only.
+//
+// The handler validates the call arguments against the schema, allowing
+// optional fields to be omitted, decodes them into Args and returns fn's
+// result, which is encoded with chat.ToolResultValue.
+func Func[Args, Result any](name, description string, fn func(context.Context, Args) (Result, error)) (chat.Tool, Handler, error) {
+	if fn == nil {
+		return chat.Tool{}, nil, fmt.Errorf("tool %q: function is nil", name)
+	}
+	argsType := reflect.TypeFor[Args]()
+	structType := argsType
+	for structType.Kind() == reflect.Pointer {
+		structType = structType.Elem()
+	}
+	if structType.Kind() != reflect.Struct {
+		return chat.Tool{}, nil, fmt.Errorf("tool %q: arguments must be a struct, got %s", name, argsType)
+	}
+
+	// Arguments are validated against the relaxed schema: providers that do
+	// not enforce strict schemas may omit optional fields.
+	relaxed, err := jsonschema.Reflect(argsType, false)
+	if err != nil {
+		return chat.Tool{}, nil, fmt.Errorf("tool %q: %w", name, err)
+	}
+	strict := true
+	schema, err := jsonschema.Reflect(argsType, true)
+	if err != nil {
+		strict = false
+		schema = relaxed
+	}
+	params, err := json.Marshal(schema)
+	if err != nil {
+		return chat.Tool{}, nil, fmt.Errorf("tool %q: %w", name, err)
+	}
+	tool := chat.FunctionTool(name, description, params)
+	tool.Function.Strict = &strict
+
+	handler := func(ctx context.Context, raw json.RawMessage) (any, error) {
+		var value any
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return nil, fmt.Errorf("invalid arguments: %w", err)
+		}
+		if err := jsonschema.Validate(relaxed, value); err != nil {
+			return nil, fmt.Errorf("invalid arguments: %w", err)
+		}
+		var args Args
+		if err := json.Unmarshal(raw, &args); err != nil {
+			return nil, fmt.Errorf("invalid arguments: %w", err)
+		}
+		return fn(ctx, args)
+	}
+	return tool, handler, nil
+}
+
+// RegisterFunc builds a tool with Func and registers it.
+func RegisterFunc[Args, Result any](r *Registry, name, description string, fn func(context.Context, Args) (Result, error)) error {
+	tool, handler, err := Func(name, description, fn)
+	if err != nil {
+		return err
+	}
+	return r.Register(tool, handler)
+}
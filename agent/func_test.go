@@ -0,0 +1,168 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/quailyquaily/uniai/chat"
+)
+
+type searchArgs struct {
+	Query  string   `json:"query" description:"Search terms" min:"1"`
+	Limit  *int     `json:"limit" min:"1" max:"20"`
+	Filter *filter  `json:"filter,omitempty"`
+	Sort   string   `json:"sort,omitempty" enum:"relevance,date"`
+	Tags   []string `json:"tags,omitempty"`
+}
+
+type filter struct {
+	Lang string `json:"lang"`
+}
+
+type searchResult struct {
+	Hits []string `json:"hits"`
+}
+
+func search(ctx context.Context, args searchArgs) (searchResult, error) {
+	limit := 3
+	if args.Limit != nil {
+		limit = *args.Limit
+	}
+	hits := []string{}
+	for i := 0; i < limit; i++ {
+		hits = append(hits, args.Query)
+	}
+	return searchResult{Hits: hits}, nil
+}
+
+func TestFuncBuildsStrictTool(t *testing.T) {
+	tool, _, err := Func("search", "Search the docs", search)
+	if err != nil {
+		t.Fatalf("func: %v", err)
+	}
+	if tool.Type != "function" || tool.Function.Name != "search" || tool.Function.Strict == nil || !*tool.Function.Strict {
+		t.Fatalf("unexpected tool: %#v", tool)
+	}
+	want := `{"additionalProperties":false,"properties":{"filter":{"additionalProperties":false,"properties":{"lang":{"type":"string"}},"required":["lang"],"type":["object","null"]},"limit":{"maximum":20,"minimum":1,"type":["integer","null"]},"query":{"description":"Search terms","type":"string"},"sort":{"enum":["relevance","date",null],"type":["string","null"]},"tags":{"items":{"type":"string"},"type":["array","null"]}},"required":["query","limit","filter","sort","tags"],"type":"object"}`
+	if got := string(tool.Function.ParametersJSONSchema); got != want {
+		t.Fatalf("unexpected schema:\n got %s\nwant %s", got, want)
+	}
+}
+
+// strictKeywords are the JSON Schema keywords OpenAI strict mode accepts.
+var strictKeywords = map[string]bool{
+	"type": true, "properties": true, "required": true, "additionalProperties": true,
+	"items": true, "enum": true, "description": true, "format": true, "pattern": true,
+	"minimum": true, "maximum": true, "exclusiveMinimum": true, "exclusiveMaximum": true,
+	"multipleOf": true, "minItems": true, "maxItems": true, "anyOf": true,
+}
+
+func checkStrictKeywords(t *testing.T, schema map[string]any, path string) {
+	t.Helper()
+	for key, value := range schema {
+		if !strictKeywords[key] {
+			t.Errorf("%s: keyword %q is not supported in strict mode", path, key)
+		}
+		switch key {
+		case "properties":
+			for name, prop := range value.(map[string]any) {
+				checkStrictKeywords(t, prop.(map[string]any), path+"."+name)
+			}
+		case "items":
+			checkStrictKeywords(t, value.(map[string]any), path+"[]")
+		}
+	}
+}
+
+func TestFuncStrictSchemaUsesSupportedKeywords(t *testing.T) {
+	type upload struct {
+		Name    string   `json:"name" min:"1" max:"10"`
+		Content []byte   `json:"content"`
+		Labels  []string `json:"labels,omitempty" max:"3"`
+	}
+	tool, handler, err := Func("upload", "Upload a file", func(ctx context.Context, args upload) (int, error) {
+		return len(args.Content), nil
+	})
+	if err != nil {
+		t.Fatalf("func: %v", err)
+	}
+	if tool.Function.Strict == nil || !*tool.Function.Strict {
+		t.Fatalf("expected a strict tool, got %#v", tool.Function.Strict)
+	}
+	var schema map[string]any
+	if err := json.Unmarshal(tool.Function.ParametersJSONSchema, &schema); err != nil {
+		t.Fatalf("decode schema: %v", err)
+	}
+	checkStrictKeywords(t, schema, "parameters")
+
+	// The handler still enforces the bounds left out of the schema.
+	if _, err := handler(context.Background(), json.RawMessage(`{"name":"a-very-long-name","content":"aGk="}`)); err == nil || !strings.Contains(err.Error(), "name") {
+		t.Fatalf("expected a length error, got %v", err)
+	}
+	if got, err := handler(context.Background(), json.RawMessage(`{"name":"hi.txt","content":"aGk="}`)); err != nil || got != 2 {
+		t.Fatalf("handler = %v, %v", got, err)
+	}
+}
+
+func TestFuncFallsBackToNonStrictForMaps(t *testing.T) {
+	type labels struct {
+		Labels map[string]string `json:"labels"`
+	}
+	tool, _, err := Func("label", "Set labels", func(ctx context.Context, args labels) (int, error) {
+		return len(args.Labels), nil
+	})
+	if err != nil {
+		t.Fatalf("func: %v", err)
+	}
+	if tool.Function.Strict == nil || *tool.Function.Strict {
+		t.Fatalf("expected a non-strict tool, got %#v", tool.Function.Strict)
+	}
+	if !strings.Contains(string(tool.Function.ParametersJSONSchema), `"labels":{"additionalProperties":{"type":"string"},"type":"object"}`) {
+		t.Fatalf("unexpected schema: %s", tool.Function.ParametersJSONSchema)
+	}
+
+	if _, _, err := Func("bad", "", func(ctx context.Context, args string) (int, error) { return 0, nil }); err == nil {
+		t.Fatalf("expected an error for non-struct arguments")
+	}
+}
+
+func TestRegistryDispatch(t *testing.T) {
+	tools := NewRegistry()
+	if err := RegisterFunc(tools, "search", "Search the docs", search); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	if err := RegisterFunc(tools, "search", "again", search); err == nil {
+		t.Fatalf("expected duplicate registration to fail")
+	}
+	ctx := context.Background()
+
+	// Optional fields may be omitted or null, as strict providers send them.
+	for _, args := range []string{`{"query":"go","limit":2}`, `{"query":"go","limit":2,"filter":null,"sort":null,"tags":null}`} {
+		msg, err := tools.Dispatch(ctx, chat.ToolCall{ID: "call_1", Function: chat.ToolCallFunction{Name: "search", Arguments: args}})
+		if err != nil {
+			t.Fatalf("dispatch %s: %v", args, err)
+		}
+		if msg.Role != chat.RoleTool || msg.ToolCallID != "call_1" || msg.Content != `{"hits":["go","go"]}` {
+			t.Fatalf("unexpected message: %#v", msg)
+		}
+	}
+
+	_, err := tools.Dispatch(ctx, chat.ToolCall{ID: "call_2", Function: chat.ToolCallFunction{Name: "search", Arguments: `{"query":"go","limit":50}`}})
+	if err == nil || err.Error() != "invalid arguments: limit: value 50 is greater than 20" {
+		t.Fatalf("expected a validation error, got %v", err)
+	}
+	_, err = tools.Dispatch(ctx, chat.ToolCall{ID: "call_3", Function: chat.ToolCallFunction{Name: "search", Arguments: `{"query":"go","sort":"stars"}`}})
+	if err == nil || !strings.Contains(err.Error(), `sort: "stars" is not one of`) {
+		t.Fatalf("expected an enum error, got %v", err)
+	}
+	if _, err := tools.Dispatch(ctx, chat.ToolCall{Function: chat.ToolCallFunction{Name: "missing"}}); err == nil {
+		t.Fatalf("expected an unknown tool error")
+	}
+
+	var raw map[string]any
+	if err := json.Unmarshal(tools.Tools()[0].Function.ParametersJSONSchema, &raw); err != nil || raw["type"] != "object" {
+		t.Fatalf("expected the registered schema, got %v %v", raw, err)
+	}
+}
@@ -0,0 +1,103 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/quailyquaily/uniai/chat"
+)
+
+// Handler runs one tool call. args holds the raw JSON arguments sent by the
+// model. The returned value is sent back with chat.ToolResultValue; a
+// returned error is reported to the model as {"error": "..."} and the run
+// continues.
+type Handler func(ctx context.Context, args json.RawMessage) (any, error)
+
+// Registry maps tool names to their definitions and handlers. It is safe for
+// concurrent use.
+type Registry struct {
+	mu       sync.RWMutex
+	tools    []chat.Tool
+	handlers map[string]Handler
+}
+
+// NewRegistry returns an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{handlers: make(map[string]Handler)}
+}
+
+// Register adds a tool. The tool name must be unique.
+func (r *Registry) Register(tool chat.Tool, handler Handler) error {
+	name := strings.TrimSpace(tool.Function.Name)
+	if name == "" {
+		return fmt.Errorf("tool name is required")
+	}
+	if handler == nil {
+		return fmt.Errorf("tool %q: handler is nil", name)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.handlers[name]; ok {
+		return fmt.Errorf("tool %q is already registered", name)
+	}
+	if tool.Type == "" {
+		tool.Type = "function"
+	}
+	r.tools = append(r.tools, chat.CloneTools([]chat.Tool{tool})...)
+	r.handlers[name] = handler
+	return nil
+}
+
+// MustRegister is like Register but panics on error.
+func (r *Registry) MustRegister(tool chat.Tool, handler Handler) {
+	if err := r.Register(tool, handler); err != nil {
+		panic(err)
+	}
+}
+
+// Tools returns the registered tool definitions in registration order.
+func (r *Registry) Tools() []chat.Tool {
+	if r == nil {
+		return nil
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return chat.CloneTools(r.tools)
+}
+
+// Handler returns the handler registered for name.
+func (r *Registry) Handler(name string) (Handler, bool) {
+	if r == nil {
+		return nil, false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	handler, ok := r.handlers[name]
+	return handler, ok
+}
+
+// Dispatch runs the handler registered for call and encodes its result with
+// chat.ToolResultValue. Empty arguments are passed as {}. It returns an
+// error for unknown tools, handler errors and unencodable results.
+func (r *Registry) Dispatch(ctx context.Context, call chat.ToolCall) (chat.Message, error) {
+	handler, ok := r.Handler(call.Function.Name)
+	if !ok {
+		return chat.Message{}, fmt.Errorf("unknown tool %q", call.Function.Name)
+	}
+	args := json.RawMessage(call.Function.Arguments)
+	if strings.TrimSpace(call.Function.Arguments) == "" {
+		args = json.RawMessage("{}")
+	}
+	value, err := handler(ctx, args)
+	if err != nil {
+		return chat.Message{}, err
+	}
+	msg, err := chat.ToolResultValue(call.ID, value)
+	if err != nil {
+		return chat.Message{}, fmt.Errorf("encode result: %w", err)
+	}
+	return msg, nil
+}
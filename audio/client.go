@@ -4,15 +4,21 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"strings"
 
+	"github.com/quailyquaily/uniai/internal/httputil"
 	"github.com/quailyquaily/uniai/internal/providers/cloudflare"
+	"github.com/quailyquaily/uniai/internal/retry"
 )
 
 type Config struct {
 	CloudflareAccountID string
 	CloudflareAPIToken  string
 	CloudflareAPIBase   string
+
+	Retry      retry.Policy
+	HTTPClient *http.Client
 }
 
 type Client struct {
@@ -24,14 +30,16 @@ func New(cfg Config) *Client {
 }
 
 func (c *Client) Create(ctx context.Context, opts ...Option) (*Result, error) {
-	req := BuildRequest(opts...)
-	provider := req.Provider
-	if provider == "" {
-		provider = pickProviderByModel(req.Model)
-	}
-	if provider == "" {
-		return nil, fmt.Errorf("provider not set")
+	return c.Do(ctx, BuildRequest(opts...))
+}
+
+// Do runs a built request. An empty Provider is resolved from the model.
+func (c *Client) Do(ctx context.Context, req *Request) (*Result, error) {
+	if err := ResolveProvider(req); err != nil {
+		return nil, err
 	}
+	ctx = httputil.WithClient(ctx, c.cfg.HTTPClient)
+	provider := req.Provider
 
 	var (
 		respData []byte
@@ -39,7 +47,10 @@ func (c *Client) Create(ctx context.Context, opts ...Option) (*Result, error) {
 	)
 	switch provider {
 	case "cloudflare":
-		respData, err = cloudflare.Transcribe(ctx, c.cfg.CloudflareAPIToken, c.cfg.CloudflareAPIBase, c.cfg.CloudflareAccountID, req.Model, req.Audio, req.Options.Cloudflare)
+		_, err = retry.Do(ctx, c.cfg.Retry, func() (err error) {
+			respData, err = cloudflare.Transcribe(ctx, c.cfg.CloudflareAPIToken, c.cfg.CloudflareAPIBase, c.cfg.CloudflareAccountID, req.Model, req.Audio, req.Options.Cloudflare)
+			return err
+		})
 	default:
 		return nil, fmt.Errorf("unknown provider: %s", provider)
 	}
@@ -54,6 +65,17 @@ func (c *Client) Create(ctx context.Context, opts ...Option) (*Result, error) {
 	return &out, nil
 }
 
+// ResolveProvider sets req.Provider from the model when it is empty.
+func ResolveProvider(req *Request) error {
+	if req.Provider == "" {
+		req.Provider = pickProviderByModel(req.Model)
+	}
+	if req.Provider == "" {
+		return fmt.Errorf("provider not set")
+	}
+	return nil
+}
+
 func pickProviderByModel(model string) string {
 	if strings.HasPrefix(model, "@cf/") {
 		return "cloudflare"
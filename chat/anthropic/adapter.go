@@ -0,0 +1,483 @@
+// Package anthropic serves Anthropic Messages API requests with a uniai
+// client, so callers written against the Messages API can use any provider.
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lyricat/goutils/structs"
+	uniai "github.com/quailyquaily/uniai"
+	"github.com/quailyquaily/uniai/chat"
+	"github.com/quailyquaily/uniai/internal/anthropicapi"
+)
+
+type Client struct {
+	base *uniai.Client
+}
+
+func New(client *uniai.Client) *Client {
+	return &Client{base: client}
+}
+
+// CreateMessage runs req through the uniai client and returns an
+// Anthropic-shaped response. Options in opts are applied after the converted
+// request, e.g. to route it to another provider or model.
+//
+// Thinking blocks are returned when the provider reports reasoning. They
+// carry a signature only when the upstream is Anthropic.
+func (c *Client) CreateMessage(ctx context.Context, req MessagesRequest, opts ...chat.Option) (*MessagesResponse, error) {
+	converted, err := toChatOptions(req)
+	if err != nil {
+		return nil, err
+	}
+	result, err := c.base.Chat(ctx, append(converted, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+	return toMessagesResponse(result, req.Model), nil
+}
+
+// ChatOptions converts req to uniai chat options.
+//
+// tool_result blocks become tool messages, and thinking blocks in assistant
+// turns are replayed as Message.ReasoningContent; their signatures are
+// dropped. cache_control is kept on system, text, image and tool
+// definitions; providers without explicit cache control reject it, see
+// WithoutCacheControl.
+func ChatOptions(req MessagesRequest) ([]chat.Option, error) {
+	return toChatOptions(req)
+}
+
+// WithoutCacheControl removes explicit cache control from the request, for
+// routing Messages requests that carry cache_control to providers that do
+// not support it. Pass it after the converted options.
+func WithoutCacheControl() chat.Option {
+	return func(r *chat.Request) {
+		for i := range r.Messages {
+			for j := range r.Messages[i].Parts {
+				r.Messages[i].Parts[j].CacheControl = nil
+			}
+		}
+		for i := range r.Tools {
+			r.Tools[i].CacheControl = nil
+		}
+	}
+}
+
+func toChatOptions(req MessagesRequest) ([]chat.Option, error) {
+	opts := []chat.Option{}
+	if req.Model != "" {
+		opts = append(opts, chat.WithModel(req.Model))
+	}
+
+	msgs := make([]chat.Message, 0, len(req.Messages)+1)
+	if len(req.System) > 0 {
+		parts, err := toParts(req.System, "system")
+		if err != nil {
+			return nil, err
+		}
+		if len(parts) > 0 {
+			msgs = append(msgs, chat.SystemParts(parts...))
+		}
+	}
+	for i, m := range req.Messages {
+		converted, err := toChatMessages(m)
+		if err != nil {
+			return nil, fmt.Errorf("messages[%d]: %w", i, err)
+		}
+		msgs = append(msgs, converted...)
+	}
+	if len(msgs) > 0 {
+		opts = append(opts, chat.WithMessages(msgs...))
+	}
+
+	if req.MaxTokens > 0 {
+		opts = append(opts, chat.WithMaxTokens(req.MaxTokens))
+	}
+	if req.Temperature != nil {
+		opts = append(opts, chat.WithTemperature(*req.Temperature))
+	}
+	if req.TopP != nil {
+		opts = append(opts, chat.WithTopP(*req.TopP))
+	}
+	if len(req.StopSequences) > 0 {
+		opts = append(opts, chat.WithStopWords(req.StopSequences...))
+	}
+	if req.Metadata != nil && req.Metadata.UserID != "" {
+		opts = append(opts, chat.WithUser(req.Metadata.UserID))
+	}
+
+	if len(req.Tools) > 0 {
+		tools, err := toTools(req.Tools)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, chat.WithTools(tools))
+	}
+	if req.ToolChoice != nil {
+		choice, err := toToolChoice(*req.ToolChoice)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, chat.WithToolChoice(choice))
+	}
+
+	if req.Thinking != nil {
+		switch req.Thinking.Type {
+		case "enabled":
+			opts = append(opts, chat.WithReasoningBudgetTokens(req.Thinking.BudgetTokens), chat.WithReasoningDetails())
+		case "adaptive":
+			opts = append(opts, chat.WithReasoningDetails())
+		case "disabled", "":
+		default:
+			return nil, fmt.Errorf("unsupported thinking type %q", req.Thinking.Type)
+		}
+	}
+
+	if extra := toAnthropicOptions(req); len(extra) > 0 {
+		opts = append(opts, chat.WithAnthropicOptions(extra))
+	}
+	return opts, nil
+}
+
+// toAnthropicOptions keeps the fields without a provider-neutral option for
+// Anthropic upstreams.
+func toAnthropicOptions(req MessagesRequest) structs.JSONMap {
+	opts := structs.NewJSONMap()
+	if req.TopK != nil {
+		opts["top_k"] = *req.TopK
+	}
+	if req.Metadata != nil && req.Metadata.UserID != "" {
+		opts["user_id"] = req.Metadata.UserID
+	}
+	if len(opts) == 0 {
+		return nil
+	}
+	return opts
+}
+
+// toChatMessages converts one Messages API turn. A user turn holding
+// tool_result blocks becomes one tool message per result, followed by a user
+// message with the remaining blocks, if any.
+func toChatMessages(m Message) ([]chat.Message, error) {
+	switch m.Role {
+	case "user":
+		out := []chat.Message{}
+		rest := make(Content, 0, len(m.Content))
+		for _, block := range m.Content {
+			if block.Type != "tool_result" {
+				rest = append(rest, block)
+				continue
+			}
+			msg, err := toToolResult(block)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, msg)
+		}
+		if len(rest) == 0 {
+			if len(out) == 0 {
+				return nil, fmt.Errorf("user message has no content")
+			}
+			return out, nil
+		}
+		parts, err := toParts(rest, m.Role)
+		if err != nil {
+			return nil, err
+		}
+		return append(out, chat.UserParts(parts...)), nil
+	case "assistant":
+		msg := chat.Message{Role: chat.RoleAssistant}
+		text := make(Content, 0, len(m.Content))
+		reasoning := make([]string, 0, 1)
+		for _, block := range m.Content {
+			switch block.Type {
+			case "tool_use":
+				call, err := toToolCall(block)
+				if err != nil {
+					return nil, err
+				}
+				msg.ToolCalls = append(msg.ToolCalls, call)
+			case "thinking":
+				if strings.TrimSpace(block.Thinking) != "" {
+					reasoning = append(reasoning, block.Thinking)
+				}
+			case "redacted_thinking":
+			default:
+				text = append(text, block)
+			}
+		}
+		parts, err := toParts(text, m.Role)
+		if err != nil {
+			return nil, err
+		}
+		msg.Parts = parts
+		msg.ReasoningContent = strings.Join(reasoning, "\n")
+		return []chat.Message{msg}, nil
+	default:
+		return nil, fmt.Errorf("unsupported role %q", m.Role)
+	}
+}
+
+func toParts(content Content, role string) ([]chat.Part, error) {
+	parts := make([]chat.Part, 0, len(content))
+	for _, block := range content {
+		ctrl, err := toCacheControl(block.CacheControl)
+		if err != nil {
+			return nil, err
+		}
+		switch block.Type {
+		case "text":
+			if strings.TrimSpace(block.Text) == "" {
+				continue
+			}
+			part := chat.TextPart(block.Text)
+			part.CacheControl = ctrl
+			parts = append(parts, part)
+		case "image":
+			if role != "user" {
+				return nil, fmt.Errorf("%s content does not support image blocks", role)
+			}
+			part, err := toImagePart(block.Source)
+			if err != nil {
+				return nil, err
+			}
+			part.CacheControl = ctrl
+			parts = append(parts, part)
+		case "document":
+			if role != "user" {
+				return nil, fmt.Errorf("%s content does not support document blocks", role)
+			}
+			part, err := toDocumentPart(block)
+			if err != nil {
+				return nil, err
+			}
+			part.CacheControl = ctrl
+			parts = append(parts, part)
+		default:
+			return nil, fmt.Errorf("%s content does not support %q blocks", role, block.Type)
+		}
+	}
+	return parts, nil
+}
+
+func toImagePart(source *Source) (chat.Part, error) {
+	if source == nil {
+		return chat.Part{}, fmt.Errorf("image block requires a source")
+	}
+	switch source.Type {
+	case "base64":
+		if source.Data == "" {
+			return chat.Part{}, fmt.Errorf("base64 image source requires data")
+		}
+		return chat.ImageBase64Part(source.MediaType, source.Data), nil
+	case "url":
+		if source.URL == "" {
+			return chat.Part{}, fmt.Errorf("url image source requires url")
+		}
+		return chat.ImageURLPart(source.URL), nil
+	default:
+		return chat.Part{}, fmt.Errorf("unsupported image source type %q", source.Type)
+	}
+}
+
+// toDocumentPart converts a document block with a base64, url or text
+// source. Content sources and file IDs are not supported.
+func toDocumentPart(block ContentBlock) (chat.Part, error) {
+	source := block.Source
+	if source == nil {
+		return chat.Part{}, fmt.Errorf("document block requires a source")
+	}
+	var part chat.Part
+	switch source.Type {
+	case "base64":
+		if source.Data == "" {
+			return chat.Part{}, fmt.Errorf("base64 document source requires data")
+		}
+		part = chat.DocumentBase64Part(source.MediaType, source.Data)
+	case "url":
+		if source.URL == "" {
+			return chat.Part{}, fmt.Errorf("url document source requires url")
+		}
+		part = chat.DocumentURLPart(source.URL)
+	case "text":
+		if strings.TrimSpace(source.Data) == "" {
+			return chat.Part{}, fmt.Errorf("text document source requires data")
+		}
+		part = chat.DocumentTextPart("", source.Data)
+	default:
+		return chat.Part{}, fmt.Errorf("unsupported document source type %q", source.Type)
+	}
+	part.Title = block.Title
+	if len(block.Citations) > 0 {
+		var citations anthropicapi.Citations
+		if err := json.Unmarshal(block.Citations, &citations); err != nil {
+			return chat.Part{}, fmt.Errorf("document citations: %w", err)
+		}
+		part.Citations = citations.Enabled
+	}
+	return part, nil
+}
+
+func toCacheControl(ctrl *CacheControl) (*chat.CacheControl, error) {
+	if ctrl == nil {
+		return nil, nil
+	}
+	if ctrl.Type != "ephemeral" {
+		return nil, fmt.Errorf("unsupported cache_control type %q", ctrl.Type)
+	}
+	return &chat.CacheControl{TTL: ctrl.TTL}, nil
+}
+
+// toToolResult converts a tool_result block to a tool message. The content
+// must be text; is_error has no provider-neutral equivalent and is dropped.
+func toToolResult(block ContentBlock) (chat.Message, error) {
+	if block.ToolUseID == "" {
+		return chat.Message{}, fmt.Errorf("tool_result requires tool_use_id")
+	}
+	texts := make([]string, 0, len(block.Content))
+	for _, inner := range block.Content {
+		if inner.Type != "text" {
+			return chat.Message{}, fmt.Errorf("tool_result content does not support %q blocks", inner.Type)
+		}
+		texts = append(texts, inner.Text)
+	}
+	return chat.ToolResult(block.ToolUseID, strings.Join(texts, "\n")), nil
+}
+
+func toToolCall(block ContentBlock) (chat.ToolCall, error) {
+	if block.ID == "" || block.Name == "" {
+		return chat.ToolCall{}, fmt.Errorf("tool_use requires id and name")
+	}
+	args := "{}"
+	if len(block.Input) > 0 && string(block.Input) != "null" {
+		args = string(block.Input)
+	}
+	return chat.ToolCall{
+		ID:   block.ID,
+		Type: "function",
+		Function: chat.ToolCallFunction{
+			Name:      block.Name,
+			Arguments: args,
+		},
+	}, nil
+}
+
+func toTools(in []Tool) ([]chat.Tool, error) {
+	tools := make([]chat.Tool, 0, len(in))
+	for _, t := range in {
+		if t.Type != "" && t.Type != "custom" {
+			return nil, fmt.Errorf("unsupported tool type %q", t.Type)
+		}
+		if t.Name == "" {
+			return nil, fmt.Errorf("tool name is required")
+		}
+		ctrl, err := toCacheControl(t.CacheControl)
+		if err != nil {
+			return nil, err
+		}
+		tools = append(tools, chat.Tool{
+			Type: "function",
+			Function: chat.ToolFunction{
+				Name:                 t.Name,
+				Description:          t.Description,
+				ParametersJSONSchema: append([]byte(nil), t.InputSchema...),
+			},
+			CacheControl: ctrl,
+		})
+	}
+	return tools, nil
+}
+
+func toToolChoice(choice ToolChoice) (chat.ToolChoice, error) {
+	switch choice.Type {
+	case "auto":
+		return chat.ToolChoiceAuto(), nil
+	case "any":
+		return chat.ToolChoiceRequired(), nil
+	case "none":
+		return chat.ToolChoiceNone(), nil
+	case "tool":
+		if choice.Name == "" {
+			return chat.ToolChoice{}, fmt.Errorf("tool_choice name is required")
+		}
+		return chat.ToolChoiceFunction(choice.Name), nil
+	default:
+		return chat.ToolChoice{}, fmt.Errorf("unsupported tool_choice type %q", choice.Type)
+	}
+}
+
+func toMessagesResponse(result *chat.Result, model string) *MessagesResponse {
+	resp := &MessagesResponse{
+		ID:         fmt.Sprintf("msg_%d", time.Now().UnixNano()),
+		Type:       "message",
+		Role:       "assistant",
+		Model:      model,
+		Content:    reasoningBlocks(result.Reasoning),
+		StopReason: anthropicapi.StopReason(result.FinishReason, result.RawFinishReason, len(result.ToolCalls) > 0),
+		Usage:      toUsage(result.Usage),
+	}
+	if result.Model != "" {
+		resp.Model = result.Model
+	}
+	if result.Text != "" {
+		resp.Content = append(resp.Content, ContentBlock{Type: "text", Text: result.Text})
+	}
+	for _, call := range result.ToolCalls {
+		resp.Content = append(resp.Content, ContentBlock{
+			Type:  "tool_use",
+			ID:    call.ID,
+			Name:  call.Function.Name,
+			Input: toolInput(call.Function.Arguments),
+		})
+	}
+	return resp
+}
+
+// reasoningBlocks returns the thinking and redacted_thinking blocks of
+// reasoning. Providers that only report summaries get one thinking block per
+// summary, without a signature.
+func reasoningBlocks(reasoning *chat.ReasoningResult) []ContentBlock {
+	out := []ContentBlock{}
+	if reasoning == nil {
+		return out
+	}
+	for _, block := range reasoning.Blocks {
+		switch block.Type {
+		case "thinking":
+			out = append(out, ContentBlock{Type: "thinking", Thinking: block.Text, Signature: block.Signature})
+		case "redacted_thinking":
+			out = append(out, ContentBlock{Type: "redacted_thinking", Data: block.Data})
+		}
+	}
+	if len(out) > 0 {
+		return out
+	}
+	for _, summary := range reasoning.Summary {
+		out = append(out, ContentBlock{Type: "thinking", Thinking: summary})
+	}
+	return out
+}
+
+// toolInput returns the tool call arguments as the tool_use input object.
+// Arguments that are not a JSON object are sent as {}.
+func toolInput(args string) json.RawMessage {
+	var input map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(args), &input); err != nil || input == nil {
+		return json.RawMessage("{}")
+	}
+	return json.RawMessage(args)
+}
+
+func toUsage(usage chat.Usage) Usage {
+	return Usage{
+		InputTokens:              usage.InputTokens,
+		OutputTokens:             usage.OutputTokens,
+		CacheCreationInputTokens: usage.Cache.CacheCreationInputTokens,
+		CacheReadInputTokens:     usage.Cache.CachedInputTokens,
+	}
+}
@@ -0,0 +1,211 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	uniai "github.com/quailyquaily/uniai"
+	"github.com/quailyquaily/uniai/chat"
+)
+
+const toolTurnRequest = `{
+	"model": "claude-sonnet-test",
+	"max_tokens": 512,
+	"system": [{"type":"text","text":"Be brief.","cache_control":{"type":"ephemeral","ttl":"1h"}}],
+	"messages": [
+		{"role":"user","content":[
+			{"type":"text","text":"Weather in Oslo?"},
+			{"type":"image","source":{"type":"base64","media_type":"image/png","data":"aGk="}}
+		]},
+		{"role":"assistant","content":[
+			{"type":"thinking","thinking":"Use the tool.","signature":"sig"},
+			{"type":"tool_use","id":"toolu_1","name":"weather","input":{"city":"Oslo"}}
+		]},
+		{"role":"user","content":[
+			{"type":"tool_result","tool_use_id":"toolu_1","content":[{"type":"text","text":"12C"}]},
+			{"type":"text","text":"Thanks"}
+		]}
+	],
+	"tools": [{"name":"weather","description":"Get weather","input_schema":{"type":"object"},"cache_control":{"type":"ephemeral"}}],
+	"tool_choice": {"type":"any"},
+	"thinking": {"type":"enabled","budget_tokens":2048},
+	"top_k": 5,
+	"stop_sequences": ["END"],
+	"stream": true
+}`
+
+func TestToChatOptions(t *testing.T) {
+	var req MessagesRequest
+	if err := json.Unmarshal([]byte(toolTurnRequest), &req); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	opts, err := ChatOptions(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	chatReq, err := chat.BuildRequest(opts...)
+	if err != nil {
+		t.Fatalf("unexpected build error: %v", err)
+	}
+
+	if chatReq.Model != "claude-sonnet-test" || *chatReq.Options.MaxTokens != 512 || chatReq.Options.Stop[0] != "END" {
+		t.Fatalf("unexpected options: %#v", chatReq.Options)
+	}
+	msgs := chatReq.Messages
+	if len(msgs) != 5 {
+		t.Fatalf("expected 5 messages, got %#v", msgs)
+	}
+	if msgs[0].Role != chat.RoleSystem || msgs[0].Parts[0].CacheControl == nil || msgs[0].Parts[0].CacheControl.TTL != "1h" {
+		t.Fatalf("unexpected system message: %#v", msgs[0])
+	}
+	if len(msgs[1].Parts) != 2 || msgs[1].Parts[1].Type != chat.PartTypeImageBase64 || msgs[1].Parts[1].MIMEType != "image/png" {
+		t.Fatalf("unexpected user message: %#v", msgs[1])
+	}
+	if msgs[2].ReasoningContent != "Use the tool." || len(msgs[2].ToolCalls) != 1 || msgs[2].ToolCalls[0].Function.Arguments != `{"city":"Oslo"}` {
+		t.Fatalf("unexpected assistant message: %#v", msgs[2])
+	}
+	if msgs[3].Role != chat.RoleTool || msgs[3].ToolCallID != "toolu_1" || msgs[3].Content != "12C" {
+		t.Fatalf("unexpected tool message: %#v", msgs[3])
+	}
+	if msgs[4].Role != chat.RoleUser || msgs[4].Parts[0].Text != "Thanks" {
+		t.Fatalf("unexpected trailing user message: %#v", msgs[4])
+	}
+	if len(chatReq.Tools) != 1 || chatReq.Tools[0].CacheControl == nil || string(chatReq.Tools[0].Function.ParametersJSONSchema) != `{"type":"object"}` {
+		t.Fatalf("unexpected tools: %#v", chatReq.Tools)
+	}
+	if chatReq.ToolChoice == nil || chatReq.ToolChoice.Mode != "required" {
+		t.Fatalf("unexpected tool choice: %#v", chatReq.ToolChoice)
+	}
+	if chatReq.Options.ReasoningBudget == nil || *chatReq.Options.ReasoningBudget != 2048 || !chatReq.Options.ReasoningDetails {
+		t.Fatalf("unexpected reasoning options: %#v", chatReq.Options)
+	}
+	if chatReq.Options.Anthropic.GetInt64("top_k") != 5 {
+		t.Fatalf("expected top_k in anthropic options, got %#v", chatReq.Options.Anthropic)
+	}
+
+	stripped, err := chat.BuildRequest(append(opts, WithoutCacheControl())...)
+	if err != nil {
+		t.Fatalf("unexpected build error: %v", err)
+	}
+	if chat.RequestHasExplicitCacheControl(stripped) {
+		t.Fatalf("expected cache control to be removed")
+	}
+}
+
+func TestToChatOptionsWithDocumentBlocks(t *testing.T) {
+	body := `{"model":"m","messages":[{"role":"user","content":[
+		{"type":"document","source":{"type":"base64","media_type":"application/pdf","data":"JVBERi0="},"title":"report.pdf","citations":{"enabled":true},"cache_control":{"type":"ephemeral"}},
+		{"type":"document","source":{"type":"url","url":"https://example.com/a.pdf"}},
+		{"type":"document","source":{"type":"text","media_type":"text/plain","data":"hello"},"title":"notes"},
+		{"type":"text","text":"Summarize."}
+	]}]}`
+	var req MessagesRequest
+	if err := json.Unmarshal([]byte(body), &req); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	opts, err := ChatOptions(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	chatReq, err := chat.BuildRequest(opts...)
+	if err != nil {
+		t.Fatalf("unexpected build error: %v", err)
+	}
+
+	parts := chatReq.Messages[0].Parts
+	if len(parts) != 4 {
+		t.Fatalf("expected 4 parts, got %#v", parts)
+	}
+	if pdf := parts[0]; pdf.Type != chat.PartTypeDocumentBase64 || pdf.MIMEType != "application/pdf" || pdf.Title != "report.pdf" || !pdf.Citations || pdf.CacheControl == nil {
+		t.Fatalf("unexpected base64 document part: %#v", pdf)
+	}
+	if parts[1].Type != chat.PartTypeDocumentURL || parts[1].URL != "https://example.com/a.pdf" {
+		t.Fatalf("unexpected url document part: %#v", parts[1])
+	}
+	if text := parts[2]; text.Type != chat.PartTypeDocumentText || text.Text != "hello" || text.Title != "notes" {
+		t.Fatalf("unexpected text document part: %#v", text)
+	}
+}
+
+func TestToChatOptionsRejectsUnsupportedBlocks(t *testing.T) {
+	cases := map[string]string{
+		"server tool":   `{"model":"m","messages":[{"role":"user","content":"hi"}],"tools":[{"type":"web_search_20250305","name":"web_search"}]}`,
+		"document":      `{"model":"m","messages":[{"role":"user","content":[{"type":"document","source":{"type":"file","file_id":"file_1"}}]}]}`,
+		"cache type":    `{"model":"m","messages":[{"role":"user","content":[{"type":"text","text":"hi","cache_control":{"type":"persistent"}}]}]}`,
+		"tool_result":   `{"model":"m","messages":[{"role":"user","content":[{"type":"tool_result","content":"x"}]}]}`,
+		"role":          `{"model":"m","messages":[{"role":"system","content":"hi"}]}`,
+		"thinking type": `{"model":"m","messages":[{"role":"user","content":"hi"}],"thinking":{"type":"always"}}`,
+	}
+	for name, body := range cases {
+		var req MessagesRequest
+		if err := json.Unmarshal([]byte(body), &req); err != nil {
+			t.Fatalf("%s: decode: %v", name, err)
+		}
+		if _, err := ChatOptions(req); err == nil {
+			t.Fatalf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestCreateMessage(t *testing.T) {
+	var upstream map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &upstream)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{
+			"id":"chatcmpl-1","object":"chat.completion","created":1,"model":"deepseek-chat",
+			"choices":[{"index":0,"finish_reason":"tool_calls","message":{
+				"role":"assistant","content":"Checking.","reasoning_content":"Need the weather.",
+				"tool_calls":[{"id":"call_1","type":"function","function":{"name":"weather","arguments":"{\"city\":\"Oslo\"}"}}]
+			}}],
+			"usage":{"prompt_tokens":20,"completion_tokens":7,"total_tokens":27}
+		}`)
+	}))
+	defer server.Close()
+	client := New(uniai.New(uniai.Config{Provider: "openai", OpenAIAPIKey: "test-key", OpenAIAPIBase: server.URL + "/v1"}))
+
+	resp, err := client.CreateMessage(context.Background(), MessagesRequest{
+		Model:     "claude-alias",
+		MaxTokens: 256,
+		System:    Content{{Type: "text", Text: "Be brief."}},
+		Messages:  []Message{{Role: "user", Content: Content{{Type: "text", Text: "Weather in Oslo?"}}}},
+		Tools:     []Tool{{Name: "weather", InputSchema: json.RawMessage(`{"type":"object"}`)}},
+		Thinking:  &Thinking{Type: "adaptive"},
+	}, chat.WithModel("deepseek-chat"))
+	if err != nil {
+		t.Fatalf("CreateMessage: %v", err)
+	}
+
+	if upstream["model"] != "deepseek-chat" {
+		t.Fatalf("expected the routed model upstream, got %v", upstream["model"])
+	}
+	if msgs, _ := upstream["messages"].([]any); len(msgs) != 2 {
+		t.Fatalf("expected system and user messages upstream, got %v", upstream["messages"])
+	}
+
+	data, _ := json.Marshal(resp)
+	if resp.Type != "message" || resp.Role != "assistant" || !strings.HasPrefix(resp.ID, "msg_") || resp.StopReason != "tool_use" {
+		t.Fatalf("unexpected response: %s", data)
+	}
+	if len(resp.Content) != 3 {
+		t.Fatalf("expected thinking, text and tool_use blocks, got %s", data)
+	}
+	if block := resp.Content[0]; block.Type != "thinking" || block.Thinking != "Need the weather." {
+		t.Fatalf("unexpected thinking block: %#v", block)
+	}
+	if block := resp.Content[1]; block.Type != "text" || block.Text != "Checking." {
+		t.Fatalf("unexpected text block: %#v", block)
+	}
+	if block := resp.Content[2]; block.Type != "tool_use" || block.ID != "call_1" || block.Name != "weather" || string(block.Input) != `{"city":"Oslo"}` {
+		t.Fatalf("unexpected tool_use block: %#v", block)
+	}
+	if resp.Usage.InputTokens != 20 || resp.Usage.OutputTokens != 7 {
+		t.Fatalf("unexpected usage: %#v", resp.Usage)
+	}
+}
@@ -0,0 +1,264 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	uniai "github.com/quailyquaily/uniai"
+	"github.com/quailyquaily/uniai/chat"
+	"github.com/quailyquaily/uniai/internal/anthropicapi"
+)
+
+// StreamEvent is one server-sent event of a streamed Messages response.
+type StreamEvent struct {
+	// Type is the event name, e.g. "content_block_delta".
+	Type string
+	// Data is the JSON event payload.
+	Data json.RawMessage
+}
+
+// Encode returns the event in SSE wire form.
+func (e StreamEvent) Encode() []byte {
+	return []byte("event: " + e.Type + "\ndata: " + string(e.Data) + "\n\n")
+}
+
+// MessageStream is a streamed Messages response returned by
+// Client.CreateMessageStream.
+type MessageStream struct {
+	stream  *uniai.ChatStream
+	events  *eventBuilder
+	pending []StreamEvent
+	err     error
+}
+
+// CreateMessageStream runs req as a streaming uniai chat call and returns
+// its events in the Messages API event sequence: message_start, then
+// content_block_start / content_block_delta / content_block_stop for each
+// thinking, text and tool_use block, then message_delta with the stop reason
+// and usage, and message_stop. Options in opts are applied after the
+// converted request.
+//
+// Content blocks are numbered 0, 1, ... in the order they start, whatever
+// indices the provider uses. Usage is reported in message_delta only.
+// Streamed thinking blocks carry no signature; use CreateMessage when
+// thinking blocks must be replayed to Anthropic.
+func (c *Client) CreateMessageStream(ctx context.Context, req MessagesRequest, opts ...chat.Option) (*MessageStream, error) {
+	converted, err := toChatOptions(req)
+	if err != nil {
+		return nil, err
+	}
+	stream, err := c.base.ChatStream(ctx, append(converted, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+	return &MessageStream{
+		stream: stream,
+		events: newEventBuilder(fmt.Sprintf("msg_%d", time.Now().UnixNano()), req.Model),
+	}, nil
+}
+
+// Recv returns the next event. It returns io.EOF after message_stop, and the
+// call error if it failed, including errors before the first event; see
+// ErrorEvent to relay it.
+func (s *MessageStream) Recv() (StreamEvent, error) {
+	for len(s.pending) == 0 {
+		if s.err != nil {
+			return StreamEvent{}, s.err
+		}
+		ev, err := s.stream.Recv()
+		if err == io.EOF && !s.events.done {
+			// Providers that fall back to a blocking call end without a
+			// Done event; finish the message from the result.
+			result, err := s.stream.Result()
+			if err != nil {
+				s.err = err
+				continue
+			}
+			s.pending = s.events.finish(result)
+			continue
+		}
+		if err != nil {
+			s.err = err
+			continue
+		}
+		s.pending = s.events.add(ev)
+	}
+	ev := s.pending[0]
+	s.pending = s.pending[1:]
+	return ev, nil
+}
+
+// Close stops the stream and releases the upstream response. It is safe to
+// call more than once.
+func (s *MessageStream) Close() error { return s.stream.Close() }
+
+// ErrorEvent returns the Messages API error event for err. Its Data is also
+// the body of a non-streaming error response.
+func ErrorEvent(err error) StreamEvent {
+	var body struct {
+		Type  string `json:"type"`
+		Error struct {
+			Type    string `json:"type"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	body.Type = "error"
+	body.Error.Type = errorType(err)
+	body.Error.Message = err.Error()
+	data, _ := json.Marshal(body)
+	return StreamEvent{Type: "error", Data: data}
+}
+
+// errorType maps uniai error categories to Messages API error types.
+func errorType(err error) string {
+	switch {
+	case errors.Is(err, uniai.ErrAuth):
+		return "authentication_error"
+	case errors.Is(err, uniai.ErrRateLimited):
+		return "rate_limit_error"
+	case errors.Is(err, uniai.ErrInvalidRequest), errors.Is(err, uniai.ErrContextLengthExceeded), errors.Is(err, uniai.ErrContentFiltered):
+		return "invalid_request_error"
+	default:
+		return "api_error"
+	}
+}
+
+// openBlock identifies the content block being streamed: its kind and the
+// provider index of the reasoning or tool call delta that opened it.
+type openBlock struct {
+	kind  string
+	key   int
+	index int
+}
+
+// eventBuilder converts stream events to Messages API events, keeping the
+// state shared across a stream: message_start and the open content block.
+type eventBuilder struct {
+	id      string
+	model   string
+	started bool
+	done    bool
+	toolUse bool
+	blocks  int
+	open    *openBlock
+}
+
+func newEventBuilder(id, model string) *eventBuilder {
+	return &eventBuilder{id: id, model: model}
+}
+
+// add returns the events for ev.
+func (b *eventBuilder) add(ev chat.StreamEvent) []StreamEvent {
+	var out []StreamEvent
+	if !b.started {
+		b.started = true
+		out = append(out, b.event("message_start", anthropicapi.MessageStartEvent{
+			Type: "message_start",
+			Message: anthropicapi.StreamMessage{
+				ID:      b.id,
+				Type:    "message",
+				Role:    "assistant",
+				Model:   b.model,
+				Content: []ContentBlock{},
+			},
+		}))
+	}
+	if ev.ReasoningDelta != nil && ev.ReasoningDelta.Delta != "" {
+		out = b.ensure(out, "thinking", ev.ReasoningDelta.Index, anthropicapi.StreamBlock{Type: "thinking", Thinking: new(string)})
+		out = b.delta(out, anthropicapi.Delta{Type: "thinking_delta", Thinking: ev.ReasoningDelta.Delta})
+	}
+	if ev.Delta != "" {
+		out = b.ensure(out, "text", 0, anthropicapi.StreamBlock{Type: "text", Text: new(string)})
+		out = b.delta(out, anthropicapi.Delta{Type: "text_delta", Text: ev.Delta})
+	}
+	if call := ev.ToolCallDelta; call != nil {
+		b.toolUse = true
+		out = b.ensure(out, "tool_use", call.Index, anthropicapi.StreamBlock{Type: "tool_use", ID: call.ID, Name: call.Name, Input: json.RawMessage("{}")})
+		if call.ArgsChunk != "" {
+			out = b.delta(out, anthropicapi.Delta{Type: "input_json_delta", PartialJSON: call.ArgsChunk})
+		}
+	}
+	if ev.Done {
+		b.done = true
+		out = b.stop(out)
+		done := anthropicapi.MessageDeltaEvent{Type: "message_delta"}
+		done.Delta.StopReason = anthropicapi.StopReason(ev.FinishReason, ev.RawFinishReason, b.toolUse)
+		if ev.Usage != nil {
+			done.Usage = toUsage(*ev.Usage)
+		}
+		out = append(out,
+			b.event("message_delta", done),
+			b.event("message_stop", anthropicapi.MessageStopEvent{Type: "message_stop"}),
+		)
+	}
+	return out
+}
+
+// finish returns the remaining events of a stream that ended without a Done
+// event. When nothing was streamed, the result content is sent as whole
+// blocks.
+func (b *eventBuilder) finish(result *chat.Result) []StreamEvent {
+	var out []StreamEvent
+	if b.blocks == 0 {
+		out = b.add(chat.StreamEvent{Delta: result.Text})
+		for i, call := range result.ToolCalls {
+			out = append(out, b.add(chat.StreamEvent{ToolCallDelta: &chat.ToolCallDelta{
+				Index:     i,
+				ID:        call.ID,
+				Name:      call.Function.Name,
+				ArgsChunk: call.Function.Arguments,
+			}})...)
+		}
+	}
+	usage := result.Usage
+	return append(out, b.add(chat.StreamEvent{
+		Done:            true,
+		Usage:           &usage,
+		FinishReason:    result.FinishReason,
+		RawFinishReason: result.RawFinishReason,
+	})...)
+}
+
+// ensure starts a content block of kind for the provider index key unless
+// it is the open block, stopping the open block first.
+func (b *eventBuilder) ensure(out []StreamEvent, kind string, key int, block anthropicapi.StreamBlock) []StreamEvent {
+	if b.open != nil && b.open.kind == kind && b.open.key == key {
+		return out
+	}
+	out = b.stop(out)
+	b.open = &openBlock{kind: kind, key: key, index: b.blocks}
+	b.blocks++
+	return append(out, b.event("content_block_start", anthropicapi.ContentBlockStartEvent{
+		Type:         "content_block_start",
+		Index:        b.open.index,
+		ContentBlock: block,
+	}))
+}
+
+func (b *eventBuilder) delta(out []StreamEvent, delta anthropicapi.Delta) []StreamEvent {
+	return append(out, b.event("content_block_delta", anthropicapi.ContentBlockDeltaEvent{
+		Type:  "content_block_delta",
+		Index: b.open.index,
+		Delta: delta,
+	}))
+}
+
+func (b *eventBuilder) stop(out []StreamEvent) []StreamEvent {
+	if b.open == nil {
+		return out
+	}
+	index := b.open.index
+	b.open = nil
+	return append(out, b.event("content_block_stop", anthropicapi.ContentBlockStopEvent{Type: "content_block_stop", Index: index}))
+}
+
+func (b *eventBuilder) event(typ string, payload any) StreamEvent {
+	// The event types hold only strings, numbers and raw JSON objects, so
+	// marshaling cannot fail.
+	data, _ := json.Marshal(payload)
+	return StreamEvent{Type: typ, Data: data}
+}
@@ -0,0 +1,151 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	uniai "github.com/quailyquaily/uniai"
+	"github.com/quailyquaily/uniai/chat"
+)
+
+func anthropicClient(t *testing.T, body string, status int) *Client {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if status != http.StatusOK {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+		} else {
+			w.Header().Set("Content-Type", "text/event-stream")
+		}
+		_, _ = io.WriteString(w, body)
+	}))
+	t.Cleanup(server.Close)
+	return New(uniai.New(uniai.Config{
+		Provider:         "anthropic",
+		AnthropicAPIKey:  "test-key",
+		AnthropicAPIBase: server.URL,
+	}))
+}
+
+func sse(events ...string) string {
+	var b strings.Builder
+	for _, ev := range events {
+		typ := ev[len(`{"type":"`):]
+		typ = typ[:strings.IndexByte(typ, '"')]
+		b.WriteString("event: " + typ + "\ndata: " + ev + "\n\n")
+	}
+	return b.String()
+}
+
+func TestCreateMessageStream(t *testing.T) {
+	client := anthropicClient(t, sse(
+		`{"type":"message_start","message":{"model":"claude-sonnet-test","usage":{"input_tokens":12}}}`,
+		`{"type":"content_block_start","index":0,"content_block":{"type":"thinking","thinking":""}}`,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"thinking_delta","thinking":"look it up"}}`,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"signature_delta","signature":"sig"}}`,
+		`{"type":"content_block_stop","index":0}`,
+		`{"type":"content_block_start","index":1,"content_block":{"type":"text","text":""}}`,
+		`{"type":"content_block_delta","index":1,"delta":{"type":"text_delta","text":"Checking."}}`,
+		`{"type":"content_block_stop","index":1}`,
+		`{"type":"content_block_start","index":2,"content_block":{"type":"tool_use","id":"toolu_a","name":"weather","input":{}}}`,
+		`{"type":"content_block_delta","index":2,"delta":{"type":"input_json_delta","partial_json":"{\"city\":"}}`,
+		`{"type":"content_block_delta","index":2,"delta":{"type":"input_json_delta","partial_json":"\"Oslo\"}"}}`,
+		`{"type":"content_block_stop","index":2}`,
+		`{"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":30}}`,
+		`{"type":"message_stop"}`,
+	), http.StatusOK)
+
+	stream, err := client.CreateMessageStream(context.Background(), MessagesRequest{
+		Model:     "claude-sonnet-test",
+		MaxTokens: 2048,
+		Messages:  []Message{{Role: "user", Content: Content{{Type: "text", Text: "Weather in Oslo?"}}}},
+		Thinking:  &Thinking{Type: "enabled", BudgetTokens: 1024},
+	})
+	if err != nil {
+		t.Fatalf("CreateMessageStream: %v", err)
+	}
+	defer stream.Close()
+
+	var (
+		types  []string
+		events []map[string]any
+	)
+	for {
+		ev, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("recv: %v", err)
+		}
+		var payload map[string]any
+		if err := json.Unmarshal(ev.Data, &payload); err != nil || payload["type"] != ev.Type {
+			t.Fatalf("event %s has payload %s", ev.Type, ev.Data)
+		}
+		types = append(types, ev.Type)
+		events = append(events, payload)
+	}
+
+	want := []string{
+		"message_start",
+		"content_block_start", "content_block_delta", "content_block_stop",
+		"content_block_start", "content_block_delta", "content_block_stop",
+		"content_block_start", "content_block_delta", "content_block_delta", "content_block_stop",
+		"message_delta", "message_stop",
+	}
+	if strings.Join(types, ",") != strings.Join(want, ",") {
+		t.Fatalf("unexpected event sequence:\n got %v\nwant %v", types, want)
+	}
+	if msg := events[0]["message"].(map[string]any); msg["role"] != "assistant" || msg["model"] != "claude-sonnet-test" {
+		t.Fatalf("unexpected message_start: %v", events[0])
+	}
+	if block := events[1]["content_block"].(map[string]any); block["type"] != "thinking" || events[2]["delta"].(map[string]any)["thinking"] != "look it up" {
+		t.Fatalf("unexpected thinking block: %v %v", events[1], events[2])
+	}
+	if block := events[4]["content_block"].(map[string]any); block["type"] != "text" || block["text"] != "" || events[4]["index"] != 1.0 {
+		t.Fatalf("unexpected text block start: %v", events[4])
+	}
+	if block := events[7]["content_block"].(map[string]any); block["id"] != "toolu_a" || block["name"] != "weather" || events[7]["index"] != 2.0 {
+		t.Fatalf("unexpected tool_use block start: %v", events[7])
+	}
+	if events[9]["delta"].(map[string]any)["partial_json"] != `"Oslo"}` {
+		t.Fatalf("unexpected input_json_delta: %v", events[9])
+	}
+	delta := events[11]
+	usage := delta["usage"].(map[string]any)
+	if delta["delta"].(map[string]any)["stop_reason"] != "tool_use" || usage["input_tokens"] != 12.0 || usage["output_tokens"] != 30.0 {
+		t.Fatalf("unexpected message_delta: %v", delta)
+	}
+}
+
+func TestCreateMessageStreamErrors(t *testing.T) {
+	client := anthropicClient(t, `{"type":"error","error":{"type":"authentication_error","message":"bad key"}}`, http.StatusUnauthorized)
+	stream, err := client.CreateMessageStream(context.Background(), MessagesRequest{
+		Model:    "claude-sonnet-test",
+		Messages: []Message{{Role: "user", Content: Content{{Type: "text", Text: "hello"}}}},
+	})
+	if err != nil {
+		t.Fatalf("CreateMessageStream: %v", err)
+	}
+	defer stream.Close()
+	_, err = stream.Recv()
+	if !errors.Is(err, uniai.ErrAuth) {
+		t.Fatalf("expected the provider error, got %v", err)
+	}
+	ev := ErrorEvent(err)
+	if ev.Type != "error" || !strings.Contains(string(ev.Data), `"type":"authentication_error"`) {
+		t.Fatalf("unexpected error event: %s", ev.Encode())
+	}
+
+	if _, err := client.CreateMessageStream(context.Background(), MessagesRequest{
+		Messages: []Message{{Role: "user", Content: Content{{Type: "document"}}}},
+	}, chat.WithModel("claude-sonnet-test")); err == nil {
+		t.Fatalf("expected invalid request to fail")
+	}
+}
@@ -0,0 +1,55 @@
+package anthropic
+
+import "github.com/quailyquaily/uniai/internal/anthropicapi"
+
+// MessagesRequest is the body of an Anthropic Messages API request.
+type MessagesRequest struct {
+	Model         string      `json:"model"`
+	System        Content     `json:"system,omitempty"`
+	Messages      []Message   `json:"messages"`
+	MaxTokens     int         `json:"max_tokens,omitempty"`
+	Temperature   *float64    `json:"temperature,omitempty"`
+	TopP          *float64    `json:"top_p,omitempty"`
+	TopK          *int        `json:"top_k,omitempty"`
+	StopSequences []string    `json:"stop_sequences,omitempty"`
+	Tools         []Tool      `json:"tools,omitempty"`
+	ToolChoice    *ToolChoice `json:"tool_choice,omitempty"`
+	Thinking      *Thinking   `json:"thinking,omitempty"`
+	Metadata      *Metadata   `json:"metadata,omitempty"`
+	// Stream is accepted so a request body can be decoded as is; the
+	// adapter method called decides whether the response is streamed.
+	Stream bool `json:"stream,omitempty"`
+}
+
+// The Messages API types below are shared with the Anthropic provider.
+
+type Message = anthropicapi.Message
+
+// Content is a list of content blocks. It also decodes from a plain string,
+// which the Messages API accepts as a single text block.
+type Content = anthropicapi.Content
+
+// ContentBlock is one content block of a message, system prompt or
+// tool_result. Type selects which fields apply. Citations lists of assistant
+// text blocks replayed from a response are dropped.
+type ContentBlock = anthropicapi.ContentBlock
+
+// Source is the source of an image or document block: base64 data with its
+// media type, a URL, or for documents plain text in Data.
+type Source = anthropicapi.Source
+
+type CacheControl = anthropicapi.CacheControl
+
+// Tool is a client tool definition. Anthropic server tools are not supported.
+type Tool = anthropicapi.Tool
+
+type ToolChoice = anthropicapi.ToolChoice
+
+type Thinking = anthropicapi.Thinking
+
+type Metadata = anthropicapi.Metadata
+
+// MessagesResponse is the body of a non-streaming Messages API response.
+type MessagesResponse = anthropicapi.Response
+
+type Usage = anthropicapi.Usage
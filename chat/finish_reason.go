@@ -0,0 +1,66 @@
+package chat
+
+import "strings"
+
+// FinishReason is the normalized reason a model stopped generating.
+type FinishReason string
+
+const (
+	// FinishReasonStop is a natural stop or a stop sequence.
+	FinishReasonStop FinishReason = "stop"
+	// FinishReasonLength means the output hit MaxTokens or the context
+	// window, so the answer is likely truncated.
+	FinishReasonLength FinishReason = "length"
+	// FinishReasonToolCalls means the model stopped to call tools.
+	FinishReasonToolCalls FinishReason = "tool_calls"
+	// FinishReasonContentFilter means a safety filter or guardrail stopped
+	// the output.
+	FinishReasonContentFilter FinishReason = "content_filter"
+	// FinishReasonRefusal means the model declined to answer.
+	FinishReasonRefusal FinishReason = "refusal"
+	// FinishReasonPause means a long-running turn was paused and can be
+	// continued by sending the response back (Anthropic pause_turn).
+	FinishReasonPause FinishReason = "pause"
+	// FinishReasonOther covers provider values without a better match.
+	FinishReasonOther FinishReason = "other"
+)
+
+// NormalizeFinishReason maps a provider finish or stop reason (OpenAI
+// finish_reason, Anthropic and Bedrock stop_reason, Gemini finishReason,
+// Responses incomplete reason) to a FinishReason. It returns "" for an empty
+// value and FinishReasonOther for unknown ones.
+func NormalizeFinishReason(raw string) FinishReason {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "":
+		return ""
+	case "stop", "end_turn", "stop_sequence", "completed", "eos":
+		return FinishReasonStop
+	case "length", "max_tokens", "max_output_tokens", "model_context_window_exceeded":
+		return FinishReasonLength
+	case "tool_calls", "tool_use", "function_call":
+		return FinishReasonToolCalls
+	case "content_filter", "content_filtered", "guardrail_intervened",
+		"safety", "recitation", "blocklist", "prohibited_content", "spii", "image_safety":
+		return FinishReasonContentFilter
+	case "refusal":
+		return FinishReasonRefusal
+	case "pause_turn":
+		return FinishReasonPause
+	default:
+		return FinishReasonOther
+	}
+}
+
+// SetFinishReason stores raw and its normalized value on r. A natural stop
+// is reported as FinishReasonToolCalls when r has tool calls, since some
+// providers (Gemini) do not distinguish the two.
+func (r *Result) SetFinishReason(raw string) {
+	if r == nil {
+		return
+	}
+	r.RawFinishReason = raw
+	r.FinishReason = NormalizeFinishReason(raw)
+	if r.FinishReason == FinishReasonStop && len(r.ToolCalls) > 0 {
+		r.FinishReason = FinishReasonToolCalls
+	}
+}
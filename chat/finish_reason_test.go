@@ -0,0 +1,42 @@
+package chat
+
+import "testing"
+
+func TestNormalizeFinishReason(t *testing.T) {
+	cases := map[string]FinishReason{
+		"":                     "",
+		"stop":                 FinishReasonStop,
+		"end_turn":             FinishReasonStop,
+		"STOP":                 FinishReasonStop,
+		"completed":            FinishReasonStop,
+		"max_tokens":           FinishReasonLength,
+		"MAX_TOKENS":           FinishReasonLength,
+		"max_output_tokens":    FinishReasonLength,
+		"tool_use":             FinishReasonToolCalls,
+		"function_call":        FinishReasonToolCalls,
+		"SAFETY":               FinishReasonContentFilter,
+		"guardrail_intervened": FinishReasonContentFilter,
+		"refusal":              FinishReasonRefusal,
+		"pause_turn":           FinishReasonPause,
+		"MALFORMED_FUNCTION":   FinishReasonOther,
+	}
+	for raw, want := range cases {
+		if got := NormalizeFinishReason(raw); got != want {
+			t.Fatalf("%q: got %q, want %q", raw, got, want)
+		}
+	}
+}
+
+func TestSetFinishReasonPrefersToolCalls(t *testing.T) {
+	result := &Result{ToolCalls: []ToolCall{{ID: "call_1"}}}
+	result.SetFinishReason("STOP")
+	if result.FinishReason != FinishReasonToolCalls || result.RawFinishReason != "STOP" {
+		t.Fatalf("unexpected finish reason: %q (%q)", result.FinishReason, result.RawFinishReason)
+	}
+
+	result = &Result{ToolCalls: []ToolCall{{ID: "call_1"}}}
+	result.SetFinishReason("length")
+	if result.FinishReason != FinishReasonLength {
+		t.Fatalf("expected length to be kept, got %q", result.FinishReason)
+	}
+}
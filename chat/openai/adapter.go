@@ -12,6 +12,7 @@ import (
 	"github.com/openai/openai-go/v3/shared/constant"
 	uniai "github.com/quailyquaily/uniai"
 	"github.com/quailyquaily/uniai/chat"
+	"github.com/quailyquaily/uniai/internal/oaicompat"
 )
 
 type Client struct {
@@ -22,18 +23,26 @@ func New(client *uniai.Client) *Client {
 	return &Client{base: client}
 }
 
-func (c *Client) CreateChatCompletion(ctx context.Context, req openai.ChatCompletionNewParams) (openai.ChatCompletion, error) {
-	opts, err := toChatOptions(req)
+// CreateChatCompletion runs req through the uniai client. Options in opts
+// are applied after the converted request, e.g. to route it to another
+// provider or model.
+func (c *Client) CreateChatCompletion(ctx context.Context, req openai.ChatCompletionNewParams, opts ...chat.Option) (openai.ChatCompletion, error) {
+	converted, err := toChatOptions(req)
 	if err != nil {
 		return openai.ChatCompletion{}, err
 	}
-	result, err := c.base.Chat(ctx, opts...)
+	result, err := c.base.Chat(ctx, append(converted, opts...)...)
 	if err != nil {
 		return openai.ChatCompletion{}, err
 	}
 	return toOpenAIResponse(result, string(req.Model)), nil
 }
 
+// ChatOptions converts req to uniai chat options.
+func ChatOptions(req openai.ChatCompletionNewParams) ([]chat.Option, error) {
+	return toChatOptions(req)
+}
+
 func toChatOptions(req openai.ChatCompletionNewParams) ([]chat.Option, error) {
 	opts := []chat.Option{}
 	if req.Model != "" {
@@ -92,6 +101,12 @@ func toChatOptions(req openai.ChatCompletionNewParams) ([]chat.Option, error) {
 		opts = append(opts, chat.WithToolChoice(choice))
 	}
 
+	if format, err := toResponseFormat(req.ResponseFormat); err != nil {
+		return nil, err
+	} else if format != nil {
+		opts = append(opts, format)
+	}
+
 	if extra := toOpenAIOptions(req); len(extra) > 0 {
 		opts = append(opts, chat.WithOpenAIOptions(extra))
 	}
@@ -99,6 +114,28 @@ func toChatOptions(req openai.ChatCompletionNewParams) ([]chat.Option, error) {
 	return opts, nil
 }
 
+// toResponseFormat maps response_format to the provider-neutral option so
+// non-OpenAI providers also honor it. The raw response_format is kept in the
+// OpenAI options as well and takes precedence on OpenAI providers.
+func toResponseFormat(format openai.ChatCompletionNewParamsResponseFormatUnion) (chat.Option, error) {
+	switch {
+	case format.OfJSONObject != nil:
+		return chat.WithJSONObject(), nil
+	case format.OfJSONSchema != nil && format.OfJSONSchema.JSONSchema.Name != "":
+		schema := format.OfJSONSchema.JSONSchema
+		var data []byte
+		if schema.Schema != nil {
+			var err error
+			if data, err = json.Marshal(schema.Schema); err != nil {
+				return nil, fmt.Errorf("response_format schema: %w", err)
+			}
+		}
+		return chat.WithJSONSchema(schema.Name, data, schema.Strict.Or(false)), nil
+	default:
+		return nil, nil
+	}
+}
+
 func toChatMessage(m openai.ChatCompletionMessageParamUnion) (chat.Message, error) {
 	switch {
 	case m.OfDeveloper != nil:
@@ -208,6 +245,13 @@ func readUserContent(content openai.ChatCompletionUserMessageParamContentUnion)
 			if url != "" {
 				outParts = append(outParts, chat.ImageURLPart(url))
 			}
+		} else if part.OfInputAudio != nil {
+			audio := part.OfInputAudio.InputAudio
+			mimeType, err := oaicompat.AudioMIMEType(audio.Format)
+			if err != nil {
+				return "", nil, err
+			}
+			outParts = append(outParts, chat.AudioBase64Part(mimeType, audio.Data))
 		}
 	}
 	if len(outParts) == 0 {
@@ -424,8 +468,25 @@ func toOpenAIResponse(result *chat.Result, model string) openai.ChatCompletion {
 	return resp
 }
 
+// finishReason maps the normalized finish reason to the Chat Completions
+// values; reasons without an equivalent are reported as "stop".
 func finishReason(result *chat.Result) string {
-	if result != nil && len(result.ToolCalls) > 0 {
+	if result == nil {
+		return "stop"
+	}
+	return finishReasonFor(result.FinishReason, len(result.ToolCalls) > 0)
+}
+
+func finishReasonFor(reason chat.FinishReason, hasToolCalls bool) string {
+	switch reason {
+	case chat.FinishReasonLength:
+		return "length"
+	case chat.FinishReasonContentFilter:
+		return "content_filter"
+	case chat.FinishReasonToolCalls:
+		return "tool_calls"
+	}
+	if hasToolCalls {
 		return "tool_calls"
 	}
 	return "stop"
@@ -125,6 +125,33 @@ func TestToChatOptionsWithUserImageParts(t *testing.T) {
 	}
 }
 
+func TestToChatOptionsWithUserAudioParts(t *testing.T) {
+	req := openai.ChatCompletionNewParams{
+		Model: openai.ChatModel("gpt-4o-audio-preview"),
+		Messages: []openai.ChatCompletionMessageParamUnion{
+			openai.UserMessage([]openai.ChatCompletionContentPartUnionParam{
+				openai.InputAudioContentPart(openai.ChatCompletionContentPartInputAudioInputAudioParam{
+					Data:   "UklGRg==",
+					Format: "wav",
+				}),
+			}),
+		},
+	}
+
+	opts, err := toChatOptions(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	chatReq, err := chat.BuildRequest(opts...)
+	if err != nil {
+		t.Fatalf("unexpected build error: %v", err)
+	}
+	part := chatReq.Messages[0].Parts[0]
+	if part.Type != chat.PartTypeAudioBase64 || part.MIMEType != "audio/wav" || part.DataBase64 != "UklGRg==" {
+		t.Fatalf("unexpected audio part: %#v", part)
+	}
+}
+
 func TestToChatOptionsReadsThoughtSignatureFromExtraContent(t *testing.T) {
 	call := openai.ChatCompletionMessageFunctionToolCallParam{
 		ID: "call_1",
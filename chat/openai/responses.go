@@ -0,0 +1,601 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lyricat/goutils/structs"
+	"github.com/openai/openai-go/v3/responses"
+	"github.com/quailyquaily/uniai/chat"
+)
+
+// CreateResponse runs req through the uniai client and returns the result as
+// a Responses API response. Options in opts are applied after the converted
+// request, e.g. to route it to another provider or model.
+//
+// The response is decoded from its wire form, so RawJSON returns a body
+// that can be forwarded as is. Reasoning is returned as a reasoning item
+// with the provider's summaries and reasoning text.
+func (c *Client) CreateResponse(ctx context.Context, req responses.ResponseNewParams, opts ...chat.Option) (*responses.Response, error) {
+	converted, err := toResponseOptions(req)
+	if err != nil {
+		return nil, err
+	}
+	result, err := c.base.Chat(ctx, append(converted, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+	b := newResponseBuilder(req, time.Now())
+	if result.Model != "" {
+		b.model = result.Model
+	}
+	b.addResult(result)
+	var resp responses.Response
+	if err := json.Unmarshal(b.encode(b.response(result.FinishReason, &result.Usage)), &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ResponseOptions converts req to uniai chat options.
+//
+// Input items are replayed as chat messages: function_call items become
+// assistant tool calls and reasoning items the next assistant message's
+// reasoning content. Server-side state (previous_response_id,
+// conversation), item references and hosted tools are not supported.
+func ResponseOptions(req responses.ResponseNewParams) ([]chat.Option, error) {
+	return toResponseOptions(req)
+}
+
+func toResponseOptions(req responses.ResponseNewParams) ([]chat.Option, error) {
+	if req.PreviousResponseID.Valid() || req.Conversation.OfString.Valid() || req.Conversation.OfConversationObject != nil {
+		return nil, fmt.Errorf("previous_response_id and conversation are not supported; send the full input")
+	}
+
+	opts := []chat.Option{}
+	if req.Model != "" {
+		opts = append(opts, chat.WithModel(string(req.Model)))
+	}
+
+	msgs := []chat.Message{}
+	if req.Instructions.Valid() && req.Instructions.Value != "" {
+		msgs = append(msgs, chat.System(req.Instructions.Value))
+	}
+	if req.Input.OfString.Valid() {
+		msgs = append(msgs, chat.User(req.Input.OfString.Value))
+	}
+	input, err := toInputMessages(req.Input.OfInputItemList)
+	if err != nil {
+		return nil, err
+	}
+	msgs = append(msgs, input...)
+	if len(msgs) > 0 {
+		opts = append(opts, chat.WithMessages(msgs...))
+	}
+
+	if req.MaxOutputTokens.Valid() {
+		opts = append(opts, chat.WithMaxTokens(int(req.MaxOutputTokens.Value)))
+	}
+	if req.Temperature.Valid() {
+		opts = append(opts, chat.WithTemperature(req.Temperature.Value))
+	}
+	if req.TopP.Valid() {
+		opts = append(opts, chat.WithTopP(req.TopP.Value))
+	}
+	if req.User.Valid() {
+		opts = append(opts, chat.WithUser(req.User.Value))
+	}
+
+	if len(req.Tools) > 0 {
+		tools, err := toResponseTools(req.Tools)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, chat.WithTools(tools))
+	}
+	if choice, ok, err := toResponseToolChoice(req.ToolChoice); err != nil {
+		return nil, err
+	} else if ok {
+		opts = append(opts, chat.WithToolChoice(choice))
+	}
+
+	if format, err := toTextFormat(req.Text.Format); err != nil {
+		return nil, err
+	} else if format != nil {
+		opts = append(opts, format)
+	}
+
+	if req.Reasoning.Effort != "" {
+		opts = append(opts, chat.WithReasoningEffort(chat.ReasoningEffort(req.Reasoning.Effort)))
+	}
+	if req.Reasoning.Summary != "" || req.Reasoning.GenerateSummary != "" {
+		opts = append(opts, chat.WithReasoningDetails())
+	}
+
+	if extra := toResponseOpenAIOptions(req); len(extra) > 0 {
+		opts = append(opts, chat.WithOpenAIOptions(extra))
+	}
+	return opts, nil
+}
+
+// toInputMessages converts input items to chat messages. Consecutive
+// assistant items (output messages and function calls) are merged into one
+// assistant message, as Chat Completions style providers expect.
+func toInputMessages(items responses.ResponseInputParam) ([]chat.Message, error) {
+	msgs := make([]chat.Message, 0, len(items))
+	reasoning := []string{}
+	// assistant appends to the trailing assistant message, or starts one.
+	assistant := func() *chat.Message {
+		if n := len(msgs); n == 0 || msgs[n-1].Role != chat.RoleAssistant {
+			msgs = append(msgs, chat.Message{Role: chat.RoleAssistant})
+		}
+		msg := &msgs[len(msgs)-1]
+		if len(reasoning) > 0 {
+			msg.ReasoningContent = strings.Join(append(nonEmpty(msg.ReasoningContent), reasoning...), "\n")
+			reasoning = reasoning[:0]
+		}
+		return msg
+	}
+
+	for i, item := range items {
+		switch {
+		case item.OfMessage != nil:
+			role := string(item.OfMessage.Role)
+			content := item.OfMessage.Content
+			if content.OfString.Valid() {
+				if role == chat.RoleAssistant {
+					msg := assistant()
+					msg.Parts = append(msg.Parts, chat.TextPart(content.OfString.Value))
+					continue
+				}
+				msg, err := inputMessage(role, []chat.Part{chat.TextPart(content.OfString.Value)})
+				if err != nil {
+					return nil, fmt.Errorf("input[%d]: %w", i, err)
+				}
+				msgs = append(msgs, msg)
+				continue
+			}
+			parts, err := toInputParts(content.OfInputItemContentList)
+			if err != nil {
+				return nil, fmt.Errorf("input[%d]: %w", i, err)
+			}
+			if role == chat.RoleAssistant {
+				msg := assistant()
+				msg.Parts = append(msg.Parts, parts...)
+				continue
+			}
+			msg, err := inputMessage(role, parts)
+			if err != nil {
+				return nil, fmt.Errorf("input[%d]: %w", i, err)
+			}
+			msgs = append(msgs, msg)
+		case item.OfInputMessage != nil:
+			parts, err := toInputParts(item.OfInputMessage.Content)
+			if err != nil {
+				return nil, fmt.Errorf("input[%d]: %w", i, err)
+			}
+			msg, err := inputMessage(item.OfInputMessage.Role, parts)
+			if err != nil {
+				return nil, fmt.Errorf("input[%d]: %w", i, err)
+			}
+			msgs = append(msgs, msg)
+		case item.OfOutputMessage != nil:
+			msg := assistant()
+			for _, part := range item.OfOutputMessage.Content {
+				switch {
+				case part.OfOutputText != nil:
+					msg.Parts = append(msg.Parts, chat.TextPart(part.OfOutputText.Text))
+				case part.OfRefusal != nil:
+					msg.Parts = append(msg.Parts, chat.TextPart(part.OfRefusal.Refusal))
+				}
+			}
+		case item.OfFunctionCall != nil:
+			call := item.OfFunctionCall
+			msg := assistant()
+			msg.ToolCalls = append(msg.ToolCalls, chat.ToolCall{
+				ID:   call.CallID,
+				Type: "function",
+				Function: chat.ToolCallFunction{
+					Name:      call.Name,
+					Arguments: call.Arguments,
+				},
+			})
+		case item.OfFunctionCallOutput != nil:
+			out := item.OfFunctionCallOutput
+			text, err := functionCallOutputText(out.Output)
+			if err != nil {
+				return nil, fmt.Errorf("input[%d]: %w", i, err)
+			}
+			msgs = append(msgs, chat.ToolResult(out.CallID, text))
+		case item.OfReasoning != nil:
+			for _, summary := range item.OfReasoning.Summary {
+				reasoning = append(reasoning, nonEmpty(summary.Text)...)
+			}
+			for _, content := range item.OfReasoning.Content {
+				reasoning = append(reasoning, nonEmpty(content.Text)...)
+			}
+		default:
+			return nil, fmt.Errorf("input[%d]: unsupported input item", i)
+		}
+	}
+	return msgs, nil
+}
+
+func inputMessage(role string, parts []chat.Part) (chat.Message, error) {
+	switch role {
+	case "user":
+		return chat.UserParts(parts...), nil
+	case "system", "developer":
+		for _, part := range parts {
+			if part.Type != chat.PartTypeText {
+				return chat.Message{}, fmt.Errorf("%s messages support text content only", role)
+			}
+		}
+		return chat.SystemParts(parts...), nil
+	default:
+		return chat.Message{}, fmt.Errorf("unsupported role %q", role)
+	}
+}
+
+func toInputParts(content responses.ResponseInputMessageContentListParam) ([]chat.Part, error) {
+	parts := make([]chat.Part, 0, len(content))
+	for _, part := range content {
+		switch {
+		case part.OfInputText != nil:
+			parts = append(parts, chat.TextPart(part.OfInputText.Text))
+		case part.OfInputImage != nil:
+			if !part.OfInputImage.ImageURL.Valid() || part.OfInputImage.ImageURL.Value == "" {
+				return nil, fmt.Errorf("input_image requires image_url; file_id is not supported")
+			}
+			parts = append(parts, chat.ImageURLPart(part.OfInputImage.ImageURL.Value))
+		case part.OfInputFile != nil:
+			doc, err := toDocumentPart(part.OfInputFile)
+			if err != nil {
+				return nil, err
+			}
+			parts = append(parts, doc)
+		default:
+			return nil, fmt.Errorf("unsupported input content part")
+		}
+	}
+	return parts, nil
+}
+
+// toDocumentPart converts an input_file with file_data (a base64 data URL)
+// or file_url to a document part.
+func toDocumentPart(file *responses.ResponseInputFileParam) (chat.Part, error) {
+	var part chat.Part
+	switch {
+	case file.FileData.Valid() && file.FileData.Value != "":
+		mimeType, data, ok := strings.Cut(strings.TrimPrefix(file.FileData.Value, "data:"), ";base64,")
+		if !ok || !strings.HasPrefix(file.FileData.Value, "data:") {
+			return chat.Part{}, fmt.Errorf("input_file file_data must be a base64 data URL")
+		}
+		part = chat.DocumentBase64Part(mimeType, data)
+	case file.FileURL.Valid() && file.FileURL.Value != "":
+		part = chat.DocumentURLPart(file.FileURL.Value)
+	default:
+		return chat.Part{}, fmt.Errorf("input_file requires file_data or file_url; file_id is not supported")
+	}
+	part.Title = file.Filename.Or("")
+	return part, nil
+}
+
+func functionCallOutputText(output responses.ResponseInputItemFunctionCallOutputOutputUnionParam) (string, error) {
+	if output.OfString.Valid() {
+		return output.OfString.Value, nil
+	}
+	texts := make([]string, 0, len(output.OfResponseFunctionCallOutputItemArray))
+	for _, item := range output.OfResponseFunctionCallOutputItemArray {
+		if item.OfInputText == nil {
+			return "", fmt.Errorf("function_call_output supports text output only")
+		}
+		texts = append(texts, item.OfInputText.Text)
+	}
+	return strings.Join(texts, "\n"), nil
+}
+
+func nonEmpty(text string) []string {
+	if strings.TrimSpace(text) == "" {
+		return nil
+	}
+	return []string{text}
+}
+
+func toResponseTools(in []responses.ToolUnionParam) ([]chat.Tool, error) {
+	tools := make([]chat.Tool, 0, len(in))
+	for _, t := range in {
+		fn := t.OfFunction
+		if fn == nil {
+			return nil, fmt.Errorf("only function tools are supported")
+		}
+		tool := chat.Tool{
+			Type: "function",
+			Function: chat.ToolFunction{
+				Name:        fn.Name,
+				Description: fn.Description.Or(""),
+			},
+		}
+		if fn.Strict.Valid() {
+			v := fn.Strict.Value
+			tool.Function.Strict = &v
+		}
+		if len(fn.Parameters) > 0 {
+			data, err := json.Marshal(fn.Parameters)
+			if err != nil {
+				return nil, err
+			}
+			tool.Function.ParametersJSONSchema = data
+		}
+		tools = append(tools, tool)
+	}
+	return tools, nil
+}
+
+func toResponseToolChoice(choice responses.ResponseNewParamsToolChoiceUnion) (chat.ToolChoice, bool, error) {
+	switch {
+	case choice.OfToolChoiceMode.Valid():
+		switch choice.OfToolChoiceMode.Value {
+		case responses.ToolChoiceOptionsAuto:
+			return chat.ToolChoiceAuto(), true, nil
+		case responses.ToolChoiceOptionsNone:
+			return chat.ToolChoiceNone(), true, nil
+		case responses.ToolChoiceOptionsRequired:
+			return chat.ToolChoiceRequired(), true, nil
+		}
+		return chat.ToolChoice{}, false, fmt.Errorf("unsupported tool_choice %q", choice.OfToolChoiceMode.Value)
+	case choice.OfFunctionTool != nil:
+		if choice.OfFunctionTool.Name == "" {
+			return chat.ToolChoice{}, false, fmt.Errorf("tool_choice function name is required")
+		}
+		return chat.ToolChoiceFunction(choice.OfFunctionTool.Name), true, nil
+	case choice.OfAllowedTools != nil, choice.OfHostedTool != nil, choice.OfMcpTool != nil, choice.OfCustomTool != nil:
+		return chat.ToolChoice{}, false, fmt.Errorf("only function tool choices are supported")
+	}
+	return chat.ToolChoice{}, false, nil
+}
+
+func toTextFormat(format responses.ResponseFormatTextConfigUnionParam) (chat.Option, error) {
+	switch {
+	case format.OfJSONObject != nil:
+		return chat.WithJSONObject(), nil
+	case format.OfJSONSchema != nil:
+		schema := format.OfJSONSchema
+		var data []byte
+		if schema.Schema != nil {
+			var err error
+			if data, err = json.Marshal(schema.Schema); err != nil {
+				return nil, fmt.Errorf("text.format schema: %w", err)
+			}
+		}
+		return chat.WithJSONSchema(schema.Name, data, schema.Strict.Or(false)), nil
+	default:
+		return nil, nil
+	}
+}
+
+// toResponseOpenAIOptions keeps the OpenAI fields shared by the Chat
+// Completions and Responses APIs for OpenAI upstreams.
+func toResponseOpenAIOptions(req responses.ResponseNewParams) structs.JSONMap {
+	opts := structs.NewJSONMap()
+	if req.ParallelToolCalls.Valid() {
+		opts["parallel_tool_calls"] = req.ParallelToolCalls.Value
+	}
+	if req.Store.Valid() {
+		opts["store"] = req.Store.Value
+	}
+	if req.PromptCacheKey.Valid() {
+		opts["prompt_cache_key"] = req.PromptCacheKey.Value
+	}
+	if req.SafetyIdentifier.Valid() {
+		opts["safety_identifier"] = req.SafetyIdentifier.Value
+	}
+	if req.ServiceTier != "" {
+		opts["service_tier"] = string(req.ServiceTier)
+	}
+	if req.Text.Verbosity != "" {
+		opts["verbosity"] = string(req.Text.Verbosity)
+	}
+	if len(req.Metadata) > 0 {
+		meta := make(map[string]any, len(req.Metadata))
+		for k, v := range req.Metadata {
+			meta[k] = v
+		}
+		opts["metadata"] = meta
+	}
+	if len(opts) == 0 {
+		return nil
+	}
+	return opts
+}
+
+// Wire forms of the Responses API response and output items. Responses are
+// encoded from these and decoded into the SDK types, whose zero values would
+// otherwise marshal as fields the API omits.
+
+type respObject struct {
+	ID                string          `json:"id"`
+	Object            string          `json:"object"`
+	CreatedAt         int64           `json:"created_at"`
+	Status            string          `json:"status"`
+	Model             string          `json:"model"`
+	Output            []any           `json:"output"`
+	Error             *struct{}       `json:"error"`
+	IncompleteDetails *respIncomplete `json:"incomplete_details"`
+	ParallelToolCalls bool            `json:"parallel_tool_calls"`
+	Usage             *respUsage      `json:"usage,omitempty"`
+}
+
+type respIncomplete struct {
+	Reason string `json:"reason"`
+}
+
+type respMessage struct {
+	ID      string        `json:"id"`
+	Type    string        `json:"type"`
+	Status  string        `json:"status"`
+	Role    string        `json:"role"`
+	Content []respContent `json:"content"`
+}
+
+type respFunctionCall struct {
+	ID        string `json:"id"`
+	Type      string `json:"type"`
+	Status    string `json:"status"`
+	CallID    string `json:"call_id"`
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+type respReasoning struct {
+	ID               string        `json:"id"`
+	Type             string        `json:"type"`
+	Status           string        `json:"status,omitempty"`
+	Summary          []respContent `json:"summary"`
+	Content          []respContent `json:"content,omitempty"`
+	EncryptedContent string        `json:"encrypted_content,omitempty"`
+}
+
+// respContent is an output_text, summary_text or reasoning_text part. Only
+// output_text parts have annotations.
+type respContent struct {
+	Type        string          `json:"type"`
+	Text        string          `json:"text"`
+	Annotations json.RawMessage `json:"annotations,omitempty"`
+}
+
+type respUsage struct {
+	InputTokens        int `json:"input_tokens"`
+	InputTokensDetails struct {
+		CachedTokens int `json:"cached_tokens"`
+	} `json:"input_tokens_details"`
+	OutputTokens        int `json:"output_tokens"`
+	OutputTokensDetails struct {
+		ReasoningTokens int `json:"reasoning_tokens"`
+	} `json:"output_tokens_details"`
+	TotalTokens int `json:"total_tokens"`
+}
+
+// responseBuilder assembles the output items of a response, from a result
+// or from stream events.
+type responseBuilder struct {
+	id        string
+	model     string
+	createdAt int64
+	// parallelToolCalls echoes the request's parallel_tool_calls, which
+	// defaults to true.
+	parallelToolCalls bool
+	items             []any
+}
+
+func newResponseBuilder(req responses.ResponseNewParams, now time.Time) *responseBuilder {
+	return &responseBuilder{
+		id:                fmt.Sprintf("resp_%d", now.UnixNano()),
+		model:             string(req.Model),
+		createdAt:         now.Unix(),
+		parallelToolCalls: !req.ParallelToolCalls.Valid() || req.ParallelToolCalls.Value,
+	}
+}
+
+// addResult appends the reasoning, message and function_call items of
+// result.
+func (b *responseBuilder) addResult(result *chat.Result) {
+	if item, ok := b.reasoningItem(result.Reasoning); ok {
+		b.items = append(b.items, item)
+	}
+	if result.Text != "" {
+		item := b.message()
+		item.Content = append(item.Content, outputText(result.Text))
+		b.items = append(b.items, item)
+	}
+	for _, call := range result.ToolCalls {
+		item := b.functionCall(call.ID, call.Function.Name)
+		item.Arguments = call.Function.Arguments
+		b.items = append(b.items, item)
+	}
+}
+
+func (b *responseBuilder) reasoningItem(reasoning *chat.ReasoningResult) (respReasoning, bool) {
+	item := b.reasoning()
+	if reasoning == nil {
+		return item, false
+	}
+	for _, summary := range reasoning.Summary {
+		item.Summary = append(item.Summary, respContent{Type: "summary_text", Text: summary})
+	}
+	for _, block := range reasoning.Blocks {
+		switch {
+		case block.Type == "encrypted":
+			item.EncryptedContent = block.Data
+		case block.Text != "":
+			item.Content = append(item.Content, respContent{Type: "reasoning_text", Text: block.Text})
+		}
+	}
+	return item, len(item.Summary) > 0 || len(item.Content) > 0 || item.EncryptedContent != ""
+}
+
+// The item constructors return completed items with an id unique in the
+// response, for the next output index.
+
+func (b *responseBuilder) message() respMessage {
+	return respMessage{ID: b.itemID("msg"), Type: "message", Status: "completed", Role: "assistant", Content: []respContent{}}
+}
+
+func (b *responseBuilder) functionCall(callID, name string) respFunctionCall {
+	return respFunctionCall{ID: b.itemID("fc"), Type: "function_call", Status: "completed", CallID: callID, Name: name}
+}
+
+func (b *responseBuilder) reasoning() respReasoning {
+	return respReasoning{ID: b.itemID("rs"), Type: "reasoning", Summary: []respContent{}}
+}
+
+func (b *responseBuilder) itemID(prefix string) string {
+	return fmt.Sprintf("%s_%s_%d", prefix, strings.TrimPrefix(b.id, "resp_"), len(b.items))
+}
+
+// response returns the response object. Without usage it is the in-progress
+// response sent at the start of a stream.
+func (b *responseBuilder) response(reason chat.FinishReason, usage *chat.Usage) respObject {
+	resp := respObject{
+		ID:                b.id,
+		Object:            "response",
+		CreatedAt:         b.createdAt,
+		Status:            "in_progress",
+		Model:             b.model,
+		Output:            append([]any{}, b.items...),
+		ParallelToolCalls: b.parallelToolCalls,
+	}
+	if usage == nil {
+		return resp
+	}
+	resp.Status = "completed"
+	switch reason {
+	case chat.FinishReasonLength:
+		resp.Status = "incomplete"
+		resp.IncompleteDetails = &respIncomplete{Reason: "max_output_tokens"}
+	case chat.FinishReasonContentFilter:
+		resp.Status = "incomplete"
+		resp.IncompleteDetails = &respIncomplete{Reason: "content_filter"}
+	}
+	resp.Usage = &respUsage{
+		InputTokens:  usage.InputTokens,
+		OutputTokens: usage.OutputTokens,
+		TotalTokens:  usage.TotalTokens,
+	}
+	resp.Usage.InputTokensDetails.CachedTokens = usage.Cache.CachedInputTokens
+	return resp
+}
+
+func (b *responseBuilder) encode(v any) []byte {
+	// The wire types hold only strings, numbers and slices of them, so
+	// marshaling cannot fail.
+	data, _ := json.Marshal(v)
+	return data
+}
+
+func outputText(text string) respContent {
+	return respContent{Type: "output_text", Text: text, Annotations: json.RawMessage("[]")}
+}
@@ -0,0 +1,329 @@
+package openai
+
+import (
+	"context"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/openai/openai-go/v3/packages/ssestream"
+	"github.com/openai/openai-go/v3/responses"
+	uniai "github.com/quailyquaily/uniai"
+	"github.com/quailyquaily/uniai/chat"
+)
+
+// CreateResponseStream runs req as a streaming uniai chat call and returns
+// its events as Responses API stream events, in the stream type returned by
+// openai-go's ResponseService.NewStreaming. Options in opts are applied
+// after the converted request.
+//
+// The stream starts with response.created and response.in_progress and ends
+// with response.completed, or response.incomplete when the output was cut
+// short, carrying the full response. In between, each reasoning, message and
+// function_call output item is sent as output_item.added, its part and delta
+// events, and output_item.done. Provider errors, including those before the
+// first event, are returned by stream.Err().
+func (c *Client) CreateResponseStream(ctx context.Context, req responses.ResponseNewParams, opts ...chat.Option) *ssestream.Stream[responses.ResponseStreamEventUnion] {
+	converted, err := toResponseOptions(req)
+	if err != nil {
+		return ssestream.NewStream[responses.ResponseStreamEventUnion](nil, err)
+	}
+	stream, err := c.base.ChatStream(ctx, append(converted, opts...)...)
+	if err != nil {
+		return ssestream.NewStream[responses.ResponseStreamEventUnion](nil, err)
+	}
+	decoder := &responseEventDecoder{
+		stream: stream,
+		events: &responseEvents{response: newResponseBuilder(req, time.Now())},
+	}
+	return ssestream.NewStream[responses.ResponseStreamEventUnion](decoder, nil)
+}
+
+// responseEventDecoder adapts a uniai.ChatStream to the ssestream.Decoder
+// interface, encoding each converted event as one SSE event.
+type responseEventDecoder struct {
+	stream  *uniai.ChatStream
+	events  *responseEvents
+	pending []ssestream.Event
+	event   ssestream.Event
+	err     error
+}
+
+func (d *responseEventDecoder) Next() bool {
+	for len(d.pending) == 0 {
+		if d.err != nil || d.events.done {
+			return false
+		}
+		ev, err := d.stream.Recv()
+		if err == io.EOF {
+			// Providers that fall back to a blocking call end without a
+			// Done event; finish the response from the result.
+			result, err := d.stream.Result()
+			if err != nil {
+				d.err = err
+				return false
+			}
+			d.pending = d.events.finish(result)
+			continue
+		}
+		if err != nil {
+			d.err = err
+			return false
+		}
+		d.pending = d.events.add(ev)
+	}
+	d.event = d.pending[0]
+	d.pending = d.pending[1:]
+	return true
+}
+
+func (d *responseEventDecoder) Event() ssestream.Event { return d.event }
+
+func (d *responseEventDecoder) Close() error { return d.stream.Close() }
+
+func (d *responseEventDecoder) Err() error { return d.err }
+
+// openItem is the output item being streamed. key is the provider index of
+// the reasoning or tool call delta that opened it.
+type openItem struct {
+	kind string
+	key  int
+	// index is the output index of the item.
+	index     int
+	reasoning respReasoning
+	message   respMessage
+	call      respFunctionCall
+	// text accumulates the open summary, reasoning text or output text.
+	text strings.Builder
+}
+
+// responseEvents converts stream events to Responses stream events, keeping
+// the state shared across a stream: the sequence number, the open item and
+// the items completed so far.
+type responseEvents struct {
+	response *responseBuilder
+	sequence int
+	started  bool
+	done     bool
+	open     *openItem
+}
+
+// add returns the events for ev.
+func (e *responseEvents) add(ev chat.StreamEvent) []ssestream.Event {
+	var out []ssestream.Event
+	if !e.started {
+		e.started = true
+		resp := e.response.response("", nil)
+		out = append(out,
+			e.event("response.created", map[string]any{"response": resp}),
+			e.event("response.in_progress", map[string]any{"response": resp}),
+		)
+	}
+	if delta := ev.ReasoningDelta; delta != nil && delta.Delta != "" {
+		out = e.reasoningDelta(out, delta)
+	}
+	if ev.Delta != "" {
+		if e.open == nil || e.open.kind != "message" {
+			out = e.close(out)
+			e.open = &openItem{kind: "message", index: len(e.response.items), message: e.response.message()}
+			e.open.message.Status = "in_progress"
+			part := outputText("")
+			out = append(out,
+				e.itemEvent("response.output_item.added", e.open.message),
+				e.event("response.content_part.added", e.partFields(part)),
+			)
+		}
+		e.open.text.WriteString(ev.Delta)
+		out = append(out, e.event("response.output_text.delta", e.partFields(nil, "delta", ev.Delta, "logprobs", []any{})))
+	}
+	if call := ev.ToolCallDelta; call != nil {
+		if e.open == nil || e.open.kind != "function_call" || e.open.key != call.Index {
+			out = e.close(out)
+			e.open = &openItem{kind: "function_call", key: call.Index, index: len(e.response.items), call: e.response.functionCall(call.ID, call.Name)}
+			e.open.call.Status = "in_progress"
+			out = append(out, e.itemEvent("response.output_item.added", e.open.call))
+		}
+		if call.ArgsChunk != "" {
+			e.open.text.WriteString(call.ArgsChunk)
+			out = append(out, e.event("response.function_call_arguments.delta", e.itemFields("delta", call.ArgsChunk)))
+		}
+	}
+	if ev.Done {
+		out = e.close(out)
+		e.done = true
+		typ := "response.completed"
+		resp := e.response.response(ev.FinishReason, usageOrZero(ev.Usage))
+		if resp.Status == "incomplete" {
+			typ = "response.incomplete"
+		}
+		out = append(out, e.event(typ, map[string]any{"response": resp}))
+	}
+	return out
+}
+
+// reasoningDelta adds a reasoning delta to the open reasoning item, starting
+// one when needed. Summary deltas open a summary part per delta index;
+// thinking deltas a reasoning_text content part.
+func (e *responseEvents) reasoningDelta(out []ssestream.Event, delta *chat.ReasoningDelta) []ssestream.Event {
+	key := delta.Index
+	if delta.Type == chat.ReasoningDeltaThinking {
+		key = -1 - delta.Index
+	}
+	if e.open == nil || e.open.kind != "reasoning" {
+		out = e.close(out)
+		e.open = &openItem{kind: "reasoning", key: key, index: len(e.response.items), reasoning: e.response.reasoning()}
+		out = append(out, e.itemEvent("response.output_item.added", e.open.reasoning))
+		out = e.openReasoningPart(out, delta.Type)
+	} else if e.open.key != key {
+		out = e.closeReasoningPart(out)
+		e.open.key = key
+		out = e.openReasoningPart(out, delta.Type)
+	}
+	e.open.text.WriteString(delta.Delta)
+	if delta.Type == chat.ReasoningDeltaThinking {
+		return append(out, e.event("response.reasoning_text.delta", e.partFields(nil, "delta", delta.Delta)))
+	}
+	return append(out, e.event("response.reasoning_summary_text.delta", e.summaryFields("delta", delta.Delta)))
+}
+
+func (e *responseEvents) openReasoningPart(out []ssestream.Event, typ chat.ReasoningDeltaType) []ssestream.Event {
+	e.open.text.Reset()
+	if typ == chat.ReasoningDeltaThinking {
+		return append(out, e.event("response.content_part.added", e.partFields(respContent{Type: "reasoning_text"})))
+	}
+	e.open.reasoning.Summary = append(e.open.reasoning.Summary, respContent{Type: "summary_text"})
+	return append(out, e.event("response.reasoning_summary_part.added", e.summaryFields("part", respContent{Type: "summary_text"})))
+}
+
+func (e *responseEvents) closeReasoningPart(out []ssestream.Event) []ssestream.Event {
+	text := e.open.text.String()
+	if e.open.key < 0 {
+		part := respContent{Type: "reasoning_text", Text: text}
+		out = append(out,
+			e.event("response.reasoning_text.done", e.partFields(nil, "text", text)),
+			e.event("response.content_part.done", e.partFields(part)),
+		)
+		e.open.reasoning.Content = append(e.open.reasoning.Content, part)
+		return out
+	}
+	part := respContent{Type: "summary_text", Text: text}
+	e.open.reasoning.Summary[len(e.open.reasoning.Summary)-1] = part
+	return append(out,
+		e.event("response.reasoning_summary_text.done", e.summaryFields("text", text)),
+		e.event("response.reasoning_summary_part.done", e.summaryFields("part", part)),
+	)
+}
+
+// close completes the open item and appends it to the response output.
+func (e *responseEvents) close(out []ssestream.Event) []ssestream.Event {
+	if e.open == nil {
+		return out
+	}
+	var item any
+	switch e.open.kind {
+	case "reasoning":
+		out = e.closeReasoningPart(out)
+		e.open.reasoning.Status = "completed"
+		item = e.open.reasoning
+	case "message":
+		text := e.open.text.String()
+		part := outputText(text)
+		e.open.message.Status = "completed"
+		e.open.message.Content = []respContent{part}
+		item = e.open.message
+		out = append(out,
+			e.event("response.output_text.done", e.partFields(nil, "text", text, "logprobs", []any{})),
+			e.event("response.content_part.done", e.partFields(part)),
+		)
+	case "function_call":
+		e.open.call.Status = "completed"
+		e.open.call.Arguments = e.open.text.String()
+		item = e.open.call
+		out = append(out, e.event("response.function_call_arguments.done", e.itemFields("arguments", e.open.call.Arguments, "name", e.open.call.Name)))
+	}
+	out = append(out, e.itemEvent("response.output_item.done", item))
+	e.response.items = append(e.response.items, item)
+	e.open = nil
+	return out
+}
+
+// finish returns the remaining events of a stream that ended without a Done
+// event. When nothing was streamed, the result content is sent as whole
+// items.
+func (e *responseEvents) finish(result *chat.Result) []ssestream.Event {
+	var out []ssestream.Event
+	if !e.started || (e.open == nil && len(e.response.items) == 0) {
+		out = e.add(chat.StreamEvent{Delta: result.Text})
+		for i, call := range result.ToolCalls {
+			out = append(out, e.add(chat.StreamEvent{ToolCallDelta: &chat.ToolCallDelta{
+				Index:     i,
+				ID:        call.ID,
+				Name:      call.Function.Name,
+				ArgsChunk: call.Function.Arguments,
+			}})...)
+		}
+	}
+	usage := result.Usage
+	return append(out, e.add(chat.StreamEvent{Done: true, Usage: &usage, FinishReason: result.FinishReason})...)
+}
+
+func (e *responseEvents) itemEvent(typ string, item any) ssestream.Event {
+	return e.event(typ, map[string]any{"output_index": e.open.index, "item": item})
+}
+
+// itemFields returns the fields of an event about the open item, plus
+// key/value pairs in kv.
+func (e *responseEvents) itemFields(kv ...any) map[string]any {
+	fields := map[string]any{"output_index": e.open.index, "item_id": e.openID()}
+	for i := 0; i+1 < len(kv); i += 2 {
+		fields[kv[i].(string)] = kv[i+1]
+	}
+	return fields
+}
+
+// partFields returns the fields of an event about the open content part,
+// with part when it is non-nil.
+func (e *responseEvents) partFields(part any, kv ...any) map[string]any {
+	fields := e.itemFields(kv...)
+	fields["content_index"] = 0
+	if e.open.kind == "reasoning" {
+		fields["content_index"] = len(e.open.reasoning.Content)
+	}
+	if part != nil {
+		fields["part"] = part
+	}
+	return fields
+}
+
+func (e *responseEvents) summaryFields(kv ...any) map[string]any {
+	fields := e.itemFields(kv...)
+	fields["summary_index"] = len(e.open.reasoning.Summary) - 1
+	return fields
+}
+
+func (e *responseEvents) openID() string {
+	switch e.open.kind {
+	case "reasoning":
+		return e.open.reasoning.ID
+	case "message":
+		return e.open.message.ID
+	default:
+		return e.open.call.ID
+	}
+}
+
+// event returns an SSE event of typ with the fields, type and sequence
+// number.
+func (e *responseEvents) event(typ string, fields map[string]any) ssestream.Event {
+	fields["type"] = typ
+	fields["sequence_number"] = e.sequence
+	e.sequence++
+	return ssestream.Event{Type: typ, Data: e.response.encode(fields)}
+}
+
+func usageOrZero(usage *chat.Usage) *chat.Usage {
+	if usage == nil {
+		return &chat.Usage{}
+	}
+	return usage
+}
@@ -0,0 +1,292 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	openai "github.com/openai/openai-go/v3"
+	"github.com/openai/openai-go/v3/responses"
+	"github.com/openai/openai-go/v3/shared"
+	uniai "github.com/quailyquaily/uniai"
+	"github.com/quailyquaily/uniai/chat"
+)
+
+func TestToResponseOptions(t *testing.T) {
+	image := responses.ResponseInputContentParamOfInputImage(responses.ResponseInputImageDetailAuto)
+	image.OfInputImage.ImageURL = openai.String("https://example.com/oslo.png")
+	req := responses.ResponseNewParams{
+		Model:        "gpt-5-mini",
+		Instructions: openai.String("Be brief."),
+		Input: responses.ResponseNewParamsInputUnion{OfInputItemList: responses.ResponseInputParam{
+			responses.ResponseInputItemParamOfMessage(responses.ResponseInputMessageContentListParam{
+				responses.ResponseInputContentParamOfInputText("Weather here?"),
+				image,
+			}, responses.EasyInputMessageRoleUser),
+			responses.ResponseInputItemParamOfReasoning("rs_1", []responses.ResponseReasoningItemSummaryParam{{Text: "Use the tool."}}),
+			responses.ResponseInputItemParamOfOutputMessage([]responses.ResponseOutputMessageContentUnionParam{
+				{OfOutputText: &responses.ResponseOutputTextParam{Text: "Checking."}},
+			}, "msg_1", responses.ResponseOutputMessageStatusCompleted),
+			responses.ResponseInputItemParamOfFunctionCall(`{"city":"Oslo"}`, "call_1", "weather"),
+			responses.ResponseInputItemParamOfFunctionCallOutput("call_1", "12C"),
+		}},
+		Tools: []responses.ToolUnionParam{
+			responses.ToolParamOfFunction("weather", map[string]any{"type": "object"}, true),
+		},
+		ToolChoice: responses.ResponseNewParamsToolChoiceUnion{
+			OfFunctionTool: &responses.ToolChoiceFunctionParam{Name: "weather"},
+		},
+		Text: responses.ResponseTextConfigParam{Format: responses.ResponseFormatTextConfigUnionParam{
+			OfJSONSchema: &responses.ResponseFormatTextJSONSchemaConfigParam{Name: "answer", Schema: map[string]any{"type": "object"}},
+		}},
+		Reasoning:       shared.ReasoningParam{Effort: shared.ReasoningEffortHigh, Summary: shared.ReasoningSummaryAuto},
+		MaxOutputTokens: openai.Int(300),
+		Store:           openai.Bool(false),
+	}
+
+	opts, err := ResponseOptions(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	chatReq, err := chat.BuildRequest(opts...)
+	if err != nil {
+		t.Fatalf("unexpected build error: %v", err)
+	}
+
+	msgs := chatReq.Messages
+	if len(msgs) != 4 {
+		t.Fatalf("expected 4 messages, got %#v", msgs)
+	}
+	if msgs[0].Role != chat.RoleSystem || msgs[0].Content != "Be brief." {
+		t.Fatalf("unexpected system message: %#v", msgs[0])
+	}
+	if len(msgs[1].Parts) != 2 || msgs[1].Parts[1].Type != chat.PartTypeImageURL {
+		t.Fatalf("unexpected user message: %#v", msgs[1])
+	}
+	assistant := msgs[2]
+	if assistant.Role != chat.RoleAssistant || assistant.ReasoningContent != "Use the tool." || assistant.Parts[0].Text != "Checking." {
+		t.Fatalf("unexpected assistant message: %#v", assistant)
+	}
+	if len(assistant.ToolCalls) != 1 || assistant.ToolCalls[0].ID != "call_1" || assistant.ToolCalls[0].Function.Arguments != `{"city":"Oslo"}` {
+		t.Fatalf("unexpected assistant tool calls: %#v", assistant.ToolCalls)
+	}
+	if msgs[3].Role != chat.RoleTool || msgs[3].ToolCallID != "call_1" || msgs[3].Content != "12C" {
+		t.Fatalf("unexpected tool message: %#v", msgs[3])
+	}
+	if len(chatReq.Tools) != 1 || chatReq.Tools[0].Function.Strict == nil || !*chatReq.Tools[0].Function.Strict {
+		t.Fatalf("unexpected tools: %#v", chatReq.Tools)
+	}
+	if chatReq.ToolChoice == nil || chatReq.ToolChoice.FunctionName != "weather" {
+		t.Fatalf("unexpected tool choice: %#v", chatReq.ToolChoice)
+	}
+	if format := chatReq.Options.ResponseFormat; format == nil || format.Name != "answer" || string(format.Schema) != `{"type":"object"}` {
+		t.Fatalf("unexpected response format: %#v", chatReq.Options.ResponseFormat)
+	}
+	if chatReq.Options.ReasoningEffort == nil || *chatReq.Options.ReasoningEffort != chat.ReasoningEffortHigh || !chatReq.Options.ReasoningDetails {
+		t.Fatalf("unexpected reasoning options: %#v", chatReq.Options)
+	}
+	if *chatReq.Options.MaxTokens != 300 || chatReq.Options.OpenAI["store"] != false {
+		t.Fatalf("unexpected options: %#v", chatReq.Options)
+	}
+
+	if _, err := ResponseOptions(responses.ResponseNewParams{Model: "m", PreviousResponseID: openai.String("resp_1")}); err == nil {
+		t.Fatalf("expected previous_response_id to be rejected")
+	}
+	if _, err := ResponseOptions(responses.ResponseNewParams{Model: "m", Tools: []responses.ToolUnionParam{
+		responses.ToolParamOfWebSearch(responses.WebSearchToolTypeWebSearch),
+	}}); err == nil {
+		t.Fatalf("expected hosted tools to be rejected")
+	}
+}
+
+func TestToResponseOptionsWithInputFiles(t *testing.T) {
+	req := responses.ResponseNewParams{
+		Model: "gpt-5-mini",
+		Input: responses.ResponseNewParamsInputUnion{OfInputItemList: responses.ResponseInputParam{
+			responses.ResponseInputItemParamOfMessage(responses.ResponseInputMessageContentListParam{
+				{OfInputFile: &responses.ResponseInputFileParam{FileData: openai.String("data:application/pdf;base64,JVBERi0="), Filename: openai.String("report.pdf")}},
+				{OfInputFile: &responses.ResponseInputFileParam{FileURL: openai.String("https://example.com/a.pdf")}},
+				responses.ResponseInputContentParamOfInputText("Summarize."),
+			}, responses.EasyInputMessageRoleUser),
+		}},
+	}
+
+	opts, err := ResponseOptions(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	chatReq, err := chat.BuildRequest(opts...)
+	if err != nil {
+		t.Fatalf("unexpected build error: %v", err)
+	}
+	parts := chatReq.Messages[0].Parts
+	if len(parts) != 3 {
+		t.Fatalf("expected 3 parts, got %#v", parts)
+	}
+	if parts[0].Type != chat.PartTypeDocumentBase64 || parts[0].MIMEType != "application/pdf" || parts[0].DataBase64 != "JVBERi0=" || parts[0].Title != "report.pdf" {
+		t.Fatalf("unexpected file_data part: %#v", parts[0])
+	}
+	if parts[1].Type != chat.PartTypeDocumentURL || parts[1].URL != "https://example.com/a.pdf" {
+		t.Fatalf("unexpected file_url part: %#v", parts[1])
+	}
+
+	req.Input.OfInputItemList[0].OfMessage.Content.OfInputItemContentList[0].OfInputFile = &responses.ResponseInputFileParam{FileID: openai.String("file_1")}
+	if _, err := ResponseOptions(req); err == nil {
+		t.Fatalf("expected file_id inputs to be rejected")
+	}
+}
+
+func TestCreateResponse(t *testing.T) {
+	var upstream map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &upstream)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{
+			"id":"chatcmpl-1","object":"chat.completion","created":1,"model":"deepseek-chat",
+			"choices":[{"index":0,"finish_reason":"tool_calls","message":{
+				"role":"assistant","content":"Checking.","reasoning_content":"Need the weather.",
+				"tool_calls":[{"id":"call_1","type":"function","function":{"name":"weather","arguments":"{\"city\":\"Oslo\"}"}}]
+			}}],
+			"usage":{"prompt_tokens":20,"completion_tokens":7,"total_tokens":27}
+		}`)
+	}))
+	defer server.Close()
+	client := New(uniai.New(uniai.Config{Provider: "openai", OpenAIAPIKey: "test-key", OpenAIAPIBase: server.URL + "/v1"}))
+
+	resp, err := client.CreateResponse(context.Background(), responses.ResponseNewParams{
+		Model:     "gpt-alias",
+		Input:     responses.ResponseNewParamsInputUnion{OfString: openai.String("Weather in Oslo?")},
+		Tools:     []responses.ToolUnionParam{responses.ToolParamOfFunction("weather", map[string]any{"type": "object"}, false)},
+		Reasoning: shared.ReasoningParam{Summary: shared.ReasoningSummaryAuto},
+	}, chat.WithModel("deepseek-chat"))
+	if err != nil {
+		t.Fatalf("CreateResponse: %v", err)
+	}
+
+	if upstream["model"] != "deepseek-chat" {
+		t.Fatalf("expected the routed model upstream, got %v", upstream["model"])
+	}
+	if !strings.HasPrefix(resp.ID, "resp_") || resp.Status != responses.ResponseStatusCompleted || resp.Model != "deepseek-chat" || !resp.ParallelToolCalls {
+		t.Fatalf("unexpected response: %s", resp.RawJSON())
+	}
+	if len(resp.Output) != 3 {
+		t.Fatalf("expected reasoning, message and function_call items, got %s", resp.RawJSON())
+	}
+	if item := resp.Output[0].AsReasoning(); item.Type != "reasoning" || len(item.Content) != 1 || item.Content[0].Text != "Need the weather." {
+		t.Fatalf("unexpected reasoning item: %s", resp.Output[0].RawJSON())
+	}
+	if resp.Output[1].Type != "message" || resp.OutputText() != "Checking." {
+		t.Fatalf("unexpected message item: %s", resp.Output[1].RawJSON())
+	}
+	if item := resp.Output[2].AsFunctionCall(); item.CallID != "call_1" || item.Name != "weather" || item.Arguments != `{"city":"Oslo"}` {
+		t.Fatalf("unexpected function_call item: %s", resp.Output[2].RawJSON())
+	}
+	if resp.Usage.InputTokens != 20 || resp.Usage.OutputTokens != 7 || resp.Usage.TotalTokens != 27 {
+		t.Fatalf("unexpected usage: %s", resp.Usage.RawJSON())
+	}
+}
+
+func TestCreateResponseStream(t *testing.T) {
+	client := anthropicClient(t, sse(
+		`{"type":"message_start","message":{"model":"claude-sonnet-test","usage":{"input_tokens":12}}}`,
+		`{"type":"content_block_start","index":0,"content_block":{"type":"thinking","thinking":""}}`,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"thinking_delta","thinking":"look it up"}}`,
+		`{"type":"content_block_stop","index":0}`,
+		`{"type":"content_block_start","index":1,"content_block":{"type":"text","text":""}}`,
+		`{"type":"content_block_delta","index":1,"delta":{"type":"text_delta","text":"Checking."}}`,
+		`{"type":"content_block_stop","index":1}`,
+		`{"type":"content_block_start","index":2,"content_block":{"type":"tool_use","id":"toolu_a","name":"weather","input":{}}}`,
+		`{"type":"content_block_delta","index":2,"delta":{"type":"input_json_delta","partial_json":"{\"city\":"}}`,
+		`{"type":"content_block_delta","index":2,"delta":{"type":"input_json_delta","partial_json":"\"Oslo\"}"}}`,
+		`{"type":"content_block_stop","index":2}`,
+		`{"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":30}}`,
+		`{"type":"message_stop"}`,
+	), http.StatusOK)
+
+	stream := client.CreateResponseStream(context.Background(), responses.ResponseNewParams{
+		Model:             "claude-sonnet-test",
+		Input:             responses.ResponseNewParamsInputUnion{OfString: openai.String("Weather in Oslo?")},
+		MaxOutputTokens:   openai.Int(2048),
+		Reasoning:         shared.ReasoningParam{Summary: shared.ReasoningSummaryAuto},
+		ParallelToolCalls: openai.Bool(false),
+	}, chat.WithReasoningBudgetTokens(1024))
+	defer stream.Close()
+
+	var events []responses.ResponseStreamEventUnion
+	for stream.Next() {
+		events = append(events, stream.Current())
+	}
+	if err := stream.Err(); err != nil {
+		t.Fatalf("stream: %v", err)
+	}
+
+	types := make([]string, 0, len(events))
+	for i, ev := range events {
+		if ev.SequenceNumber != int64(i) {
+			t.Fatalf("event %d has sequence number %d", i, ev.SequenceNumber)
+		}
+		types = append(types, strings.TrimPrefix(ev.Type, "response."))
+	}
+	want := []string{
+		"created", "in_progress",
+		"output_item.added", "content_part.added", "reasoning_text.delta", "reasoning_text.done", "content_part.done", "output_item.done",
+		"output_item.added", "content_part.added", "output_text.delta", "output_text.done", "content_part.done", "output_item.done",
+		"output_item.added", "function_call_arguments.delta", "function_call_arguments.delta", "function_call_arguments.done", "output_item.done",
+		"completed",
+	}
+	if strings.Join(types, ",") != strings.Join(want, ",") {
+		t.Fatalf("unexpected event sequence:\n got %v\nwant %v", types, want)
+	}
+	if ev := events[10]; ev.Delta != "Checking." || ev.OutputIndex != 1 {
+		t.Fatalf("unexpected output_text.delta: %s", ev.RawJSON())
+	}
+	if ev := events[17]; ev.Arguments != `{"city":"Oslo"}` || ev.OutputIndex != 2 {
+		t.Fatalf("unexpected function_call_arguments.done: %s", ev.RawJSON())
+	}
+
+	if events[0].Response.ParallelToolCalls {
+		t.Fatalf("expected parallel_tool_calls false in response.created: %s", events[0].RawJSON())
+	}
+	resp := events[len(events)-1].Response
+	if resp.Status != responses.ResponseStatusCompleted || len(resp.Output) != 3 || resp.OutputText() != "Checking." || resp.ParallelToolCalls {
+		t.Fatalf("unexpected completed response: %s", resp.RawJSON())
+	}
+	if item := resp.Output[0].AsReasoning(); len(item.Content) != 1 || item.Content[0].Text != "look it up" {
+		t.Fatalf("unexpected reasoning item: %s", resp.Output[0].RawJSON())
+	}
+	if item := resp.Output[2].AsFunctionCall(); item.CallID != "toolu_a" || item.Arguments != `{"city":"Oslo"}` {
+		t.Fatalf("unexpected function_call item: %s", resp.Output[2].RawJSON())
+	}
+	if resp.Usage.InputTokens != 12 || resp.Usage.OutputTokens != 30 {
+		t.Fatalf("unexpected usage: %s", resp.Usage.RawJSON())
+	}
+}
+
+func TestCreateResponseStreamErrors(t *testing.T) {
+	client := anthropicClient(t, `{"type":"error","error":{"type":"authentication_error","message":"bad key"}}`, http.StatusUnauthorized)
+	stream := client.CreateResponseStream(context.Background(), responses.ResponseNewParams{
+		Model: "claude-sonnet-test",
+		Input: responses.ResponseNewParamsInputUnion{OfString: openai.String("hello")},
+	})
+	defer stream.Close()
+	if stream.Next() {
+		t.Fatalf("expected no events, got %s", stream.Current().RawJSON())
+	}
+	if !errors.Is(stream.Err(), uniai.ErrAuth) {
+		t.Fatalf("expected the provider error, got %v", stream.Err())
+	}
+
+	invalid := client.CreateResponseStream(context.Background(), responses.ResponseNewParams{
+		Model:              "claude-sonnet-test",
+		PreviousResponseID: openai.String("resp_1"),
+	})
+	if invalid.Next() || invalid.Err() == nil {
+		t.Fatalf("expected invalid request to fail")
+	}
+}
@@ -0,0 +1,223 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	openai "github.com/openai/openai-go/v3"
+	"github.com/openai/openai-go/v3/packages/ssestream"
+	uniai "github.com/quailyquaily/uniai"
+	"github.com/quailyquaily/uniai/chat"
+)
+
+// CreateChatCompletionStream runs req as a streaming uniai chat call and
+// returns its events as Chat Completions chunks, in the stream type returned
+// by openai-go's ChatCompletionService.NewStreaming. Options in opts are
+// applied after the converted request.
+//
+// The first chunk carries the assistant role. With chat.WithReasoningDetails
+// in opts, reasoning is sent as reasoning_content deltas, readable from the
+// chunk's RawJSON or Delta.JSON.ExtraFields. Tool
+// call deltas are numbered 0, 1, ... in the order the calls start, whatever
+// indices the provider uses. When req.StreamOptions.IncludeUsage is set, a
+// final chunk with no choices carries the usage.
+func (c *Client) CreateChatCompletionStream(ctx context.Context, req openai.ChatCompletionNewParams, opts ...chat.Option) *ssestream.Stream[openai.ChatCompletionChunk] {
+	converted, err := toChatOptions(req)
+	if err != nil {
+		return ssestream.NewStream[openai.ChatCompletionChunk](nil, err)
+	}
+	stream, err := c.base.ChatStream(ctx, append(converted, opts...)...)
+	if err != nil {
+		return ssestream.NewStream[openai.ChatCompletionChunk](nil, err)
+	}
+	now := time.Now()
+	decoder := &chunkDecoder{
+		stream: stream,
+		chunks: newChunkBuilder(
+			fmt.Sprintf("chatcmpl-%d", now.UnixNano()),
+			string(req.Model),
+			now.Unix(),
+			req.StreamOptions.IncludeUsage.Or(false),
+		),
+	}
+	return ssestream.NewStream[openai.ChatCompletionChunk](decoder, nil)
+}
+
+// chunkDecoder adapts a uniai.ChatStream to the ssestream.Decoder interface,
+// encoding each converted chunk as the data of one event.
+type chunkDecoder struct {
+	stream  *uniai.ChatStream
+	chunks  *chunkBuilder
+	pending [][]byte
+	event   ssestream.Event
+	err     error
+}
+
+func (d *chunkDecoder) Next() bool {
+	for len(d.pending) == 0 {
+		if d.err != nil {
+			return false
+		}
+		ev, err := d.stream.Recv()
+		if err == io.EOF {
+			return false
+		}
+		if err != nil {
+			d.err = err
+			return false
+		}
+		d.pending = d.chunks.add(ev)
+	}
+	d.event = ssestream.Event{Data: d.pending[0]}
+	d.pending = d.pending[1:]
+	return true
+}
+
+func (d *chunkDecoder) Event() ssestream.Event { return d.event }
+
+func (d *chunkDecoder) Close() error { return d.stream.Close() }
+
+func (d *chunkDecoder) Err() error { return d.err }
+
+// streamChunk is the wire form of openai.ChatCompletionChunk. It is encoded
+// rather than built as the SDK type because SDK response types do not
+// marshal fields they do not declare, such as reasoning_content.
+type streamChunk struct {
+	ID      string              `json:"id"`
+	Object  string              `json:"object"`
+	Created int64               `json:"created"`
+	Model   string              `json:"model"`
+	Choices []streamChunkChoice `json:"choices"`
+	Usage   *streamUsage        `json:"usage,omitempty"`
+}
+
+type streamChunkChoice struct {
+	Index        int         `json:"index"`
+	Delta        streamDelta `json:"delta"`
+	FinishReason *string     `json:"finish_reason"`
+}
+
+type streamDelta struct {
+	Role             string           `json:"role,omitempty"`
+	Content          string           `json:"content,omitempty"`
+	ReasoningContent string           `json:"reasoning_content,omitempty"`
+	ToolCalls        []streamToolCall `json:"tool_calls,omitempty"`
+}
+
+type streamToolCall struct {
+	Index    int                `json:"index"`
+	ID       string             `json:"id,omitempty"`
+	Type     string             `json:"type,omitempty"`
+	Function streamToolFunction `json:"function"`
+}
+
+type streamToolFunction struct {
+	Name      string `json:"name,omitempty"`
+	Arguments string `json:"arguments"`
+}
+
+type streamUsage struct {
+	PromptTokens        int `json:"prompt_tokens"`
+	CompletionTokens    int `json:"completion_tokens"`
+	TotalTokens         int `json:"total_tokens"`
+	PromptTokensDetails struct {
+		CachedTokens int `json:"cached_tokens"`
+	} `json:"prompt_tokens_details"`
+}
+
+// chunkBuilder converts stream events to chunks, keeping the state shared
+// across a stream: the role chunk, tool call numbering and finish reason.
+type chunkBuilder struct {
+	id           string
+	model        string
+	created      int64
+	includeUsage bool
+	started      bool
+	toolIndex    map[int]int
+}
+
+func newChunkBuilder(id, model string, created int64, includeUsage bool) *chunkBuilder {
+	return &chunkBuilder{id: id, model: model, created: created, includeUsage: includeUsage, toolIndex: map[int]int{}}
+}
+
+// add returns the encoded chunks for ev.
+func (b *chunkBuilder) add(ev chat.StreamEvent) [][]byte {
+	var chunks []streamChunk
+	if !b.started {
+		chunks = append(chunks, b.delta(streamDelta{}))
+	}
+	if ev.ReasoningDelta != nil && ev.ReasoningDelta.Delta != "" {
+		chunks = append(chunks, b.delta(streamDelta{ReasoningContent: ev.ReasoningDelta.Delta}))
+	}
+	if ev.Delta != "" {
+		chunks = append(chunks, b.delta(streamDelta{Content: ev.Delta}))
+	}
+	if ev.ToolCallDelta != nil {
+		chunks = append(chunks, b.delta(streamDelta{ToolCalls: []streamToolCall{b.toolCall(ev.ToolCallDelta)}}))
+	}
+	if ev.Done {
+		reason := finishReasonFor(ev.FinishReason, len(b.toolIndex) > 0)
+		done := b.delta(streamDelta{})
+		done.Choices[0].FinishReason = &reason
+		chunks = append(chunks, done)
+		if b.includeUsage && ev.Usage != nil {
+			chunks = append(chunks, b.usage(*ev.Usage))
+		}
+	}
+	return b.encode(chunks...)
+}
+
+// delta returns a chunk with one choice. The first chunk of a stream also
+// carries the assistant role.
+func (b *chunkBuilder) delta(d streamDelta) streamChunk {
+	if !b.started {
+		b.started = true
+		d.Role = "assistant"
+	}
+	c := b.chunk()
+	c.Choices = []streamChunkChoice{{Delta: d}}
+	return c
+}
+
+func (b *chunkBuilder) toolCall(delta *chat.ToolCallDelta) streamToolCall {
+	call := streamToolCall{Function: streamToolFunction{Name: delta.Name, Arguments: delta.ArgsChunk}}
+	index, seen := b.toolIndex[delta.Index]
+	if !seen {
+		index = len(b.toolIndex)
+		b.toolIndex[delta.Index] = index
+		call.ID = delta.ID
+		call.Type = "function"
+	}
+	call.Index = index
+	return call
+}
+
+func (b *chunkBuilder) usage(usage chat.Usage) streamChunk {
+	c := b.chunk()
+	c.Choices = []streamChunkChoice{}
+	c.Usage = &streamUsage{
+		PromptTokens:     usage.InputTokens,
+		CompletionTokens: usage.OutputTokens,
+		TotalTokens:      usage.TotalTokens,
+	}
+	c.Usage.PromptTokensDetails.CachedTokens = usage.Cache.CachedInputTokens
+	return c
+}
+
+func (b *chunkBuilder) chunk() streamChunk {
+	return streamChunk{ID: b.id, Object: "chat.completion.chunk", Created: b.created, Model: b.model}
+}
+
+func (b *chunkBuilder) encode(chunks ...streamChunk) [][]byte {
+	out := make([][]byte, 0, len(chunks))
+	for _, c := range chunks {
+		// The chunk types hold only strings and numbers, so marshaling
+		// cannot fail.
+		data, _ := json.Marshal(c)
+		out = append(out, data)
+	}
+	return out
+}
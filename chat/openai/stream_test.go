@@ -0,0 +1,139 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	openai "github.com/openai/openai-go/v3"
+	uniai "github.com/quailyquaily/uniai"
+	"github.com/quailyquaily/uniai/chat"
+)
+
+func anthropicClient(t *testing.T, body string, status int) *Client {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if status != http.StatusOK {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+		} else {
+			w.Header().Set("Content-Type", "text/event-stream")
+		}
+		_, _ = io.WriteString(w, body)
+	}))
+	t.Cleanup(server.Close)
+	return New(uniai.New(uniai.Config{
+		Provider:         "anthropic",
+		AnthropicAPIKey:  "test-key",
+		AnthropicAPIBase: server.URL,
+	}))
+}
+
+func sse(events ...string) string {
+	var b strings.Builder
+	for _, ev := range events {
+		typ := ev[len(`{"type":"`):]
+		typ = typ[:strings.IndexByte(typ, '"')]
+		b.WriteString("event: " + typ + "\ndata: " + ev + "\n\n")
+	}
+	return b.String()
+}
+
+func TestCreateChatCompletionStream(t *testing.T) {
+	client := anthropicClient(t, sse(
+		`{"type":"message_start","message":{"model":"claude-sonnet-test","usage":{"input_tokens":12}}}`,
+		`{"type":"content_block_start","index":0,"content_block":{"type":"thinking","thinking":""}}`,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"thinking_delta","thinking":"look it up"}}`,
+		`{"type":"content_block_stop","index":0}`,
+		`{"type":"content_block_start","index":1,"content_block":{"type":"text","text":""}}`,
+		`{"type":"content_block_delta","index":1,"delta":{"type":"text_delta","text":"Checking."}}`,
+		`{"type":"content_block_stop","index":1}`,
+		`{"type":"content_block_start","index":2,"content_block":{"type":"tool_use","id":"toolu_a","name":"weather","input":{}}}`,
+		`{"type":"content_block_delta","index":2,"delta":{"type":"input_json_delta","partial_json":"{\"city\":"}}`,
+		`{"type":"content_block_delta","index":2,"delta":{"type":"input_json_delta","partial_json":"\"Oslo\"}"}}`,
+		`{"type":"content_block_stop","index":2}`,
+		`{"type":"content_block_start","index":3,"content_block":{"type":"tool_use","id":"toolu_b","name":"time","input":{}}}`,
+		`{"type":"content_block_delta","index":3,"delta":{"type":"input_json_delta","partial_json":"{}"}}`,
+		`{"type":"content_block_stop","index":3}`,
+		`{"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":30}}`,
+		`{"type":"message_stop"}`,
+	), http.StatusOK)
+
+	stream := client.CreateChatCompletionStream(context.Background(), openai.ChatCompletionNewParams{
+		Model:         openai.ChatModel("claude-sonnet-test"),
+		Messages:      []openai.ChatCompletionMessageParamUnion{openai.UserMessage("Weather and time in Oslo?")},
+		StreamOptions: openai.ChatCompletionStreamOptionsParam{IncludeUsage: openai.Bool(true)},
+	}, chat.WithReasoningDetails(), chat.WithReasoningBudgetTokens(1024))
+	defer stream.Close()
+
+	var (
+		chunks    []openai.ChatCompletionChunk
+		reasoning strings.Builder
+		acc       openai.ChatCompletionAccumulator
+	)
+	for stream.Next() {
+		chunk := stream.Current()
+		chunks = append(chunks, chunk)
+		acc.AddChunk(chunk)
+		if len(chunk.Choices) > 0 {
+			if field, ok := chunk.Choices[0].Delta.JSON.ExtraFields["reasoning_content"]; ok {
+				reasoning.WriteString(strings.Trim(field.Raw(), `"`))
+			}
+		}
+	}
+	if err := stream.Err(); err != nil {
+		t.Fatalf("stream: %v", err)
+	}
+
+	if len(chunks) < 3 || chunks[0].Choices[0].Delta.Role != "assistant" || chunks[0].Model != "claude-sonnet-test" || chunks[0].Object != "chat.completion.chunk" {
+		t.Fatalf("unexpected first chunk: %s", chunks[0].RawJSON())
+	}
+	if reasoning.String() != "look it up" {
+		t.Fatalf("expected reasoning_content deltas, got %q", reasoning.String())
+	}
+	last := chunks[len(chunks)-1]
+	if len(last.Choices) != 0 || last.Usage.PromptTokens != 12 || last.Usage.CompletionTokens != 30 {
+		t.Fatalf("expected a final usage chunk, got %s", last.RawJSON())
+	}
+	if reason := chunks[len(chunks)-2].Choices[0].FinishReason; reason != "tool_calls" {
+		t.Fatalf("expected finish_reason tool_calls, got %q", reason)
+	}
+
+	// The accumulator reassembles the calls from indices 0 and 1, although
+	// the provider numbered their content blocks 2 and 3.
+	msg := acc.Choices[0].Message
+	if msg.Content != "Checking." || len(msg.ToolCalls) != 2 {
+		t.Fatalf("unexpected accumulated message: %#v", msg)
+	}
+	if call := msg.ToolCalls[0]; call.ID != "toolu_a" || call.Function.Name != "weather" || call.Function.Arguments != `{"city":"Oslo"}` {
+		t.Fatalf("unexpected first call: %#v", call)
+	}
+	if call := msg.ToolCalls[1]; call.ID != "toolu_b" || call.Function.Name != "time" || call.Function.Arguments != "{}" {
+		t.Fatalf("unexpected second call: %#v", call)
+	}
+}
+
+func TestCreateChatCompletionStreamErrors(t *testing.T) {
+	client := anthropicClient(t, `{"type":"error","error":{"type":"authentication_error","message":"bad key"}}`, http.StatusUnauthorized)
+	stream := client.CreateChatCompletionStream(context.Background(), openai.ChatCompletionNewParams{
+		Model:    openai.ChatModel("claude-sonnet-test"),
+		Messages: []openai.ChatCompletionMessageParamUnion{openai.UserMessage("hello")},
+	})
+	if stream.Next() {
+		t.Fatalf("expected no chunks, got %s", stream.Current().RawJSON())
+	}
+	if !errors.Is(stream.Err(), uniai.ErrAuth) {
+		t.Fatalf("expected the provider error, got %v", stream.Err())
+	}
+	stream.Close()
+
+	stream = client.CreateChatCompletionStream(context.Background(), openai.ChatCompletionNewParams{})
+	if stream.Next() || stream.Err() == nil {
+		t.Fatalf("expected invalid params to fail")
+	}
+	stream.Close()
+}
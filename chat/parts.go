@@ -1,6 +1,7 @@
 package chat
 
 import (
+	"encoding/base64"
 	"fmt"
 	"strings"
 )
@@ -76,11 +77,113 @@ func ValidatePart(part Part) error {
 			return fmt.Errorf("part type %q requires data_base64", PartTypeImageBase64)
 		}
 		return nil
+	case PartTypeAudioURL:
+		if strings.TrimSpace(part.URL) == "" {
+			return fmt.Errorf("part type %q requires url", PartTypeAudioURL)
+		}
+		return nil
+	case PartTypeAudioBase64:
+		if strings.TrimSpace(part.DataBase64) == "" {
+			return fmt.Errorf("part type %q requires data_base64", PartTypeAudioBase64)
+		}
+		if strings.TrimSpace(part.MIMEType) == "" {
+			return fmt.Errorf("part type %q requires mime_type", PartTypeAudioBase64)
+		}
+		return nil
+	case PartTypeDocumentURL:
+		if strings.TrimSpace(part.URL) == "" {
+			return fmt.Errorf("part type %q requires url", PartTypeDocumentURL)
+		}
+		return nil
+	case PartTypeDocumentBase64:
+		if strings.TrimSpace(part.DataBase64) == "" {
+			return fmt.Errorf("part type %q requires data_base64", PartTypeDocumentBase64)
+		}
+		return nil
+	case PartTypeDocumentText:
+		if strings.TrimSpace(part.Text) == "" {
+			return fmt.Errorf("part type %q requires text", PartTypeDocumentText)
+		}
+		return nil
 	default:
 		return fmt.Errorf("unsupported part type %q", part.Type)
 	}
 }
 
+// IsDocumentPart reports whether part is a document_url, document_base64 or
+// document_text part.
+func IsDocumentPart(part Part) bool {
+	switch part.Type {
+	case PartTypeDocumentURL, PartTypeDocumentBase64, PartTypeDocumentText:
+		return true
+	}
+	return false
+}
+
+// DocumentMIMEType returns the MIME type of a document part, defaulting to
+// application/pdf for files and text/plain for text documents.
+func DocumentMIMEType(part Part) string {
+	if mimeType := strings.TrimSpace(part.MIMEType); mimeType != "" {
+		return mimeType
+	}
+	if part.Type == PartTypeDocumentText {
+		return "text/plain"
+	}
+	return "application/pdf"
+}
+
+// DocumentSize returns the size in bytes of a document part's content: the
+// decoded size of document_base64 data or the length of document_text text.
+// It is 0 for document_url parts, whose size is unknown.
+func DocumentSize(part Part) int {
+	switch part.Type {
+	case PartTypeDocumentBase64:
+		data := strings.TrimSpace(part.DataBase64)
+		return len(data)/4*3 - strings.Count(data[max(len(data)-2, 0):], "=")
+	case PartTypeDocumentText:
+		return len(part.Text)
+	}
+	return 0
+}
+
+// ValidateDocumentSize rejects a document part larger than maxBytes, the
+// limit of provider.
+func ValidateDocumentSize(part Part, provider string, maxBytes int) error {
+	if size := DocumentSize(part); size > maxBytes {
+		return fmt.Errorf("%s provider accepts documents up to %d bytes, got %d bytes", provider, maxBytes, size)
+	}
+	return nil
+}
+
+// EncodedDocumentSize returns the size in bytes of a document part's content
+// once base64-encoded: the length of document_base64 data, or the encoded
+// length of document_text text. It is 0 for document_url parts.
+func EncodedDocumentSize(part Part) int {
+	switch part.Type {
+	case PartTypeDocumentBase64:
+		return len(strings.TrimSpace(part.DataBase64))
+	case PartTypeDocumentText:
+		return base64.StdEncoding.EncodedLen(len(part.Text))
+	}
+	return 0
+}
+
+// ValidateRequestDocumentSize rejects messages whose document parts add up to
+// more than maxBytes, for providers whose limit applies to the whole request.
+// size measures one part, e.g. EncodedDocumentSize.
+func ValidateRequestDocumentSize(messages []Message, provider string, maxBytes int, size func(Part) int) error {
+	total := 0
+	for _, msg := range messages {
+		for _, part := range msg.Parts {
+			total += size(part)
+		}
+	}
+	if total > maxBytes {
+		return fmt.Errorf("%s provider accepts up to %d bytes of documents per request, got %d bytes", provider, maxBytes, total)
+	}
+	return nil
+}
+
 func ValidateCacheControl(ctrl *CacheControl) error {
 	if ctrl == nil {
 		return nil
@@ -224,8 +327,13 @@ func EnsureResultParts(result *Result) {
 	if result == nil {
 		return
 	}
-	if len(result.Parts) > 0 || result.Text == "" {
+	if result.Text == "" {
 		return
 	}
-	result.Parts = []Part{TextPart(result.Text)}
+	for _, part := range result.Parts {
+		if part.Type == PartTypeText {
+			return
+		}
+	}
+	result.Parts = append([]Part{TextPart(result.Text)}, result.Parts...)
 }
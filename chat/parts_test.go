@@ -33,13 +33,87 @@ func TestNormalizeMessagePartsFromContent(t *testing.T) {
 
 func TestBuildRequestRejectsUnsupportedPartType(t *testing.T) {
 	_, err := BuildRequest(
-		WithMessages(UserParts(Part{Type: "audio_base64", DataBase64: "abc"})),
+		WithMessages(UserParts(Part{Type: "video_base64", DataBase64: "abc"})),
 	)
 	if err == nil {
 		t.Fatalf("expected unsupported part error")
 	}
 }
 
+func TestValidatePartDocument(t *testing.T) {
+	for _, part := range []Part{
+		DocumentBase64Part("", "JVBERi0="),
+		DocumentURLPart("https://example.com/a.pdf"),
+		DocumentTextPart("notes", "hello"),
+	} {
+		if err := ValidatePart(part); err != nil {
+			t.Fatalf("unexpected %s error: %v", part.Type, err)
+		}
+	}
+	if err := ValidatePart(DocumentTextPart("notes", " ")); err == nil {
+		t.Fatalf("expected empty text document to be rejected")
+	}
+	if got := DocumentMIMEType(DocumentBase64Part("", "JVBERi0=")); got != "application/pdf" {
+		t.Fatalf("unexpected default document mime type %q", got)
+	}
+	if got := DocumentMIMEType(DocumentTextPart("", "hello")); got != "text/plain" {
+		t.Fatalf("unexpected text document mime type %q", got)
+	}
+}
+
+func TestValidateDocumentSize(t *testing.T) {
+	// "JVBERi0=" decodes to the 5 bytes "%PDF-".
+	part := DocumentBase64Part("application/pdf", "JVBERi0=")
+	if got := DocumentSize(part); got != 5 {
+		t.Fatalf("DocumentSize() = %d, want 5", got)
+	}
+	if err := ValidateDocumentSize(part, "test", 5); err != nil {
+		t.Fatalf("unexpected error at the limit: %v", err)
+	}
+	if err := ValidateDocumentSize(part, "test", 4); err == nil || !strings.Contains(err.Error(), "test provider accepts documents up to 4 bytes") {
+		t.Fatalf("expected size error, got %v", err)
+	}
+	if err := ValidateDocumentSize(DocumentURLPart("https://example.com/a.pdf"), "test", 1); err != nil {
+		t.Fatalf("expected url documents to skip the size check, got %v", err)
+	}
+}
+
+func TestValidateRequestDocumentSize(t *testing.T) {
+	part := DocumentBase64Part("application/pdf", "JVBERi0=")
+	if got := EncodedDocumentSize(part); got != 8 {
+		t.Fatalf("EncodedDocumentSize() = %d, want 8", got)
+	}
+	if got := EncodedDocumentSize(DocumentTextPart("", "hello")); got != 8 {
+		t.Fatalf("EncodedDocumentSize(text) = %d, want 8", got)
+	}
+	messages := []Message{
+		UserParts(TextPart("compare"), part),
+		UserParts(DocumentTextPart("", "hello"), DocumentURLPart("https://example.com/a.pdf")),
+	}
+	if err := ValidateRequestDocumentSize(messages, "test", 16, EncodedDocumentSize); err != nil {
+		t.Fatalf("unexpected error at the limit: %v", err)
+	}
+	err := ValidateRequestDocumentSize(messages, "test", 15, EncodedDocumentSize)
+	if err == nil || !strings.Contains(err.Error(), "test provider accepts up to 15 bytes of documents per request, got 16 bytes") {
+		t.Fatalf("expected size error, got %v", err)
+	}
+}
+
+func TestValidatePartAudio(t *testing.T) {
+	if err := ValidatePart(AudioBase64Part("audio/wav", "UklGRg==")); err != nil {
+		t.Fatalf("unexpected audio_base64 error: %v", err)
+	}
+	if err := ValidatePart(AudioURLPart("audio/mpeg", "https://example.com/a.mp3")); err != nil {
+		t.Fatalf("unexpected audio_url error: %v", err)
+	}
+	if err := ValidatePart(AudioBase64Part("", "UklGRg==")); err == nil || !strings.Contains(err.Error(), "mime_type") {
+		t.Fatalf("expected missing mime_type error, got %v", err)
+	}
+	if err := ValidatePart(AudioURLPart("audio/mpeg", " ")); err == nil {
+		t.Fatalf("expected missing url error")
+	}
+}
+
 func TestMessageTextRejectsNonTextPart(t *testing.T) {
 	_, err := MessageText(UserParts(ImageURLPart("https://example.com/a.png")))
 	if err == nil {
@@ -56,6 +130,33 @@ func TestBuildRequestRejectsNonTextPartForAssistantRole(t *testing.T) {
 	}
 }
 
+func TestBuildRequestAllowsAssistantImageBase64Part(t *testing.T) {
+	if _, err := BuildRequest(
+		WithMessages(
+			UserParts(TextPart("draw a cat")),
+			AssistantParts(TextPart("Here it is."), ImageBase64Part("image/png", "QUJD")),
+			UserParts(TextPart("make it blue")),
+		),
+	); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := BuildRequest(WithMessages(SystemParts(ImageBase64Part("image/png", "QUJD")))); err == nil {
+		t.Fatalf("expected role constraint error for system images")
+	}
+}
+
+func TestEnsureResultPartsKeepsImageParts(t *testing.T) {
+	result := &Result{Text: "Here it is.", Parts: []Part{ImageBase64Part("image/png", "QUJD")}}
+	EnsureResultParts(result)
+	if len(result.Parts) != 2 || result.Parts[0].Text != "Here it is." || result.Parts[1].Type != PartTypeImageBase64 {
+		t.Fatalf("unexpected parts: %#v", result.Parts)
+	}
+	EnsureResultParts(result)
+	if len(result.Parts) != 2 {
+		t.Fatalf("expected text part to be added once, got %#v", result.Parts)
+	}
+}
+
 func TestBuildRequestRejectsEmptyCachedTextPart(t *testing.T) {
 	_, err := BuildRequest(
 		WithMessages(UserParts(WithPartCacheControl(TextPart(" \t"), CacheTTL5m()))),
@@ -0,0 +1,118 @@
+package chat
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ResponseFormatType selects the kind of structured output requested.
+type ResponseFormatType string
+
+const (
+	// ResponseFormatJSONSchema asks for JSON matching ResponseFormat.Schema.
+	ResponseFormatJSONSchema ResponseFormatType = "json_schema"
+	// ResponseFormatJSONObject asks for any JSON object.
+	ResponseFormatJSONObject ResponseFormatType = "json_object"
+)
+
+// DefaultResponseFormatName names WithJSONObject formats and the forced tool
+// used for structured output on providers without a native JSON mode.
+const DefaultResponseFormatName = "json_output"
+
+// DefaultJSONRepairAttempts is the number of repair round-trips uniai.ChatJSON
+// makes when WithJSONRepairAttempts is not set.
+const DefaultJSONRepairAttempts = 2
+
+// ResponseFormat is the provider-neutral structured output request set by
+// WithJSONSchema and WithJSONObject.
+//
+// Providers map it to their native mechanism: Chat Completions
+// response_format, Responses text.format, Gemini responseMimeType and
+// responseSchema, Cloudflare response_format, and a forced single tool call
+// on Anthropic and Bedrock. Raw provider options (for example
+// openai.response_format) take precedence when both are set.
+type ResponseFormat struct {
+	Type   ResponseFormatType `json:"type"`
+	Name   string             `json:"name,omitempty"`
+	Schema json.RawMessage    `json:"schema,omitempty"`
+	Strict bool               `json:"strict,omitempty"`
+}
+
+// ToolName returns the tool name used when the format is implemented as a
+// forced tool call.
+func (f *ResponseFormat) ToolName() string {
+	if f == nil || strings.TrimSpace(f.Name) == "" {
+		return DefaultResponseFormatName
+	}
+	return strings.TrimSpace(f.Name)
+}
+
+// SchemaMap decodes Schema. JSON object formats and empty schemas return
+// {"type":"object"}.
+func (f *ResponseFormat) SchemaMap() (map[string]any, error) {
+	if f == nil || f.Type != ResponseFormatJSONSchema || len(bytes.TrimSpace(f.Schema)) == 0 {
+		return map[string]any{"type": "object"}, nil
+	}
+	var schema map[string]any
+	if err := json.Unmarshal(f.Schema, &schema); err != nil {
+		return nil, fmt.Errorf("response format schema: %w", err)
+	}
+	return schema, nil
+}
+
+// ValidateResponseFormat checks the format set on a request.
+func ValidateResponseFormat(f *ResponseFormat) error {
+	if f == nil {
+		return nil
+	}
+	switch f.Type {
+	case ResponseFormatJSONObject:
+		return nil
+	case ResponseFormatJSONSchema:
+		if strings.TrimSpace(f.Name) == "" {
+			return fmt.Errorf("response format json_schema requires a name")
+		}
+		trimmed := bytes.TrimSpace(f.Schema)
+		if len(trimmed) > 0 && (trimmed[0] != '{' || !json.Valid(trimmed)) {
+			return fmt.Errorf("response format json_schema schema must be a JSON object")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported response format type %q", f.Type)
+	}
+}
+
+// WithJSONSchema asks the model for JSON matching schema. With strict set,
+// providers that support it (OpenAI) enforce the schema exactly; strict
+// schemas must list every property as required and disallow additional
+// properties.
+func WithJSONSchema(name string, schema []byte, strict bool) Option {
+	return func(r *Request) {
+		r.Options.ResponseFormat = &ResponseFormat{
+			Type:   ResponseFormatJSONSchema,
+			Name:   name,
+			Schema: append(json.RawMessage(nil), schema...),
+			Strict: strict,
+		}
+	}
+}
+
+// WithJSONObject asks the model for a JSON object without a schema. OpenAI
+// requires the word "JSON" to appear in the messages for this mode.
+func WithJSONObject() Option {
+	return func(r *Request) {
+		r.Options.ResponseFormat = &ResponseFormat{Type: ResponseFormatJSONObject}
+	}
+}
+
+// WithJSONRepairAttempts sets how many times uniai.ChatJSON sends a reply that
+// fails to decode or validate back to the model, together with the error,
+// before giving up. Zero disables repair round-trips. Client.Chat ignores it.
+func WithJSONRepairAttempts(n int) Option {
+	return func(r *Request) {
+		v := max(n, 0)
+		r.Options.JSONRepairAttempts = &v
+	}
+}
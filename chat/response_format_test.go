@@ -0,0 +1,23 @@
+package chat
+
+import "testing"
+
+func TestBuildRequestValidatesResponseFormat(t *testing.T) {
+	if _, err := BuildRequest(WithMessages(User("hi")), WithJSONSchema("", []byte(`{"type":"object"}`), false)); err == nil {
+		t.Fatal("expected missing name error")
+	}
+	if _, err := BuildRequest(WithMessages(User("hi")), WithJSONSchema("out", []byte(`[1]`), false)); err == nil {
+		t.Fatal("expected non-object schema error")
+	}
+	req, err := BuildRequest(WithMessages(User("hi")), WithJSONObject())
+	if err != nil {
+		t.Fatalf("build request: %v", err)
+	}
+	if req.Options.ResponseFormat.ToolName() != DefaultResponseFormatName {
+		t.Fatalf("unexpected tool name: %q", req.Options.ResponseFormat.ToolName())
+	}
+	schema, err := req.Options.ResponseFormat.SchemaMap()
+	if err != nil || schema["type"] != "object" {
+		t.Fatalf("unexpected schema: %#v %v", schema, err)
+	}
+}
@@ -0,0 +1,220 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+)
+
+// ChatFunc sends a chat request, as uniai.Client.Chat does.
+type ChatFunc func(ctx context.Context, opts ...Option) (*Result, error)
+
+// Session owns the history of one conversation: a system prompt, the
+// messages exchanged so far and the default request options. Recording a
+// result appends the assistant replay messages (see AssistantReplayMessages),
+// so provider replay state such as Gemini thought signatures and
+// reasoning_content survives later turns.
+//
+// A Session serializes to JSON for persistence. Default options without a
+// JSON form (streaming and debug callbacks, fallbacks, priorities) are
+// dropped on the way and must be set again after loading. A Session is not
+// safe for concurrent use.
+type Session struct {
+	System   string          `json:"system,omitempty"`
+	Messages []Message       `json:"messages"`
+	Defaults SessionDefaults `json:"defaults"`
+	// Usage is the cumulative usage of the recorded turns. Its cost is nil
+	// when a turn with tokens was not priced.
+	Usage Usage `json:"usage"`
+	// Turns lists the recorded results, in order.
+	Turns []SessionTurn `json:"turns,omitempty"`
+}
+
+// SessionDefaults are applied to every request of a Session before the
+// per-call options.
+type SessionDefaults struct {
+	Provider          string      `json:"provider,omitempty"`
+	InferenceProvider string      `json:"inference_provider,omitempty"`
+	Model             string      `json:"model,omitempty"`
+	Options           Options     `json:"options,omitempty"`
+	Tools             []Tool      `json:"tools,omitempty"`
+	ToolChoice        *ToolChoice `json:"tool_choice,omitempty"`
+}
+
+// SessionTurn records one result added with Session.Record.
+type SessionTurn struct {
+	// Messages is the length of Session.Messages once the result was
+	// recorded.
+	Messages int    `json:"messages"`
+	Model    string `json:"model,omitempty"`
+	Usage    Usage  `json:"usage"`
+}
+
+// NewSession returns a Session with a system prompt and default options.
+// Messages set by the defaults start the history.
+func NewSession(system string, defaults ...Option) *Session {
+	req := &Request{}
+	for _, opt := range defaults {
+		if opt != nil {
+			opt(req)
+		}
+	}
+	return &Session{
+		System:   system,
+		Messages: req.Messages,
+		Defaults: SessionDefaults{
+			Provider:          req.Provider,
+			InferenceProvider: req.InferenceProvider,
+			Model:             req.Model,
+			Options:           req.Options,
+			Tools:             req.Tools,
+			ToolChoice:        req.ToolChoice,
+		},
+	}
+}
+
+// Add appends messages to the history.
+func (s *Session) Add(msgs ...Message) {
+	for _, msg := range msgs {
+		s.Messages = append(s.Messages, cloneMessage(msg))
+	}
+}
+
+// AddToolResult appends the result of a tool call, encoded with
+// ToolResultValue.
+func (s *Session) AddToolResult(toolCallID string, value any) error {
+	msg, err := ToolResultValue(toolCallID, value)
+	if err != nil {
+		return err
+	}
+	s.Messages = append(s.Messages, msg)
+	return nil
+}
+
+// Record appends the assistant replay messages of result to the history and
+// adds its usage to the session.
+func (s *Session) Record(result *Result) {
+	if result == nil {
+		return
+	}
+	s.Messages = append(s.Messages, AssistantReplayMessages(result)...)
+	s.Usage = s.Usage.Add(result.Usage)
+	s.Turns = append(s.Turns, SessionTurn{
+		Messages: len(s.Messages),
+		Model:    result.Model,
+		Usage:    result.Usage,
+	})
+}
+
+// PendingToolCalls returns the tool calls of the last assistant message that
+// have no tool result yet.
+func (s *Session) PendingToolCalls() []ToolCall {
+	answered := map[string]bool{}
+	for i := len(s.Messages) - 1; i >= 0; i-- {
+		msg := s.Messages[i]
+		switch msg.Role {
+		case RoleTool:
+			answered[msg.ToolCallID] = true
+		case RoleAssistant:
+			var pending []ToolCall
+			for _, call := range msg.ToolCalls {
+				if !answered[call.ID] {
+					pending = append(pending, call)
+				}
+			}
+			return pending
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+// Options returns the options for the next request: the defaults, then opts,
+// then the system prompt and history. Messages added by opts follow the
+// history but are not recorded; use Add for that.
+func (s *Session) Options(opts ...Option) []Option {
+	out := make([]Option, 0, len(opts)+2)
+	out = append(out, s.Defaults.apply)
+	out = append(out, opts...)
+	out = append(out, s.messages)
+	return out
+}
+
+// Send calls fn with the session options and records the result. Add the
+// next user message (or tool results) first. On error the history is left
+// unchanged.
+func (s *Session) Send(ctx context.Context, fn ChatFunc, opts ...Option) (*Result, error) {
+	result, err := fn(ctx, s.Options(opts...)...)
+	if err != nil {
+		return result, err
+	}
+	s.Record(result)
+	return result, nil
+}
+
+// Fork returns a copy of the session truncated to its first n messages. Only
+// the turns recorded within those messages count towards the fork usage.
+// Forking between an assistant tool call and its results leaves the calls
+// pending.
+func (s *Session) Fork(n int) (*Session, error) {
+	if n < 0 || n > len(s.Messages) {
+		return nil, fmt.Errorf("fork at message %d: session has %d messages", n, len(s.Messages))
+	}
+	out := &Session{
+		System:   s.System,
+		Defaults: s.Defaults.clone(),
+	}
+	out.Add(s.Messages[:n]...)
+	for _, turn := range s.Turns {
+		if turn.Messages > n {
+			break
+		}
+		out.Turns = append(out.Turns, turn)
+		out.Usage = out.Usage.Add(turn.Usage)
+	}
+	return out, nil
+}
+
+func (s *Session) messages(r *Request) {
+	history := make([]Message, 0, len(s.Messages)+len(r.Messages)+1)
+	if s.System != "" {
+		history = append(history, System(s.System))
+	}
+	for _, msg := range s.Messages {
+		history = append(history, cloneMessage(msg))
+	}
+	r.Messages = append(history, r.Messages...)
+}
+
+func (d SessionDefaults) apply(r *Request) {
+	if d.Provider != "" {
+		r.Provider = d.Provider
+	}
+	if d.InferenceProvider != "" {
+		r.InferenceProvider = d.InferenceProvider
+	}
+	if d.Model != "" {
+		r.Model = d.Model
+	}
+	r.Options = d.Options
+	r.Options.Stop = append([]string(nil), d.Options.Stop...)
+	r.Options.Fallbacks = append([]Fallback(nil), d.Options.Fallbacks...)
+	r.Options.FallbackOn = append([]error(nil), d.Options.FallbackOn...)
+	if len(d.Tools) > 0 {
+		r.Tools = CloneTools(d.Tools)
+	}
+	if d.ToolChoice != nil {
+		choice := *d.ToolChoice
+		r.ToolChoice = &choice
+	}
+}
+
+func (d SessionDefaults) clone() SessionDefaults {
+	out := d
+	out.Tools = CloneTools(d.Tools)
+	if d.ToolChoice != nil {
+		choice := *d.ToolChoice
+		out.ToolChoice = &choice
+	}
+	return out
+}
@@ -0,0 +1,117 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestSessionOwnsHistory(t *testing.T) {
+	var requests []*Request
+	replies := []*Result{
+		{
+			Model: "deepseek-test",
+			Messages: []Message{{
+				Role:             RoleAssistant,
+				ReasoningContent: "need the weather tool",
+				ToolCalls:        []ToolCall{{ID: "call_1", Type: "function", Function: ToolCallFunction{Name: "weather", Arguments: `{"city":"Paris"}`}}},
+			}},
+			ToolCalls: []ToolCall{{ID: "call_1", Type: "function", Function: ToolCallFunction{Name: "weather", Arguments: `{"city":"Paris"}`}}},
+			Usage:     Usage{InputTokens: 10, OutputTokens: 5, Cost: &UsageCost{Currency: "USD", Estimated: true, Total: 0.25}},
+		},
+		{
+			Model: "deepseek-test",
+			Text:  "Sunny.",
+			Usage: Usage{InputTokens: 20, OutputTokens: 2, Cost: &UsageCost{Currency: "USD", Estimated: true, Total: 0.5}},
+		},
+	}
+	send := func(ctx context.Context, opts ...Option) (*Result, error) {
+		req, err := BuildRequest(opts...)
+		if err != nil {
+			return nil, err
+		}
+		requests = append(requests, req)
+		return replies[len(requests)-1], nil
+	}
+
+	session := NewSession("Be brief.", WithModel("deepseek-test"), WithTemperature(0.2))
+	session.Add(User("Weather in Paris?"))
+	ctx := context.Background()
+	resp, err := session.Send(ctx, send, WithTemperature(0.7))
+	if err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	if pending := session.PendingToolCalls(); len(pending) != 1 || pending[0].ID != "call_1" {
+		t.Fatalf("unexpected pending calls: %#v", pending)
+	}
+	for _, call := range resp.ToolCalls {
+		if err := session.AddToolResult(call.ID, map[string]string{"sky": "sunny"}); err != nil {
+			t.Fatalf("tool result: %v", err)
+		}
+	}
+	if pending := session.PendingToolCalls(); len(pending) != 0 {
+		t.Fatalf("expected no pending calls, got %#v", pending)
+	}
+	if _, err := session.Send(ctx, send); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+
+	first, second := requests[0], requests[1]
+	if first.Model != "deepseek-test" || *first.Options.Temperature != 0.7 || *second.Options.Temperature != 0.2 {
+		t.Fatalf("expected defaults with per-call overrides, got %#v %#v", first.Options, second.Options)
+	}
+	if len(first.Messages) != 2 || first.Messages[0].Role != RoleSystem || first.Messages[0].Content != "Be brief." {
+		t.Fatalf("unexpected first request messages: %#v", first.Messages)
+	}
+	if len(second.Messages) != 4 || second.Messages[2].ReasoningContent != "need the weather tool" || second.Messages[3].Content != `{"sky":"sunny"}` {
+		t.Fatalf("expected replayed reasoning and the tool result, got %#v", second.Messages)
+	}
+	if len(session.Messages) != 4 || session.Messages[3].Content != "Sunny." {
+		t.Fatalf("unexpected history: %#v", session.Messages)
+	}
+	if session.Usage.InputTokens != 30 || session.Usage.TotalTokens != 37 || session.Usage.Cost == nil || session.Usage.Cost.Total != 0.75 {
+		t.Fatalf("unexpected cumulative usage: %#v %#v", session.Usage, session.Usage.Cost)
+	}
+
+	data, err := json.Marshal(session)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var loaded Session
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if !reflect.DeepEqual(loaded.Messages, session.Messages) || loaded.Defaults.Model != "deepseek-test" || *loaded.Defaults.Options.Temperature != 0.2 || len(loaded.Turns) != 2 || loaded.Usage.Cost.Total != 0.75 {
+		t.Fatalf("session did not survive a JSON round trip: %s", data)
+	}
+
+	fork, err := loaded.Fork(3)
+	if err != nil {
+		t.Fatalf("fork: %v", err)
+	}
+	if len(fork.Messages) != 3 || len(fork.Turns) != 1 || fork.Usage.InputTokens != 10 || fork.Usage.Cost.Total != 0.25 {
+		t.Fatalf("unexpected fork: %#v", fork)
+	}
+	fork.Add(User("And in Oslo?"))
+	if len(loaded.Messages) != 4 || loaded.Messages[3].Content != "Sunny." {
+		t.Fatalf("fork must not share history with its parent")
+	}
+	if _, err := loaded.Fork(5); err == nil {
+		t.Fatalf("expected an out-of-range fork to fail")
+	}
+}
+
+func TestUsageAddDropsPartialCost(t *testing.T) {
+	priced := Usage{InputTokens: 1, Cost: &UsageCost{Currency: "USD", Total: 0.1}}
+	unpriced := Usage{InputTokens: 1}
+	if got := priced.Add(priced); got.Cost == nil || got.Cost.Total != 0.2 || got.TotalTokens != 2 {
+		t.Fatalf("unexpected sum: %#v %#v", got, got.Cost)
+	}
+	if got := priced.Add(unpriced).Add(priced); got.Cost != nil || got.InputTokens != 3 {
+		t.Fatalf("expected no cost once a turn is unpriced, got %#v", got.Cost)
+	}
+	if got := (Usage{}).Add(priced); got.Cost == nil || got.Cost == priced.Cost {
+		t.Fatalf("expected a copied cost, got %#v", got.Cost)
+	}
+}
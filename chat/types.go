@@ -19,6 +19,13 @@ const (
 	PartTypeText        = "text"
 	PartTypeImageURL    = "image_url"
 	PartTypeImageBase64 = "image_base64"
+	PartTypeAudioURL    = "audio_url"
+	PartTypeAudioBase64 = "audio_base64"
+	// Document parts carry a file such as a PDF (document_url,
+	// document_base64) or a plain-text document (document_text, in Text).
+	PartTypeDocumentURL    = "document_url"
+	PartTypeDocumentBase64 = "document_base64"
+	PartTypeDocumentText   = "document_text"
 )
 
 type Part struct {
@@ -28,6 +35,19 @@ type Part struct {
 	DataBase64   string        `json:"data_base64,omitempty"`
 	MIMEType     string        `json:"mime_type,omitempty"`
 	CacheControl *CacheControl `json:"cache_control,omitempty"`
+	// Title names a document part. Providers that take a filename use it as
+	// one.
+	Title string `json:"title,omitempty"`
+	// Citations asks the provider to cite passages of a document part in
+	// its answer. Only Anthropic models, direct or on Bedrock, support it;
+	// other providers ignore it.
+	Citations bool `json:"citations,omitempty"`
+	// ID and ThoughtSignature identify an output part to the provider that
+	// returned it: the Responses image_generation_call id, or the Gemini
+	// thought signature. Keep them when replaying the part in an assistant
+	// message.
+	ID               string `json:"id,omitempty"`
+	ThoughtSignature string `json:"thought_signature,omitempty"`
 }
 
 type Message struct {
@@ -120,10 +140,36 @@ type Options struct {
 	Bedrock            structs.JSONMap    `json:"bedrock_options,omitempty"`
 	Cloudflare         structs.JSONMap    `json:"cloudflare_options,omitempty"`
 	ToolsEmulationMode ToolsEmulationMode `json:"tools_emulation_mode,omitempty"`
+	ResponseFormat     *ResponseFormat    `json:"response_format,omitempty"`
+	Priority           Priority           `json:"-"`
+	JSONRepairAttempts *int               `json:"-"`
+	Fallbacks          []Fallback         `json:"-"`
+	FallbackOn         []error            `json:"-"`
 	OnStream           OnStreamFunc       `json:"-"`
 	DebugFn            DebugFn            `json:"-"`
 }
 
+// Priority orders chat requests waiting on a client-side rate limiter. Higher
+// priorities are served first.
+type Priority int
+
+const (
+	PriorityBatch       Priority = -1
+	PriorityNormal      Priority = 0
+	PriorityInteractive Priority = 1
+)
+
+// Fallback is an alternative target tried by Client.Chat, in order, when the
+// previous target fails with an error listed in Options.FallbackOn.
+type Fallback struct {
+	// Provider defaults to the provider of the original request.
+	Provider string
+	// Model defaults to the model of the original request.
+	Model string
+	// Options are applied on top of the original request for this target only.
+	Options []Option
+}
+
 type Request struct {
 	Provider          string      `json:"provider,omitempty"`
 	InferenceProvider string      `json:"inference_provider,omitempty"`
@@ -231,8 +277,30 @@ type Result struct {
 	ToolCalls []ToolCall       `json:"tool_calls,omitempty"`
 	Reasoning *ReasoningResult `json:"reasoning,omitempty"`
 	Usage     Usage            `json:"usage,omitempty"`
-	Raw       any              `json:"raw,omitempty"`
-	Warnings  []string         `json:"warnings,omitempty"`
+	// JSON is the structured output parsed from Text when the request set a
+	// response format, after repairing code fences, surrounding prose and
+	// truncated brackets. It is nil when no valid JSON could be recovered.
+	JSON json.RawMessage `json:"json,omitempty"`
+	// FinishReason is the normalized reason generation stopped; check for
+	// FinishReasonLength to detect truncated answers. RawFinishReason is the
+	// provider value it was derived from. Both are empty when the provider
+	// does not report one.
+	FinishReason    FinishReason `json:"finish_reason,omitempty"`
+	RawFinishReason string       `json:"raw_finish_reason,omitempty"`
+	Raw             any          `json:"raw,omitempty"`
+	Warnings        []string     `json:"warnings,omitempty"`
+	// Attempts lists the provider requests made to produce this result, in
+	// order, across retries, fallbacks and tool emulation. Failed attempts
+	// carry their error; the last entry is the attempt that succeeded.
+	Attempts []Attempt `json:"attempts,omitempty"`
+}
+
+// Attempt records a single provider request made by Client.Chat.
+type Attempt struct {
+	Provider string `json:"provider,omitempty"`
+	Model    string `json:"model,omitempty"`
+	Error    string `json:"error,omitempty"`
+	Err      error  `json:"-"`
 }
 
 type ReasoningResult struct {
@@ -277,6 +345,13 @@ type StreamEvent struct {
 	Usage          *Usage
 	Raw            any
 	Done           bool
+	// FinishReason and RawFinishReason are set on the Done event, as on
+	// Result.
+	FinishReason    FinishReason
+	RawFinishReason string
+	// Part is a whole non-text output part, such as a generated image_base64
+	// part. Parts are not split across events; text is streamed in Delta.
+	Part *Part
 }
 
 // ToolCallDelta represents an incremental update to a tool call during streaming.
@@ -305,14 +380,21 @@ func BuildRequest(opts ...Option) (*Request, error) {
 			return nil, fmt.Errorf("message[%d]: %w", i, err)
 		}
 		for _, part := range msg.Parts {
-			if part.Type == PartTypeText {
-				continue
-			}
-			if msg.Role != RoleUser {
+			switch {
+			case part.Type == PartTypeText || msg.Role == RoleUser:
+			case msg.Role == RoleAssistant:
+				// Generated images are replayed for multi-turn editing.
+				if part.Type != PartTypeImageBase64 {
+					return nil, fmt.Errorf("message[%d]: role %q supports only %q and %q part types", i, msg.Role, PartTypeText, PartTypeImageBase64)
+				}
+			default:
 				return nil, fmt.Errorf("message[%d]: role %q supports only %q part type", i, msg.Role, PartTypeText)
 			}
 		}
 	}
+	if err := ValidateResponseFormat(req.Options.ResponseFormat); err != nil {
+		return nil, err
+	}
 	for i := range req.Tools {
 		if err := ValidateCacheControl(req.Tools[i].CacheControl); err != nil {
 			return nil, fmt.Errorf("tool[%d]: %w", i, err)
@@ -393,6 +475,26 @@ func WithToolsEmulationMode(mode ToolsEmulationMode) Option {
 	return func(r *Request) { r.Options.ToolsEmulationMode = mode }
 }
 
+// WithPriority sets the priority used by the client-side rate limiter.
+func WithPriority(p Priority) Option {
+	return func(r *Request) { r.Options.Priority = p }
+}
+
+// WithFallbacks appends fallback targets that are tried in order when the
+// primary provider fails. Fallbacks are not used once a stream event has been
+// delivered to OnStream.
+func WithFallbacks(fallbacks ...Fallback) Option {
+	return func(r *Request) { r.Options.Fallbacks = append(r.Options.Fallbacks, fallbacks...) }
+}
+
+// WithFallbackOn sets the error categories (uniai.ErrRateLimited,
+// uniai.ErrServerError, ...) that move a request to the next fallback.
+// Defaults to rate limit and server errors, including transport errors, and
+// open circuit breakers.
+func WithFallbackOn(categories ...error) Option {
+	return func(r *Request) { r.Options.FallbackOn = append([]error{}, categories...) }
+}
+
 func WithOnStream(fn OnStreamFunc) Option {
 	return func(r *Request) { r.Options.OnStream = fn }
 }
@@ -523,6 +625,31 @@ func ImageBase64Part(mimeType, dataBase64 string) Part {
 	return Part{Type: PartTypeImageBase64, MIMEType: mimeType, DataBase64: dataBase64}
 }
 
+func AudioURLPart(mimeType, url string) Part {
+	return Part{Type: PartTypeAudioURL, MIMEType: mimeType, URL: url}
+}
+
+func AudioBase64Part(mimeType, dataBase64 string) Part {
+	return Part{Type: PartTypeAudioBase64, MIMEType: mimeType, DataBase64: dataBase64}
+}
+
+// DocumentURLPart returns a document part for a file URL. The MIME type
+// defaults to application/pdf where a provider needs one.
+func DocumentURLPart(url string) Part {
+	return Part{Type: PartTypeDocumentURL, URL: url}
+}
+
+// DocumentBase64Part returns a document part with base64 file data, e.g. a
+// PDF. An empty mimeType defaults to application/pdf.
+func DocumentBase64Part(mimeType, dataBase64 string) Part {
+	return Part{Type: PartTypeDocumentBase64, MIMEType: mimeType, DataBase64: dataBase64}
+}
+
+// DocumentTextPart returns a plain-text document part.
+func DocumentTextPart(title, text string) Part {
+	return Part{Type: PartTypeDocumentText, Title: title, Text: text}
+}
+
 func WithPartCacheControl(part Part, ctrl CacheControl) Part {
 	part.CacheControl = CloneCacheControl(&ctrl)
 	return part
@@ -0,0 +1,96 @@
+package chat
+
+import "math"
+
+// Add returns the sum of u and other, for aggregating the usage of several
+// chat calls. Costs are summed as well, but the result has no cost when
+// either side has pricable tokens without one, so a partial estimate is never
+// reported as the total.
+func (u Usage) Add(other Usage) Usage {
+	out := Usage{
+		InputTokens:  u.InputTokens + other.InputTokens,
+		OutputTokens: u.OutputTokens + other.OutputTokens,
+		TotalTokens:  u.totalTokens() + other.totalTokens(),
+		Cache: UsageCache{
+			CachedInputTokens:        u.Cache.CachedInputTokens + other.Cache.CachedInputTokens,
+			CacheCreationInputTokens: u.Cache.CacheCreationInputTokens + other.Cache.CacheCreationInputTokens,
+		},
+	}
+	addUsageDetails(&out.Cache, u.Cache.Details)
+	addUsageDetails(&out.Cache, other.Cache.Details)
+	if u.costComplete() && other.costComplete() {
+		out.Cost = u.Cost.Add(other.Cost)
+	}
+	return out
+}
+
+// Pricable reports whether u has token counts a pricing rule would charge
+// for.
+func (u Usage) Pricable() bool {
+	return u.InputTokens > 0 ||
+		u.OutputTokens > 0 ||
+		u.Cache.CachedInputTokens > 0 ||
+		u.Cache.CacheCreationInputTokens > 0
+}
+
+func (u Usage) totalTokens() int {
+	if u.TotalTokens == 0 && (u.InputTokens > 0 || u.OutputTokens > 0) {
+		return u.InputTokens + u.OutputTokens
+	}
+	return u.TotalTokens
+}
+
+func (u Usage) costComplete() bool {
+	return u.Cost != nil || !u.Pricable()
+}
+
+// Add returns the sum of c and other. Nil costs count as zero; the result is
+// nil only when both are nil.
+func (c *UsageCost) Add(other *UsageCost) *UsageCost {
+	switch {
+	case c == nil && other == nil:
+		return nil
+	case c == nil:
+		out := *other
+		return &out
+	case other == nil:
+		out := *c
+		return &out
+	}
+	out := *c
+	out.Input = roundUSD(c.Input + other.Input)
+	out.CachedInput = roundUSD(c.CachedInput + other.CachedInput)
+	out.CacheCreationInput = roundUSD(c.CacheCreationInput + other.CacheCreationInput)
+	out.Output = roundUSD(c.Output + other.Output)
+	out.Total = roundUSD(c.Total + other.Total)
+	if out.Currency == "" {
+		out.Currency = other.Currency
+	}
+	out.Estimated = c.Estimated || other.Estimated
+	return &out
+}
+
+func addUsageDetails(dst *UsageCache, details map[string]int) {
+	if dst == nil || len(details) == 0 {
+		return
+	}
+	if dst.Details == nil {
+		dst.Details = make(map[string]int, len(details))
+	}
+	for key, value := range details {
+		if key == "" || value == 0 {
+			continue
+		}
+		dst.Details[key] += value
+		if dst.Details[key] == 0 {
+			delete(dst.Details, key)
+		}
+	}
+	if len(dst.Details) == 0 {
+		dst.Details = nil
+	}
+}
+
+func roundUSD(v float64) float64 {
+	return math.Round(v*1e12) / 1e12
+}
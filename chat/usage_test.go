@@ -0,0 +1,44 @@
+package chat
+
+import "testing"
+
+func TestUsageAddAggregatesFields(t *testing.T) {
+	usage := Usage{
+		InputTokens:  10,
+		OutputTokens: 2,
+		TotalTokens:  12,
+		Cache: UsageCache{
+			CachedInputTokens:        3,
+			CacheCreationInputTokens: 1,
+			Details: map[string]int{
+				"ephemeral_5m_input_tokens": 1,
+			},
+		},
+		Cost: &UsageCost{Currency: "USD", Total: 1},
+	}.Add(Usage{
+		InputTokens:  7,
+		OutputTokens: 4,
+		TotalTokens:  11,
+		Cache: UsageCache{
+			CachedInputTokens:        2,
+			CacheCreationInputTokens: 5,
+			Details: map[string]int{
+				"ephemeral_5m_input_tokens": 2,
+				"ephemeral_1h_input_tokens": 3,
+			},
+		},
+	})
+
+	if usage.InputTokens != 17 || usage.OutputTokens != 6 || usage.TotalTokens != 23 {
+		t.Fatalf("unexpected aggregate usage: %#v", usage)
+	}
+	if usage.Cache.CachedInputTokens != 5 || usage.Cache.CacheCreationInputTokens != 6 {
+		t.Fatalf("unexpected aggregate cache usage: %#v", usage.Cache)
+	}
+	if usage.Cache.Details["ephemeral_5m_input_tokens"] != 3 || usage.Cache.Details["ephemeral_1h_input_tokens"] != 3 {
+		t.Fatalf("unexpected aggregate cache details: %#v", usage.Cache.Details)
+	}
+	if usage.Cost != nil {
+		t.Fatalf("expected no cost when a priced usage is added to an unpriced one, got %#v", usage.Cost)
+	}
+}
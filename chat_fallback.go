@@ -0,0 +1,106 @@
+package uniai
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/quailyquaily/uniai/chat"
+	"github.com/quailyquaily/uniai/internal/retry"
+)
+
+// defaultFallbackOn extends the default retry categories with open circuit
+// breakers, which fail fast precisely so the request can move elsewhere.
+var defaultFallbackOn = []error{ErrRateLimited, ErrServerError, ErrCircuitOpen}
+
+// chatWithFallbacks tries the primary target and then each fallback in order,
+// moving on only while nothing has been streamed to the caller and the error
+// falls into one of the FallbackOn categories.
+func (c *Client) chatWithFallbacks(ctx context.Context, req *chat.Request) (*chat.Result, error) {
+	targets := append([]chat.Fallback{{Provider: req.Provider, Model: req.Model}}, req.Options.Fallbacks...)
+	fallbackOn := retry.Policy{RetryOn: req.Options.FallbackOn}
+	if len(fallbackOn.RetryOn) == 0 {
+		fallbackOn.RetryOn = defaultFallbackOn
+	}
+	for i, target := range targets {
+		targetReq := fallbackRequest(req, target)
+		emitted := false
+		if onStream := targetReq.Options.OnStream; onStream != nil {
+			targetReq.Options.OnStream = func(ev chat.StreamEvent) error {
+				emitted = true
+				return onStream(ev)
+			}
+		}
+
+		resp, err := c.chatTarget(ctx, targetReq.Provider, targetReq)
+		if err == nil {
+			if i > 0 && resp != nil {
+				resp.Warnings = append(resp.Warnings, fmt.Sprintf("served by fallback %d: %s", i, c.fallbackTargetLabel(targetReq)))
+			}
+			return resp, nil
+		}
+		if emitted || i == len(targets)-1 || !fallbackOn.Retryable(err) {
+			return nil, err
+		}
+	}
+	return nil, fmt.Errorf("no chat targets")
+}
+
+// fallbackRequest returns a copy of req aimed at target. Slices are copied so
+// target options cannot leak into the original request or other targets.
+func fallbackRequest(req *chat.Request, target chat.Fallback) *chat.Request {
+	out := *req
+	out.Messages = append([]chat.Message(nil), req.Messages...)
+	out.Tools = chat.CloneTools(req.Tools)
+	out.Options.Stop = append([]string(nil), req.Options.Stop...)
+	out.Options.Fallbacks = nil
+	if target.Provider != "" {
+		out.Provider = target.Provider
+	}
+	if target.Model != "" {
+		out.Model = target.Model
+	}
+	for _, opt := range target.Options {
+		if opt != nil {
+			opt(&out)
+		}
+	}
+	return &out
+}
+
+func (c *Client) fallbackTargetLabel(req *chat.Request) string {
+	model := c.resolveChatRequestedModel(req.Provider, req)
+	if model == "" {
+		return req.Provider
+	}
+	return req.Provider + "/" + model
+}
+
+type attemptLogKey struct{}
+
+// attemptLog collects every provider request made during one Client.Chat call
+// so Result.Attempts covers retries, fallbacks and tool emulation.
+type attemptLog struct {
+	mu       sync.Mutex
+	attempts []chat.Attempt
+}
+
+func (l *attemptLog) snapshot() []chat.Attempt {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]chat.Attempt(nil), l.attempts...)
+}
+
+func withAttemptLog(ctx context.Context, log *attemptLog) context.Context {
+	return context.WithValue(ctx, attemptLogKey{}, log)
+}
+
+func recordAttempts(ctx context.Context, attempts ...chat.Attempt) {
+	log, ok := ctx.Value(attemptLogKey{}).(*attemptLog)
+	if !ok {
+		return
+	}
+	log.mu.Lock()
+	log.attempts = append(log.attempts, attempts...)
+	log.mu.Unlock()
+}
@@ -0,0 +1,139 @@
+package uniai
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/quailyquaily/uniai/chat"
+)
+
+func newFallbackTestServer(t *testing.T, anthropicStatus int, anthropicCalls, openaiCalls *atomic.Int32) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/messages":
+			anthropicCalls.Add(1)
+			w.WriteHeader(anthropicStatus)
+			_, _ = io.WriteString(w, `{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`)
+		case "/v1/chat/completions":
+			openaiCalls.Add(1)
+			_, _ = io.WriteString(w, `{"id":"chatcmpl-1","object":"chat.completion","created":1,"model":"gpt-fallback","choices":[{"index":0,"message":{"role":"assistant","content":"from fallback"},"finish_reason":"stop"}],"usage":{"prompt_tokens":10,"completion_tokens":5,"total_tokens":15}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestClientChatFallsBackToNextTarget(t *testing.T) {
+	var anthropicCalls, openaiCalls atomic.Int32
+	server := newFallbackTestServer(t, 529, &anthropicCalls, &openaiCalls)
+
+	client := New(Config{
+		Provider:         "anthropic",
+		AnthropicAPIKey:  "test-key",
+		AnthropicAPIBase: server.URL,
+		OpenAIAPIKey:     "test-key",
+		OpenAIAPIBase:    server.URL + "/v1",
+		Pricing: &PricingCatalog{Chat: []ChatPricingRule{
+			{Model: "claude-primary", InputUSDPerMillion: 1000, OutputUSDPerMillion: 1000},
+			{Model: "gpt-fallback", InputUSDPerMillion: 1, OutputUSDPerMillion: 1},
+		}},
+	})
+
+	var sawTemperature bool
+	resp, err := client.Chat(context.Background(),
+		chat.WithModel("claude-primary"),
+		chat.WithMessages(chat.User("hello")),
+		chat.WithFallbacks(chat.Fallback{
+			Provider: "openai",
+			Model:    "gpt-fallback",
+			Options: []chat.Option{
+				chat.WithTemperature(0.2),
+				func(r *chat.Request) { sawTemperature = r.Options.Temperature != nil },
+			},
+		}),
+	)
+	if err != nil {
+		t.Fatalf("chat: %v", err)
+	}
+	if anthropicCalls.Load() != 1 || openaiCalls.Load() != 1 {
+		t.Fatalf("unexpected calls: anthropic=%d openai=%d", anthropicCalls.Load(), openaiCalls.Load())
+	}
+	if resp.Text != "from fallback" || !sawTemperature {
+		t.Fatalf("unexpected result: %#v", resp)
+	}
+	if len(resp.Attempts) != 2 {
+		t.Fatalf("expected 2 attempts, got %#v", resp.Attempts)
+	}
+	if first := resp.Attempts[0]; first.Provider != "anthropic" || first.Model != "claude-primary" || !errors.Is(first.Err, ErrServerError) {
+		t.Fatalf("unexpected first attempt: %#v", first)
+	}
+	if last := resp.Attempts[1]; last.Provider != "openai" || last.Model != "gpt-fallback" || last.Err != nil {
+		t.Fatalf("unexpected serving attempt: %#v", last)
+	}
+	if len(resp.Warnings) != 1 || resp.Warnings[0] != "served by fallback 1: openai/gpt-fallback" {
+		t.Fatalf("unexpected warnings: %#v", resp.Warnings)
+	}
+	if resp.Usage.Cost == nil {
+		t.Fatalf("expected cost for the serving model")
+	}
+	assertNearlyEqual(t, resp.Usage.Cost.Total, 0.000015)
+}
+
+func TestClientChatSkipsFallbackForOtherErrors(t *testing.T) {
+	var anthropicCalls, openaiCalls atomic.Int32
+	server := newFallbackTestServer(t, http.StatusBadRequest, &anthropicCalls, &openaiCalls)
+
+	client := New(Config{
+		Provider:         "anthropic",
+		AnthropicAPIKey:  "test-key",
+		AnthropicAPIBase: server.URL,
+		AnthropicModel:   "claude-primary",
+		OpenAIAPIKey:     "test-key",
+		OpenAIAPIBase:    server.URL + "/v1",
+	})
+	fallback := chat.WithFallbacks(chat.Fallback{Provider: "openai", Model: "gpt-fallback"})
+
+	_, err := client.Chat(context.Background(), chat.WithMessages(chat.User("hello")), fallback)
+	if !errors.Is(err, ErrInvalidRequest) || openaiCalls.Load() != 0 {
+		t.Fatalf("expected invalid request without fallback, got %v (openai calls %d)", err, openaiCalls.Load())
+	}
+
+	resp, err := client.Chat(context.Background(),
+		chat.WithMessages(chat.User("hello")),
+		fallback,
+		chat.WithFallbackOn(ErrInvalidRequest),
+	)
+	if err != nil || resp.Text != "from fallback" || openaiCalls.Load() != 1 {
+		t.Fatalf("expected configured category to fall back, got %v (openai calls %d)", err, openaiCalls.Load())
+	}
+}
+
+func TestFallbackRequestDoesNotMutateOriginal(t *testing.T) {
+	req := &chat.Request{
+		Provider: "anthropic",
+		Model:    "claude-primary",
+		Messages: make([]chat.Message, 1, 4),
+		Options:  chat.Options{Fallbacks: []chat.Fallback{{Provider: "openai"}}},
+	}
+	req.Messages[0] = chat.User("hello")
+
+	out := fallbackRequest(req, chat.Fallback{
+		Provider: "openai",
+		Options:  []chat.Option{chat.WithMessages(chat.User("extra"))},
+	})
+	if out.Provider != "openai" || out.Model != "claude-primary" || len(out.Options.Fallbacks) != 0 {
+		t.Fatalf("unexpected fallback request: %#v", out)
+	}
+	if len(out.Messages) != 2 || len(req.Messages) != 1 || req.Messages[:2][1].Content != "" {
+		t.Fatalf("fallback options leaked into the original request")
+	}
+}
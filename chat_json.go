@@ -0,0 +1,264 @@
+package uniai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/quailyquaily/uniai/chat"
+	"github.com/quailyquaily/uniai/internal/jsonoutput"
+	"github.com/quailyquaily/uniai/internal/jsonschema"
+)
+
+// ErrStructuredOutput is matched (errors.Is) by ChatJSON errors returned when
+// the model's reply still does not decode into the requested type after all
+// repair round-trips.
+var ErrStructuredOutput = errors.New("structured output does not match the schema")
+
+// strictJSONSchemaProviders enforce strict JSON schemas natively; other
+// providers get the relaxed schema, where optional fields may be omitted.
+var strictJSONSchemaProviders = map[string]bool{
+	"openai":       true,
+	"openai_resp":  true,
+	"openai_codex": true,
+	"azure":        true,
+}
+
+// promptJSONSchemaProviders only offer a JSON object mode, so the schema is
+// sent as a system prompt instruction instead.
+var promptJSONSchemaProviders = map[string]bool{
+	"deepseek": true,
+}
+
+// ChatJSON runs a chat request and decodes the reply into T.
+//
+// The JSON Schema sent to the provider is derived from T (see WithJSONSchema):
+// fields are named by their json tags, omitempty and pointer fields are
+// optional, and `description:"..."` and `enum:"a,b"` tags document and
+// restrict values. Non-object types are wrapped in a {"value": ...} object.
+// Options passed by the caller override the derived response format.
+//
+// Replies are recovered from code fences and surrounding prose and repaired
+// when they are almost valid JSON. A reply that still fails to decode or
+// validate is sent back to the model with the error, up to the number of
+// round-trips set by WithJSONRepairAttempts (DefaultJSONRepairAttempts by
+// default). The returned result is the last one, with Usage, Attempts and
+// Warnings aggregated across round-trips.
+func ChatJSON[T any](ctx context.Context, client *Client, opts ...chat.Option) (T, *chat.Result, error) {
+	var zero T
+	if client == nil {
+		return zero, nil, fmt.Errorf("client is nil")
+	}
+	req, err := chat.BuildRequest(opts...)
+	if err != nil {
+		return zero, nil, err
+	}
+	providerName := client.resolveChatProvider(req.Provider)
+	spec, err := newJSONOutputSpec(reflect.TypeFor[T](), strictJSONSchemaProviders[providerName])
+	if err != nil {
+		return zero, nil, err
+	}
+	repairs := chat.DefaultJSONRepairAttempts
+	if req.Options.JSONRepairAttempts != nil {
+		repairs = *req.Options.JSONRepairAttempts
+	}
+
+	base := spec.options(providerName)
+	var (
+		history []chat.Message
+		total   *chat.Result
+	)
+	for round := 0; ; round++ {
+		callOpts := make([]chat.Option, 0, len(base)+len(opts)+1)
+		callOpts = append(callOpts, base...)
+		callOpts = append(callOpts, opts...)
+		callOpts = append(callOpts, chat.WithMessages(history...))
+		resp, err := client.Chat(ctx, callOpts...)
+		total = mergeChatJSONResult(total, resp)
+		if err != nil {
+			return zero, total, err
+		}
+		if len(resp.ToolCalls) > 0 {
+			return zero, total, fmt.Errorf("ChatJSON does not run tools: model requested %d tool call(s)", len(resp.ToolCalls))
+		}
+
+		var value T
+		decodeErr := spec.decodeResult(resp, &value)
+		if decodeErr == nil {
+			return value, total, nil
+		}
+		if round >= repairs {
+			return zero, total, fmt.Errorf("%w: %w", ErrStructuredOutput, decodeErr)
+		}
+		history = append(history, chat.AssistantReplayMessages(resp)...)
+		history = append(history, chat.User(spec.repairPrompt(decodeErr)))
+	}
+}
+
+type jsonOutputSpec struct {
+	name string
+	// schema validates replies. It is the relaxed schema, which also
+	// accepts strict replies and keeps the keywords strict schemas leave
+	// out.
+	schema  map[string]any
+	raw     []byte
+	strict  bool
+	wrapped bool
+}
+
+func newJSONOutputSpec(t reflect.Type, strict bool) (*jsonOutputSpec, error) {
+	relaxed, err := jsonschema.Reflect(t, false)
+	if err != nil {
+		return nil, fmt.Errorf("json schema for %s: %w", t, err)
+	}
+	schema := relaxed
+	if strict {
+		// Types outside the strict subset (maps, interfaces) still get a
+		// best-effort schema.
+		schema, err = jsonschema.Reflect(t, true)
+		if err != nil {
+			strict = false
+			schema = relaxed
+		}
+	}
+	spec := &jsonOutputSpec{name: jsonSchemaName(t), strict: strict}
+	if schema["type"] != "object" || schema["properties"] == nil {
+		spec.wrapped = true
+		schema, relaxed = wrapJSONSchema(schema), wrapJSONSchema(relaxed)
+	}
+	raw, err := json.Marshal(schema)
+	if err != nil {
+		return nil, fmt.Errorf("json schema for %s: %w", t, err)
+	}
+	spec.schema = relaxed
+	spec.raw = raw
+	return spec, nil
+}
+
+// wrapJSONSchema wraps a schema for a non-object type in a {"value": ...}
+// object.
+func wrapJSONSchema(schema map[string]any) map[string]any {
+	return map[string]any{
+		"type":                 "object",
+		"properties":           map[string]any{"value": schema},
+		"required":             []string{"value"},
+		"additionalProperties": false,
+	}
+}
+
+// jsonSchemaName derives a response format name from t, limited to the
+// characters and length OpenAI accepts.
+func jsonSchemaName(t reflect.Type) string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	var b strings.Builder
+	for _, r := range t.Name() {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '-':
+			b.WriteRune(r)
+		case b.Len() > 0 && !strings.HasSuffix(b.String(), "_"):
+			b.WriteByte('_')
+		}
+		if b.Len() >= 64 {
+			break
+		}
+	}
+	name := strings.Trim(b.String(), "_")
+	if name == "" {
+		return "response"
+	}
+	return name
+}
+
+func (s *jsonOutputSpec) options(providerName string) []chat.Option {
+	if !promptJSONSchemaProviders[providerName] {
+		return []chat.Option{chat.WithJSONSchema(s.name, s.raw, s.strict)}
+	}
+	return []chat.Option{
+		chat.WithJSONObject(),
+		chat.WithMessages(chat.System("Respond only with a JSON object that matches this JSON Schema:\n" + string(s.raw))),
+	}
+}
+
+func (s *jsonOutputSpec) repairPrompt(err error) string {
+	return fmt.Sprintf("Your previous reply could not be used: %v\n\nReply again with only a JSON object that matches this JSON Schema:\n%s", err, s.raw)
+}
+
+// decodeResult decodes the first candidate in resp that validates against the
+// schema. Result.JSON is tried first, then every candidate collected from the
+// text, as is and repaired.
+func (s *jsonOutputSpec) decodeResult(resp *chat.Result, out any) error {
+	var candidates []string
+	if len(resp.JSON) > 0 {
+		candidates = append(candidates, string(resp.JSON))
+	}
+	if collected, err := jsonoutput.CollectCandidates(resp.Text); err == nil {
+		for _, candidate := range collected {
+			candidates = append(candidates, candidate)
+			if repaired := jsonoutput.AttemptRepair(candidate); repaired != "" && repaired != candidate {
+				candidates = append(candidates, repaired)
+			}
+		}
+	}
+
+	var firstErr error
+	for _, candidate := range candidates {
+		err := s.decode(candidate, out)
+		if err == nil {
+			return nil
+		}
+		if firstErr == nil && !errors.Is(err, errNotJSON) {
+			firstErr = err
+		}
+	}
+	if firstErr == nil {
+		firstErr = fmt.Errorf("reply does not contain JSON")
+	}
+	return firstErr
+}
+
+var errNotJSON = errors.New("not JSON")
+
+func (s *jsonOutputSpec) decode(payload string, out any) error {
+	data := []byte(strings.TrimSpace(payload))
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return errNotJSON
+	}
+	if err := jsonschema.Validate(s.schema, value); err != nil {
+		return err
+	}
+	if s.wrapped {
+		var envelope struct {
+			Value json.RawMessage `json:"value"`
+		}
+		if err := json.Unmarshal(data, &envelope); err != nil {
+			return err
+		}
+		data = envelope.Value
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("decode: %w", err)
+	}
+	return nil
+}
+
+// mergeChatJSONResult folds the usage, attempts and warnings of earlier
+// round-trips into next. The cost is kept only when every round-trip was
+// priced.
+func mergeChatJSONResult(prev, next *chat.Result) *chat.Result {
+	if prev == nil {
+		return next
+	}
+	if next == nil {
+		return prev
+	}
+	next.Usage = prev.Usage.Add(next.Usage)
+	next.Attempts = append(append([]chat.Attempt(nil), prev.Attempts...), next.Attempts...)
+	next.Warnings = append(append([]string(nil), prev.Warnings...), next.Warnings...)
+	return next
+}
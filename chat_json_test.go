@@ -0,0 +1,135 @@
+package uniai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/quailyquaily/uniai/chat"
+)
+
+type chatJSONPerson struct {
+	Name string   `json:"name" description:"Full name"`
+	Role string   `json:"role" enum:"admin,user"`
+	Tags []string `json:"tags,omitempty"`
+}
+
+// redirectTransport sends every request to target, for providers with a
+// fixed API base.
+type redirectTransport struct {
+	target *url.URL
+}
+
+func (t redirectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = t.target.Scheme
+	req.URL.Host = t.target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func chatCompletionBody(content string) string {
+	encoded, _ := json.Marshal(content)
+	return fmt.Sprintf(`{"id":"c1","object":"chat.completion","model":"gpt-test","choices":[{"index":0,"finish_reason":"stop","message":{"role":"assistant","content":%s}}],"usage":{"prompt_tokens":10,"completion_tokens":5,"total_tokens":15}}`, encoded)
+}
+
+func TestChatJSONRepairsInvalidReply(t *testing.T) {
+	var bodies []map[string]any
+	replies := []string{
+		"```json\n{\"name\":\"Ada\",\"role\":\"owner\",\"tags\":[]}\n```",
+		`{"name":"Ada","role":"admin","tags":null}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		bodies = append(bodies, body)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, chatCompletionBody(replies[len(bodies)-1]))
+	}))
+	defer server.Close()
+
+	client := New(Config{Provider: "openai", OpenAIAPIKey: "test-key", OpenAIAPIBase: server.URL + "/v1", OpenAIModel: "gpt-test"})
+	person, resp, err := ChatJSON[chatJSONPerson](context.Background(), client, chat.WithMessages(chat.User("who?")))
+	if err != nil {
+		t.Fatalf("chat json: %v", err)
+	}
+	if person.Name != "Ada" || person.Role != "admin" {
+		t.Fatalf("unexpected value: %#v", person)
+	}
+	if len(bodies) != 2 {
+		t.Fatalf("expected one repair round-trip, got %d requests", len(bodies))
+	}
+	format := bodies[0]["response_format"].(map[string]any)
+	schema := format["json_schema"].(map[string]any)
+	if schema["name"] != "chatJSONPerson" || schema["strict"] != true {
+		t.Fatalf("unexpected response_format: %#v", format)
+	}
+	messages := bodies[1]["messages"].([]any)
+	if len(messages) != 3 {
+		t.Fatalf("expected the reply and a repair message, got %#v", messages)
+	}
+	repair := messages[2].(map[string]any)["content"].(string)
+	if !strings.Contains(repair, `role: "owner" is not one of ["admin","user"]`) {
+		t.Fatalf("expected the validation error in the repair message, got %q", repair)
+	}
+	if resp.Usage.InputTokens != 20 || resp.Usage.TotalTokens != 30 || len(resp.Attempts) != 2 {
+		t.Fatalf("expected usage and attempts across round-trips, got %#v %#v", resp.Usage, resp.Attempts)
+	}
+}
+
+func TestChatJSONGivesUpAfterRepairAttempts(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if format := body["response_format"].(map[string]any); format["type"] != "json_object" {
+			t.Errorf("expected json_object mode, got %#v", format)
+		}
+		system := body["messages"].([]any)[0].(map[string]any)
+		if system["role"] != "system" || !strings.Contains(system["content"].(string), `"value"`) {
+			t.Errorf("expected the schema in a system message, got %#v", system)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, chatCompletionBody("no idea"))
+	}))
+	defer server.Close()
+
+	target, _ := url.Parse(server.URL)
+	client := New(Config{
+		Provider:     "deepseek",
+		OpenAIAPIKey: "test-key",
+		OpenAIModel:  "deepseek-test",
+		Transport:    redirectTransport{target: target},
+	})
+	_, resp, err := ChatJSON[[]string](context.Background(), client,
+		chat.WithMessages(chat.User("list")),
+		chat.WithJSONRepairAttempts(1),
+	)
+	if !errors.Is(err, ErrStructuredOutput) || resp == nil {
+		t.Fatalf("expected structured output error, got %v", err)
+	}
+	if requests != 2 {
+		t.Fatalf("expected two requests, got %d", requests)
+	}
+}
+
+func TestChatJSONUnwrapsNonObjectTypes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, chatCompletionBody(`{"value":["a","b"]}`))
+	}))
+	defer server.Close()
+
+	client := New(Config{Provider: "openai", OpenAIAPIKey: "test-key", OpenAIAPIBase: server.URL + "/v1", OpenAIModel: "gpt-test"})
+	got, _, err := ChatJSON[[]string](context.Background(), client, chat.WithMessages(chat.User("list")))
+	if err != nil || strings.Join(got, ",") != "a,b" {
+		t.Fatalf("unexpected result: %v %v", got, err)
+	}
+}
@@ -0,0 +1,110 @@
+package uniai
+
+import (
+	"context"
+	"io"
+	"iter"
+	"sync/atomic"
+
+	"github.com/quailyquaily/uniai/chat"
+)
+
+// ChatStream is a pull-based chat stream returned by Client.ChatStream.
+//
+// Events are handed over one at a time: the provider stream does not read
+// ahead of the consumer, so a slow consumer slows the upstream read instead
+// of buffering it. Call Close, or drain the stream with Result, to release
+// the underlying HTTP response.
+type ChatStream struct {
+	events chan chat.StreamEvent
+	cancel context.CancelFunc
+	closed atomic.Bool
+	// result and err are written before events is closed.
+	result *chat.Result
+	err    error
+}
+
+// ChatStream starts a streaming chat call and returns a stream to pull its
+// events from. It runs Client.Chat with an OnStream callback of its own, so
+// middleware, retries, fallbacks and tool emulation apply as usual; an
+// OnStream option in opts is replaced. Errors from the provider, including
+// errors before the first event, are returned by Recv.
+func (c *Client) ChatStream(ctx context.Context, opts ...chat.Option) (*ChatStream, error) {
+	if _, err := chat.BuildRequest(opts...); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	s := &ChatStream{
+		events: make(chan chat.StreamEvent),
+		cancel: cancel,
+	}
+	onStream := func(ev chat.StreamEvent) error {
+		select {
+		case s.events <- ev:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	opts = append(opts[:len(opts):len(opts)], chat.WithOnStream(onStream))
+	go func() {
+		defer close(s.events)
+		defer cancel()
+		s.result, s.err = c.Chat(ctx, opts...)
+	}()
+	return s, nil
+}
+
+// Recv returns the next event. It returns io.EOF once the stream has ended
+// or was closed, and the call error if it failed.
+func (s *ChatStream) Recv() (chat.StreamEvent, error) {
+	if ev, ok := <-s.events; ok {
+		return ev, nil
+	}
+	if s.err != nil && !s.closed.Load() {
+		return chat.StreamEvent{}, s.err
+	}
+	return chat.StreamEvent{}, io.EOF
+}
+
+// Events returns an iterator over the remaining events. A failed call yields
+// its error once as the last element. Breaking out of the loop closes the
+// stream.
+func (s *ChatStream) Events() iter.Seq2[chat.StreamEvent, error] {
+	return func(yield func(chat.StreamEvent, error) bool) {
+		for {
+			ev, err := s.Recv()
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				yield(chat.StreamEvent{}, err)
+				return
+			}
+			if !yield(ev, nil) {
+				s.Close()
+				return
+			}
+		}
+	}
+}
+
+// Result waits for the call to finish, discarding events not received yet,
+// and returns the final result as Client.Chat would. After Close it returns
+// the cancellation error unless the call had already completed.
+func (s *ChatStream) Result() (*chat.Result, error) {
+	for range s.events {
+	}
+	return s.result, s.err
+}
+
+// Close stops the stream, cancelling the upstream request and closing its
+// response body. It is safe to call more than once and concurrently with
+// Recv.
+func (s *ChatStream) Close() error {
+	s.closed.Store(true)
+	s.cancel()
+	for range s.events {
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package uniai
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/quailyquaily/uniai/chat"
+)
+
+func anthropicStreamServer(t *testing.T, handler func(w http.ResponseWriter, r *http.Request)) *Client {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(handler))
+	t.Cleanup(server.Close)
+	return New(Config{
+		Provider:         "anthropic",
+		AnthropicAPIKey:  "test-key",
+		AnthropicAPIBase: server.URL,
+		AnthropicModel:   "claude-sonnet-test",
+	})
+}
+
+func TestChatStreamYieldsEventsAndResult(t *testing.T) {
+	client := anthropicStreamServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, "event: message_start\ndata: {\"type\":\"message_start\",\"message\":{\"model\":\"claude-sonnet-test\",\"usage\":{\"input_tokens\":3}}}\n\n"+
+			"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\"Hel\"}}\n\n"+
+			"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\"lo\"}}\n\n"+
+			"event: message_delta\ndata: {\"type\":\"message_delta\",\"delta\":{\"stop_reason\":\"end_turn\"},\"usage\":{\"output_tokens\":2}}\n\n"+
+			"event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n")
+	})
+
+	stream, err := client.ChatStream(context.Background(), chat.WithMessages(chat.User("hello")))
+	if err != nil {
+		t.Fatalf("chat stream: %v", err)
+	}
+	text, done := "", false
+	for ev, err := range stream.Events() {
+		if err != nil {
+			t.Fatalf("event: %v", err)
+		}
+		text += ev.Delta
+		done = done || ev.Done
+	}
+	if text != "Hello" || !done {
+		t.Fatalf("unexpected events: text %q, done %v", text, done)
+	}
+	if _, err := stream.Recv(); err != io.EOF {
+		t.Fatalf("expected io.EOF after the last event, got %v", err)
+	}
+	resp, err := stream.Result()
+	if err != nil || resp.Text != "Hello" || resp.Usage.OutputTokens != 2 {
+		t.Fatalf("unexpected result: %#v %v", resp, err)
+	}
+}
+
+func TestChatStreamReturnsErrorsFromRecv(t *testing.T) {
+	client := anthropicStreamServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = io.WriteString(w, `{"type":"error","error":{"type":"authentication_error","message":"bad key"}}`)
+	})
+
+	stream, err := client.ChatStream(context.Background(), chat.WithMessages(chat.User("hello")))
+	if err != nil {
+		t.Fatalf("chat stream: %v", err)
+	}
+	if _, err := stream.Recv(); !errors.Is(err, ErrAuth) {
+		t.Fatalf("expected an auth error, got %v", err)
+	}
+	if _, err := stream.Result(); !errors.Is(err, ErrAuth) {
+		t.Fatalf("expected the same error from Result, got %v", err)
+	}
+	if _, err := client.ChatStream(context.Background()); err == nil {
+		t.Fatalf("expected invalid options to fail up front")
+	}
+}
+
+func TestChatStreamBreakCancelsUpstream(t *testing.T) {
+	cancelled := make(chan struct{})
+	client := anthropicStreamServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, "event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\"Hel\"}}\n\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+		close(cancelled)
+	})
+
+	stream, err := client.ChatStream(context.Background(), chat.WithMessages(chat.User("hello")))
+	if err != nil {
+		t.Fatalf("chat stream: %v", err)
+	}
+	for ev, err := range stream.Events() {
+		if err != nil || ev.Delta != "Hel" {
+			t.Fatalf("unexpected first event: %#v %v", ev, err)
+		}
+		break
+	}
+	select {
+	case <-cancelled:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("upstream request was not cancelled")
+	}
+	if _, err := stream.Recv(); err != io.EOF {
+		t.Fatalf("expected io.EOF from a closed stream, got %v", err)
+	}
+	if _, err := stream.Result(); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected a cancellation error, got %v", err)
+	}
+	if err := stream.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+}
@@ -0,0 +1,54 @@
+package uniai
+
+import (
+	"context"
+
+	"github.com/quailyquaily/uniai/chat"
+	"github.com/quailyquaily/uniai/internal/breaker"
+)
+
+// CircuitBreakerConfig configures the per-provider circuit breakers. The zero
+// value disables them.
+type CircuitBreakerConfig = breaker.Config
+
+// CircuitBreakerError is returned without calling a provider whose breaker is
+// open. It matches ErrCircuitOpen.
+type CircuitBreakerError = breaker.Error
+
+// ProviderHealth is a snapshot of one provider's circuit breaker.
+type ProviderHealth = breaker.Health
+
+// CircuitState is the state of a circuit breaker.
+type CircuitState = breaker.State
+
+const (
+	CircuitClosed   = breaker.StateClosed
+	CircuitOpen     = breaker.StateOpen
+	CircuitHalfOpen = breaker.StateHalfOpen
+)
+
+// ErrCircuitOpen matches requests rejected because the provider's circuit
+// breaker is open.
+var ErrCircuitOpen = breaker.ErrOpen
+
+// ProviderHealth returns the state of every circuit breaker the client has
+// used so far. It is empty when circuit breaking is disabled.
+func (c *Client) ProviderHealth() []ProviderHealth {
+	return c.breakers.Snapshot()
+}
+
+// chatAttempt sends one provider request through the provider's circuit
+// breaker and the rate limiter.
+func (c *Client) chatAttempt(ctx context.Context, providerName string, req *chat.Request) (*chat.Result, error) {
+	baseURL := ""
+	if c.cfg.CircuitBreaker.PerBaseURL {
+		baseURL = c.configView(providerName).APIBase
+	}
+	done, err := c.breakers.Allow(ctx, providerName, baseURL)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.chatProviderLimited(ctx, providerName, req)
+	done(err)
+	return resp, err
+}
@@ -3,12 +3,16 @@ package uniai
 import (
 	"context"
 	"fmt"
+	"sync"
 
 	"github.com/quailyquaily/uniai/audio"
 	"github.com/quailyquaily/uniai/chat"
 	"github.com/quailyquaily/uniai/classify"
 	"github.com/quailyquaily/uniai/embedding"
 	"github.com/quailyquaily/uniai/image"
+	"github.com/quailyquaily/uniai/internal/breaker"
+	"github.com/quailyquaily/uniai/internal/jsonoutput"
+	"github.com/quailyquaily/uniai/internal/retry"
 	"github.com/quailyquaily/uniai/providers/anthropic"
 	"github.com/quailyquaily/uniai/providers/azure"
 	"github.com/quailyquaily/uniai/providers/bedrock"
@@ -22,17 +26,31 @@ import (
 type Client struct {
 	cfg Config
 
+	providersMu sync.RWMutex
+	providers   map[string]chatBackend
+	breakers    *breaker.Set
+
 	embeddingClient *embedding.Client
 	imageClient     *image.Client
 	rerankClient    *rerank.Client
 	classifyClient  *classify.Client
 	audioClient     *audio.Client
+
+	chatHandler      ChatHandler
+	chatCallHandler  ChatHandler
+	embeddingHandler EmbeddingHandler
+	imageHandler     ImageHandler
+	imageEditHandler ImageEditHandler
+	rerankHandler    RerankHandler
+	classifyHandler  ClassifyHandler
+	audioHandler     AudioHandler
 }
 
 func New(cfg Config) *Client {
 	cfg = cfg.withDefaults()
-	return &Client{
-		cfg: cfg,
+	c := &Client{
+		cfg:      cfg,
+		breakers: breaker.New(cfg.CircuitBreaker),
 		embeddingClient: embedding.New(embedding.Config{
 			JinaAPIKey:          cfg.JinaAPIKey,
 			JinaAPIBase:         cfg.JinaAPIBase,
@@ -43,6 +61,8 @@ func New(cfg Config) *Client {
 			CloudflareAccountID: cfg.CloudflareAccountID,
 			CloudflareAPIToken:  cfg.CloudflareAPIToken,
 			CloudflareAPIBase:   cfg.CloudflareAPIBase,
+			Retry:               cfg.Retry,
+			HTTPClient:          cfg.HTTPClient,
 		}),
 		imageClient: image.New(image.Config{
 			OpenAIAPIKey:        cfg.OpenAIAPIKey,
@@ -51,21 +71,40 @@ func New(cfg Config) *Client {
 			CloudflareAccountID: cfg.CloudflareAccountID,
 			CloudflareAPIToken:  cfg.CloudflareAPIToken,
 			CloudflareAPIBase:   cfg.CloudflareAPIBase,
+			Retry:               cfg.Retry,
+			HTTPClient:          cfg.HTTPClient,
 		}),
 		rerankClient: rerank.New(rerank.Config{
 			JinaAPIKey:  cfg.JinaAPIKey,
 			JinaAPIBase: cfg.JinaAPIBase,
+			Retry:       cfg.Retry,
+			HTTPClient:  cfg.HTTPClient,
 		}),
 		classifyClient: classify.New(classify.Config{
 			JinaAPIKey:  cfg.JinaAPIKey,
 			JinaAPIBase: cfg.JinaAPIBase,
+			Retry:       cfg.Retry,
+			HTTPClient:  cfg.HTTPClient,
 		}),
 		audioClient: audio.New(audio.Config{
 			CloudflareAccountID: cfg.CloudflareAccountID,
 			CloudflareAPIToken:  cfg.CloudflareAPIToken,
 			CloudflareAPIBase:   cfg.CloudflareAPIBase,
+			Retry:               cfg.Retry,
+			HTTPClient:          cfg.HTTPClient,
 		}),
 	}
+	c.chatHandler = chain[ChatHandler](c.chatResolved, cfg.ChatMiddleware)
+	c.chatCallHandler = chain[ChatHandler](func(ctx context.Context, req *chat.Request) (*chat.Result, error) {
+		return c.chatOnce(ctx, req.Provider, req)
+	}, cfg.ChatCallMiddleware)
+	c.embeddingHandler = chain[EmbeddingHandler](c.embeddingClient.Do, cfg.EmbeddingMiddleware)
+	c.imageHandler = chain[ImageHandler](c.imageClient.Do, cfg.ImageMiddleware)
+	c.imageEditHandler = chain[ImageEditHandler](c.imageClient.DoEdit, cfg.ImageEditMiddleware)
+	c.rerankHandler = chain[RerankHandler](c.rerankClient.Do, cfg.RerankMiddleware)
+	c.classifyHandler = chain[ClassifyHandler](c.classifyClient.Do, cfg.ClassifyMiddleware)
+	c.audioHandler = chain[AudioHandler](c.audioClient.Do, cfg.AudioMiddleware)
+	return c
 }
 
 func (c *Client) Chat(ctx context.Context, opts ...chat.Option) (*chat.Result, error) {
@@ -74,14 +113,57 @@ func (c *Client) Chat(ctx context.Context, opts ...chat.Option) (*chat.Result, e
 		return nil, err
 	}
 
-	providerName := req.Provider
+	req.Provider = c.resolveChatProvider(req.Provider)
+	return c.chatHandler(ctx, req)
+}
+
+// chatResolved is the innermost ChatHandler.
+func (c *Client) chatResolved(ctx context.Context, req *chat.Request) (*chat.Result, error) {
+	providerName := c.resolveChatProvider(req.Provider)
+	req.Provider = providerName
+
+	attempts := &attemptLog{}
+	ctx = withAttemptLog(ctx, attempts)
+	var (
+		resp *chat.Result
+		err  error
+	)
+	if len(req.Options.Fallbacks) > 0 {
+		resp, err = c.chatWithFallbacks(ctx, req)
+	} else {
+		resp, err = c.chatTarget(ctx, providerName, req)
+	}
+	if resp != nil {
+		resp.Attempts = attempts.snapshot()
+	}
+	return resp, err
+}
+
+func (c *Client) resolveChatProvider(providerName string) string {
 	if providerName == "" {
 		providerName = c.cfg.Provider
 	}
 	if providerName == "" {
 		providerName = "openai"
 	}
-	req.Provider = providerName
+	return providerName
+}
+
+// chatTarget runs a resolved request against a single provider, including
+// model limit checks, tool emulation and cost annotation.
+func (c *Client) chatTarget(ctx context.Context, providerName string, req *chat.Request) (*chat.Result, error) {
+	warning, err := c.applyModelLimits(providerName, req)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.chatTargetChecked(ctx, providerName, req)
+	if resp != nil && warning != "" {
+		resp.Warnings = append(resp.Warnings, warning)
+	}
+	return resp, err
+}
+
+func (c *Client) chatTargetChecked(ctx context.Context, providerName string, req *chat.Request) (*chat.Result, error) {
 	mode := req.Options.ToolsEmulationMode
 	if mode == "" {
 		mode = chat.ToolsEmulationOff
@@ -98,33 +180,41 @@ func (c *Client) Chat(ctx context.Context, opts ...chat.Option) (*chat.Result, e
 	}
 	if len(req.Tools) > 0 && mode == chat.ToolsEmulationForce {
 		resp, err := c.chatWithToolEmulation(ctx, providerName, req, nil, userOnStream)
-		c.annotateChatResultCost(providerName, req, resp)
-		chat.EnsureResultParts(resp)
+		c.finishChatResult(providerName, req, resp)
 		return resp, err
 	}
-	resp, err := c.chatOnce(ctx, providerName, req)
+	resp, err := c.chatCall(ctx, providerName, req)
 	if err != nil {
 		return nil, err
 	}
-	if len(req.Tools) == 0 {
-		c.annotateChatResultCost(providerName, req, resp)
-		chat.EnsureResultParts(resp)
-		return resp, nil
-	}
-	if len(resp.ToolCalls) > 0 {
-		c.annotateChatResultCost(providerName, req, resp)
-		chat.EnsureResultParts(resp)
-		return resp, nil
-	}
-	if mode == chat.ToolsEmulationOff {
-		c.annotateChatResultCost(providerName, req, resp)
-		chat.EnsureResultParts(resp)
+	if len(req.Tools) == 0 || len(resp.ToolCalls) > 0 || mode == chat.ToolsEmulationOff {
+		c.finishChatResult(providerName, req, resp)
 		return resp, nil
 	}
 	resp, err = c.chatWithToolEmulation(ctx, providerName, req, resp, userOnStream)
+	c.finishChatResult(providerName, req, resp)
+	return resp, err
+}
+
+// finishChatResult fills the fields derived from a complete result: cost,
+// parts and structured output.
+func (c *Client) finishChatResult(providerName string, req *chat.Request, resp *chat.Result) {
 	c.annotateChatResultCost(providerName, req, resp)
 	chat.EnsureResultParts(resp)
-	return resp, err
+	parseChatResultJSON(req, resp)
+}
+
+// parseChatResultJSON fills Result.JSON from the text of a response to a
+// request with a response format.
+func parseChatResultJSON(req *chat.Request, resp *chat.Result) {
+	if resp == nil || req.Options.ResponseFormat == nil || len(resp.ToolCalls) > 0 {
+		return
+	}
+	if data, ok := jsonoutput.Extract(resp.Text); ok {
+		resp.JSON = data
+		return
+	}
+	resp.Warnings = append(resp.Warnings, "structured output is not valid JSON")
 }
 
 func (c *Client) annotateChatResultCost(providerName string, req *chat.Request, resp *chat.Result) {
@@ -199,7 +289,107 @@ func (c *Client) resolveChatRequestedModel(providerName string, req *chat.Reques
 	}
 }
 
+// chatCall sends one upstream chat request through Config.ChatCallMiddleware.
+func (c *Client) chatCall(ctx context.Context, providerName string, req *chat.Request) (*chat.Result, error) {
+	if req.Provider != providerName {
+		copied := *req
+		copied.Provider = providerName
+		req = &copied
+	}
+	return c.chatCallHandler(ctx, req)
+}
+
 func (c *Client) chatOnce(ctx context.Context, providerName string, req *chat.Request) (*chat.Result, error) {
+	attemptReq := req
+	emitted := false
+	if c.cfg.Retry.Enabled() && req.Options.OnStream != nil {
+		// Once an event reaches the caller the stream cannot be replayed, so
+		// later failures are returned as-is instead of being retried.
+		onStream := req.Options.OnStream
+		copied := *req
+		copied.Options.OnStream = func(ev chat.StreamEvent) error {
+			emitted = true
+			return onStream(ev)
+		}
+		attemptReq = &copied
+	}
+
+	var resp *chat.Result
+	attemptErrs, err := retry.Do(ctx, c.cfg.Retry, func() error {
+		var err error
+		resp, err = c.chatAttempt(ctx, providerName, attemptReq)
+		err = labelAPIError(err, providerName)
+		if err != nil && emitted {
+			return retry.Permanent(err)
+		}
+		return err
+	})
+	model := c.resolveChatRequestedModel(providerName, req)
+	attempts := make([]chat.Attempt, 0, len(attemptErrs)+1)
+	for _, attemptErr := range attemptErrs {
+		attempts = append(attempts, chat.Attempt{
+			Provider: providerName,
+			Model:    model,
+			Error:    attemptErr.Error(),
+			Err:      attemptErr,
+		})
+	}
+	if err != nil {
+		recordAttempts(ctx, attempts...)
+		return nil, err
+	}
+	if resp != nil {
+		if resp.Model != "" {
+			model = resp.Model
+		}
+		resp.Attempts = append(attempts, chat.Attempt{Provider: providerName, Model: model})
+		recordAttempts(ctx, resp.Attempts...)
+	}
+	return resp, nil
+}
+
+// chatBackend is the method set shared by the providers/* chat providers.
+type chatBackend interface {
+	Chat(ctx context.Context, req *chat.Request) (*chat.Result, error)
+}
+
+func (c *Client) chatProvider(ctx context.Context, providerName string, req *chat.Request) (*chat.Result, error) {
+	p, err := c.chatBackendFor(providerName)
+	if err != nil {
+		return nil, err
+	}
+	return p.Chat(ctx, req)
+}
+
+// chatBackendFor returns the provider instance for providerName, building it
+// on first use. The effective provider config is derived only from
+// providerName and the Client config, which is immutable after New, so the
+// name is a sufficient cache key. Providers are safe for concurrent use.
+func (c *Client) chatBackendFor(providerName string) (chatBackend, error) {
+	c.providersMu.RLock()
+	p, ok := c.providers[providerName]
+	c.providersMu.RUnlock()
+	if ok {
+		return p, nil
+	}
+
+	c.providersMu.Lock()
+	defer c.providersMu.Unlock()
+	if p, ok := c.providers[providerName]; ok {
+		return p, nil
+	}
+	p, err := c.newChatBackend(providerName)
+	if err != nil {
+		return nil, err
+	}
+	if c.providers == nil {
+		c.providers = make(map[string]chatBackend)
+	}
+	c.providers[providerName] = p
+	return p, nil
+}
+
+func (c *Client) newChatBackend(providerName string) (chatBackend, error) {
 	switch providerName {
 	case "openai", "deepseek", "xai", "groq", "meta":
 		base := c.cfg.OpenAIAPIBase
@@ -215,43 +405,49 @@ func (c *Client) chatOnce(ctx context.Context, providerName string, req *chat.Re
 		}
 
 		p, err := openai.New(openai.Config{
-			APIKey:       c.cfg.OpenAIAPIKey,
-			BaseURL:      base,
-			DefaultModel: c.cfg.OpenAIModel,
-			Headers:      c.cfg.ChatHeaders,
-			Debug:        c.cfg.Debug,
+			APIKey:         c.cfg.OpenAIAPIKey,
+			BaseURL:        base,
+			DefaultModel:   c.cfg.OpenAIModel,
+			Headers:        c.cfg.ChatHeaders,
+			Debug:          c.cfg.Debug,
+			DisableRetries: c.cfg.Retry.Enabled(),
+			HTTPClient:     c.cfg.HTTPClient,
 		})
 		if err != nil {
 			return nil, err
 		}
-		return p.Chat(ctx, req)
+		return p, nil
 
 	case "openai_resp", "openai_codex":
 		p, err := openairesp.New(openairesp.Config{
-			APIKey:       c.cfg.OpenAIAPIKey,
-			BaseURL:      c.cfg.OpenAIAPIBase,
-			DefaultModel: c.cfg.OpenAIModel,
-			Headers:      c.cfg.ChatHeaders,
-			Debug:        c.cfg.Debug,
-			OpenAICodex:  providerName == "openai_codex",
+			APIKey:         c.cfg.OpenAIAPIKey,
+			BaseURL:        c.cfg.OpenAIAPIBase,
+			DefaultModel:   c.cfg.OpenAIModel,
+			Headers:        c.cfg.ChatHeaders,
+			Debug:          c.cfg.Debug,
+			OpenAICodex:    providerName == "openai_codex",
+			DisableRetries: c.cfg.Retry.Enabled(),
+			HTTPClient:     c.cfg.HTTPClient,
 		})
 		if err != nil {
 			return nil, err
 		}
-		return p.Chat(ctx, req)
+		return p, nil
 
 	case "sakana":
 		p, err := openairesp.New(openairesp.Config{
-			APIKey:       c.cfg.OpenAIAPIKey,
-			BaseURL:      resolveSakanaAPIBase(c.cfg.OpenAIAPIBase),
-			DefaultModel: c.cfg.OpenAIModel,
-			Headers:      c.cfg.ChatHeaders,
-			Debug:        c.cfg.Debug,
+			APIKey:         c.cfg.OpenAIAPIKey,
+			BaseURL:        resolveSakanaAPIBase(c.cfg.OpenAIAPIBase),
+			DefaultModel:   c.cfg.OpenAIModel,
+			Headers:        c.cfg.ChatHeaders,
+			Debug:          c.cfg.Debug,
+			DisableRetries: c.cfg.Retry.Enabled(),
+			HTTPClient:     c.cfg.HTTPClient,
 		})
 		if err != nil {
 			return nil, err
 		}
-		return p.Chat(ctx, req)
+		return p, nil
 
 	case "gemini":
 		apiKey := c.cfg.GeminiAPIKey
@@ -268,38 +464,41 @@ func (c *Client) chatOnce(ctx context.Context, providerName string, req *chat.Re
 			DefaultModel: geminiModel,
 			Headers:      c.cfg.ChatHeaders,
 			Debug:        c.cfg.Debug,
+			HTTPClient:   c.cfg.HTTPClient,
 		})
 		if err != nil {
 			return nil, err
 		}
-		return p.Chat(ctx, req)
+		return p, nil
 
 	case "azure":
 		p, err := azure.New(azure.Config{
-			APIKey:     c.cfg.AzureOpenAIAPIKey,
-			Endpoint:   c.cfg.AzureOpenAIEndpoint,
-			Deployment: c.cfg.AzureOpenAIModel,
-			APIVersion: c.cfg.AzureOpenAIAPIVersion,
-			Headers:    c.cfg.ChatHeaders,
-			Debug:      c.cfg.Debug,
+			APIKey:         c.cfg.AzureOpenAIAPIKey,
+			Endpoint:       c.cfg.AzureOpenAIEndpoint,
+			Deployment:     c.cfg.AzureOpenAIModel,
+			APIVersion:     c.cfg.AzureOpenAIAPIVersion,
+			Headers:        c.cfg.ChatHeaders,
+			Debug:          c.cfg.Debug,
+			DisableRetries: c.cfg.Retry.Enabled(),
+			HTTPClient:     c.cfg.HTTPClient,
 		})
 		if err != nil {
 			return nil, err
 		}
-		return p.Chat(ctx, req)
+		return p, nil
 
 	case "anthropic":
-		p := anthropic.New(anthropic.Config{
+		return anthropic.New(anthropic.Config{
 			APIKey:       c.cfg.AnthropicAPIKey,
 			APIBase:      c.cfg.AnthropicAPIBase,
 			DefaultModel: c.cfg.AnthropicModel,
 			Headers:      c.cfg.ChatHeaders,
 			Debug:        c.cfg.Debug,
-		})
-		return p.Chat(ctx, req)
+			HTTPClient:   c.cfg.HTTPClient,
+		}), nil
 
 	case "bedrock":
-		p := bedrock.New(bedrock.Config{
+		return bedrock.New(bedrock.Config{
 			AwsKey:          c.cfg.AwsKey,
 			AwsSecret:       c.cfg.AwsSecret,
 			AwsSessionToken: c.cfg.AwsSessionToken,
@@ -307,21 +506,23 @@ func (c *Client) chatOnce(ctx context.Context, providerName string, req *chat.Re
 			ModelArn:        c.cfg.AwsBedrockModelArn,
 			Headers:         c.cfg.ChatHeaders,
 			Debug:           c.cfg.Debug,
-		})
-		return p.Chat(ctx, req)
+			DisableRetries:  c.cfg.Retry.Enabled(),
+			HTTPClient:      c.cfg.HTTPClient,
+		}), nil
 
 	case "cloudflare":
 		p, err := cloudflare.New(cloudflare.Config{
-			AccountID: c.cfg.CloudflareAccountID,
-			APIToken:  c.cfg.CloudflareAPIToken,
-			APIBase:   c.cfg.CloudflareAPIBase,
-			Headers:   c.cfg.ChatHeaders,
-			Debug:     c.cfg.Debug,
+			AccountID:  c.cfg.CloudflareAccountID,
+			APIToken:   c.cfg.CloudflareAPIToken,
+			APIBase:    c.cfg.CloudflareAPIBase,
+			Headers:    c.cfg.ChatHeaders,
+			Debug:      c.cfg.Debug,
+			HTTPClient: c.cfg.HTTPClient,
 		})
 		if err != nil {
 			return nil, err
 		}
-		return p.Chat(ctx, req)
+		return p, nil
 
 	default:
 		return nil, fmt.Errorf("provider %s not supported", providerName)
@@ -332,7 +533,11 @@ func (c *Client) Embedding(ctx context.Context, opts ...embedding.Option) (*embe
 	if c.embeddingClient == nil {
 		return nil, fmt.Errorf("embedding client not configured")
 	}
-	return c.embeddingClient.Create(ctx, opts...)
+	req := embedding.BuildRequest(opts...)
+	if err := embedding.ResolveProvider(req); err != nil {
+		return nil, err
+	}
+	return c.embeddingHandler(ctx, req)
 }
 
 func (c *Client) Image(ctx context.Context, opts ...image.Option) (*image.Result, error) {
@@ -340,8 +545,12 @@ func (c *Client) Image(ctx context.Context, opts ...image.Option) (*image.Result
 		return nil, fmt.Errorf("image client not configured")
 	}
 	req := image.BuildRequest(opts...)
-	resp, err := c.imageClient.Create(ctx, opts...)
-	c.annotateImageResultCost(req.Provider, req.Model, resp)
+	inferenceProvider := req.Provider
+	if err := image.ResolveProvider(req); err != nil {
+		return nil, err
+	}
+	resp, err := c.imageHandler(ctx, req)
+	c.annotateImageResultCost(inferenceProvider, req.Model, resp)
 	return resp, err
 }
 
@@ -350,8 +559,12 @@ func (c *Client) EditImage(ctx context.Context, opts ...image.ImageEditOption) (
 		return nil, fmt.Errorf("image client not configured")
 	}
 	req := image.BuildEditRequest(opts...)
-	resp, err := c.imageClient.Edit(ctx, opts...)
-	c.annotateImageResultCost(req.Provider, req.Model, resp)
+	inferenceProvider := req.Provider
+	if err := image.ResolveEditProvider(req); err != nil {
+		return nil, err
+	}
+	resp, err := c.imageEditHandler(ctx, req)
+	c.annotateImageResultCost(inferenceProvider, req.Model, resp)
 	return resp, err
 }
 
@@ -368,19 +581,31 @@ func (c *Client) Audio(ctx context.Context, opts ...audio.Option) (*audio.Result
 	if c.audioClient == nil {
 		return nil, fmt.Errorf("audio client not configured")
 	}
-	return c.audioClient.Create(ctx, opts...)
+	req := audio.BuildRequest(opts...)
+	if err := audio.ResolveProvider(req); err != nil {
+		return nil, err
+	}
+	return c.audioHandler(ctx, req)
 }
 
 func (c *Client) Rerank(ctx context.Context, opts ...rerank.Option) (*rerank.Result, error) {
 	if c.rerankClient == nil {
 		return nil, fmt.Errorf("rerank client not configured")
 	}
-	return c.rerankClient.Rerank(ctx, opts...)
+	req := rerank.BuildRequest(opts...)
+	if err := rerank.ResolveProvider(req); err != nil {
+		return nil, err
+	}
+	return c.rerankHandler(ctx, req)
 }
 
 func (c *Client) Classify(ctx context.Context, opts ...classify.Option) (*classify.Result, error) {
 	if c.classifyClient == nil {
 		return nil, fmt.Errorf("classify client not configured")
 	}
-	return c.classifyClient.Classify(ctx, opts...)
+	req := classify.BuildRequest(opts...)
+	if err := classify.ResolveProvider(req); err != nil {
+		return nil, err
+	}
+	return c.classifyHandler(ctx, req)
 }
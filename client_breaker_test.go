@@ -0,0 +1,92 @@
+package uniai
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/quailyquaily/uniai/chat"
+)
+
+func TestClientCircuitBreakerFailsFast(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = io.WriteString(w, `{"type":"error","error":{"type":"api_error","message":"boom"}}`)
+	}))
+	defer server.Close()
+
+	client := New(Config{
+		Provider:         "anthropic",
+		AnthropicAPIKey:  "test-key",
+		AnthropicAPIBase: server.URL,
+		AnthropicModel:   "claude-sonnet-test",
+		CircuitBreaker:   CircuitBreakerConfig{FailureThreshold: 2, OpenTimeout: time.Minute},
+	})
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.Chat(context.Background(), chat.WithMessages(chat.User("hello"))); !errors.Is(err, ErrServerError) {
+			t.Fatalf("call %d: expected server error, got %v", i, err)
+		}
+	}
+	_, err := client.Chat(context.Background(), chat.WithMessages(chat.User("hello")))
+	var openErr *CircuitBreakerError
+	if !errors.Is(err, ErrCircuitOpen) || !errors.As(err, &openErr) || openErr.Provider != "anthropic" {
+		t.Fatalf("expected open circuit error, got %v", err)
+	}
+	if calls.Load() != 2 {
+		t.Fatalf("expected the open breaker to skip the request, got %d calls", calls.Load())
+	}
+
+	health := client.ProviderHealth()
+	if len(health) != 1 || health[0].Provider != "anthropic" || health[0].State != CircuitOpen || health[0].ConsecutiveFailures != 2 {
+		t.Fatalf("unexpected health: %#v", health)
+	}
+}
+
+func TestClientCircuitBreakerFallsBack(t *testing.T) {
+	var primaryCalls atomic.Int32
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		primaryCalls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer primary.Close()
+	secondary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"id":"1","object":"chat.completion","model":"gpt-test","choices":[{"index":0,"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}]}`)
+	}))
+	defer secondary.Close()
+
+	client := New(Config{
+		Provider:         "anthropic",
+		AnthropicAPIKey:  "test-key",
+		AnthropicAPIBase: primary.URL,
+		AnthropicModel:   "claude-sonnet-test",
+		OpenAIAPIKey:     "test-key",
+		OpenAIAPIBase:    secondary.URL + "/v1",
+		CircuitBreaker:   CircuitBreakerConfig{FailureThreshold: 1, OpenTimeout: time.Minute},
+	})
+	opts := []chat.Option{
+		chat.WithMessages(chat.User("hello")),
+		chat.WithFallbacks(chat.Fallback{Provider: "openai", Model: "gpt-test"}),
+	}
+	for i := 0; i < 2; i++ {
+		resp, err := client.Chat(context.Background(), opts...)
+		if err != nil || resp.Text != "ok" {
+			t.Fatalf("call %d: expected fallback result, got %#v, %v", i, resp, err)
+		}
+		if i == 1 && !errors.Is(resp.Attempts[0].Err, ErrCircuitOpen) {
+			t.Fatalf("expected the primary attempt to fail fast, got %#v", resp.Attempts[0])
+		}
+	}
+	if primaryCalls.Load() != 1 {
+		t.Fatalf("expected one request to the primary, got %d", primaryCalls.Load())
+	}
+}
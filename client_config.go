@@ -19,7 +19,11 @@ type ClientConfigView struct {
 // GetConfig returns a non-sensitive client configuration snapshot.
 // It intentionally excludes secrets such as API keys.
 func (c *Client) GetConfig() ClientConfigView {
-	provider := c.cfg.Provider
+	return c.configView(c.cfg.Provider)
+}
+
+// configView resolves the model and API base used for provider.
+func (c *Client) configView(provider string) ClientConfigView {
 	if provider == "" {
 		provider = "openai"
 	}
@@ -0,0 +1,66 @@
+package uniai
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/quailyquaily/uniai/chat"
+	"github.com/quailyquaily/uniai/embedding"
+)
+
+type countingTransport struct {
+	calls atomic.Int32
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.calls.Add(1)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestClientUsesConfiguredTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/messages":
+			_, _ = io.WriteString(w, `{"content":[{"type":"text","text":"ok"}],"model":"claude-sonnet-test","usage":{"input_tokens":1,"output_tokens":1}}`)
+		case "/v1/chat/completions":
+			_, _ = io.WriteString(w, `{"id":"chatcmpl-1","object":"chat.completion","created":1,"model":"gpt-4.1-mini","choices":[{"index":0,"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}],"usage":{"prompt_tokens":1,"completion_tokens":1,"total_tokens":2}}`)
+		case "/v1/embeddings":
+			_, _ = io.WriteString(w, `{"object":"list","data":[{"object":"embedding","index":0,"embedding":"AAAA"}],"model":"jina-embeddings-v3","usage":{"total_tokens":2,"prompt_tokens":2}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	transport := &countingTransport{}
+	client := New(Config{
+		Transport:        transport,
+		AnthropicAPIKey:  "test-key",
+		AnthropicAPIBase: server.URL,
+		AnthropicModel:   "claude-sonnet-test",
+		OpenAIAPIKey:     "test-key",
+		OpenAIAPIBase:    server.URL + "/v1",
+		OpenAIModel:      "gpt-4.1-mini",
+		JinaAPIKey:       "test-key",
+		JinaAPIBase:      server.URL,
+	})
+
+	ctx := context.Background()
+	if _, err := client.Chat(ctx, chat.WithProvider("anthropic"), chat.WithMessages(chat.User("hello"))); err != nil {
+		t.Fatalf("anthropic chat: %v", err)
+	}
+	if _, err := client.Chat(ctx, chat.WithProvider("openai"), chat.WithMessages(chat.User("hello"))); err != nil {
+		t.Fatalf("openai chat: %v", err)
+	}
+	if _, err := client.Embedding(ctx, embedding.Embedding("jina-embeddings-v3", "hello")); err != nil {
+		t.Fatalf("embedding: %v", err)
+	}
+	if got := transport.calls.Load(); got != 3 {
+		t.Fatalf("expected 3 requests through the configured transport, got %d", got)
+	}
+}
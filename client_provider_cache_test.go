@@ -0,0 +1,117 @@
+package uniai
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/quailyquaily/uniai/chat"
+)
+
+func TestClientReusesChatProviders(t *testing.T) {
+	client := New(Config{
+		OpenAIAPIKey:    "test-key",
+		AnthropicAPIKey: "test-key",
+	})
+
+	const workers = 8
+	got := make([]chatBackend, workers)
+	var wg sync.WaitGroup
+	for i := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p, err := client.chatBackendFor("openai")
+			if err != nil {
+				t.Errorf("chatBackendFor: %v", err)
+				return
+			}
+			got[i] = p
+		}()
+	}
+	wg.Wait()
+	for i := 1; i < workers; i++ {
+		if got[i] != got[0] {
+			t.Fatalf("expected a single cached openai provider")
+		}
+	}
+
+	anthropicProvider, err := client.chatBackendFor("anthropic")
+	if err != nil {
+		t.Fatalf("chatBackendFor anthropic: %v", err)
+	}
+	if anthropicProvider == got[0] {
+		t.Fatalf("expected distinct providers per provider name")
+	}
+	codex, err := client.chatBackendFor("openai_codex")
+	if err != nil {
+		t.Fatalf("chatBackendFor openai_codex: %v", err)
+	}
+	resp, err := client.chatBackendFor("openai_resp")
+	if err != nil {
+		t.Fatalf("chatBackendFor openai_resp: %v", err)
+	}
+	if codex == resp {
+		t.Fatalf("expected openai_codex and openai_resp to use different configs")
+	}
+}
+
+func TestClientDoesNotCacheProviderErrors(t *testing.T) {
+	client := New(Config{})
+	if _, err := client.chatBackendFor("openai"); err == nil {
+		t.Fatalf("expected missing api key error")
+	}
+	if _, ok := client.providers["openai"]; ok {
+		t.Fatalf("expected failed provider construction not to be cached")
+	}
+}
+
+// BenchmarkClientChat reports allocations per Chat call with cached providers
+// and, for comparison, when every call builds a fresh provider.
+func BenchmarkClientChat(b *testing.B) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"id":"chatcmpl-1","object":"chat.completion","created":1,"model":"gpt-4.1-mini","choices":[{"index":0,"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}],"usage":{"prompt_tokens":1,"completion_tokens":1,"total_tokens":2}}`)
+	}))
+	defer server.Close()
+
+	cfg := Config{
+		Provider:      "openai",
+		OpenAIAPIKey:  "test-key",
+		OpenAIAPIBase: server.URL + "/v1",
+		OpenAIModel:   "gpt-4.1-mini",
+	}
+	ctx := context.Background()
+	opts := []chat.Option{chat.WithMessages(chat.User("hello"))}
+
+	b.Run("cached", func(b *testing.B) {
+		client := New(cfg)
+		b.ReportAllocs()
+		for b.Loop() {
+			if _, err := client.Chat(ctx, opts...); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("uncached", func(b *testing.B) {
+		client := New(cfg)
+		req, err := chat.BuildRequest(opts...)
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.ReportAllocs()
+		for b.Loop() {
+			p, err := client.newChatBackend("openai")
+			if err != nil {
+				b.Fatal(err)
+			}
+			if _, err := p.Chat(ctx, req); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,54 @@
+package uniai
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/quailyquaily/uniai/chat"
+)
+
+func TestClientChatRateLimiterFailFast(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"content":[{"type":"text","text":"ok"}],"model":"claude-sonnet-test","usage":{"input_tokens":3,"output_tokens":2}}`)
+	}))
+	defer server.Close()
+
+	limiter := NewRateLimiter(RateLimitConfig{
+		Limits: map[string]RateLimit{
+			"anthropic/claude-sonnet-test": {RequestsPerMinute: 100, TokensPerMinute: 1000},
+		},
+		FailFast: true,
+	})
+	client := New(Config{
+		Provider:         "anthropic",
+		AnthropicAPIKey:  "test-key",
+		AnthropicAPIBase: server.URL,
+		AnthropicModel:   "claude-sonnet-test",
+		RateLimiter:      limiter,
+	})
+
+	// The estimate includes MaxTokens; the reported usage (5 tokens) gives
+	// the rest back, so a second call with the same estimate still fits.
+	for i := 0; i < 2; i++ {
+		if _, err := client.Chat(context.Background(), chat.WithMessages(chat.User("hello")), chat.WithMaxTokens(900)); err != nil {
+			t.Fatalf("chat %d: %v", i, err)
+		}
+	}
+
+	_, err := client.Chat(context.Background(), chat.WithMessages(chat.User("hello")), chat.WithMaxTokens(999), chat.WithPriority(chat.PriorityInteractive))
+	var limitErr *RateLimitError
+	if !errors.Is(err, ErrClientRateLimited) || !errors.As(err, &limitErr) || limitErr.Key != "anthropic/claude-sonnet-test" {
+		t.Fatalf("expected client rate limit error, got %v", err)
+	}
+	if calls.Load() != 2 {
+		t.Fatalf("expected the limited call not to reach the provider, got %d requests", calls.Load())
+	}
+}
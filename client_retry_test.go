@@ -0,0 +1,200 @@
+package uniai
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/quailyquaily/uniai/chat"
+	"github.com/quailyquaily/uniai/embedding"
+)
+
+func TestClientChatRetriesAndReportsAttempts(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if calls.Add(1) == 1 {
+			w.Header().Set("retry-after-ms", "1")
+			w.WriteHeader(529)
+			_, _ = io.WriteString(w, `{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"content":[{"type":"text","text":"ok"}],"model":"claude-sonnet-test","usage":{"input_tokens":1,"output_tokens":1}}`)
+	}))
+	defer server.Close()
+
+	client := New(Config{
+		Provider:         "anthropic",
+		AnthropicAPIKey:  "test-key",
+		AnthropicAPIBase: server.URL,
+		AnthropicModel:   "claude-sonnet-test",
+		Retry:            RetryPolicy{MaxAttempts: 3, BaseBackoff: time.Millisecond},
+	})
+
+	resp, err := client.Chat(context.Background(), chat.WithMessages(chat.User("hello")))
+	if err != nil {
+		t.Fatalf("chat: %v", err)
+	}
+	if calls.Load() != 2 {
+		t.Fatalf("expected 2 requests, got %d", calls.Load())
+	}
+	if len(resp.Attempts) != 2 {
+		t.Fatalf("expected 2 attempts, got %#v", resp.Attempts)
+	}
+	if !errors.Is(resp.Attempts[0].Err, ErrServerError) || resp.Attempts[0].Error == "" {
+		t.Fatalf("unexpected first attempt: %#v", resp.Attempts[0])
+	}
+	if resp.Attempts[1].Err != nil || resp.Attempts[1].Provider != "anthropic" || resp.Attempts[1].Model != "claude-sonnet-test" {
+		t.Fatalf("unexpected final attempt: %#v", resp.Attempts[1])
+	}
+}
+
+func TestClientChatDoesNotRetryWithoutPolicy(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = io.WriteString(w, `{"type":"error","error":{"type":"api_error","message":"boom"}}`)
+	}))
+	defer server.Close()
+
+	client := New(Config{
+		Provider:         "anthropic",
+		AnthropicAPIKey:  "test-key",
+		AnthropicAPIBase: server.URL,
+		AnthropicModel:   "claude-sonnet-test",
+	})
+
+	_, err := client.Chat(context.Background(), chat.WithMessages(chat.User("hello")))
+	if !errors.Is(err, ErrServerError) || calls.Load() != 1 {
+		t.Fatalf("expected a single failed request, got %d: %v", calls.Load(), err)
+	}
+}
+
+func TestClientChatReplacesSDKRetries(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = io.WriteString(w, `{"error":{"message":"boom","type":"server_error"}}`)
+	}))
+	defer server.Close()
+
+	client := New(Config{
+		Provider:      "openai",
+		OpenAIAPIKey:  "test-key",
+		OpenAIAPIBase: server.URL + "/v1",
+		OpenAIModel:   "gpt-4.1-mini",
+		Retry:         RetryPolicy{MaxAttempts: 2, BaseBackoff: time.Millisecond},
+	})
+
+	_, err := client.Chat(context.Background(), chat.WithMessages(chat.User("hello")))
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("expected api error, got %v", err)
+	}
+	if calls.Load() != 2 {
+		t.Fatalf("expected exactly 2 requests, got %d", calls.Load())
+	}
+}
+
+func TestClientChatStreamRetriesOnlyBeforeFirstEvent(t *testing.T) {
+	cases := []struct {
+		name      string
+		firstBody string
+		wantCalls int32
+		wantErr   bool
+	}{
+		{
+			name:      "error before events",
+			firstBody: "event: error\ndata: {\"type\":\"error\",\"error\":{\"type\":\"overloaded_error\",\"message\":\"Overloaded\"}}\n\n",
+			wantCalls: 2,
+		},
+		{
+			name: "error after delta",
+			firstBody: "event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\"Hel\"}}\n\n" +
+				"event: error\ndata: {\"type\":\"error\",\"error\":{\"type\":\"overloaded_error\",\"message\":\"Overloaded\"}}\n\n",
+			wantCalls: 1,
+			wantErr:   true,
+		},
+	}
+	okBody := "event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\"Hello\"}}\n\n" +
+		"event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n"
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var calls atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/event-stream")
+				if calls.Add(1) == 1 {
+					_, _ = io.WriteString(w, tc.firstBody)
+					return
+				}
+				_, _ = io.WriteString(w, okBody)
+			}))
+			defer server.Close()
+
+			client := New(Config{
+				Provider:         "anthropic",
+				AnthropicAPIKey:  "test-key",
+				AnthropicAPIBase: server.URL,
+				AnthropicModel:   "claude-sonnet-test",
+				Retry:            RetryPolicy{MaxAttempts: 3, BaseBackoff: time.Millisecond},
+			})
+
+			resp, err := client.Chat(context.Background(),
+				chat.WithMessages(chat.User("hello")),
+				chat.WithOnStream(func(chat.StreamEvent) error { return nil }),
+			)
+			if calls.Load() != tc.wantCalls {
+				t.Fatalf("expected %d requests, got %d", tc.wantCalls, calls.Load())
+			}
+			if tc.wantErr {
+				if !errors.Is(err, ErrServerError) {
+					t.Fatalf("expected server error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("chat: %v", err)
+			}
+			if resp.Text != "Hello" || len(resp.Attempts) != 2 {
+				t.Fatalf("unexpected result: text=%q attempts=%#v", resp.Text, resp.Attempts)
+			}
+		})
+	}
+}
+
+func TestClientEmbeddingRetries(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			_, _ = io.WriteString(w, `{"detail":"rate limited"}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"object":"list","data":[{"object":"embedding","index":0,"embedding":"AAAA"}],"model":"jina-embeddings-v3","usage":{"total_tokens":2,"prompt_tokens":2}}`)
+	}))
+	defer server.Close()
+
+	client := New(Config{
+		JinaAPIKey:  "test-key",
+		JinaAPIBase: server.URL,
+		Retry:       RetryPolicy{MaxAttempts: 2, BaseBackoff: time.Millisecond},
+	})
+
+	resp, err := client.Embedding(context.Background(), embedding.Embedding("jina-embeddings-v3", "hello"))
+	if err != nil {
+		t.Fatalf("embedding: %v", err)
+	}
+	if calls.Load() != 2 || len(resp.Data) != 1 {
+		t.Fatalf("unexpected result after %d calls: %#v", calls.Load(), resp)
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	openai "github.com/openai/openai-go/v3"
+	"github.com/quailyquaily/uniai/chat"
+	uniaiopenai "github.com/quailyquaily/uniai/chat/openai"
+)
+
+// streamFlag holds the stream field, which openai.ChatCompletionNewParams
+// does not carry since the SDK selects streaming by method instead.
+type streamFlag struct {
+	Stream bool `json:"stream"`
+}
+
+func (s *server) handleChatCompletions(w http.ResponseWriter, r *http.Request, _ string) {
+	body, ok := readBody(w, r)
+	if !ok {
+		return
+	}
+	var req openai.ChatCompletionNewParams
+	if err := req.UnmarshalJSON(body); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", "", fmt.Sprintf("invalid request body: %v", err))
+		return
+	}
+	var flag streamFlag
+	if err := json.Unmarshal(body, &flag); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", "", fmt.Sprintf("invalid request body: %v", err))
+		return
+	}
+	rt, ok := s.lookup(w, string(req.Model), routeKindChat)
+	if !ok {
+		return
+	}
+
+	// Convert once up front so malformed requests are rejected as caller
+	// errors rather than reported as upstream failures.
+	opts, err := uniaiopenai.ChatOptions(req)
+	if err == nil {
+		_, err = chat.BuildRequest(opts...)
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", "", err.Error())
+		return
+	}
+
+	routing := []chat.Option{chat.WithModel(rt.TargetModel)}
+	if flag.Stream {
+		if rt.Reasoning {
+			routing = append(routing, chat.WithReasoningDetails())
+		}
+		s.streamChat(w, r, rt, req, routing)
+		return
+	}
+	resp, err := rt.upstream.chat.CreateChatCompletion(r.Context(), req, routing...)
+	if err != nil {
+		writeUpstreamError(w, err)
+		return
+	}
+	resp.Model = rt.Model
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// streamChat relays the adapter's chunk stream as Chat Completions SSE.
+// Errors before the first chunk are returned as a regular error response;
+// later errors end the stream with an error object.
+func (s *server) streamChat(w http.ResponseWriter, r *http.Request, rt route, req openai.ChatCompletionNewParams, opts []chat.Option) {
+	stream := rt.upstream.chat.CreateChatCompletionStream(r.Context(), req, opts...)
+	defer stream.Close()
+
+	started := false
+	start := func() {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.WriteHeader(http.StatusOK)
+		started = true
+	}
+	for stream.Next() {
+		if !started {
+			start()
+		}
+		fmt.Fprintf(w, "data: %s\n\n", stream.Current().RawJSON())
+		flush(w)
+	}
+	if err := stream.Err(); err != nil {
+		if !started {
+			writeUpstreamError(w, err)
+			return
+		}
+		_, typ, code := upstreamErrorStatus(err)
+		writeEvent(w, map[string]errorBody{"error": {Message: err.Error(), Type: typ, Code: code}})
+		return
+	}
+	if !started {
+		start()
+	}
+	fmt.Fprint(w, "data: [DONE]\n\n")
+	flush(w)
+}
+
+func writeEvent(w http.ResponseWriter, v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(w, "data: %s\n\n", data)
+	flush(w)
+}
+
+func flush(w http.ResponseWriter) {
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
@@ -0,0 +1,269 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/quailyquaily/uniai"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	routeKindChat      = "chat"
+	routeKindEmbedding = "embedding"
+	routeKindImage     = "image"
+)
+
+type fileConfig struct {
+	Listen      string           `yaml:"listen"`
+	PricingFile string           `yaml:"pricing_file"`
+	Upstreams   []upstreamConfig `yaml:"upstreams"`
+	Routes      []routeConfig    `yaml:"routes"`
+	Keys        []keyConfig      `yaml:"keys"`
+}
+
+// upstreamConfig is one provider account. Each upstream gets its own
+// uniai.Client.
+type upstreamConfig struct {
+	Name      string `yaml:"name"`
+	Provider  string `yaml:"provider"`
+	APIBase   string `yaml:"api_base"`
+	APIKeyRef string `yaml:"api_key_ref"`
+}
+
+// routeConfig maps a model name sent by callers to an upstream model.
+type routeConfig struct {
+	Model       string `yaml:"model"`
+	Upstream    string `yaml:"upstream"`
+	TargetModel string `yaml:"target_model"`
+	Kind        string `yaml:"kind"`
+	// Reasoning requests reasoning details from the upstream and relays them
+	// as reasoning_content deltas in streamed responses. The upstream model
+	// must support reasoning details.
+	Reasoning bool `yaml:"reasoning"`
+}
+
+// keyConfig names a caller API key. The key itself is read from the env
+// variable KeyRef so it never lives in the config file.
+type keyConfig struct {
+	Name   string `yaml:"name"`
+	KeyRef string `yaml:"key_ref"`
+}
+
+func loadConfig(path string) (*fileConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read config %q: %w", path, err)
+	}
+
+	var cfg fileConfig
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("parse config %q: %w", path, err)
+	}
+	if err := cfg.normalize(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+func (cfg *fileConfig) normalize() error {
+	cfg.Listen = strings.TrimSpace(cfg.Listen)
+	if cfg.Listen == "" {
+		cfg.Listen = defaultListen
+	}
+	cfg.PricingFile = strings.TrimSpace(cfg.PricingFile)
+	if len(cfg.Upstreams) == 0 {
+		return fmt.Errorf("config has no upstreams")
+	}
+	if len(cfg.Routes) == 0 {
+		return fmt.Errorf("config has no routes")
+	}
+	if len(cfg.Keys) == 0 {
+		return fmt.Errorf("config has no keys")
+	}
+
+	upstreams := make(map[string]string, len(cfg.Upstreams))
+	for i := range cfg.Upstreams {
+		up := &cfg.Upstreams[i]
+		up.Name = strings.TrimSpace(up.Name)
+		up.Provider = strings.ToLower(strings.TrimSpace(up.Provider))
+		up.APIBase = strings.TrimSpace(up.APIBase)
+		up.APIKeyRef = strings.TrimSpace(up.APIKeyRef)
+		if up.Name == "" {
+			return fmt.Errorf("upstreams[%d].name is required", i)
+		}
+		if _, ok := upstreams[up.Name]; ok {
+			return fmt.Errorf("duplicate upstream name %q", up.Name)
+		}
+		if up.Provider == "" {
+			return fmt.Errorf("upstreams[%d].provider is required", i)
+		}
+		if up.APIKeyRef == "" {
+			return fmt.Errorf("upstreams[%d].api_key_ref is required", i)
+		}
+		upstreams[up.Name] = up.Provider
+	}
+
+	models := make(map[string]struct{}, len(cfg.Routes))
+	for i := range cfg.Routes {
+		route := &cfg.Routes[i]
+		route.Model = strings.TrimSpace(route.Model)
+		route.Upstream = strings.TrimSpace(route.Upstream)
+		route.TargetModel = strings.TrimSpace(route.TargetModel)
+		route.Kind = strings.ToLower(strings.TrimSpace(route.Kind))
+		if route.Model == "" {
+			return fmt.Errorf("routes[%d].model is required", i)
+		}
+		if _, ok := models[route.Model]; ok {
+			return fmt.Errorf("duplicate route model %q", route.Model)
+		}
+		models[route.Model] = struct{}{}
+		if route.TargetModel == "" {
+			route.TargetModel = route.Model
+		}
+		if route.Kind == "" {
+			route.Kind = routeKindChat
+		}
+		provider, ok := upstreams[route.Upstream]
+		if !ok {
+			return fmt.Errorf("routes[%d].upstream %q is not defined", i, route.Upstream)
+		}
+		if !supportsKind(provider, route.Kind) {
+			return fmt.Errorf("routes[%d]: provider %q does not support %s routes", i, provider, route.Kind)
+		}
+		if route.Reasoning && route.Kind != routeKindChat {
+			return fmt.Errorf("routes[%d].reasoning applies to chat routes only", i)
+		}
+	}
+
+	names := make(map[string]struct{}, len(cfg.Keys))
+	for i := range cfg.Keys {
+		key := &cfg.Keys[i]
+		key.Name = strings.TrimSpace(key.Name)
+		key.KeyRef = strings.TrimSpace(key.KeyRef)
+		if key.Name == "" {
+			return fmt.Errorf("keys[%d].name is required", i)
+		}
+		if _, ok := names[key.Name]; ok {
+			return fmt.Errorf("duplicate key name %q", key.Name)
+		}
+		names[key.Name] = struct{}{}
+		if key.KeyRef == "" {
+			return fmt.Errorf("keys[%d].key_ref is required", i)
+		}
+	}
+	return nil
+}
+
+// loadKeys reads the caller keys from the environment and maps each key to
+// its name.
+func loadKeys(keys []keyConfig) (map[string]string, error) {
+	out := make(map[string]string, len(keys))
+	for _, key := range keys {
+		value := strings.TrimSpace(os.Getenv(key.KeyRef))
+		if value == "" {
+			return nil, fmt.Errorf("env %s is empty", key.KeyRef)
+		}
+		if other, ok := out[value]; ok {
+			return nil, fmt.Errorf("keys %q and %q share the same key", other, key.Name)
+		}
+		out[value] = key.Name
+	}
+	return out, nil
+}
+
+func loadPricing(path string) (*uniai.PricingCatalog, error) {
+	if path == "" {
+		return uniai.DefaultPricingCatalog(), nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read pricing %q: %w", path, err)
+	}
+	catalog, err := uniai.ParsePricingYAML(data)
+	if err != nil {
+		return nil, fmt.Errorf("parse pricing %q: %w", path, err)
+	}
+	return catalog, nil
+}
+
+func buildClientConfig(up upstreamConfig, pricing *uniai.PricingCatalog) (uniai.Config, error) {
+	apiKey := strings.TrimSpace(os.Getenv(up.APIKeyRef))
+	if apiKey == "" {
+		return uniai.Config{}, fmt.Errorf("upstream %s: env %s is empty", up.Name, up.APIKeyRef)
+	}
+
+	switch up.Provider {
+	case "gemini":
+		return uniai.Config{
+			Provider:      up.Provider,
+			Pricing:       pricing,
+			GeminiAPIKey:  apiKey,
+			GeminiAPIBase: up.APIBase,
+		}, nil
+	case "anthropic":
+		return uniai.Config{
+			Provider:         up.Provider,
+			Pricing:          pricing,
+			AnthropicAPIKey:  apiKey,
+			AnthropicAPIBase: up.APIBase,
+		}, nil
+	case "jina":
+		return uniai.Config{
+			Pricing:     pricing,
+			JinaAPIKey:  apiKey,
+			JinaAPIBase: up.APIBase,
+		}, nil
+	case "openai", "openai_resp", "openai_codex", "deepseek", "sakana", "xai", "groq", "meta":
+		return uniai.Config{
+			Provider:      up.Provider,
+			Pricing:       pricing,
+			OpenAIAPIKey:  apiKey,
+			OpenAIAPIBase: up.APIBase,
+		}, nil
+	default:
+		return uniai.Config{}, fmt.Errorf("provider %q is not supported by cmd/gateway", up.Provider)
+	}
+}
+
+func supportsKind(provider, kind string) bool {
+	switch kind {
+	case routeKindChat:
+		return provider != "jina"
+	case routeKindEmbedding:
+		return embeddingProvider(provider) != ""
+	case routeKindImage:
+		return imageProvider(provider) != ""
+	default:
+		return false
+	}
+}
+
+// embeddingProvider returns the embedding backend serving an upstream
+// provider. Other OpenAI-compatible vendors can serve embeddings through an
+// "openai" upstream with their api_base.
+func embeddingProvider(provider string) string {
+	switch provider {
+	case "openai", "openai_resp":
+		return "openai"
+	case "gemini", "jina":
+		return provider
+	default:
+		return ""
+	}
+}
+
+func imageProvider(provider string) string {
+	switch provider {
+	case "openai", "openai_resp":
+		return "openai"
+	case "gemini":
+		return provider
+	default:
+		return ""
+	}
+}
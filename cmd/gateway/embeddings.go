@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"net/http"
+
+	"github.com/lyricat/goutils/structs"
+	openai "github.com/openai/openai-go/v3"
+	"github.com/quailyquaily/uniai"
+	"github.com/quailyquaily/uniai/embedding"
+)
+
+type embeddingData struct {
+	Object    string `json:"object"`
+	Index     int    `json:"index"`
+	Embedding any    `json:"embedding"`
+}
+
+type embeddingResponse struct {
+	Object string          `json:"object"`
+	Model  string          `json:"model"`
+	Data   []embeddingData `json:"data"`
+	Usage  struct {
+		PromptTokens int `json:"prompt_tokens"`
+		TotalTokens  int `json:"total_tokens"`
+	} `json:"usage"`
+}
+
+func (s *server) handleEmbeddings(w http.ResponseWriter, r *http.Request, key string) {
+	body, ok := readBody(w, r)
+	if !ok {
+		return
+	}
+	var req openai.EmbeddingNewParams
+	if err := req.UnmarshalJSON(body); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", "", fmt.Sprintf("invalid request body: %v", err))
+		return
+	}
+	rt, ok := s.lookup(w, string(req.Model), routeKindEmbedding)
+	if !ok {
+		return
+	}
+	var inputs []string
+	switch {
+	case req.Input.OfString.Valid():
+		inputs = []string{req.Input.OfString.Value}
+	case len(req.Input.OfArrayOfStrings) > 0:
+		inputs = req.Input.OfArrayOfStrings
+	default:
+		writeError(w, http.StatusBadRequest, "invalid_request_error", "", "input must be a string or an array of strings")
+		return
+	}
+	if req.EncodingFormat != "" && req.EncodingFormat != openai.EmbeddingNewParamsEncodingFormatFloat && req.EncodingFormat != openai.EmbeddingNewParamsEncodingFormatBase64 {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", "", fmt.Sprintf("unsupported encoding_format %q", req.EncodingFormat))
+		return
+	}
+
+	provider := embeddingProvider(rt.upstream.provider)
+	opts := []embedding.Option{
+		embedding.Embedding(rt.TargetModel, inputs...),
+		embedding.WithProvider(provider),
+	}
+	if req.Dimensions.Valid() {
+		opts = append(opts, embedding.WithOptions(embeddingDimensions(provider, req.Dimensions.Value)))
+	}
+	result, err := rt.upstream.client.Embedding(r.Context(), opts...)
+	if err != nil {
+		writeUpstreamError(w, err)
+		return
+	}
+
+	out := embeddingResponse{Object: "list", Model: rt.Model, Data: make([]embeddingData, 0, len(result.Data))}
+	for _, item := range result.Data {
+		var vector any = item.Embedding
+		if req.EncodingFormat != openai.EmbeddingNewParamsEncodingFormatBase64 {
+			floats, err := decodeEmbedding(item.Embedding)
+			if err != nil {
+				writeError(w, http.StatusBadGateway, "upstream_error", "", err.Error())
+				return
+			}
+			vector = floats
+		}
+		out.Data = append(out.Data, embeddingData{Object: "embedding", Index: item.Index, Embedding: vector})
+	}
+	out.Usage.PromptTokens = result.Usage.PromptTokens
+	out.Usage.TotalTokens = result.Usage.TotalTokens
+
+	// Embedding results carry no cost; price them with the chat rules of the
+	// catalog, which bill input tokens only.
+	usage := uniai.Usage{InputTokens: result.Usage.PromptTokens, TotalTokens: result.Usage.TotalTokens}
+	if cost, ok := s.pricing.EstimateChatCostWithInferenceProvider(rt.upstream.provider, rt.TargetModel, usage); ok {
+		usage.Cost = cost
+	}
+	s.usage.record(key, usage)
+	writeJSON(w, http.StatusOK, out)
+}
+
+func embeddingDimensions(provider string, dimensions int64) embedding.Options {
+	switch provider {
+	case "gemini":
+		return embedding.Options{Gemini: structs.JSONMap{"output_dimensionality": dimensions}}
+	case "jina":
+		return embedding.Options{Jina: structs.JSONMap{"dimensions": dimensions}}
+	default:
+		return embedding.Options{OpenAI: structs.JSONMap{"dimensions": dimensions}}
+	}
+}
+
+// decodeEmbedding decodes the little-endian float32 vectors uniai returns,
+// which is also the OpenAI base64 wire format.
+func decodeEmbedding(encoded string) ([]float32, error) {
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("decode embedding: %w", err)
+	}
+	if len(data)%4 != 0 {
+		return nil, fmt.Errorf("decode embedding: %d bytes is not a float32 vector", len(data))
+	}
+	out := make([]float32, len(data)/4)
+	for i := range out {
+		out[i] = math.Float32frombits(binary.LittleEndian.Uint32(data[i*4:]))
+	}
+	return out, nil
+}
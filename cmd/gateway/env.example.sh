@@ -0,0 +1,13 @@
+#!/usr/bin/env bash
+
+# copy: cp cmd/gateway/env.example.sh .env.gateway.sh
+# usage: source .env.gateway.sh
+
+# Upstream provider keys referenced by api_key_ref.
+export OPENAI_API_KEY=""
+export ANTHROPIC_API_KEY=""
+export DEEPSEEK_API_KEY=""
+
+# Caller keys referenced by key_ref. Callers send them as Bearer tokens.
+export GATEWAY_KEY_ALICE=""
+export GATEWAY_KEY_CI=""
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/lyricat/goutils/structs"
+	openai "github.com/openai/openai-go/v3"
+	"github.com/quailyquaily/uniai"
+	"github.com/quailyquaily/uniai/image"
+)
+
+type imageData struct {
+	B64JSON       string `json:"b64_json,omitempty"`
+	URL           string `json:"url,omitempty"`
+	RevisedPrompt string `json:"revised_prompt,omitempty"`
+}
+
+func (s *server) handleImageGenerations(w http.ResponseWriter, r *http.Request, key string) {
+	body, ok := readBody(w, r)
+	if !ok {
+		return
+	}
+	var req openai.ImageGenerateParams
+	if err := req.UnmarshalJSON(body); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", "", fmt.Sprintf("invalid request body: %v", err))
+		return
+	}
+	if req.Prompt == "" {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", "", "prompt is required")
+		return
+	}
+	rt, ok := s.lookup(w, string(req.Model), routeKindImage)
+	if !ok {
+		return
+	}
+
+	provider := imageProvider(rt.upstream.provider)
+	opts := []image.Option{
+		image.Image(rt.TargetModel, req.Prompt),
+		image.WithProvider(provider),
+	}
+	if req.N.Valid() {
+		opts = append(opts, image.WithCount(int(req.N.Value)))
+	}
+	if provider == "openai" {
+		if options := imageOpenAIOptions(req); len(options) > 0 {
+			opts = append(opts, image.WithOptions(image.Options{OpenAI: options}))
+		}
+	}
+	result, err := rt.upstream.client.Image(r.Context(), opts...)
+	if err != nil {
+		writeUpstreamError(w, err)
+		return
+	}
+
+	data := make([]imageData, 0, len(result.Images))
+	for _, asset := range result.Images {
+		item := imageData{RevisedPrompt: asset.RevisedPrompt}
+		switch {
+		case req.ResponseFormat == openai.ImageGenerateParamsResponseFormatURL && asset.URL != "":
+			item.URL = asset.URL
+		case req.ResponseFormat == openai.ImageGenerateParamsResponseFormatURL:
+			// The upstream returned inline data only; hand it back as a data
+			// URL so callers asking for URLs still get something usable.
+			item.URL = "data:" + asset.MIMEType + ";base64," + asset.DataBase64
+		case asset.DataBase64 != "":
+			item.B64JSON = asset.DataBase64
+		default:
+			item.URL = asset.URL
+		}
+		data = append(data, item)
+	}
+	created := int64(result.Created)
+	if created == 0 {
+		created = time.Now().Unix()
+	}
+
+	s.usage.record(key, uniai.Usage{
+		InputTokens:  result.Usage.InputTokens,
+		OutputTokens: result.Usage.OutputTokens,
+		TotalTokens:  result.Usage.TotalTokens,
+		Cost:         result.Usage.Cost,
+	})
+	writeJSON(w, http.StatusOK, map[string]any{"created": created, "data": data})
+}
+
+func imageOpenAIOptions(req openai.ImageGenerateParams) structs.JSONMap {
+	options := structs.NewJSONMap()
+	if req.Size != "" {
+		options["size"] = string(req.Size)
+	}
+	if req.Quality != "" {
+		options["quality"] = string(req.Quality)
+	}
+	if req.Background != "" {
+		options["background"] = string(req.Background)
+	}
+	if req.OutputFormat != "" {
+		options["output_format"] = string(req.OutputFormat)
+	}
+	if req.OutputCompression.Valid() {
+		options["output_compression"] = req.OutputCompression.Value
+	}
+	if req.Moderation != "" {
+		options["moderation"] = string(req.Moderation)
+	}
+	if req.User.Valid() {
+		options["user"] = req.User.Value
+	}
+	return options
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/quailyquaily/uniai"
+	"github.com/quailyquaily/uniai/chat"
+	uniaiopenai "github.com/quailyquaily/uniai/chat/openai"
+)
+
+const maxRequestBytes = 32 << 20
+
+type upstream struct {
+	name     string
+	provider string
+	client   *uniai.Client
+	chat     *uniaiopenai.Client
+}
+
+type route struct {
+	routeConfig
+	upstream *upstream
+}
+
+type server struct {
+	routes  map[string]route
+	order   []string
+	keys    map[string]string
+	pricing *uniai.PricingCatalog
+	usage   *usageLedger
+}
+
+type callerKeyContextKey struct{}
+
+func newServer(cfg *fileConfig) (*server, error) {
+	keys, err := loadKeys(cfg.Keys)
+	if err != nil {
+		return nil, err
+	}
+	pricing, err := loadPricing(cfg.PricingFile)
+	if err != nil {
+		return nil, err
+	}
+	s := &server{
+		routes:  make(map[string]route, len(cfg.Routes)),
+		keys:    keys,
+		pricing: pricing,
+		usage:   newUsageLedger(),
+	}
+
+	upstreams := make(map[string]*upstream, len(cfg.Upstreams))
+	for _, up := range cfg.Upstreams {
+		clientConfig, err := buildClientConfig(up, pricing)
+		if err != nil {
+			return nil, err
+		}
+		// Chat usage is recorded by middleware, after the client has priced
+		// the result, so streamed and non-streamed calls are accounted alike.
+		clientConfig.ChatMiddleware = []func(uniai.ChatHandler) uniai.ChatHandler{s.recordChatUsage}
+		client := uniai.New(clientConfig)
+		upstreams[up.Name] = &upstream{
+			name:     up.Name,
+			provider: up.Provider,
+			client:   client,
+			chat:     uniaiopenai.New(client),
+		}
+	}
+	for _, rc := range cfg.Routes {
+		s.routes[rc.Model] = route{routeConfig: rc, upstream: upstreams[rc.Upstream]}
+		s.order = append(s.order, rc.Model)
+	}
+	return s, nil
+}
+
+func (s *server) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/chat/completions", s.authorized(s.handleChatCompletions))
+	mux.HandleFunc("POST /v1/embeddings", s.authorized(s.handleEmbeddings))
+	mux.HandleFunc("POST /v1/images/generations", s.authorized(s.handleImageGenerations))
+	mux.HandleFunc("GET /v1/models", s.authorized(s.handleModels))
+	mux.HandleFunc("GET /v1/usage", s.authorized(s.handleUsage))
+	return mux
+}
+
+// authorized resolves the caller from a bearer key and stores its name in
+// the request context for usage accounting.
+func (s *server) authorized(next func(http.ResponseWriter, *http.Request, string)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		name, known := s.keys[strings.TrimSpace(token)]
+		if !ok || !known {
+			writeError(w, http.StatusUnauthorized, "invalid_request_error", "invalid_api_key", "invalid API key")
+			return
+		}
+		r = r.WithContext(context.WithValue(r.Context(), callerKeyContextKey{}, name))
+		next(w, r, name)
+	}
+}
+
+func (s *server) recordChatUsage(next uniai.ChatHandler) uniai.ChatHandler {
+	return func(ctx context.Context, req *chat.Request) (*chat.Result, error) {
+		resp, err := next(ctx, req)
+		if key, ok := ctx.Value(callerKeyContextKey{}).(string); ok && resp != nil {
+			s.usage.record(key, resp.Usage)
+		}
+		return resp, err
+	}
+}
+
+// lookup returns the route for model. A route of another kind is reported
+// as a missing model, as OpenAI does for models without the endpoint.
+func (s *server) lookup(w http.ResponseWriter, model, kind string) (route, bool) {
+	rt, ok := s.routes[model]
+	if !ok || rt.Kind != kind {
+		writeError(w, http.StatusNotFound, "invalid_request_error", "model_not_found", fmt.Sprintf("the model %q does not exist", model))
+		return route{}, false
+	}
+	return rt, true
+}
+
+func (s *server) handleModels(w http.ResponseWriter, r *http.Request, _ string) {
+	type model struct {
+		ID      string `json:"id"`
+		Object  string `json:"object"`
+		Created int64  `json:"created"`
+		OwnedBy string `json:"owned_by"`
+	}
+	data := make([]model, 0, len(s.order))
+	for _, name := range s.order {
+		data = append(data, model{ID: name, Object: "model", OwnedBy: s.routes[name].upstream.name})
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"object": "list", "data": data})
+}
+
+func (s *server) handleUsage(w http.ResponseWriter, r *http.Request, key string) {
+	writeJSON(w, http.StatusOK, s.usage.get(key))
+}
+
+func readBody(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestBytes))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", "", fmt.Sprintf("read request body: %v", err))
+		return nil, false
+	}
+	return data, true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+type errorBody struct {
+	Message string `json:"message"`
+	Type    string `json:"type"`
+	Code    string `json:"code,omitempty"`
+}
+
+func writeError(w http.ResponseWriter, status int, typ, code, message string) {
+	writeJSON(w, status, map[string]errorBody{"error": {Message: message, Type: typ, Code: code}})
+}
+
+// writeUpstreamError reports a failed uniai call. Upstream authentication
+// failures are a gateway misconfiguration, not a caller error, so they map
+// to 502 like other upstream failures.
+func writeUpstreamError(w http.ResponseWriter, err error) {
+	status, typ, code := upstreamErrorStatus(err)
+	writeError(w, status, typ, code, err.Error())
+}
+
+func upstreamErrorStatus(err error) (int, string, string) {
+	switch {
+	case errors.Is(err, uniai.ErrContextLengthExceeded):
+		return http.StatusBadRequest, "invalid_request_error", "context_length_exceeded"
+	case errors.Is(err, uniai.ErrContentFiltered):
+		return http.StatusBadRequest, "invalid_request_error", "content_filter"
+	case errors.Is(err, uniai.ErrInvalidRequest):
+		return http.StatusBadRequest, "invalid_request_error", ""
+	case errors.Is(err, uniai.ErrRateLimited):
+		return http.StatusTooManyRequests, "rate_limit_error", "rate_limit_exceeded"
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout, "server_error", "timeout"
+	}
+	var apiErr *uniai.APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode >= 400 && apiErr.StatusCode < 500 && !errors.Is(err, uniai.ErrAuth) {
+		return apiErr.StatusCode, "invalid_request_error", apiErr.Code
+	}
+	return http.StatusBadGateway, "upstream_error", ""
+}
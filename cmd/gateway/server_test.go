@@ -0,0 +1,342 @@
+package main
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGatewayChatCompletions(t *testing.T) {
+	var upstreamModels []string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer upstream-secret" {
+			t.Errorf("unexpected upstream authorization: %q", got)
+		}
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decode upstream request: %v", err)
+		}
+		upstreamModels = append(upstreamModels, fmt.Sprint(body["model"]))
+		if body["stream"] == true {
+			w.Header().Set("Content-Type", "text/event-stream")
+			writeSSE(w, `{"id":"c1","object":"chat.completion.chunk","created":0,"model":"deepseek-chat","choices":[{"index":0,"delta":{"reasoning_content":"think"},"finish_reason":null}]}`)
+			writeSSE(w, `{"id":"c1","object":"chat.completion.chunk","created":0,"model":"deepseek-chat","choices":[{"index":0,"delta":{"content":"Hel"},"finish_reason":null}]}`)
+			writeSSE(w, `{"id":"c1","object":"chat.completion.chunk","created":0,"model":"deepseek-chat","choices":[{"index":0,"delta":{"content":"lo"},"finish_reason":null}]}`)
+			writeSSE(w, `{"id":"c1","object":"chat.completion.chunk","created":0,"model":"deepseek-chat","choices":[{"index":0,"delta":{},"finish_reason":"stop"}]}`)
+			writeSSE(w, `{"id":"c1","object":"chat.completion.chunk","created":0,"model":"deepseek-chat","choices":[],"usage":{"prompt_tokens":10,"completion_tokens":5,"total_tokens":15}}`)
+			fmt.Fprint(w, "data: [DONE]\n\n")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id":"c0","object":"chat.completion","created":0,"model":"deepseek-chat","choices":[{"index":0,"message":{"role":"assistant","content":"Hello"},"finish_reason":"stop"}],"usage":{"prompt_tokens":10,"completion_tokens":5,"total_tokens":15}}`)
+	}))
+	defer upstream.Close()
+	gateway := newTestGateway(t, upstream.URL)
+
+	// Unknown keys are rejected before anything reaches the upstream.
+	resp := postJSON(t, gateway.URL+"/v1/chat/completions", "wrong", `{"model":"fast","messages":[{"role":"user","content":"hi"}]}`)
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", resp.StatusCode)
+	}
+	resp.Body.Close()
+	resp = postJSON(t, gateway.URL+"/v1/chat/completions", "alice-key", `{"model":"missing","messages":[{"role":"user","content":"hi"}]}`)
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unrouted model, got %d", resp.StatusCode)
+	}
+	resp.Body.Close()
+
+	resp = postJSON(t, gateway.URL+"/v1/chat/completions", "alice-key", `{"model":"fast","messages":[{"role":"user","content":"hi"}]}`)
+	var completion struct {
+		Model   string `json:"model"`
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+			FinishReason string `json:"finish_reason"`
+		} `json:"choices"`
+		Usage struct {
+			TotalTokens int `json:"total_tokens"`
+		} `json:"usage"`
+	}
+	decodeResponse(t, resp, http.StatusOK, &completion)
+	if completion.Model != "fast" || len(completion.Choices) != 1 || completion.Choices[0].Message.Content != "Hello" || completion.Choices[0].FinishReason != "stop" || completion.Usage.TotalTokens != 15 {
+		t.Fatalf("unexpected completion: %#v", completion)
+	}
+
+	resp = postJSON(t, gateway.URL+"/v1/chat/completions", "alice-key", `{"model":"fast","stream":true,"stream_options":{"include_usage":true},"messages":[{"role":"user","content":"hi"}]}`)
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("unexpected stream response: %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	events := readSSE(t, resp.Body)
+	resp.Body.Close()
+	want := []string{
+		`{"role":"assistant"}`,
+		`{"reasoning_content":"think"}`,
+		`{"content":"Hel"}`,
+		`{"content":"lo"}`,
+		`{}`,
+	}
+	if len(events) != len(want)+2 || events[len(events)-1] != "[DONE]" {
+		t.Fatalf("unexpected events:\n%s", strings.Join(events, "\n"))
+	}
+	for i, delta := range want {
+		var c struct {
+			Model   string `json:"model"`
+			Object  string `json:"object"`
+			Choices []struct {
+				Delta json.RawMessage `json:"delta"`
+			} `json:"choices"`
+		}
+		if err := json.Unmarshal([]byte(events[i]), &c); err != nil {
+			t.Fatalf("decode chunk %d: %v", i, err)
+		}
+		if c.Model != "fast" || c.Object != "chat.completion.chunk" || len(c.Choices) != 1 || string(c.Choices[0].Delta) != delta {
+			t.Fatalf("chunk %d: got %s, want delta %s", i, events[i], delta)
+		}
+	}
+	if !strings.Contains(events[4], `"finish_reason":"stop"`) {
+		t.Fatalf("expected a finish chunk, got %s", events[4])
+	}
+	if !strings.Contains(events[5], `"choices":[]`) || !strings.Contains(events[5], `"total_tokens":15`) {
+		t.Fatalf("expected a usage chunk, got %s", events[5])
+	}
+
+	if strings.Join(upstreamModels, ",") != "deepseek-chat,deepseek-chat" {
+		t.Fatalf("expected routed upstream models, got %v", upstreamModels)
+	}
+
+	// Both calls are accounted to alice: 2 x (10 input x $1/M + 5 output x $2/M).
+	resp = get(t, gateway.URL+"/v1/usage", "alice-key")
+	var usage keyUsage
+	decodeResponse(t, resp, http.StatusOK, &usage)
+	if usage.Key != "alice" || usage.Requests != 2 || usage.InputTokens != 20 || usage.OutputTokens != 10 || usage.CostUSD != 0.00004 || usage.UnpricedRequests != 0 {
+		t.Fatalf("unexpected usage: %#v", usage)
+	}
+	resp = get(t, gateway.URL+"/v1/usage", "bob-key")
+	decodeResponse(t, resp, http.StatusOK, &usage)
+	if usage.Key != "bob" || usage.Requests != 0 {
+		t.Fatalf("expected no usage for bob, got %#v", usage)
+	}
+}
+
+func TestGatewayEmbeddingsAndModels(t *testing.T) {
+	vector := make([]byte, 8)
+	binary.LittleEndian.PutUint32(vector, math.Float32bits(0.5))
+	binary.LittleEndian.PutUint32(vector[4:], math.Float32bits(-1))
+	encoded := base64.StdEncoding.EncodeToString(vector)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/embeddings" {
+			t.Errorf("unexpected upstream path: %s", r.URL.Path)
+		}
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decode upstream request: %v", err)
+		}
+		if body["model"] != "vendor-embed" || body["dimensions"] != float64(2) {
+			t.Errorf("unexpected upstream request: %#v", body)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"object":"list","model":"vendor-embed","data":[{"object":"embedding","index":0,"embedding":%q}],"usage":{"prompt_tokens":4,"total_tokens":4}}`, encoded)
+	}))
+	defer upstream.Close()
+	gateway := newTestGateway(t, upstream.URL)
+
+	resp := postJSON(t, gateway.URL+"/v1/embeddings", "bob-key", `{"model":"embed","input":"hello","dimensions":2}`)
+	var floats struct {
+		Model string `json:"model"`
+		Data  []struct {
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+		Usage struct {
+			PromptTokens int `json:"prompt_tokens"`
+		} `json:"usage"`
+	}
+	decodeResponse(t, resp, http.StatusOK, &floats)
+	if floats.Model != "embed" || len(floats.Data) != 1 || fmt.Sprint(floats.Data[0].Embedding) != "[0.5 -1]" || floats.Usage.PromptTokens != 4 {
+		t.Fatalf("unexpected embeddings: %#v", floats)
+	}
+
+	resp = postJSON(t, gateway.URL+"/v1/embeddings", "bob-key", `{"model":"embed","input":["hello"],"dimensions":2,"encoding_format":"base64"}`)
+	var raw struct {
+		Data []struct {
+			Embedding string `json:"embedding"`
+		} `json:"data"`
+	}
+	decodeResponse(t, resp, http.StatusOK, &raw)
+	if len(raw.Data) != 1 || raw.Data[0].Embedding != encoded {
+		t.Fatalf("expected the base64 vector, got %#v", raw)
+	}
+
+	// A chat model is not served by the embeddings endpoint.
+	resp = postJSON(t, gateway.URL+"/v1/embeddings", "bob-key", `{"model":"fast","input":"hello"}`)
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", resp.StatusCode)
+	}
+	resp.Body.Close()
+
+	resp = get(t, gateway.URL+"/v1/usage", "bob-key")
+	var usage keyUsage
+	decodeResponse(t, resp, http.StatusOK, &usage)
+	if usage.Requests != 2 || usage.InputTokens != 8 || usage.UnpricedRequests != 2 {
+		t.Fatalf("unexpected usage: %#v", usage)
+	}
+
+	resp = get(t, gateway.URL+"/v1/models", "bob-key")
+	var models struct {
+		Object string `json:"object"`
+		Data   []struct {
+			ID      string `json:"id"`
+			Object  string `json:"object"`
+			OwnedBy string `json:"owned_by"`
+		} `json:"data"`
+	}
+	decodeResponse(t, resp, http.StatusOK, &models)
+	if models.Object != "list" || len(models.Data) != 2 || models.Data[0].ID != "fast" || models.Data[1].ID != "embed" || models.Data[0].OwnedBy != "vendor" {
+		t.Fatalf("unexpected models: %#v", models)
+	}
+}
+
+func TestLoadConfigRejectsBadRoutes(t *testing.T) {
+	for name, routes := range map[string]string{
+		"unknown upstream": "  - model: fast\n    upstream: missing\n",
+		"duplicate model":  "  - model: fast\n    upstream: vendor\n  - model: fast\n    upstream: vendor\n",
+		"unsupported kind": "  - model: fast\n    upstream: vendor\n    kind: audio\n",
+	} {
+		path := writeFile(t, "config.yaml", "upstreams:\n  - name: vendor\n    provider: openai\n    api_key_ref: KEY\nroutes:\n"+routes+"keys:\n  - name: alice\n    key_ref: ALICE\n")
+		if _, err := loadConfig(path); err == nil {
+			t.Fatalf("%s: expected an error", name)
+		}
+	}
+}
+
+func newTestGateway(t *testing.T, upstreamURL string) *httptest.Server {
+	t.Helper()
+	t.Setenv("GATEWAY_TEST_UPSTREAM_KEY", "upstream-secret")
+	t.Setenv("GATEWAY_TEST_ALICE_KEY", "alice-key")
+	t.Setenv("GATEWAY_TEST_BOB_KEY", "bob-key")
+	pricingPath := writeFile(t, "pricing.yaml", `
+chat:
+  - inference_provider: openai
+    model: deepseek-chat
+    input_usd_per_million: 1
+    output_usd_per_million: 2
+`)
+	configPath := writeFile(t, "config.yaml", fmt.Sprintf(`
+pricing_file: %s
+upstreams:
+  - name: vendor
+    provider: openai
+    api_base: %s/v1
+    api_key_ref: GATEWAY_TEST_UPSTREAM_KEY
+routes:
+  - model: fast
+    upstream: vendor
+    target_model: deepseek-chat
+    reasoning: true
+  - model: embed
+    upstream: vendor
+    target_model: vendor-embed
+    kind: embedding
+keys:
+  - name: alice
+    key_ref: GATEWAY_TEST_ALICE_KEY
+  - name: bob
+    key_ref: GATEWAY_TEST_BOB_KEY
+`, pricingPath, upstreamURL))
+	cfg, err := loadConfig(configPath)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	srv, err := newServer(cfg)
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	gateway := httptest.NewServer(srv.handler())
+	t.Cleanup(gateway.Close)
+	return gateway
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(strings.TrimLeft(content, "\n")), 0o600); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+	return path
+}
+
+func postJSON(t *testing.T, url, key, body string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("new request: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+key)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("post %s: %v", url, err)
+	}
+	return resp
+}
+
+func get(t *testing.T, url, key string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatalf("new request: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+key)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("get %s: %v", url, err)
+	}
+	return resp
+}
+
+func decodeResponse(t *testing.T, resp *http.Response, status int, v any) {
+	t.Helper()
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("read response: %v", err)
+	}
+	if resp.StatusCode != status {
+		t.Fatalf("expected status %d, got %d: %s", status, resp.StatusCode, data)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		t.Fatalf("decode response %s: %v", data, err)
+	}
+}
+
+func readSSE(t *testing.T, r io.Reader) []string {
+	t.Helper()
+	var events []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if data, ok := strings.CutPrefix(scanner.Text(), "data: "); ok {
+			events = append(events, data)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("read stream: %v", err)
+	}
+	return events
+}
+
+func writeSSE(w http.ResponseWriter, data string) {
+	fmt.Fprintf(w, "data: %s\n\n", data)
+	if flusher, ok := w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
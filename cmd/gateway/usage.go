@@ -0,0 +1,77 @@
+package main
+
+import (
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/quailyquaily/uniai"
+)
+
+// keyUsage is the running total of one caller key.
+type keyUsage struct {
+	Key          string  `json:"key"`
+	Requests     int     `json:"requests"`
+	InputTokens  int     `json:"input_tokens"`
+	OutputTokens int     `json:"output_tokens"`
+	CostUSD      float64 `json:"cost_usd"`
+	// UnpricedRequests counts requests with tokens but no pricing rule, so
+	// CostUSD is a lower bound when it is non-zero.
+	UnpricedRequests int `json:"unpriced_requests"`
+}
+
+// usageLedger accounts usage and cost per caller key. It is safe for
+// concurrent use.
+type usageLedger struct {
+	mu   sync.Mutex
+	keys map[string]*keyUsage
+}
+
+func newUsageLedger() *usageLedger {
+	return &usageLedger{keys: map[string]*keyUsage{}}
+}
+
+func (l *usageLedger) record(key string, usage uniai.Usage) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	entry := l.entry(key)
+	entry.Requests++
+	entry.InputTokens += usage.InputTokens
+	entry.OutputTokens += usage.OutputTokens
+	switch {
+	case usage.Cost != nil:
+		entry.CostUSD = roundUSD(entry.CostUSD + usage.Cost.Total)
+	case usage.Pricable():
+		entry.UnpricedRequests++
+	}
+}
+
+func (l *usageLedger) get(key string) keyUsage {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return *l.entry(key)
+}
+
+func (l *usageLedger) snapshot() []keyUsage {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	out := make([]keyUsage, 0, len(l.keys))
+	for _, entry := range l.keys {
+		out = append(out, *entry)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	return out
+}
+
+func (l *usageLedger) entry(key string) *keyUsage {
+	entry, ok := l.keys[key]
+	if !ok {
+		entry = &keyUsage{Key: key}
+		l.keys[key] = entry
+	}
+	return entry
+}
+
+func roundUSD(v float64) float64 {
+	return math.Round(v*1e12) / 1e12
+}
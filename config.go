@@ -1,6 +1,8 @@
 package uniai
 
 import (
+	"net/http"
+
 	"github.com/quailyquaily/uniai/internal/httputil"
 	"github.com/quailyquaily/uniai/providers/anthropic"
 )
@@ -19,6 +21,52 @@ type Config struct {
 	// Usage.Cost derivation.
 	Pricing *PricingCatalog
 
+	// Models overrides the model catalog used to check chat requests against
+	// context windows and output limits. When nil, uniai uses the embedded
+	// default catalog. Use an empty catalog to disable the checks.
+	Models *ModelCatalog
+	// MaxTokensPolicy selects whether WithMaxTokens values above a model's
+	// output limit are clamped (the default) or rejected.
+	MaxTokensPolicy MaxTokensPolicy
+
+	// Retry configures automatic retries for chat, embedding, image, rerank,
+	// classify and audio calls. The zero value makes a single attempt.
+	Retry RetryPolicy
+
+	// RateLimiter applies client-side RPM/TPM budgets to chat requests,
+	// including every retry and fallback attempt. Nil disables limiting.
+	RateLimiter *RateLimiter
+
+	// CircuitBreaker stops sending chat requests to a provider after
+	// consecutive server or timeout errors. The zero value disables it.
+	CircuitBreaker CircuitBreakerConfig
+
+	// HTTPClient is used for every provider request (chat, embedding, image,
+	// rerank, classify and audio). When nil, uniai uses a shared client with a
+	// 120s timeout, and the OpenAI SDK its own default client.
+	HTTPClient *http.Client
+	// Transport is a convenience for setting only the round tripper: when
+	// HTTPClient is nil, uniai wraps Transport in a client with a 120s timeout.
+	Transport http.RoundTripper
+
+	// ChatMiddleware wraps every Chat call. The first entry is the outermost
+	// handler. Handlers see the request after provider defaulting and the
+	// final result, including fallbacks, retries and tool emulation.
+	ChatMiddleware []func(next ChatHandler) ChatHandler
+	// ChatCallMiddleware wraps each upstream chat request made by a Chat
+	// call: one per fallback target and per tool emulation round. Retries of
+	// the same request happen inside the handler.
+	ChatCallMiddleware []func(next ChatHandler) ChatHandler
+	// EmbeddingMiddleware, ImageMiddleware, ImageEditMiddleware,
+	// RerankMiddleware, ClassifyMiddleware and AudioMiddleware wrap the
+	// corresponding calls in the same way.
+	EmbeddingMiddleware []func(next EmbeddingHandler) EmbeddingHandler
+	ImageMiddleware     []func(next ImageHandler) ImageHandler
+	ImageEditMiddleware []func(next ImageEditHandler) ImageEditHandler
+	RerankMiddleware    []func(next RerankHandler) RerankHandler
+	ClassifyMiddleware  []func(next ClassifyHandler) ClassifyHandler
+	AudioMiddleware     []func(next AudioHandler) AudioHandler
+
 	// OpenAI / OpenAI-compatible
 	OpenAIAPIKey  string
 	OpenAIAPIBase string
@@ -74,6 +122,13 @@ func (cfg Config) withDefaults() Config {
 	} else {
 		cfg.Pricing = cfg.Pricing.Clone()
 	}
+	if cfg.Models == nil {
+		cfg.Models = DefaultModelCatalog()
+	} else {
+		cfg.Models = cfg.Models.Clone()
+	}
+	cfg.Retry.RetryOn = append([]error(nil), cfg.Retry.RetryOn...)
+	cfg.HTTPClient = httputil.NewClient(cfg.HTTPClient, cfg.Transport)
 	if cfg.OpenAIAPIBase == "" {
 		cfg.OpenAIAPIBase = DefaultOpenAIAPIBase
 	}
@@ -0,0 +1,83 @@
+package uniai
+
+import (
+	"context"
+
+	"github.com/quailyquaily/uniai/chat"
+	"github.com/quailyquaily/uniai/internal/tokenest"
+)
+
+// TokenCount is the input size of a chat request before it is sent.
+type TokenCount struct {
+	InputTokens int    `json:"input_tokens"`
+	Provider    string `json:"provider"`
+	Model       string `json:"model,omitempty"`
+	// Estimated is true when the count comes from the local estimator rather
+	// than a provider counting endpoint.
+	Estimated bool `json:"estimated"`
+}
+
+// nativeTokenCountProviders have a counting endpoint: Anthropic
+// /messages/count_tokens, Gemini countTokens and OpenAI
+// responses/input_tokens. Other providers, including openai_codex and
+// sakana, which share the Responses backend but not that endpoint, are
+// estimated locally.
+var nativeTokenCountProviders = map[string]bool{
+	"anthropic":   true,
+	"gemini":      true,
+	"openai_resp": true,
+}
+
+// tokenCounter is implemented by providers with a counting endpoint.
+type tokenCounter interface {
+	CountTokens(ctx context.Context, req *chat.Request) (int, error)
+}
+
+// tokenEstimator is implemented by providers that estimate the input tokens
+// of the request body they would send.
+type tokenEstimator interface {
+	EstimateTokens(req *chat.Request) (int, error)
+}
+
+// CountTokens returns the input token count of the chat request built from
+// opts, including the system prompt, images and tool definitions as the
+// provider request builder lays them out. Providers with a counting endpoint
+// are asked directly; the count for other providers is a local estimate. The
+// request bypasses middleware, retries and rate limiting.
+func (c *Client) CountTokens(ctx context.Context, opts ...chat.Option) (*TokenCount, error) {
+	req, err := chat.BuildRequest(opts...)
+	if err != nil {
+		return nil, err
+	}
+	providerName := c.resolveChatProvider(req.Provider)
+	req.Provider = providerName
+	backend, err := c.chatBackendFor(providerName)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &TokenCount{
+		Provider: providerName,
+		Model:    c.resolveChatRequestedModel(providerName, req),
+	}
+	if counter, ok := backend.(tokenCounter); ok && nativeTokenCountProviders[providerName] {
+		n, err := counter.CountTokens(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		out.InputTokens = n
+		return out, nil
+	}
+
+	out.Estimated = true
+	if estimator, ok := backend.(tokenEstimator); ok {
+		n, err := estimator.EstimateTokens(req)
+		if err != nil {
+			return nil, err
+		}
+		out.InputTokens = n
+		return out, nil
+	}
+	out.InputTokens = tokenest.Request(req)
+	return out, nil
+}
@@ -0,0 +1,104 @@
+package uniai
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/quailyquaily/uniai/chat"
+)
+
+func TestCountTokensUsesNativeEndpoints(t *testing.T) {
+	bodies := map[string]map[string]any{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		var body map[string]any
+		if err := json.Unmarshal(data, &body); err != nil {
+			t.Errorf("decode %s: %v", r.URL.Path, err)
+		}
+		bodies[r.URL.Path] = body
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/messages/count_tokens":
+			_, _ = io.WriteString(w, `{"input_tokens":11}`)
+		case "/v1beta/models/gemini-test:countTokens":
+			_, _ = io.WriteString(w, `{"totalTokens":22}`)
+		case "/v1/responses/input_tokens":
+			_, _ = io.WriteString(w, `{"object":"response.input_tokens","input_tokens":33}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := New(Config{
+		AnthropicAPIKey:  "test-key",
+		AnthropicAPIBase: server.URL,
+		AnthropicModel:   "claude-test",
+		GeminiAPIKey:     "test-key",
+		GeminiAPIBase:    server.URL,
+		GeminiModel:      "gemini-test",
+		OpenAIAPIKey:     "test-key",
+		OpenAIAPIBase:    server.URL + "/v1",
+		OpenAIModel:      "gpt-test",
+	})
+	opts := []chat.Option{
+		chat.WithMessages(chat.System("Be brief."), chat.User("What is the weather?")),
+		chat.WithTools([]chat.Tool{chat.FunctionTool("weather", "Look up the weather", []byte(`{"type":"object","properties":{"city":{"type":"string"}}}`))}),
+		chat.WithMaxTokens(100),
+		chat.WithTemperature(0.5),
+	}
+	ctx := context.Background()
+	for provider, want := range map[string]int{"anthropic": 11, "gemini": 22, "openai_resp": 33} {
+		count, err := client.CountTokens(ctx, append([]chat.Option{chat.WithProvider(provider)}, opts...)...)
+		if err != nil {
+			t.Fatalf("%s: %v", provider, err)
+		}
+		if count.InputTokens != want || count.Estimated || count.Provider != provider {
+			t.Fatalf("%s: unexpected count %#v", provider, count)
+		}
+	}
+
+	anthropicBody := bodies["/messages/count_tokens"]
+	if anthropicBody["system"] != "Be brief." || anthropicBody["tools"] == nil || anthropicBody["max_tokens"] != nil || anthropicBody["temperature"] != nil {
+		t.Fatalf("unexpected anthropic body: %v", anthropicBody)
+	}
+	geminiBody, _ := bodies["/v1beta/models/gemini-test:countTokens"]["generateContentRequest"].(map[string]any)
+	if geminiBody["model"] != "models/gemini-test" || geminiBody["systemInstruction"] == nil || geminiBody["tools"] == nil {
+		t.Fatalf("unexpected gemini body: %v", geminiBody)
+	}
+	responsesBody := bodies["/v1/responses/input_tokens"]
+	if responsesBody["model"] != "gpt-test" || responsesBody["tools"] == nil || responsesBody["max_output_tokens"] != nil || responsesBody["temperature"] != nil {
+		t.Fatalf("unexpected responses body: %v", responsesBody)
+	}
+}
+
+func TestCountTokensEstimatesProviderPayload(t *testing.T) {
+	client := New(Config{OpenAIAPIKey: "test-key", OpenAIModel: "gpt-test"})
+	ctx := context.Background()
+	short, err := client.CountTokens(ctx, chat.WithMessages(chat.User("hi")))
+	if err != nil {
+		t.Fatalf("count: %v", err)
+	}
+	if !short.Estimated || short.Provider != "openai" || short.Model != "gpt-test" || short.InputTokens == 0 {
+		t.Fatalf("unexpected count: %#v", short)
+	}
+
+	image := chat.Part{Type: chat.PartTypeImageBase64, MIMEType: "image/png", DataBase64: strings.Repeat("A", 40000)}
+	long, err := client.CountTokens(ctx,
+		chat.WithMessages(chat.System(strings.Repeat("rule ", 100)), chat.UserParts(chat.TextPart("hi"), image)),
+		chat.WithTools([]chat.Tool{chat.FunctionTool("weather", "Look up the weather", []byte(`{"type":"object"}`))}),
+	)
+	if err != nil {
+		t.Fatalf("count: %v", err)
+	}
+	// The system prompt and tool add over 100 tokens; the image counts flat
+	// instead of by its 40k base64 bytes.
+	if extra := long.InputTokens - short.InputTokens; extra < 200 || extra > 400 {
+		t.Fatalf("unexpected estimate: short %d, long %d", short.InputTokens, long.InputTokens)
+	}
+}
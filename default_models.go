@@ -0,0 +1,28 @@
+package uniai
+
+import (
+	_ "embed"
+	"fmt"
+	"sync"
+)
+
+//go:embed models.example.yaml
+var embeddedDefaultModelsYAML []byte
+
+var (
+	defaultModelsOnce    sync.Once
+	defaultModelsCatalog *ModelCatalog
+	defaultModelsErr     error
+)
+
+// DefaultModelCatalog returns a cloned copy of the embedded default model
+// catalog used when Config.Models is nil.
+func DefaultModelCatalog() *ModelCatalog {
+	defaultModelsOnce.Do(func() {
+		defaultModelsCatalog, defaultModelsErr = ParseModelsYAML(embeddedDefaultModelsYAML)
+	})
+	if defaultModelsErr != nil {
+		panic(fmt.Sprintf("uniai: parse embedded default model catalog: %v", defaultModelsErr))
+	}
+	return defaultModelsCatalog.Clone()
+}
@@ -4,12 +4,15 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"strings"
 
+	"github.com/quailyquaily/uniai/internal/httputil"
 	"github.com/quailyquaily/uniai/internal/providers/cloudflare"
 	"github.com/quailyquaily/uniai/internal/providers/gemini"
 	"github.com/quailyquaily/uniai/internal/providers/jina"
 	"github.com/quailyquaily/uniai/internal/providers/openai"
+	"github.com/quailyquaily/uniai/internal/retry"
 )
 
 type Config struct {
@@ -22,6 +25,9 @@ type Config struct {
 	CloudflareAccountID string
 	CloudflareAPIToken  string
 	CloudflareAPIBase   string
+
+	Retry      retry.Policy
+	HTTPClient *http.Client
 }
 
 type Client struct {
@@ -33,14 +39,16 @@ func New(cfg Config) *Client {
 }
 
 func (c *Client) Create(ctx context.Context, opts ...Option) (*Result, error) {
-	req := BuildRequest(opts...)
-	provider := req.Provider
-	if provider == "" {
-		provider = pickProviderByModel(req.Model)
-	}
-	if provider == "" {
-		return nil, fmt.Errorf("provider not set")
+	return c.Do(ctx, BuildRequest(opts...))
+}
+
+// Do runs a built request. An empty Provider is resolved from the model.
+func (c *Client) Do(ctx context.Context, req *Request) (*Result, error) {
+	if err := ResolveProvider(req); err != nil {
+		return nil, err
 	}
+	ctx = httputil.WithClient(ctx, c.cfg.HTTPClient)
+	provider := req.Provider
 
 	var (
 		respData []byte
@@ -48,13 +56,25 @@ func (c *Client) Create(ctx context.Context, opts ...Option) (*Result, error) {
 	)
 	switch provider {
 	case "jina":
-		respData, err = jina.CreateEmbeddings(ctx, c.cfg.JinaAPIKey, c.cfg.JinaAPIBase, req.Model, toJinaInputs(req.Input), req.Options.Jina)
+		_, err = retry.Do(ctx, c.cfg.Retry, func() (err error) {
+			respData, err = jina.CreateEmbeddings(ctx, c.cfg.JinaAPIKey, c.cfg.JinaAPIBase, req.Model, toJinaInputs(req.Input), req.Options.Jina)
+			return err
+		})
 	case "openai":
-		respData, err = openai.CreateEmbeddings(ctx, c.cfg.OpenAIAPIKey, c.cfg.OpenAIAPIBase, req.Model, toTextInputs(req.Input), req.Options.OpenAI)
+		_, err = retry.Do(ctx, c.cfg.Retry, func() (err error) {
+			respData, err = openai.CreateEmbeddings(ctx, c.cfg.OpenAIAPIKey, c.cfg.OpenAIAPIBase, req.Model, toTextInputs(req.Input), req.Options.OpenAI)
+			return err
+		})
 	case "gemini":
-		respData, err = gemini.CreateEmbeddings(ctx, c.cfg.GeminiAPIKey, c.cfg.GeminiAPIBase, req.Model, toTextInputs(req.Input), req.Options.Gemini)
+		_, err = retry.Do(ctx, c.cfg.Retry, func() (err error) {
+			respData, err = gemini.CreateEmbeddings(ctx, c.cfg.GeminiAPIKey, c.cfg.GeminiAPIBase, req.Model, toTextInputs(req.Input), req.Options.Gemini)
+			return err
+		})
 	case "cloudflare":
-		respData, err = cloudflare.CreateEmbeddings(ctx, c.cfg.CloudflareAPIToken, c.cfg.CloudflareAPIBase, c.cfg.CloudflareAccountID, req.Model, toTextInputs(req.Input), req.Options.Cloudflare)
+		_, err = retry.Do(ctx, c.cfg.Retry, func() (err error) {
+			respData, err = cloudflare.CreateEmbeddings(ctx, c.cfg.CloudflareAPIToken, c.cfg.CloudflareAPIBase, c.cfg.CloudflareAccountID, req.Model, toTextInputs(req.Input), req.Options.Cloudflare)
+			return err
+		})
 	default:
 		return nil, fmt.Errorf("unknown provider: %s", provider)
 	}
@@ -69,6 +89,17 @@ func (c *Client) Create(ctx context.Context, opts ...Option) (*Result, error) {
 	return &out, nil
 }
 
+// ResolveProvider sets req.Provider from the model when it is empty.
+func ResolveProvider(req *Request) error {
+	if req.Provider == "" {
+		req.Provider = pickProviderByModel(req.Model)
+	}
+	if req.Provider == "" {
+		return fmt.Errorf("provider not set")
+	}
+	return nil
+}
+
 func pickProviderByModel(model string) string {
 	if strings.Contains(model, "jina") {
 		return "jina"
@@ -0,0 +1,40 @@
+package uniai
+
+import (
+	"errors"
+
+	"github.com/quailyquaily/uniai/internal/apierror"
+	"github.com/quailyquaily/uniai/internal/retry"
+)
+
+// APIError is returned when a provider API rejects a request. Use errors.As
+// to read the status, provider error code, request ID and Retry-After delay.
+type APIError = apierror.Error
+
+// Error categories carried by APIError. Match them with errors.Is.
+var (
+	ErrRateLimited           = apierror.ErrRateLimited
+	ErrContextLengthExceeded = apierror.ErrContextLengthExceeded
+	ErrContentFiltered       = apierror.ErrContentFiltered
+	ErrAuth                  = apierror.ErrAuth
+	ErrInvalidRequest        = apierror.ErrInvalidRequest
+	ErrServerError           = apierror.ErrServerError
+)
+
+// RetryPolicy configures automatic retries with exponential backoff. Only
+// errors in RetryOn (ErrRateLimited and ErrServerError by default) are
+// retried, Retry-After / retry-after-ms delays are honored, and a streaming
+// chat call is never retried once an event has reached the caller. While a
+// policy is enabled, the built-in retries of the OpenAI and AWS SDKs are
+// turned off, so each attempt is a single request.
+type RetryPolicy = retry.Policy
+
+// labelAPIError records the requested provider name on API errors raised by
+// shared provider implementations (e.g. deepseek served by providers/openai).
+func labelAPIError(err error, providerName string) error {
+	var apiErr *APIError
+	if providerName != "" && errors.As(err, &apiErr) {
+		apiErr.Provider = providerName
+	}
+	return err
+}
@@ -37,6 +37,14 @@ type (
 	ReasoningDelta     = chat.ReasoningDelta
 	ReasoningDeltaType = chat.ReasoningDeltaType
 	ToolCallDelta      = chat.ToolCallDelta
+	ChatAttempt        = chat.Attempt
+	ChatFallback       = chat.Fallback
+	Priority           = chat.Priority
+	FinishReason       = chat.FinishReason
+	ResponseFormat     = chat.ResponseFormat
+	Session            = chat.Session
+	SessionDefaults    = chat.SessionDefaults
+	SessionTurn        = chat.SessionTurn
 )
 
 const (
@@ -50,6 +58,12 @@ const (
 	PartTypeText        = chat.PartTypeText
 	PartTypeImageURL    = chat.PartTypeImageURL
 	PartTypeImageBase64 = chat.PartTypeImageBase64
+	PartTypeAudioURL    = chat.PartTypeAudioURL
+	PartTypeAudioBase64 = chat.PartTypeAudioBase64
+
+	PartTypeDocumentURL    = chat.PartTypeDocumentURL
+	PartTypeDocumentBase64 = chat.PartTypeDocumentBase64
+	PartTypeDocumentText   = chat.PartTypeDocumentText
 )
 
 const (
@@ -67,6 +81,22 @@ const (
 	ReasoningDeltaThinking = chat.ReasoningDeltaThinking
 )
 
+const (
+	PriorityBatch       = chat.PriorityBatch
+	PriorityNormal      = chat.PriorityNormal
+	PriorityInteractive = chat.PriorityInteractive
+)
+
+const (
+	FinishReasonStop          = chat.FinishReasonStop
+	FinishReasonLength        = chat.FinishReasonLength
+	FinishReasonToolCalls     = chat.FinishReasonToolCalls
+	FinishReasonContentFilter = chat.FinishReasonContentFilter
+	FinishReasonRefusal       = chat.FinishReasonRefusal
+	FinishReasonPause         = chat.FinishReasonPause
+	FinishReasonOther         = chat.FinishReasonOther
+)
+
 const (
 	ToolsEmulationOff      = chat.ToolsEmulationOff
 	ToolsEmulationFallback = chat.ToolsEmulationFallback
@@ -97,8 +127,16 @@ func WithReasoningDetails() ChatOption           { return chat.WithReasoningDeta
 func WithToolsEmulationMode(mode ToolsEmulationMode) ChatOption {
 	return chat.WithToolsEmulationMode(mode)
 }
-func WithOnStream(fn OnStreamFunc) ChatOption { return chat.WithOnStream(fn) }
-func WithDebugFn(fn DebugFn) ChatOption       { return chat.WithDebugFn(fn) }
+func WithPriority(p Priority) ChatOption                 { return chat.WithPriority(p) }
+func WithFallbacks(fallbacks ...ChatFallback) ChatOption { return chat.WithFallbacks(fallbacks...) }
+func WithFallbackOn(categories ...error) ChatOption      { return chat.WithFallbackOn(categories...) }
+func WithOnStream(fn OnStreamFunc) ChatOption            { return chat.WithOnStream(fn) }
+func WithDebugFn(fn DebugFn) ChatOption                  { return chat.WithDebugFn(fn) }
+func WithJSONSchema(name string, schema []byte, strict bool) ChatOption {
+	return chat.WithJSONSchema(name, schema, strict)
+}
+func WithJSONObject() ChatOption              { return chat.WithJSONObject() }
+func WithJSONRepairAttempts(n int) ChatOption { return chat.WithJSONRepairAttempts(n) }
 func WithOpenAIOptions(opts structs.JSONMap) ChatOption {
 	return chat.WithOpenAIOptions(opts)
 }
@@ -143,6 +181,12 @@ func ToolResult(toolCallID, content string) Message { return chat.ToolResult(too
 func ToolResultValue(toolCallID string, value any) (Message, error) {
 	return chat.ToolResultValue(toolCallID, value)
 }
+
+// NewSession returns a conversation Session with a system prompt and default
+// options. Pass client.Chat to Session.Send.
+func NewSession(system string, defaults ...ChatOption) *Session {
+	return chat.NewSession(system, defaults...)
+}
 func SystemParts(parts ...Part) Message    { return chat.SystemParts(parts...) }
 func UserParts(parts ...Part) Message      { return chat.UserParts(parts...) }
 func AssistantParts(parts ...Part) Message { return chat.AssistantParts(parts...) }
@@ -151,6 +195,15 @@ func ImageURLPart(url string) Part         { return chat.ImageURLPart(url) }
 func ImageBase64Part(mimeType, dataBase64 string) Part {
 	return chat.ImageBase64Part(mimeType, dataBase64)
 }
+func AudioURLPart(mimeType, url string) Part { return chat.AudioURLPart(mimeType, url) }
+func AudioBase64Part(mimeType, dataBase64 string) Part {
+	return chat.AudioBase64Part(mimeType, dataBase64)
+}
+func DocumentURLPart(url string) Part { return chat.DocumentURLPart(url) }
+func DocumentBase64Part(mimeType, dataBase64 string) Part {
+	return chat.DocumentBase64Part(mimeType, dataBase64)
+}
+func DocumentTextPart(title, text string) Part { return chat.DocumentTextPart(title, text) }
 func WithPartCacheControl(part Part, ctrl CacheControl) Part {
 	return chat.WithPartCacheControl(part, ctrl)
 }
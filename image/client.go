@@ -4,11 +4,14 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"strings"
 
+	"github.com/quailyquaily/uniai/internal/httputil"
 	"github.com/quailyquaily/uniai/internal/providers/cloudflare"
 	"github.com/quailyquaily/uniai/internal/providers/gemini"
 	"github.com/quailyquaily/uniai/internal/providers/openai"
+	"github.com/quailyquaily/uniai/internal/retry"
 )
 
 type Config struct {
@@ -18,6 +21,9 @@ type Config struct {
 	CloudflareAccountID string
 	CloudflareAPIToken  string
 	CloudflareAPIBase   string
+
+	Retry      retry.Policy
+	HTTPClient *http.Client
 }
 
 type Client struct {
@@ -29,14 +35,16 @@ func New(cfg Config) *Client {
 }
 
 func (c *Client) Create(ctx context.Context, opts ...Option) (*Result, error) {
-	req := BuildRequest(opts...)
-	provider := req.Provider
-	if provider == "" {
-		provider = pickProviderByModel(req.Model)
-	}
-	if provider == "" {
-		return nil, fmt.Errorf("provider not set")
+	return c.Do(ctx, BuildRequest(opts...))
+}
+
+// Do runs a built request. An empty Provider is resolved from the model.
+func (c *Client) Do(ctx context.Context, req *Request) (*Result, error) {
+	if err := ResolveProvider(req); err != nil {
+		return nil, err
 	}
+	ctx = httputil.WithClient(ctx, c.cfg.HTTPClient)
+	provider := req.Provider
 
 	var (
 		respData []byte
@@ -45,11 +53,20 @@ func (c *Client) Create(ctx context.Context, opts ...Option) (*Result, error) {
 	)
 	switch provider {
 	case "openai":
-		respData, rawData, err = openai.CreateImages(ctx, c.cfg.OpenAIAPIKey, c.cfg.OpenAIAPIBase, req.Model, req.Prompt, req.Count, req.Options.OpenAI)
+		_, err = retry.Do(ctx, c.cfg.Retry, func() (err error) {
+			respData, rawData, err = openai.CreateImages(ctx, c.cfg.OpenAIAPIKey, c.cfg.OpenAIAPIBase, req.Model, req.Prompt, req.Count, req.Options.OpenAI)
+			return err
+		})
 	case "gemini":
-		respData, rawData, err = gemini.CreateImages(ctx, c.cfg.GeminiAPIKey, req.Model, req.Prompt, req.Count, req.Options.Gemini)
+		_, err = retry.Do(ctx, c.cfg.Retry, func() (err error) {
+			respData, rawData, err = gemini.CreateImages(ctx, c.cfg.GeminiAPIKey, req.Model, req.Prompt, req.Count, req.Options.Gemini)
+			return err
+		})
 	case "cloudflare":
-		respData, rawData, err = cloudflare.CreateImages(ctx, c.cfg.CloudflareAPIToken, c.cfg.CloudflareAPIBase, c.cfg.CloudflareAccountID, req.Model, req.Prompt, req.Count, req.Options.Cloudflare)
+		_, err = retry.Do(ctx, c.cfg.Retry, func() (err error) {
+			respData, rawData, err = cloudflare.CreateImages(ctx, c.cfg.CloudflareAPIToken, c.cfg.CloudflareAPIBase, c.cfg.CloudflareAccountID, req.Model, req.Prompt, req.Count, req.Options.Cloudflare)
+			return err
+		})
 	default:
 		return nil, fmt.Errorf("unknown provider: %s", provider)
 	}
@@ -69,14 +86,16 @@ func (c *Client) Create(ctx context.Context, opts ...Option) (*Result, error) {
 }
 
 func (c *Client) Edit(ctx context.Context, opts ...ImageEditOption) (*Result, error) {
-	req := BuildEditRequest(opts...)
-	provider := req.Provider
-	if provider == "" {
-		provider = pickProviderByModel(req.Model)
-	}
-	if provider == "" {
-		return nil, fmt.Errorf("provider not set")
+	return c.DoEdit(ctx, BuildEditRequest(opts...))
+}
+
+// DoEdit runs a built request. An empty Provider is resolved from the model.
+func (c *Client) DoEdit(ctx context.Context, req *EditRequest) (*Result, error) {
+	if err := ResolveEditProvider(req); err != nil {
+		return nil, err
 	}
+	ctx = httputil.WithClient(ctx, c.cfg.HTTPClient)
+	provider := req.Provider
 
 	var (
 		respData []byte
@@ -85,9 +104,15 @@ func (c *Client) Edit(ctx context.Context, opts ...ImageEditOption) (*Result, er
 	)
 	switch provider {
 	case "openai":
-		respData, rawData, err = openai.EditImages(ctx, c.cfg.OpenAIAPIKey, c.cfg.OpenAIAPIBase, req.Model, req.Prompt, toOpenAIInputImages(req.Images), req.Count, req.Options.OpenAI)
+		_, err = retry.Do(ctx, c.cfg.Retry, func() (err error) {
+			respData, rawData, err = openai.EditImages(ctx, c.cfg.OpenAIAPIKey, c.cfg.OpenAIAPIBase, req.Model, req.Prompt, toOpenAIInputImages(req.Images), req.Count, req.Options.OpenAI)
+			return err
+		})
 	case "gemini":
-		respData, rawData, err = gemini.EditImages(ctx, c.cfg.GeminiAPIKey, req.Model, req.Prompt, toGeminiInputImages(req.Images), req.Count, req.Options.Gemini)
+		_, err = retry.Do(ctx, c.cfg.Retry, func() (err error) {
+			respData, rawData, err = gemini.EditImages(ctx, c.cfg.GeminiAPIKey, req.Model, req.Prompt, toGeminiInputImages(req.Images), req.Count, req.Options.Gemini)
+			return err
+		})
 	case "cloudflare":
 		return nil, fmt.Errorf("cloudflare image edit is not supported")
 	default:
@@ -108,6 +133,28 @@ func (c *Client) Edit(ctx context.Context, opts ...ImageEditOption) (*Result, er
 	return &out, nil
 }
 
+// ResolveProvider sets req.Provider from the model when it is empty.
+func ResolveProvider(req *Request) error {
+	if req.Provider == "" {
+		req.Provider = pickProviderByModel(req.Model)
+	}
+	if req.Provider == "" {
+		return fmt.Errorf("provider not set")
+	}
+	return nil
+}
+
+// ResolveEditProvider sets req.Provider from the model when it is empty.
+func ResolveEditProvider(req *EditRequest) error {
+	if req.Provider == "" {
+		req.Provider = pickProviderByModel(req.Model)
+	}
+	if req.Provider == "" {
+		return fmt.Errorf("provider not set")
+	}
+	return nil
+}
+
 func pickProviderByModel(model string) string {
 	model = NormalizeModelAlias(strings.TrimSpace(model))
 	if strings.HasPrefix(model, "gemini-") || strings.HasPrefix(model, "imagen-") {
@@ -0,0 +1,42 @@
+package anthropicapi
+
+import "github.com/quailyquaily/uniai/chat"
+
+// stopReasons maps finish reasons to Messages API stop_reason values, the
+// inverse of chat.NormalizeFinishReason for stop_reason values. Content
+// filtering has no stop_reason of its own and is reported as a refusal.
+var stopReasons = map[chat.FinishReason]string{
+	chat.FinishReasonStop:          "end_turn",
+	chat.FinishReasonLength:        "max_tokens",
+	chat.FinishReasonToolCalls:     "tool_use",
+	chat.FinishReasonContentFilter: "refusal",
+	chat.FinishReasonRefusal:       "refusal",
+	chat.FinishReasonPause:         "pause_turn",
+}
+
+// SetFinishReason records stopReason on result. The forced structured output
+// call ends with tool_use, which is reported as a natural stop; outputTool is
+// the name of that tool, or "" when the request has no response format.
+func SetFinishReason(result *chat.Result, stopReason, outputTool string) {
+	result.SetFinishReason(stopReason)
+	if outputTool != "" && result.FinishReason == chat.FinishReasonToolCalls && len(result.ToolCalls) == 0 {
+		result.FinishReason = chat.FinishReasonStop
+	}
+}
+
+// StopReason returns the stop_reason for a result that finished with reason.
+// raw is the upstream value; an Anthropic stop_sequence is kept. A natural
+// stop with tool calls is reported as tool_use, and reasons without an
+// equivalent as end_turn.
+func StopReason(reason chat.FinishReason, raw string, hasToolCalls bool) string {
+	if raw == "stop_sequence" {
+		return raw
+	}
+	if stopReason, ok := stopReasons[reason]; ok && reason != chat.FinishReasonStop {
+		return stopReason
+	}
+	if hasToolCalls {
+		return "tool_use"
+	}
+	return "end_turn"
+}
@@ -0,0 +1,67 @@
+package anthropicapi
+
+import (
+	"testing"
+
+	"github.com/quailyquaily/uniai/chat"
+)
+
+func TestSetFinishReason(t *testing.T) {
+	call := []chat.ToolCall{{ID: "toolu_1", Type: "function"}}
+	tests := []struct {
+		name       string
+		stopReason string
+		outputTool string
+		toolCalls  []chat.ToolCall
+		want       chat.FinishReason
+	}{
+		{name: "end turn", stopReason: "end_turn", want: chat.FinishReasonStop},
+		{name: "tool use", stopReason: "tool_use", toolCalls: call, want: chat.FinishReasonToolCalls},
+		{name: "structured output", stopReason: "tool_use", outputTool: "json_output", want: chat.FinishReasonStop},
+		{name: "tool call beside output tool", stopReason: "tool_use", outputTool: "json_output", toolCalls: call, want: chat.FinishReasonToolCalls},
+		{name: "max tokens", stopReason: "max_tokens", outputTool: "json_output", want: chat.FinishReasonLength},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := &chat.Result{ToolCalls: tt.toolCalls}
+			SetFinishReason(result, tt.stopReason, tt.outputTool)
+			if result.FinishReason != tt.want {
+				t.Fatalf("FinishReason = %q, want %q", result.FinishReason, tt.want)
+			}
+			if result.RawFinishReason != tt.stopReason {
+				t.Fatalf("RawFinishReason = %q, want %q", result.RawFinishReason, tt.stopReason)
+			}
+		})
+	}
+}
+
+func TestStopReasonRoundTrip(t *testing.T) {
+	for _, stopReason := range []string{"end_turn", "stop_sequence", "max_tokens", "tool_use", "refusal", "pause_turn"} {
+		got := StopReason(chat.NormalizeFinishReason(stopReason), stopReason, stopReason == "tool_use")
+		if got != stopReason {
+			t.Fatalf("StopReason(%q) = %q", stopReason, got)
+		}
+	}
+}
+
+func TestStopReason(t *testing.T) {
+	tests := []struct {
+		reason       chat.FinishReason
+		raw          string
+		hasToolCalls bool
+		want         string
+	}{
+		{reason: chat.FinishReasonStop, raw: "stop", want: "end_turn"},
+		{reason: chat.FinishReasonStop, raw: "STOP", hasToolCalls: true, want: "tool_use"},
+		{reason: chat.FinishReasonLength, raw: "length", want: "max_tokens"},
+		{reason: chat.FinishReasonToolCalls, raw: "tool_calls", want: "tool_use"},
+		{reason: chat.FinishReasonContentFilter, raw: "content_filter", want: "refusal"},
+		{reason: chat.FinishReasonOther, raw: "unknown", want: "end_turn"},
+		{want: "end_turn"},
+	}
+	for _, tt := range tests {
+		if got := StopReason(tt.reason, tt.raw, tt.hasToolCalls); got != tt.want {
+			t.Fatalf("StopReason(%q, %q, %v) = %q, want %q", tt.reason, tt.raw, tt.hasToolCalls, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package anthropicapi
+
+import "encoding/json"
+
+// Data of the Messages API stream events, by event type.
+
+type MessageStartEvent struct {
+	Type    string        `json:"type"`
+	Message StreamMessage `json:"message"`
+}
+
+// StreamMessage is the message of a message_start event. Its content is
+// empty and its stop reason null; both arrive in later events.
+type StreamMessage struct {
+	ID           string         `json:"id"`
+	Type         string         `json:"type"`
+	Role         string         `json:"role"`
+	Model        string         `json:"model"`
+	Content      []ContentBlock `json:"content"`
+	StopReason   *string        `json:"stop_reason"`
+	StopSequence *string        `json:"stop_sequence"`
+	Usage        Usage          `json:"usage"`
+}
+
+type ContentBlockStartEvent struct {
+	Type         string      `json:"type"`
+	Index        int         `json:"index"`
+	ContentBlock StreamBlock `json:"content_block"`
+}
+
+// StreamBlock is the content block of a content_block_start event. Blocks
+// start empty, so Text and Thinking are pointers that are sent when set, even
+// to "", where ContentBlock would omit them.
+type StreamBlock struct {
+	Type     string          `json:"type"`
+	Text     *string         `json:"text,omitempty"`
+	Thinking *string         `json:"thinking,omitempty"`
+	ID       string          `json:"id,omitempty"`
+	Name     string          `json:"name,omitempty"`
+	Input    json.RawMessage `json:"input,omitempty"`
+}
+
+type ContentBlockDeltaEvent struct {
+	Type  string `json:"type"`
+	Index int    `json:"index"`
+	Delta Delta  `json:"delta"`
+}
+
+// Delta is the delta of a content_block_delta event. Type selects which
+// field applies: text_delta, thinking_delta, signature_delta or
+// input_json_delta.
+type Delta struct {
+	Type        string `json:"type"`
+	Text        string `json:"text,omitempty"`
+	Thinking    string `json:"thinking,omitempty"`
+	Signature   string `json:"signature,omitempty"`
+	PartialJSON string `json:"partial_json,omitempty"`
+}
+
+type ContentBlockStopEvent struct {
+	Type  string `json:"type"`
+	Index int    `json:"index"`
+}
+
+type MessageDeltaEvent struct {
+	Type  string       `json:"type"`
+	Delta MessageDelta `json:"delta"`
+	Usage Usage        `json:"usage"`
+}
+
+type MessageDelta struct {
+	StopReason   string  `json:"stop_reason"`
+	StopSequence *string `json:"stop_sequence"`
+}
+
+type MessageStopEvent struct {
+	Type string `json:"type"`
+}
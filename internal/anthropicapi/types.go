@@ -0,0 +1,133 @@
+// Package anthropicapi holds the Anthropic Messages API wire types and the
+// stop_reason mapping shared by the Anthropic and Bedrock providers and the
+// chat/anthropic adapter. Bedrock keeps its own usage type, which has
+// Bedrock-only cache fields.
+package anthropicapi
+
+import "encoding/json"
+
+// Message is one turn of a Messages API request.
+type Message struct {
+	Role    string  `json:"role"`
+	Content Content `json:"content"`
+}
+
+// Content is a list of content blocks. It also decodes from a plain string,
+// which the Messages API accepts as a single text block.
+type Content []ContentBlock
+
+func (c *Content) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*c = Content{{Type: "text", Text: text}}
+		return nil
+	}
+	var blocks []ContentBlock
+	if err := json.Unmarshal(data, &blocks); err != nil {
+		return err
+	}
+	*c = blocks
+	return nil
+}
+
+// ContentBlock is one content block of a message, system prompt or
+// tool_result. Type selects which fields apply.
+type ContentBlock struct {
+	Type string `json:"type"`
+	// text
+	Text string `json:"text,omitempty"`
+	// image and document
+	Source *Source `json:"source,omitempty"`
+	// document
+	Title string `json:"title,omitempty"`
+	// Citations is the citations setting of a document block, see
+	// Citations. Response text blocks carry their list of citations here
+	// instead.
+	Citations json.RawMessage `json:"citations,omitempty"`
+	// tool_use
+	ID    string          `json:"id,omitempty"`
+	Name  string          `json:"name,omitempty"`
+	Input json.RawMessage `json:"input,omitempty"`
+	// tool_result
+	ToolUseID string  `json:"tool_use_id,omitempty"`
+	Content   Content `json:"content,omitempty"`
+	IsError   bool    `json:"is_error,omitempty"`
+	// thinking and redacted_thinking
+	Thinking  string `json:"thinking,omitempty"`
+	Signature string `json:"signature,omitempty"`
+	Data      string `json:"data,omitempty"`
+
+	CacheControl *CacheControl `json:"cache_control,omitempty"`
+}
+
+// Source is the source of an image or document block: base64 data with its
+// media type, a URL, or for documents plain text in Data.
+type Source struct {
+	Type      string `json:"type"`
+	MediaType string `json:"media_type,omitempty"`
+	Data      string `json:"data,omitempty"`
+	URL       string `json:"url,omitempty"`
+}
+
+// Citations is the citations setting of a document block.
+type Citations struct {
+	Enabled bool `json:"enabled"`
+}
+
+// CitationsEnabled returns the ContentBlock.Citations value that enables
+// citations on a document block.
+func CitationsEnabled() json.RawMessage {
+	return json.RawMessage(`{"enabled":true}`)
+}
+
+type CacheControl struct {
+	Type string `json:"type"`
+	TTL  string `json:"ttl,omitempty"`
+}
+
+// Tool is a client tool definition.
+type Tool struct {
+	Type         string          `json:"type,omitempty"`
+	Name         string          `json:"name"`
+	Description  string          `json:"description,omitempty"`
+	InputSchema  json.RawMessage `json:"input_schema"`
+	CacheControl *CacheControl   `json:"cache_control,omitempty"`
+}
+
+type ToolChoice struct {
+	Type                   string `json:"type"` // auto|any|tool|none
+	Name                   string `json:"name,omitempty"`
+	DisableParallelToolUse bool   `json:"disable_parallel_tool_use,omitempty"`
+}
+
+type Thinking struct {
+	Type         string `json:"type"` // enabled|adaptive|disabled
+	BudgetTokens int    `json:"budget_tokens,omitempty"`
+	Display      string `json:"display,omitempty"` // summarized|omitted
+}
+
+type Metadata struct {
+	UserID string `json:"user_id,omitempty"`
+}
+
+// Response is the body of a non-streaming Messages API response.
+type Response struct {
+	ID           string         `json:"id"`
+	Type         string         `json:"type"`
+	Role         string         `json:"role"`
+	Model        string         `json:"model"`
+	Content      []ContentBlock `json:"content"`
+	StopReason   string         `json:"stop_reason"`
+	StopSequence *string        `json:"stop_sequence"`
+	Usage        Usage          `json:"usage"`
+}
+
+// Usage is the token usage of a response. CacheCreation breaks cache writes
+// down by TTL.
+type Usage struct {
+	InputTokens              int            `json:"input_tokens"`
+	OutputTokens             int            `json:"output_tokens"`
+	CacheCreationInputTokens int            `json:"cache_creation_input_tokens"`
+	CacheReadInputTokens     int            `json:"cache_read_input_tokens"`
+	CacheCreation            map[string]int `json:"cache_creation,omitempty"`
+}
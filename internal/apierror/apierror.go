@@ -0,0 +1,322 @@
+// Package apierror defines the typed error returned when a provider API
+// rejects a request, and the sentinel categories used to classify it.
+package apierror
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrRateLimited           = errors.New("rate limited")
+	ErrContextLengthExceeded = errors.New("context length exceeded")
+	ErrContentFiltered       = errors.New("content filtered")
+	ErrAuth                  = errors.New("authentication failed")
+	ErrInvalidRequest        = errors.New("invalid request")
+	ErrServerError           = errors.New("server error")
+)
+
+var requestIDHeaders = []string{
+	"x-request-id",
+	"request-id",
+	"x-amzn-requestid",
+	"x-amz-request-id",
+	"x-goog-request-id",
+	"apim-request-id",
+	"cf-ray",
+}
+
+// Error describes a failed provider API call.
+//
+// Category holds one of the package sentinels (or nil when the failure
+// could not be classified), so callers can use errors.Is(err, ErrRateLimited)
+// as well as errors.As(err, &*Error) to inspect the details.
+type Error struct {
+	Provider   string
+	StatusCode int
+	Code       string
+	Type       string
+	Message    string
+	RequestID  string
+	RetryAfter time.Duration
+	Body       []byte
+	Category   error
+	// Err is the underlying SDK error, if any.
+	Err error
+}
+
+func (e *Error) Error() string {
+	provider := e.Provider
+	if provider == "" {
+		provider = "provider"
+	}
+	msg := strings.TrimSpace(e.Message)
+	if msg == "" {
+		msg = strings.TrimSpace(string(e.Body))
+	}
+	if msg == "" && e.Category != nil {
+		msg = e.Category.Error()
+	}
+	if e.StatusCode > 0 {
+		return fmt.Sprintf("%s api error: status %d: %s", provider, e.StatusCode, msg)
+	}
+	return fmt.Sprintf("%s api error: %s", provider, msg)
+}
+
+func (e *Error) Unwrap() []error {
+	errs := make([]error, 0, 2)
+	if e.Category != nil {
+		errs = append(errs, e.Category)
+	}
+	if e.Err != nil {
+		errs = append(errs, e.Err)
+	}
+	return errs
+}
+
+// FromResponse builds an Error from a non-success HTTP response. The body is
+// parsed for the provider error code, type and message when it is JSON.
+func FromResponse(provider string, statusCode int, header http.Header, body []byte) *Error {
+	e := &Error{
+		Provider:   provider,
+		StatusCode: statusCode,
+		RequestID:  RequestID(header),
+		RetryAfter: RetryAfter(header, time.Now()),
+		Body:       append([]byte(nil), body...),
+	}
+	e.Code, e.Type, e.Message = parseBody(body)
+	e.Category = Classify(e.StatusCode, e.Code, e.Type, e.Message)
+	return e
+}
+
+// New builds an Error from fields reported outside a plain HTTP response,
+// such as an error event in the middle of a stream.
+func New(provider string, statusCode int, code, typ, message string) *Error {
+	return &Error{
+		Provider:   provider,
+		StatusCode: statusCode,
+		Code:       code,
+		Type:       typ,
+		Message:    message,
+		Category:   Classify(statusCode, code, typ, message),
+	}
+}
+
+// Classify maps an HTTP status and provider error details to one of the
+// sentinel categories. It returns nil when nothing matches.
+func Classify(statusCode int, code, typ, message string) error {
+	switch {
+	case statusCode == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden:
+		return ErrAuth
+	case statusCode >= 500 || statusCode == http.StatusRequestTimeout:
+		return ErrServerError
+	}
+
+	text := strings.ToLower(strings.Join([]string{code, typ, message}, " "))
+	if containsAny(text, contextLengthMarkers) {
+		return ErrContextLengthExceeded
+	}
+	if containsAny(text, contentFilterMarkers) {
+		return ErrContentFiltered
+	}
+	// Gemini rejects a bad key with 400 INVALID_ARGUMENT and reason
+	// API_KEY_INVALID.
+	if containsAny(strings.ToLower(code), authMarkers) {
+		return ErrAuth
+	}
+	if statusCode >= 400 {
+		return ErrInvalidRequest
+	}
+
+	kind := strings.ToLower(code + " " + typ)
+	switch {
+	case containsAny(kind, rateLimitMarkers):
+		return ErrRateLimited
+	case containsAny(kind, authMarkers):
+		return ErrAuth
+	case containsAny(kind, serverMarkers):
+		return ErrServerError
+	case containsAny(kind, invalidRequestMarkers):
+		return ErrInvalidRequest
+	}
+	return nil
+}
+
+var (
+	contextLengthMarkers = []string{
+		"context_length_exceeded",
+		"context length",
+		"context window",
+		"maximum context",
+		"prompt is too long",
+		"input is too long",
+		"exceeds the maximum number of tokens",
+		"too many input tokens",
+	}
+	contentFilterMarkers = []string{
+		"content_filter",
+		"content_policy",
+		"content policy",
+		"content management policy",
+		"responsibleaipolicyviolation",
+		"moderation_blocked",
+		"policy_violation",
+		"bio_policy",
+	}
+	rateLimitMarkers      = []string{"rate_limit", "ratelimit", "resource_exhausted", "throttl", "too_many_requests", "quota", "modelnotready"}
+	authMarkers           = []string{"authentication", "unauthenticated", "permission", "invalid_api_key", "api_key_invalid", "accessdenied", "unauthorized"}
+	serverMarkers         = []string{"overloaded", "server_error", "api_error", "internal", "unavailable", "timeout", "modelstreamerror"}
+	invalidRequestMarkers = []string{"invalid_request", "invalid_argument", "validation", "not_found", "bad_request", "failed_precondition", "invalid"}
+)
+
+func containsAny(text string, markers []string) bool {
+	for _, marker := range markers {
+		if strings.Contains(text, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// RequestID returns the first request identifier header set by the provider.
+func RequestID(header http.Header) string {
+	for _, key := range requestIDHeaders {
+		if value := strings.TrimSpace(header.Get(key)); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// RetryAfter returns the delay requested by retry-after-ms or Retry-After
+// (seconds or HTTP date). It returns zero when neither header is usable.
+func RetryAfter(header http.Header, now time.Time) time.Duration {
+	if header == nil {
+		return 0
+	}
+	if value := strings.TrimSpace(header.Get("retry-after-ms")); value != "" {
+		if ms, err := strconv.ParseFloat(value, 64); err == nil && ms > 0 {
+			return time.Duration(ms * float64(time.Millisecond))
+		}
+	}
+	value := strings.TrimSpace(header.Get("Retry-After"))
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		if seconds <= 0 {
+			return 0
+		}
+		return time.Duration(seconds * float64(time.Second))
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if delay := at.Sub(now); delay > 0 {
+			return delay
+		}
+	}
+	return 0
+}
+
+type errorDetail struct {
+	Code    json.RawMessage `json:"code"`
+	Type    string          `json:"type"`
+	Status  string          `json:"status"`
+	Message string          `json:"message"`
+	Details []struct {
+		Reason string `json:"reason"`
+	} `json:"details"`
+}
+
+type errorBody struct {
+	Error   json.RawMessage `json:"error"`
+	Errors  []errorDetail   `json:"errors"`
+	Detail  json.RawMessage `json:"detail"`
+	Code    json.RawMessage `json:"code"`
+	Type    string          `json:"type"`
+	Message string          `json:"message"`
+}
+
+// parseBody extracts code, type and message from the common provider error
+// envelopes: {"error":{...}} (OpenAI, Anthropic, Gemini), {"errors":[...]}
+// (Cloudflare), {"detail":...} (Jina) and flat {"code","message"} bodies.
+func parseBody(body []byte) (code, typ, message string) {
+	var env errorBody
+	if err := json.Unmarshal(body, &env); err != nil {
+		return "", "", strings.TrimSpace(string(body))
+	}
+	if len(env.Error) > 0 {
+		var detail errorDetail
+		if err := json.Unmarshal(env.Error, &detail); err == nil {
+			code = rawString(detail.Code)
+			typ = firstNonEmpty(detail.Type, detail.Status)
+			if code == "" || isNumber(code) {
+				for _, d := range detail.Details {
+					if d.Reason != "" {
+						code = d.Reason
+						break
+					}
+				}
+			}
+			return code, typ, strings.TrimSpace(detail.Message)
+		}
+		var text string
+		if err := json.Unmarshal(env.Error, &text); err == nil {
+			return rawString(env.Code), env.Type, strings.TrimSpace(text)
+		}
+	}
+	if len(env.Errors) > 0 {
+		msgs := make([]string, 0, len(env.Errors))
+		for _, item := range env.Errors {
+			if code == "" {
+				code = rawString(item.Code)
+			}
+			if item.Message != "" {
+				msgs = append(msgs, item.Message)
+			}
+		}
+		return code, "", strings.Join(msgs, "; ")
+	}
+	if len(env.Detail) > 0 {
+		var text string
+		if err := json.Unmarshal(env.Detail, &text); err == nil {
+			return "", "", strings.TrimSpace(text)
+		}
+		return "", "", strings.TrimSpace(string(env.Detail))
+	}
+	if env.Message != "" {
+		return rawString(env.Code), env.Type, strings.TrimSpace(env.Message)
+	}
+	return "", "", strings.TrimSpace(string(body))
+}
+
+func rawString(raw json.RawMessage) string {
+	if len(raw) == 0 || string(raw) == "null" {
+		return ""
+	}
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return text
+	}
+	return strings.TrimSpace(string(raw))
+}
+
+func isNumber(value string) bool {
+	_, err := strconv.Atoi(value)
+	return err == nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,195 @@
+package apierror
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFromResponseParsesProviderEnvelopes(t *testing.T) {
+	cases := []struct {
+		name     string
+		provider string
+		status   int
+		body     string
+		code     string
+		typ      string
+		message  string
+		category error
+	}{
+		{
+			name:     "openai context length",
+			provider: "openai",
+			status:   http.StatusBadRequest,
+			body:     `{"error":{"message":"This model's maximum context length is 128000 tokens.","type":"invalid_request_error","param":"messages","code":"context_length_exceeded"}}`,
+			code:     "context_length_exceeded",
+			typ:      "invalid_request_error",
+			message:  "This model's maximum context length is 128000 tokens.",
+			category: ErrContextLengthExceeded,
+		},
+		{
+			name:     "anthropic overloaded",
+			provider: "anthropic",
+			status:   529,
+			body:     `{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`,
+			typ:      "overloaded_error",
+			message:  "Overloaded",
+			category: ErrServerError,
+		},
+		{
+			name:     "gemini quota",
+			provider: "gemini",
+			status:   http.StatusTooManyRequests,
+			body:     `{"error":{"code":429,"message":"quota exceeded","status":"RESOURCE_EXHAUSTED"}}`,
+			code:     "429",
+			typ:      "RESOURCE_EXHAUSTED",
+			message:  "quota exceeded",
+			category: ErrRateLimited,
+		},
+		{
+			name:     "gemini invalid key",
+			provider: "gemini",
+			status:   http.StatusBadRequest,
+			body:     `{"error":{"code":400,"message":"API key not valid.","status":"INVALID_ARGUMENT","details":[{"reason":"API_KEY_INVALID"}]}}`,
+			code:     "API_KEY_INVALID",
+			typ:      "INVALID_ARGUMENT",
+			message:  "API key not valid.",
+			category: ErrAuth,
+		},
+		{
+			name:     "gemini invalid argument",
+			provider: "gemini",
+			status:   http.StatusBadRequest,
+			body:     `{"error":{"code":400,"message":"Invalid value at 'contents'","status":"INVALID_ARGUMENT"}}`,
+			code:     "400",
+			typ:      "INVALID_ARGUMENT",
+			message:  "Invalid value at 'contents'",
+			category: ErrInvalidRequest,
+		},
+		{
+			name:     "cloudflare errors",
+			provider: "cloudflare",
+			status:   http.StatusUnauthorized,
+			body:     `{"success":false,"errors":[{"code":10000,"message":"Authentication error"}]}`,
+			code:     "10000",
+			message:  "Authentication error",
+			category: ErrAuth,
+		},
+		{
+			name:     "jina detail",
+			provider: "jina",
+			status:   http.StatusUnprocessableEntity,
+			body:     `{"detail":"model not found"}`,
+			message:  "model not found",
+			category: ErrInvalidRequest,
+		},
+		{
+			name:     "plain text",
+			provider: "openai",
+			status:   http.StatusBadGateway,
+			body:     "upstream unavailable\n",
+			message:  "upstream unavailable",
+			category: ErrServerError,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := FromResponse(tc.provider, tc.status, nil, []byte(tc.body))
+			if err.Provider != tc.provider || err.StatusCode != tc.status {
+				t.Fatalf("unexpected provider/status: %#v", err)
+			}
+			if err.Code != tc.code || err.Type != tc.typ || err.Message != tc.message {
+				t.Fatalf("unexpected details: code=%q type=%q message=%q", err.Code, err.Type, err.Message)
+			}
+			if !errors.Is(err, tc.category) {
+				t.Fatalf("expected category %v, got %v", tc.category, err.Category)
+			}
+			if string(err.Body) != tc.body {
+				t.Fatalf("unexpected body: %q", err.Body)
+			}
+		})
+	}
+}
+
+func TestFromResponseReadsHeaders(t *testing.T) {
+	header := http.Header{}
+	header.Set("request-id", "req_123")
+	header.Set("Retry-After", "3")
+
+	err := FromResponse("anthropic", http.StatusTooManyRequests, header, []byte(`{"type":"error","error":{"type":"rate_limit_error","message":"slow down"}}`))
+	if err.RequestID != "req_123" {
+		t.Fatalf("unexpected request id: %q", err.RequestID)
+	}
+	if err.RetryAfter != 3*time.Second {
+		t.Fatalf("unexpected retry after: %v", err.RetryAfter)
+	}
+	if got := err.Error(); got != "anthropic api error: status 429: slow down" {
+		t.Fatalf("unexpected message: %q", got)
+	}
+
+	var target *Error
+	wrapped := errors.Join(errors.New("context"), err)
+	if !errors.As(wrapped, &target) || target != err {
+		t.Fatalf("expected errors.As to find *Error")
+	}
+	if !errors.Is(wrapped, ErrRateLimited) || errors.Is(wrapped, ErrServerError) {
+		t.Fatalf("unexpected category matching")
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	cases := []struct {
+		name   string
+		header http.Header
+		want   time.Duration
+	}{
+		{name: "missing", header: http.Header{}, want: 0},
+		{name: "seconds", header: http.Header{"Retry-After": {"2"}}, want: 2 * time.Second},
+		{name: "milliseconds wins", header: http.Header{"Retry-After": {"2"}, "Retry-After-Ms": {"250"}}, want: 250 * time.Millisecond},
+		{name: "http date", header: http.Header{"Retry-After": {now.Add(5 * time.Second).Format(http.TimeFormat)}}, want: 5 * time.Second},
+		{name: "past date", header: http.Header{"Retry-After": {now.Add(-time.Minute).Format(http.TimeFormat)}}, want: 0},
+		{name: "garbage", header: http.Header{"Retry-After": {"soon"}}, want: 0},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := RetryAfter(tc.header, now); got != tc.want {
+				t.Fatalf("expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestClassifyWithoutStatus(t *testing.T) {
+	cases := []struct {
+		code    string
+		typ     string
+		message string
+		want    error
+	}{
+		{typ: "overloaded_error", message: "Overloaded", want: ErrServerError},
+		{code: "rate_limit_exceeded", message: "slow down", want: ErrRateLimited},
+		{code: "ThrottlingException", message: "Too many requests", want: ErrRateLimited},
+		{code: "AccessDeniedException", message: "denied", want: ErrAuth},
+		{code: "ValidationException", message: "Input is too long for requested model.", want: ErrContextLengthExceeded},
+		{code: "image_content_policy_violation", message: "blocked", want: ErrContentFiltered},
+		{typ: "invalid_request_error", message: "bad field", want: ErrInvalidRequest},
+		{message: "something odd", want: nil},
+	}
+	for _, tc := range cases {
+		got := Classify(0, tc.code, tc.typ, tc.message)
+		if got != tc.want {
+			t.Fatalf("Classify(%q, %q, %q) = %v, want %v", tc.code, tc.typ, tc.message, got, tc.want)
+		}
+	}
+}
+
+func TestNewWithoutStatusFormatsMessage(t *testing.T) {
+	err := New("gemini", 0, "", "", "quota exceeded")
+	if got := err.Error(); !strings.Contains(got, "quota exceeded") || strings.Contains(got, "status") {
+		t.Fatalf("unexpected message: %q", got)
+	}
+}
@@ -0,0 +1,244 @@
+// Package breaker implements the per-provider circuit breaker used by chat
+// calls.
+package breaker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/quailyquaily/uniai/internal/apierror"
+	"github.com/quailyquaily/uniai/internal/ratelimit"
+)
+
+const (
+	DefaultOpenTimeout    = 30 * time.Second
+	DefaultHalfOpenProbes = 1
+)
+
+// ErrOpen is matched (errors.Is) by errors returned while a breaker is open.
+var ErrOpen = errors.New("circuit breaker open")
+
+// Error is returned instead of calling a provider whose breaker is open.
+type Error struct {
+	Provider string
+	BaseURL  string
+	// RetryAfter is the time left until the breaker lets a probe through.
+	RetryAfter time.Duration
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s (retry after %s)", e.Provider, ErrOpen.Error(), e.RetryAfter.Round(time.Millisecond))
+}
+
+func (e *Error) Is(target error) bool {
+	return target == ErrOpen
+}
+
+// State is the state of one breaker.
+type State string
+
+const (
+	StateClosed   State = "closed"
+	StateOpen     State = "open"
+	StateHalfOpen State = "half_open"
+)
+
+// Config configures the breakers. The zero value disables them.
+type Config struct {
+	// FailureThreshold is the number of consecutive server or timeout errors
+	// that opens a breaker. Values <= 0 disable circuit breaking.
+	FailureThreshold int
+	// OpenTimeout is how long a breaker stays open before letting probes
+	// through. Defaults to DefaultOpenTimeout.
+	OpenTimeout time.Duration
+	// HalfOpenProbes is the number of concurrent probe requests allowed while
+	// half-open. Defaults to DefaultHalfOpenProbes.
+	HalfOpenProbes int
+	// PerBaseURL keeps a separate breaker for every API base URL of a
+	// provider instead of one per provider.
+	PerBaseURL bool
+}
+
+// Enabled reports whether circuit breaking is on.
+func (c Config) Enabled() bool {
+	return c.FailureThreshold > 0
+}
+
+// Health is a snapshot of one breaker.
+type Health struct {
+	Provider            string    `json:"provider"`
+	BaseURL             string    `json:"base_url,omitempty"`
+	State               State     `json:"state"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	OpenedAt            time.Time `json:"opened_at,omitempty"`
+	LastError           string    `json:"last_error,omitempty"`
+}
+
+// Set holds one breaker per provider (and base URL when configured). It is
+// safe for concurrent use.
+type Set struct {
+	cfg Config
+	now func() time.Time
+
+	mu       sync.Mutex
+	breakers map[key]*breaker
+}
+
+type key struct {
+	provider string
+	baseURL  string
+}
+
+type breaker struct {
+	state     State
+	failures  int
+	openedAt  time.Time
+	probes    int
+	lastError string
+}
+
+// New returns a Set for cfg.
+func New(cfg Config) *Set {
+	if cfg.OpenTimeout <= 0 {
+		cfg.OpenTimeout = DefaultOpenTimeout
+	}
+	if cfg.HalfOpenProbes <= 0 {
+		cfg.HalfOpenProbes = DefaultHalfOpenProbes
+	}
+	return &Set{
+		cfg:      cfg,
+		now:      time.Now,
+		breakers: make(map[key]*breaker),
+	}
+}
+
+// Allow reports whether a request to provider may proceed. On success the
+// caller must pass the request error (or nil) to done. Errors caused by ctx
+// being canceled or reaching its deadline are not counted.
+func (s *Set) Allow(ctx context.Context, provider, baseURL string) (done func(err error), err error) {
+	if s == nil || !s.cfg.Enabled() {
+		return func(error) {}, nil
+	}
+	if !s.cfg.PerBaseURL {
+		baseURL = ""
+	}
+	k := key{provider: provider, baseURL: baseURL}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.breakers[k]
+	if !ok {
+		b = &breaker{state: StateClosed}
+		s.breakers[k] = b
+	}
+
+	probe := false
+	switch b.state {
+	case StateOpen:
+		if elapsed := s.now().Sub(b.openedAt); elapsed < s.cfg.OpenTimeout {
+			return nil, &Error{Provider: provider, BaseURL: baseURL, RetryAfter: s.cfg.OpenTimeout - elapsed}
+		}
+		b.state = StateHalfOpen
+		b.probes = 0
+		fallthrough
+	case StateHalfOpen:
+		if b.probes >= s.cfg.HalfOpenProbes {
+			return nil, &Error{Provider: provider, BaseURL: baseURL}
+		}
+		b.probes++
+		probe = true
+	}
+	return func(err error) {
+		result := outcome(err)
+		if err != nil && ctx.Err() != nil {
+			result = outcomeIgnore
+		}
+		s.record(b, probe, result, err)
+	}, nil
+}
+
+func (s *Set) record(b *breaker, probe bool, result result, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if probe && b.state == StateHalfOpen {
+		b.probes--
+	}
+	switch result {
+	case outcomeIgnore:
+	case outcomeSuccess:
+		b.state = StateClosed
+		b.failures = 0
+		b.openedAt = time.Time{}
+	case outcomeFailure:
+		b.failures++
+		b.lastError = err.Error()
+		if (b.state == StateClosed && b.failures >= s.cfg.FailureThreshold) || (b.state == StateHalfOpen && probe) {
+			b.state = StateOpen
+			b.openedAt = s.now()
+		}
+	}
+}
+
+// Snapshot returns the state of every breaker seen so far, sorted by
+// provider and base URL.
+func (s *Set) Snapshot() []Health {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]Health, 0, len(s.breakers))
+	for k, b := range s.breakers {
+		state := b.state
+		if state == StateOpen && s.now().Sub(b.openedAt) >= s.cfg.OpenTimeout {
+			state = StateHalfOpen
+		}
+		out = append(out, Health{
+			Provider:            k.provider,
+			BaseURL:             k.baseURL,
+			State:               state,
+			ConsecutiveFailures: b.failures,
+			OpenedAt:            b.openedAt,
+			LastError:           b.lastError,
+		})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Provider != out[j].Provider {
+			return out[i].Provider < out[j].Provider
+		}
+		return out[i].BaseURL < out[j].BaseURL
+	})
+	return out
+}
+
+type result int
+
+const (
+	outcomeSuccess result = iota
+	outcomeFailure
+	outcomeIgnore
+)
+
+func outcome(err error) result {
+	var netErr net.Error
+	switch {
+	case err == nil:
+		return outcomeSuccess
+	case errors.Is(err, context.Canceled), errors.Is(err, ratelimit.ErrLimited):
+		// The request never got a verdict from the provider.
+		return outcomeIgnore
+	case errors.Is(err, apierror.ErrServerError), errors.As(err, &netErr):
+		return outcomeFailure
+	case errors.Is(err, context.DeadlineExceeded):
+		// The caller's own deadline is ignored in Allow; this one comes from
+		// the HTTP client timeout.
+		return outcomeFailure
+	default:
+		return outcomeSuccess
+	}
+}
@@ -0,0 +1,132 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/quailyquaily/uniai/internal/apierror"
+)
+
+func newTestSet(cfg Config) (*Set, *time.Time) {
+	s := New(cfg)
+	now := time.Unix(1000, 0)
+	s.now = func() time.Time { return now }
+	return s, &now
+}
+
+func call(t *testing.T, s *Set, err error) error {
+	t.Helper()
+	done, allowErr := s.Allow(context.Background(), "openai", "https://api.openai.com/v1")
+	if allowErr != nil {
+		return allowErr
+	}
+	done(err)
+	return nil
+}
+
+func TestBreakerOpensAfterConsecutiveFailures(t *testing.T) {
+	s, now := newTestSet(Config{FailureThreshold: 2, OpenTimeout: time.Minute})
+	serverErr := apierror.New("openai", http.StatusBadGateway, "", "", "bad gateway")
+
+	_ = call(t, s, serverErr)
+	_ = call(t, s, apierror.New("openai", http.StatusBadRequest, "", "", "bad request"))
+	if got := s.Snapshot()[0]; got.State != StateClosed || got.ConsecutiveFailures != 0 {
+		t.Fatalf("expected client errors to reset the count, got %#v", got)
+	}
+
+	_ = call(t, s, serverErr)
+	_ = call(t, s, serverErr)
+	err := call(t, s, nil)
+	var openErr *Error
+	if !errors.Is(err, ErrOpen) || !errors.As(err, &openErr) || openErr.RetryAfter != time.Minute {
+		t.Fatalf("expected open breaker error, got %v", err)
+	}
+	health := s.Snapshot()
+	if len(health) != 1 || health[0].State != StateOpen || health[0].ConsecutiveFailures != 2 || health[0].LastError == "" {
+		t.Fatalf("unexpected health: %#v", health)
+	}
+
+	*now = now.Add(30 * time.Second)
+	if err := call(t, s, nil); !errors.As(err, &openErr) || openErr.RetryAfter != 30*time.Second {
+		t.Fatalf("expected breaker to stay open, got %v", err)
+	}
+}
+
+func TestBreakerHalfOpenProbes(t *testing.T) {
+	s, now := newTestSet(Config{FailureThreshold: 1, OpenTimeout: time.Second})
+	serverErr := apierror.New("openai", http.StatusServiceUnavailable, "", "", "unavailable")
+	_ = call(t, s, serverErr)
+
+	*now = now.Add(time.Second)
+	if got := s.Snapshot()[0].State; got != StateHalfOpen {
+		t.Fatalf("expected half-open after timeout, got %s", got)
+	}
+	probeDone, err := s.Allow(context.Background(), "openai", "")
+	if err != nil {
+		t.Fatalf("expected probe to be allowed: %v", err)
+	}
+	if _, err := s.Allow(context.Background(), "openai", ""); !errors.Is(err, ErrOpen) {
+		t.Fatalf("expected a second concurrent probe to be rejected, got %v", err)
+	}
+	probeDone(serverErr)
+	if got := s.Snapshot()[0].State; got != StateOpen {
+		t.Fatalf("expected failed probe to reopen, got %s", got)
+	}
+
+	*now = now.Add(time.Second)
+	if err := call(t, s, nil); err != nil {
+		t.Fatalf("expected probe: %v", err)
+	}
+	if got := s.Snapshot()[0]; got.State != StateClosed || got.ConsecutiveFailures != 0 {
+		t.Fatalf("expected successful probe to close, got %#v", got)
+	}
+}
+
+func TestBreakerIgnoresCallerCancellation(t *testing.T) {
+	s, _ := newTestSet(Config{FailureThreshold: 1})
+	ctx, cancel := context.WithCancel(context.Background())
+	done, err := s.Allow(ctx, "anthropic", "")
+	if err != nil {
+		t.Fatalf("allow: %v", err)
+	}
+	cancel()
+	done(context.Canceled)
+	if got := s.Snapshot()[0]; got.State != StateClosed || got.ConsecutiveFailures != 0 {
+		t.Fatalf("expected cancellation to be ignored, got %#v", got)
+	}
+
+	done, _ = s.Allow(context.Background(), "anthropic", "")
+	done(context.DeadlineExceeded)
+	if got := s.Snapshot()[0].State; got != StateOpen {
+		t.Fatalf("expected client timeout to count as a failure, got %s", got)
+	}
+}
+
+func TestBreakerPerBaseURL(t *testing.T) {
+	s, _ := newTestSet(Config{FailureThreshold: 1, PerBaseURL: true})
+	done, _ := s.Allow(context.Background(), "openai", "https://a.example")
+	done(apierror.New("openai", http.StatusInternalServerError, "", "", "boom"))
+	if _, err := s.Allow(context.Background(), "openai", "https://a.example"); !errors.Is(err, ErrOpen) {
+		t.Fatalf("expected breaker for a.example to be open, got %v", err)
+	}
+	if _, err := s.Allow(context.Background(), "openai", "https://b.example"); err != nil {
+		t.Fatalf("expected b.example to be unaffected, got %v", err)
+	}
+}
+
+func TestDisabledSetAllowsEverything(t *testing.T) {
+	s := New(Config{})
+	for i := 0; i < 3; i++ {
+		done, err := s.Allow(context.Background(), "openai", "")
+		if err != nil {
+			t.Fatalf("allow: %v", err)
+		}
+		done(apierror.New("openai", http.StatusInternalServerError, "", "", "boom"))
+	}
+	if len(s.Snapshot()) != 0 {
+		t.Fatalf("expected no breakers when disabled")
+	}
+}
@@ -13,22 +13,55 @@ const (
 	MaxResponseBodySize = 100 * 1024 * 1024 // 100 MB
 )
 
-// DefaultClient is a shared http.Client with a reasonable timeout.
+// DefaultClient is a shared http.Client with a reasonable timeout. Providers
+// that send their own requests use it when their Config.HTTPClient is nil.
 var DefaultClient = &http.Client{
 	Timeout: DefaultTimeout,
 }
 
-// ClientForContext returns a client that won't impose a shorter timeout than
-// the caller's context deadline. When the caller already set a deadline, rely
-// on context cancellation instead of http.Client.Timeout.
+type clientKey struct{}
+
+// WithClient returns a context whose requests go through client instead of
+// DefaultClient. A nil client leaves ctx unchanged.
+func WithClient(ctx context.Context, client *http.Client) context.Context {
+	if client == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, clientKey{}, client)
+}
+
+// NewClient returns the client configured by the caller: client itself when
+// set, otherwise a client with DefaultTimeout around transport. It returns nil
+// when neither is set so callers fall back to their defaults.
+func NewClient(client *http.Client, transport http.RoundTripper) *http.Client {
+	if client != nil {
+		return client
+	}
+	if transport == nil {
+		return nil
+	}
+	return &http.Client{
+		Transport: transport,
+		Timeout:   DefaultTimeout,
+	}
+}
+
+// ClientForContext returns the client attached with WithClient (or
+// DefaultClient) in a form that won't impose a shorter timeout than the
+// caller's context deadline. When the caller already set a deadline, rely on
+// context cancellation instead of http.Client.Timeout.
 func ClientForContext(ctx context.Context) *http.Client {
 	if ctx == nil {
 		return DefaultClient
 	}
-	if _, ok := ctx.Deadline(); !ok {
-		return DefaultClient
+	base := DefaultClient
+	if custom, ok := ctx.Value(clientKey{}).(*http.Client); ok {
+		base = custom
+	}
+	if _, ok := ctx.Deadline(); !ok || base.Timeout == 0 {
+		return base
 	}
-	client := *DefaultClient
+	client := *base
 	client.Timeout = 0
 	return &client
 }
@@ -2,6 +2,7 @@ package httputil
 
 import (
 	"context"
+	"net/http"
 	"testing"
 	"time"
 )
@@ -28,3 +29,36 @@ func TestClientForContextDisablesClientTimeoutWhenContextHasDeadline(t *testing.
 		t.Fatalf("expected cloned client timeout to be disabled, got %s", got.Timeout)
 	}
 }
+
+func TestClientForContextUsesAttachedClient(t *testing.T) {
+	custom := &http.Client{Timeout: time.Minute}
+	ctx := WithClient(context.Background(), custom)
+	if got := ClientForContext(ctx); got != custom {
+		t.Fatalf("expected attached client")
+	}
+
+	deadlineCtx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	got := ClientForContext(deadlineCtx)
+	if got == custom || got.Timeout != 0 {
+		t.Fatalf("expected cloned attached client without timeout")
+	}
+
+	if WithClient(context.Background(), nil) != context.Background() {
+		t.Fatalf("expected nil client to leave context unchanged")
+	}
+}
+
+func TestNewClient(t *testing.T) {
+	custom := &http.Client{}
+	if got := NewClient(custom, http.DefaultTransport); got != custom {
+		t.Fatalf("expected explicit client to win")
+	}
+	got := NewClient(nil, http.DefaultTransport)
+	if got == nil || got.Transport != http.DefaultTransport || got.Timeout != DefaultTimeout {
+		t.Fatalf("unexpected client from transport: %#v", got)
+	}
+	if NewClient(nil, nil) != nil {
+		t.Fatalf("expected nil without client or transport")
+	}
+}
@@ -89,6 +89,40 @@ func NormalizeSingleJSONContent(text string) (string, bool) {
 	return body, true
 }
 
+// Extract returns the first JSON object or array recovered from text. It
+// tries the whole text, code fence bodies, embedded snippets and finally the
+// span from the first opening brace, unquoting string-encoded payloads and
+// applying AttemptRepair to candidates that do not parse.
+func Extract(text string) (json.RawMessage, bool) {
+	candidates, err := CollectCandidates(text)
+	if err != nil {
+		return nil, false
+	}
+	if start := strings.IndexAny(text, "{["); start >= 0 {
+		if end := strings.LastIndexAny(text, "}]"); end > start {
+			candidates = append(candidates, text[start:end+1])
+		}
+		candidates = append(candidates, text[start:])
+	}
+	for _, candidate := range candidates {
+		payload := strings.TrimSpace(candidate)
+		if unquoted := UnquoteJSONStringPayload(payload); unquoted != "" {
+			payload = unquoted
+		}
+		if !isJSONObjectOrArray(payload) {
+			continue
+		}
+		if !json.Valid([]byte(payload)) {
+			payload = AttemptRepair(payload)
+			if payload == "" || !json.Valid([]byte(payload)) {
+				continue
+			}
+		}
+		return json.RawMessage(payload), true
+	}
+	return nil, false
+}
+
 func AttemptRepair(input string) string {
 	trimmed := strings.TrimSpace(input)
 	if trimmed == "" {
@@ -30,3 +30,24 @@ func TestNormalizeSingleJSONContentLeavesNonJSONFenceAlone(t *testing.T) {
 		t.Fatalf("expected non-json fence to stay unchanged, got %q", got)
 	}
 }
+
+func TestExtract(t *testing.T) {
+	cases := map[string]string{
+		`{"ok":true}`:                        `{"ok":true}`,
+		"Sure:\n```json\n{\"ok\":true}\n```": `{"ok":true}`,
+		`The answer is {"ok":true}. Thanks!`: `{"ok":true}`,
+		`{"items":[1,2,],}`:                  `{"items":[1,2]}`,
+		`{"name":"Ada"`:                      `{"name":"Ada"}`,
+		`"{\"ok\":true}"`:                    `{"ok":true}`,
+		`[{"id":1}]`:                         `[{"id":1}]`,
+	}
+	for input, want := range cases {
+		got, ok := Extract(input)
+		if !ok || string(got) != want {
+			t.Fatalf("%q: got %q (%v), want %q", input, got, ok, want)
+		}
+	}
+	if got, ok := Extract("no json here"); ok {
+		t.Fatalf("expected no JSON, got %q", got)
+	}
+}
@@ -0,0 +1,510 @@
+// Package jsonschema derives JSON Schemas from Go types and validates decoded
+// JSON values against the subset of JSON Schema it produces.
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	timeType       = reflect.TypeFor[time.Time]()
+	rawMessageType = reflect.TypeFor[json.RawMessage]()
+)
+
+// Reflect returns the JSON Schema for t.
+//
+// Struct fields are named by their json tag and skipped when unexported or
+// tagged "-". Fields tagged omitempty and pointer fields are optional and
+// accept null. A `description:"..."` tag sets the description, an
+// `enum:"a,b,c"` tag lists the allowed values, parsed according to the field
+// kind, and `min:"..."` and `max:"..."` tags bound numbers, string lengths and
+// array lengths.
+//
+// With strict set, the schema follows the OpenAI strict subset: every
+// property is required, with optional fields relying on null, objects
+// disallow additional properties, and maps and interface types are
+// rejected. String length bounds and the base64 encoding of byte slices are
+// left out, since strict mode rejects those keywords; validate against the
+// non-strict schema to enforce them. Recursive types are always rejected.
+func Reflect(t reflect.Type, strict bool) (map[string]any, error) {
+	r := &reflector{strict: strict, visiting: map[reflect.Type]bool{}}
+	return r.schema(t, "")
+}
+
+type reflector struct {
+	strict   bool
+	visiting map[reflect.Type]bool
+}
+
+func (r *reflector) schema(t reflect.Type, path string) (map[string]any, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return map[string]any{"type": "string", "format": "date-time"}, nil
+	case t == rawMessageType:
+		return r.any(path)
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}, nil
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}, nil
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}, nil
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			// encoding/json writes byte slices as base64 strings, and byte
+			// arrays as arrays of numbers.
+			if r.strict {
+				return map[string]any{"type": "string"}, nil
+			}
+			return map[string]any{"type": "string", "contentEncoding": "base64"}, nil
+		}
+		items, err := r.schema(t.Elem(), path+"[]")
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"type": "array", "items": items}, nil
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("%s: map keys must be strings, got %s", describe(path), t.Key())
+		}
+		if r.strict {
+			return nil, fmt.Errorf("%s: maps are not supported by strict schemas", describe(path))
+		}
+		values, err := r.schema(t.Elem(), path+"{}")
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"type": "object", "additionalProperties": values}, nil
+	case reflect.Interface:
+		return r.any(path)
+	case reflect.Struct:
+		return r.object(t, path)
+	default:
+		return nil, fmt.Errorf("%s: unsupported type %s", describe(path), t)
+	}
+}
+
+func (r *reflector) any(path string) (map[string]any, error) {
+	if r.strict {
+		return nil, fmt.Errorf("%s: untyped values are not supported by strict schemas", describe(path))
+	}
+	return map[string]any{}, nil
+}
+
+func (r *reflector) object(t reflect.Type, path string) (map[string]any, error) {
+	if r.visiting[t] {
+		return nil, fmt.Errorf("%s: recursive type %s is not supported", describe(path), t)
+	}
+	r.visiting[t] = true
+	defer delete(r.visiting, t)
+
+	properties := map[string]any{}
+	required := []string{}
+	if err := r.fields(t, path, properties, &required); err != nil {
+		return nil, err
+	}
+	out := map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	if len(required) > 0 {
+		out["required"] = required
+	}
+	return out, nil
+}
+
+func (r *reflector) fields(t reflect.Type, path string, properties map[string]any, required *[]string) error {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				if err := r.fields(embedded, path, properties, required); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fieldPath := name
+		if path != "" {
+			fieldPath = path + "." + name
+		}
+
+		schema, err := r.schema(field.Type, fieldPath)
+		if err != nil {
+			return err
+		}
+		if desc := field.Tag.Get("description"); desc != "" {
+			schema["description"] = desc
+		}
+		if enum := field.Tag.Get("enum"); enum != "" {
+			values, err := enumValues(field.Type, enum)
+			if err != nil {
+				return fmt.Errorf("%s: %w", describe(fieldPath), err)
+			}
+			if items, ok := schema["items"].(map[string]any); ok {
+				items["enum"] = values
+			} else {
+				schema["enum"] = values
+			}
+		}
+		if err := r.applyBounds(schema, field); err != nil {
+			return fmt.Errorf("%s: %w", describe(fieldPath), err)
+		}
+
+		optional := field.Type.Kind() == reflect.Pointer || hasOption(opts, "omitempty") || hasOption(opts, "omitzero")
+		if optional {
+			allowNull(schema)
+		}
+		if !optional || r.strict {
+			*required = append(*required, name)
+		}
+		properties[name] = schema
+	}
+	return nil
+}
+
+func hasOption(opts, want string) bool {
+	for opts != "" {
+		var opt string
+		opt, opts, _ = strings.Cut(opts, ",")
+		if opt == want {
+			return true
+		}
+	}
+	return false
+}
+
+// allowNull widens schema to also accept null, which is how strict schemas
+// express optional fields.
+func allowNull(schema map[string]any) {
+	if typ, ok := schema["type"].(string); ok {
+		schema["type"] = []any{typ, "null"}
+	}
+	if enum, ok := schema["enum"].([]any); ok {
+		schema["enum"] = append(enum, nil)
+	}
+}
+
+func enumValues(t reflect.Type, tag string) ([]any, error) {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	var out []any
+	for _, raw := range strings.Split(tag, ",") {
+		raw = strings.TrimSpace(raw)
+		var (
+			value any
+			err   error
+		)
+		switch t.Kind() {
+		case reflect.String:
+			value = raw
+		case reflect.Bool:
+			value, err = strconv.ParseBool(raw)
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			value, err = strconv.ParseInt(raw, 10, 64)
+		case reflect.Float32, reflect.Float64:
+			value, err = strconv.ParseFloat(raw, 64)
+		default:
+			return nil, fmt.Errorf("enum tag is not supported on %s", t)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("enum value %q: %w", raw, err)
+		}
+		out = append(out, value)
+	}
+	return out, nil
+}
+
+// applyBounds maps the min and max tags to the keyword matching the schema
+// type. Strict schemas get no string length keywords.
+func (r *reflector) applyBounds(schema map[string]any, field reflect.StructField) error {
+	for _, bound := range []struct {
+		tag                        string
+		number, length, itemsCount string
+	}{
+		{"min", "minimum", "minLength", "minItems"},
+		{"max", "maximum", "maxLength", "maxItems"},
+	} {
+		raw, ok := field.Tag.Lookup(bound.tag)
+		if !ok {
+			continue
+		}
+		raw = strings.TrimSpace(raw)
+		switch schema["type"] {
+		case "integer":
+			n, err := strconv.ParseInt(raw, 10, 64)
+			if err != nil {
+				return fmt.Errorf("%s tag %q: %w", bound.tag, raw, err)
+			}
+			schema[bound.number] = n
+		case "number":
+			n, err := strconv.ParseFloat(raw, 64)
+			if err != nil {
+				return fmt.Errorf("%s tag %q: %w", bound.tag, raw, err)
+			}
+			schema[bound.number] = n
+		case "string", "array":
+			n, err := strconv.ParseUint(raw, 10, 63)
+			if err != nil {
+				return fmt.Errorf("%s tag %q: %w", bound.tag, raw, err)
+			}
+			switch {
+			case schema["type"] == "array":
+				schema[bound.itemsCount] = int64(n)
+			case !r.strict:
+				schema[bound.length] = int64(n)
+			}
+		default:
+			return fmt.Errorf("%s tag is not supported on %s", bound.tag, field.Type)
+		}
+	}
+	return nil
+}
+
+func describe(path string) string {
+	if path == "" {
+		return "value"
+	}
+	return path
+}
+
+// Validate checks a value decoded by encoding/json into any against schema.
+// It understands the keywords produced by Reflect: type, properties,
+// required, additionalProperties, items and enum.
+func Validate(schema map[string]any, value any) error {
+	return validate(schema, value, "")
+}
+
+func validate(schema map[string]any, value any, path string) error {
+	if len(schema) == 0 {
+		return nil
+	}
+	if types := schemaTypes(schema["type"]); len(types) > 0 && !matchesType(types, value) {
+		return fmt.Errorf("%s: expected %s, got %s", describe(path), strings.Join(types, " or "), jsonType(value))
+	}
+	if enum, ok := schema["enum"].([]any); ok && !inEnum(enum, value) {
+		return fmt.Errorf("%s: %s is not one of %s", describe(path), compact(value), compact(enum))
+	}
+	if err := validateBounds(schema, value, path); err != nil {
+		return err
+	}
+	switch v := value.(type) {
+	case map[string]any:
+		properties, _ := schema["properties"].(map[string]any)
+		for _, name := range requiredNames(schema["required"]) {
+			if _, ok := v[name]; !ok {
+				return fmt.Errorf("%s: missing required property %q", describe(path), name)
+			}
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			childPath := key
+			if path != "" {
+				childPath = path + "." + key
+			}
+			if prop, ok := properties[key].(map[string]any); ok {
+				if err := validate(prop, v[key], childPath); err != nil {
+					return err
+				}
+				continue
+			}
+			switch extra := schema["additionalProperties"].(type) {
+			case bool:
+				if !extra {
+					return fmt.Errorf("%s: unexpected property %q", describe(path), key)
+				}
+			case map[string]any:
+				if err := validate(extra, v[key], childPath); err != nil {
+					return err
+				}
+			}
+		}
+	case []any:
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range v {
+				if err := validate(items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func validateBounds(schema map[string]any, value any, path string) error {
+	var (
+		actual   float64
+		min, max string
+		noun     string
+	)
+	switch v := value.(type) {
+	case float64:
+		actual, min, max, noun = v, "minimum", "maximum", "value"
+	case json.Number:
+		actual, _ = v.Float64()
+		min, max, noun = "minimum", "maximum", "value"
+	case string:
+		actual, min, max, noun = float64(len([]rune(v))), "minLength", "maxLength", "length"
+	case []any:
+		actual, min, max, noun = float64(len(v)), "minItems", "maxItems", "item count"
+	default:
+		return nil
+	}
+	if bound, ok := numberValue(schema[min]); ok && actual < bound {
+		return fmt.Errorf("%s: %s %v is less than %v", describe(path), noun, actual, bound)
+	}
+	if bound, ok := numberValue(schema[max]); ok && actual > bound {
+		return fmt.Errorf("%s: %s %v is greater than %v", describe(path), noun, actual, bound)
+	}
+	return nil
+}
+
+func numberValue(value any) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func schemaTypes(value any) []string {
+	switch t := value.(type) {
+	case string:
+		return []string{t}
+	case []any:
+		out := make([]string, 0, len(t))
+		for _, item := range t {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	case []string:
+		return t
+	}
+	return nil
+}
+
+func requiredNames(value any) []string {
+	switch t := value.(type) {
+	case []string:
+		return t
+	case []any:
+		out := make([]string, 0, len(t))
+		for _, item := range t {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+func matchesType(types []string, value any) bool {
+	actual := jsonType(value)
+	for _, typ := range types {
+		if typ == actual || (typ == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+func jsonType(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64:
+		if v == float64(int64(v)) {
+			return "integer"
+		}
+		return "number"
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return "integer"
+		}
+		return "number"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func inEnum(enum []any, value any) bool {
+	for _, allowed := range enum {
+		if reflect.DeepEqual(normalizeNumber(allowed), normalizeNumber(value)) {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeNumber(value any) any {
+	switch v := value.(type) {
+	case int64:
+		return float64(v)
+	case int:
+		return float64(v)
+	case json.Number:
+		f, _ := v.Float64()
+		return f
+	}
+	return value
+}
+
+func compact(value any) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
@@ -0,0 +1,180 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type address struct {
+	City string `json:"city"`
+}
+
+type base struct {
+	ID string `json:"id"`
+}
+
+type person struct {
+	base
+	Name     string    `json:"name" description:"Full name"`
+	Age      int       `json:"age,omitempty"`
+	Role     string    `json:"role" enum:"admin,user"`
+	Address  *address  `json:"address"`
+	Tags     []string  `json:"tags"`
+	Born     time.Time `json:"born"`
+	Ignored  string    `json:"-"`
+	internal string
+}
+
+func reflectJSON(t *testing.T, value any, strict bool) string {
+	t.Helper()
+	schema, err := Reflect(reflect.TypeOf(value), strict)
+	if err != nil {
+		t.Fatalf("reflect: %v", err)
+	}
+	data, err := json.Marshal(schema)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	return string(data)
+}
+
+func TestReflect(t *testing.T) {
+	got := reflectJSON(t, person{}, false)
+	want := `{"additionalProperties":false,"properties":{"address":{"additionalProperties":false,"properties":{"city":{"type":"string"}},"required":["city"],"type":["object","null"]},"age":{"type":["integer","null"]},"born":{"format":"date-time","type":"string"},"id":{"type":"string"},"name":{"description":"Full name","type":"string"},"role":{"enum":["admin","user"],"type":"string"},"tags":{"items":{"type":"string"},"type":"array"}},"required":["id","name","role","tags","born"],"type":"object"}`
+	if got != want {
+		t.Fatalf("unexpected schema:\n got %s\nwant %s", got, want)
+	}
+}
+
+func TestReflectStrictRequiresNullableOptionalFields(t *testing.T) {
+	type answer struct {
+		Level int    `json:"level,omitempty" enum:"1,2"`
+		Note  string `json:"note"`
+	}
+	got := reflectJSON(t, answer{}, true)
+	want := `{"additionalProperties":false,"properties":{"level":{"enum":[1,2,null],"type":["integer","null"]},"note":{"type":"string"}},"required":["level","note"],"type":"object"}`
+	if got != want {
+		t.Fatalf("unexpected schema:\n got %s\nwant %s", got, want)
+	}
+}
+
+func TestReflectBoundsAndSliceEnums(t *testing.T) {
+	type query struct {
+		Limit  int      `json:"limit" min:"1" max:"50"`
+		Score  float64  `json:"score" max:"0.5"`
+		Text   string   `json:"text" min:"3"`
+		Fields []string `json:"fields" max:"2" enum:"id,name"`
+	}
+	got := reflectJSON(t, query{}, false)
+	want := `{"additionalProperties":false,"properties":{"fields":{"items":{"enum":["id","name"],"type":"string"},"maxItems":2,"type":"array"},"limit":{"maximum":50,"minimum":1,"type":"integer"},"score":{"maximum":0.5,"type":"number"},"text":{"minLength":3,"type":"string"}},"required":["limit","score","text","fields"],"type":"object"}`
+	if got != want {
+		t.Fatalf("unexpected schema:\n got %s\nwant %s", got, want)
+	}
+	schema, _ := Reflect(reflect.TypeFor[query](), false)
+	for input, wantErr := range map[string]string{
+		`{"limit":0,"score":0,"text":"abc","fields":[]}`:                 "limit: value 0 is less than 1",
+		`{"limit":1,"score":0,"text":"ab","fields":[]}`:                  "text: length 2 is less than 3",
+		`{"limit":1,"score":0,"text":"abc","fields":["id","name","id"]}`: "fields: item count 3 is greater than 2",
+		`{"limit":1,"score":0,"text":"abc","fields":["email"]}`:          `fields[0]: "email" is not one of ["id","name"]`,
+	} {
+		var value any
+		_ = json.Unmarshal([]byte(input), &value)
+		if err := Validate(schema, value); err == nil || err.Error() != wantErr {
+			t.Fatalf("%s: got %v, want %q", input, err, wantErr)
+		}
+	}
+}
+
+func TestReflectStrictOmitsUnsupportedKeywords(t *testing.T) {
+	type upload struct {
+		Name string   `json:"name" min:"1" max:"10"`
+		Data []byte   `json:"data"`
+		Tags []string `json:"tags" max:"3"`
+		Size int      `json:"size" min:"0"`
+	}
+	got := reflectJSON(t, upload{}, true)
+	want := `{"additionalProperties":false,"properties":{"data":{"type":"string"},"name":{"type":"string"},"size":{"minimum":0,"type":"integer"},"tags":{"items":{"type":"string"},"maxItems":3,"type":"array"}},"required":["name","data","tags","size"],"type":"object"}`
+	if got != want {
+		t.Fatalf("unexpected schema:\n got %s\nwant %s", got, want)
+	}
+	if got := reflectJSON(t, upload{}, false); !strings.Contains(got, `"maxLength":10`) || !strings.Contains(got, `"contentEncoding":"base64"`) {
+		t.Fatalf("expected the non-strict schema to keep the keywords, got %s", got)
+	}
+}
+
+func TestReflectByteSlicesAndArrays(t *testing.T) {
+	type digest struct {
+		Data []byte   `json:"data"`
+		Sum  [4]byte  `json:"sum"`
+		Raw  []uint8  `json:"raw"`
+		IDs  [2]int64 `json:"ids"`
+	}
+	got := reflectJSON(t, digest{}, false)
+	want := `{"additionalProperties":false,"properties":{"data":{"contentEncoding":"base64","type":"string"},"ids":{"items":{"type":"integer"},"type":"array"},"raw":{"contentEncoding":"base64","type":"string"},"sum":{"items":{"type":"integer"},"type":"array"}},"required":["data","sum","raw","ids"],"type":"object"}`
+	if got != want {
+		t.Fatalf("unexpected schema:\n got %s\nwant %s", got, want)
+	}
+
+	// The schema matches what encoding/json writes.
+	schema, _ := Reflect(reflect.TypeFor[digest](), false)
+	data, _ := json.Marshal(digest{Data: []byte("hi"), Sum: [4]byte{1, 2, 3, 4}, Raw: []uint8{0}})
+	var value any
+	_ = json.Unmarshal(data, &value)
+	if err := Validate(schema, value); err != nil {
+		t.Fatalf("validate %s: %v", data, err)
+	}
+}
+
+func TestReflectErrors(t *testing.T) {
+	type node struct {
+		Next *node `json:"next"`
+	}
+	type loose struct {
+		Extra map[string]any `json:"extra"`
+	}
+	if _, err := Reflect(reflect.TypeFor[node](), false); err == nil || !strings.Contains(err.Error(), "recursive") {
+		t.Fatalf("expected recursive type error, got %v", err)
+	}
+	if _, err := Reflect(reflect.TypeFor[loose](), true); err == nil || !strings.Contains(err.Error(), "extra") {
+		t.Fatalf("expected strict map error, got %v", err)
+	}
+	if _, err := Reflect(reflect.TypeFor[loose](), false); err != nil {
+		t.Fatalf("expected non-strict map schema, got %v", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	schema, err := Reflect(reflect.TypeFor[person](), false)
+	if err != nil {
+		t.Fatalf("reflect: %v", err)
+	}
+	cases := []struct {
+		input string
+		err   string
+	}{
+		{`{"id":"1","name":"Ada","role":"admin","tags":["x"],"born":"1815-12-10T00:00:00Z","age":36}`, ""},
+		{`{"id":"1","name":"Ada","role":"owner","tags":[],"born":""}`, `role: "owner" is not one of ["admin","user"]`},
+		{`{"id":"1","role":"user","tags":[],"born":""}`, `value: missing required property "name"`},
+		{`{"id":"1","name":"Ada","role":"user","tags":[1],"born":""}`, `tags[0]: expected string, got integer`},
+		{`{"id":"1","name":"Ada","role":"user","tags":[],"born":"","address":{"city":"London","zip":"N1"}}`, `address: unexpected property "zip"`},
+	}
+	for _, tc := range cases {
+		var value any
+		if err := json.Unmarshal([]byte(tc.input), &value); err != nil {
+			t.Fatalf("unmarshal %s: %v", tc.input, err)
+		}
+		err := Validate(schema, value)
+		if tc.err == "" {
+			if err != nil {
+				t.Fatalf("%s: unexpected error %v", tc.input, err)
+			}
+			continue
+		}
+		if err == nil || err.Error() != tc.err {
+			t.Fatalf("%s: got error %v, want %q", tc.input, err, tc.err)
+		}
+	}
+}
@@ -179,11 +179,49 @@ func toUserPart(part chat.Part) (openai.ChatCompletionContentPartUnionParam, err
 		return openai.ImageContentPart(openai.ChatCompletionContentPartImageImageURLParam{
 			URL: dataURL,
 		}), nil
+	case chat.PartTypeAudioBase64:
+		format, err := AudioFormat(part.MIMEType)
+		if err != nil {
+			return openai.ChatCompletionContentPartUnionParam{}, err
+		}
+		return openai.InputAudioContentPart(openai.ChatCompletionContentPartInputAudioInputAudioParam{
+			Data:   strings.TrimSpace(part.DataBase64),
+			Format: format,
+		}), nil
+	case chat.PartTypeAudioURL:
+		return openai.ChatCompletionContentPartUnionParam{}, fmt.Errorf("input_audio requires %q parts; %q is not supported", chat.PartTypeAudioBase64, chat.PartTypeAudioURL)
+	case chat.PartTypeDocumentURL, chat.PartTypeDocumentBase64, chat.PartTypeDocumentText:
+		return openai.ChatCompletionContentPartUnionParam{}, fmt.Errorf("chat completions do not support %q parts; use the openai_resp provider for documents", part.Type)
 	default:
 		return openai.ChatCompletionContentPartUnionParam{}, fmt.Errorf("unsupported part type %q", part.Type)
 	}
 }
 
+// AudioFormat returns the input_audio format for an audio MIME type. OpenAI
+// accepts wav and mp3 input audio.
+func AudioFormat(mimeType string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(mimeType)) {
+	case "audio/wav", "audio/wave", "audio/x-wav", "audio/vnd.wave":
+		return "wav", nil
+	case "audio/mpeg", "audio/mp3":
+		return "mp3", nil
+	default:
+		return "", fmt.Errorf("unsupported input_audio mime_type %q; use audio/wav or audio/mpeg", mimeType)
+	}
+}
+
+// AudioMIMEType returns the MIME type of an input_audio format.
+func AudioMIMEType(format string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case "wav":
+		return "audio/wav", nil
+	case "mp3":
+		return "audio/mpeg", nil
+	default:
+		return "", fmt.Errorf("unsupported input_audio format %q", format)
+	}
+}
+
 // ToToolParams converts chat.Tool slice to OpenAI SDK tool params.
 func ToToolParams(tools []chat.Tool) ([]openai.ChatCompletionToolUnionParam, error) {
 	out := make([]openai.ChatCompletionToolUnionParam, 0, len(tools))
@@ -506,6 +544,35 @@ func SetResponseFormatByType(params *openai.ChatCompletionNewParams, typeName st
 	}
 }
 
+// ApplyChatResponseFormat sets the response format on params from the
+// provider-neutral chat.ResponseFormat. Raw response_format options applied
+// afterwards override it.
+func ApplyChatResponseFormat(params *openai.ChatCompletionNewParams, format *chat.ResponseFormat) error {
+	if params == nil || format == nil {
+		return nil
+	}
+	if format.Type == chat.ResponseFormatJSONObject {
+		params.ResponseFormat = openai.ChatCompletionNewParamsResponseFormatUnion{
+			OfJSONObject: &shared.ResponseFormatJSONObjectParam{Type: "json_object"},
+		}
+		return nil
+	}
+	schema, err := format.SchemaMap()
+	if err != nil {
+		return err
+	}
+	params.ResponseFormat = openai.ChatCompletionNewParamsResponseFormatUnion{
+		OfJSONSchema: &shared.ResponseFormatJSONSchemaParam{
+			JSONSchema: shared.ResponseFormatJSONSchemaJSONSchemaParam{
+				Name:   format.ToolName(),
+				Schema: schema,
+				Strict: openai.Bool(format.Strict),
+			},
+		},
+	}
+	return nil
+}
+
 // ParseLogitBias extracts a map[string]int64 from a raw option value.
 func ParseLogitBias(value any) map[string]int64 {
 	out := map[string]int64{}
@@ -0,0 +1,60 @@
+package oaicompat
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	openai "github.com/openai/openai-go/v3"
+	"github.com/openai/openai-go/v3/packages/ssestream"
+	"github.com/quailyquaily/uniai/internal/apierror"
+)
+
+// WrapError converts an openai-go API or stream error into an *apierror.Error
+// for the given provider. Other errors are returned unchanged.
+func WrapError(provider string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var existing *apierror.Error
+	if errors.As(err, &existing) {
+		return err
+	}
+	var streamErr *ssestream.StreamError
+	if errors.As(err, &streamErr) {
+		out := apierror.FromResponse(provider, 0, nil, streamErr.Event.Data)
+		out.Err = err
+		return out
+	}
+	var sdkErr *openai.Error
+	if !errors.As(err, &sdkErr) {
+		return err
+	}
+
+	raw := strings.TrimSpace(sdkErr.RawJSON())
+	var header http.Header
+	if sdkErr.Response != nil {
+		header = sdkErr.Response.Header
+	}
+	statusCode := sdkErr.StatusCode
+	if statusCode == 0 && sdkErr.Response != nil {
+		statusCode = sdkErr.Response.StatusCode
+	}
+	out := &apierror.Error{
+		Provider:   provider,
+		StatusCode: statusCode,
+		Code:       sdkErr.Code,
+		Type:       sdkErr.Type,
+		Message:    strings.TrimSpace(sdkErr.Message),
+		RequestID:  apierror.RequestID(header),
+		RetryAfter: apierror.RetryAfter(header, time.Now()),
+		Body:       []byte(raw),
+		Err:        err,
+	}
+	if out.Message == "" {
+		out.Message = raw
+	}
+	out.Category = apierror.Classify(out.StatusCode, out.Code, out.Type, out.Message)
+	return out
+}
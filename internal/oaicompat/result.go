@@ -19,7 +19,11 @@ func ChatCompletionToResult(resp *openai.ChatCompletion) *chat.Result {
 	parts := make([]chat.Part, 0, 1)
 	messages := make([]chat.Message, 0, len(resp.Choices))
 	var toolCalls []chat.ToolCall
+	finishReason := ""
 	for _, choice := range resp.Choices {
+		if finishReason == "" {
+			finishReason = choice.FinishReason
+		}
 		messageToolCalls := ToToolCalls(choice.Message.ToolCalls)
 		content := choice.Message.Content
 		if normalized, ok := jsonoutput.NormalizeSingleJSONContent(content); ok {
@@ -43,7 +47,7 @@ func ChatCompletionToResult(resp *openai.ChatCompletion) *chat.Result {
 		parts = append(parts, chat.TextPart(text))
 	}
 
-	return &chat.Result{
+	result := &chat.Result{
 		Text:      text,
 		Parts:     parts,
 		Model:     resp.Model,
@@ -52,6 +56,8 @@ func ChatCompletionToResult(resp *openai.ChatCompletion) *chat.Result {
 		Usage:     ChatCompletionUsageToChatUsage(resp.Usage),
 		Raw:       resp,
 	}
+	result.SetFinishReason(finishReason)
+	return result
 }
 
 func reasoningContentFromRawJSON(raw string) string {
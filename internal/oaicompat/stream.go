@@ -40,6 +40,7 @@ func consumeChatCompletionStream(stream *ssestream.Stream[openai.ChatCompletionC
 	acc := openai.ChatCompletionAccumulator{}
 	toolCalls := streamToolCallAccumulator{}
 	var finalUsage *chat.Usage
+	var finishReason string
 	var reasoningContent strings.Builder
 	rawChunks := make([]openai.ChatCompletionChunk, 0)
 
@@ -55,6 +56,9 @@ func consumeChatCompletionStream(stream *ssestream.Stream[openai.ChatCompletionC
 		if len(chunk.Choices) == 0 {
 			continue
 		}
+		if reason := chunk.Choices[0].FinishReason; reason != "" {
+			finishReason = reason
+		}
 
 		if content := reasoningContentFromRawJSON(chunk.Choices[0].Delta.RawJSON()); content != "" {
 			reasoningContent.WriteString(content)
@@ -114,12 +118,15 @@ func consumeChatCompletionStream(stream *ssestream.Stream[openai.ChatCompletionC
 		result.Usage = *finalUsage
 	}
 	result.Raw = rawChunks
+	result.SetFinishReason(finishReason)
 
 	if onStream != nil {
 		if err := onStream(chat.StreamEvent{
-			Done:  true,
-			Usage: &result.Usage,
-			Raw:   rawChunks,
+			Done:            true,
+			Usage:           &result.Usage,
+			Raw:             rawChunks,
+			FinishReason:    result.FinishReason,
+			RawFinishReason: result.RawFinishReason,
 		}); err != nil {
 			return nil, err
 		}
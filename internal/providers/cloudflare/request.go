@@ -6,8 +6,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 
+	"github.com/quailyquaily/uniai/internal/apierror"
 	"github.com/quailyquaily/uniai/internal/httputil"
 )
 
@@ -70,7 +72,7 @@ func RunWithHeaders(ctx context.Context, token, base, accountID, model string, h
 		return nil, err
 	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("cloudflare API request failed with status %d: %s", resp.StatusCode, string(respData))
+		return nil, apierror.FromResponse("cloudflare", resp.StatusCode, resp.Header, respData)
 	}
 
 	var envelope apiEnvelope
@@ -78,14 +80,26 @@ func RunWithHeaders(ctx context.Context, token, base, accountID, model string, h
 		return nil, err
 	}
 	if !envelope.Success {
-		return nil, fmt.Errorf("cloudflare API request failed: %s", formatMessages(envelope.Errors, envelope.Messages))
+		return nil, envelopeError(resp.Header, respData, envelope.Errors, formatMessages(envelope.Errors, envelope.Messages))
 	}
 	if len(envelope.Errors) > 0 {
-		return nil, fmt.Errorf("cloudflare API errors: %s", formatMessages(envelope.Errors, nil))
+		return nil, envelopeError(resp.Header, respData, envelope.Errors, formatMessages(envelope.Errors, nil))
 	}
 	return envelope.Result, nil
 }
 
+// envelopeError reports a 200 response whose envelope carries errors.
+func envelopeError(header http.Header, body []byte, errs []apiMessage, message string) error {
+	code := ""
+	if len(errs) > 0 && errs[0].Code != 0 {
+		code = strconv.Itoa(errs[0].Code)
+	}
+	apiErr := apierror.New("cloudflare", 0, code, "", message)
+	apiErr.RequestID = apierror.RequestID(header)
+	apiErr.Body = body
+	return apiErr
+}
+
 func buildRunURL(base, accountID, model string) (string, error) {
 	if accountID == "" {
 		return "", fmt.Errorf("cloudflare account id is required")
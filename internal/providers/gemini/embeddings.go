@@ -12,6 +12,7 @@ import (
 	"strings"
 
 	"github.com/lyricat/goutils/structs"
+	"github.com/quailyquaily/uniai/internal/apierror"
 	"github.com/quailyquaily/uniai/internal/httputil"
 )
 
@@ -122,7 +123,7 @@ func CreateEmbeddings(ctx context.Context, token, base, model string, inputs []s
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("gemini API request failed with status %d: %s", resp.StatusCode, string(respData))
+		return nil, apierror.FromResponse("gemini", resp.StatusCode, resp.Header, respData)
 	}
 
 	var batchResp geminiBatchResponse
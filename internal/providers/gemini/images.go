@@ -13,6 +13,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/quailyquaily/uniai/internal/apierror"
 	"github.com/quailyquaily/uniai/internal/httputil"
 
 	"github.com/lyricat/goutils/structs"
@@ -366,7 +367,7 @@ func geminiPredictImagen(ctx context.Context, token string, geminiInput *GeminiC
 		return nil, nil, readErr
 	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, body, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+		return nil, body, apierror.FromResponse("gemini", resp.StatusCode, resp.Header, body)
 	}
 
 	var data struct {
@@ -492,7 +493,7 @@ func geminiGenerateContentOnce(ctx context.Context, token, model, prompt string,
 		return nil, nil, readErr
 	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, body, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+		return nil, body, apierror.FromResponse("gemini", resp.StatusCode, resp.Header, body)
 	}
 
 	var data struct {
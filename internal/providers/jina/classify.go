@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"net/http"
 
+	"github.com/quailyquaily/uniai/internal/apierror"
 	"github.com/quailyquaily/uniai/internal/httputil"
 )
 
@@ -77,7 +78,7 @@ func Classify(ctx context.Context, token, base, model string, labels []string, i
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("jina API request failed with status %d: %s", resp.StatusCode, string(respData))
+		return nil, apierror.FromResponse("jina", resp.StatusCode, resp.Header, respData)
 	}
 
 	return respData, nil
@@ -8,6 +8,7 @@ import (
 	"net/http"
 
 	"github.com/lyricat/goutils/structs"
+	"github.com/quailyquaily/uniai/internal/apierror"
 	"github.com/quailyquaily/uniai/internal/httputil"
 )
 
@@ -80,7 +81,7 @@ func CreateEmbeddings(ctx context.Context, token, base, model string, inputs []E
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("jina API request failed with status %d: %s", resp.StatusCode, string(respData))
+		return nil, apierror.FromResponse("jina", resp.StatusCode, resp.Header, respData)
 	}
 
 	return respData, nil
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"net/http"
 
+	"github.com/quailyquaily/uniai/internal/apierror"
 	"github.com/quailyquaily/uniai/internal/httputil"
 )
 
@@ -85,7 +86,7 @@ func Rerank(ctx context.Context, token, base, model, query string, docs []Rerank
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("jina API request failed with status %d: %s", resp.StatusCode, string(respData))
+		return nil, apierror.FromResponse("jina", resp.StatusCode, resp.Header, respData)
 	}
 
 	return respData, nil
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/quailyquaily/uniai/internal/apierror"
 	"github.com/quailyquaily/uniai/internal/httputil"
 )
 
@@ -41,7 +42,7 @@ func doRequestWithContentType(ctx context.Context, token, base, method, path, co
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return respData, fmt.Errorf("openai API request failed with status %d: %s", resp.StatusCode, string(respData))
+		return respData, apierror.FromResponse("openai", resp.StatusCode, resp.Header, respData)
 	}
 
 	return respData, nil
@@ -0,0 +1,308 @@
+// Package ratelimit implements the client-side requests-per-minute and
+// tokens-per-minute limiter used by chat calls.
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrLimited is matched (errors.Is) by errors returned when a request does
+// not fit the client-side budget in fail-fast mode.
+var ErrLimited = errors.New("client-side rate limit exceeded")
+
+// Error reports a request rejected by the limiter in fail-fast mode.
+type Error struct {
+	Key string
+	// RetryAfter is the estimated wait until the request would fit.
+	RetryAfter time.Duration
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s (retry after %s)", e.Key, ErrLimited.Error(), e.RetryAfter.Round(time.Millisecond))
+}
+
+func (e *Error) Is(target error) bool {
+	return target == ErrLimited
+}
+
+// Limit is a per-minute budget. Zero fields are unlimited.
+type Limit struct {
+	RequestsPerMinute int
+	TokensPerMinute   int
+}
+
+func (l Limit) unlimited() bool {
+	return l.RequestsPerMinute <= 0 && l.TokensPerMinute <= 0
+}
+
+// Config configures a Limiter.
+type Config struct {
+	// Limits maps "provider/model" or "provider" to a budget. The most
+	// specific entry wins. Every provider/model pair gets its own budget.
+	Limits map[string]Limit
+	// Default applies to provider/model pairs without an entry in Limits.
+	Default Limit
+	// FailFast makes Acquire return an *Error right away instead of waiting
+	// for budget to become available.
+	FailFast bool
+}
+
+// Limiter enforces per provider/model budgets. It is safe for concurrent use
+// and may be shared by several clients.
+type Limiter struct {
+	cfg Config
+	now func() time.Time
+
+	mu   sync.Mutex
+	keys map[string]*keyState
+	seq  uint64
+}
+
+// New returns a Limiter for cfg.
+func New(cfg Config) *Limiter {
+	limits := make(map[string]Limit, len(cfg.Limits))
+	for key, limit := range cfg.Limits {
+		limits[key] = limit
+	}
+	cfg.Limits = limits
+	return &Limiter{
+		cfg:  cfg,
+		now:  time.Now,
+		keys: make(map[string]*keyState),
+	}
+}
+
+// Reservation is the budget taken by one request.
+type Reservation struct {
+	limiter *Limiter
+	state   *keyState
+	tokens  int
+}
+
+// Reconcile replaces the estimated token count with the actual usage, giving
+// back over-estimated tokens or charging the difference.
+func (r *Reservation) Reconcile(actualTokens int) {
+	if r == nil || r.state == nil || r.state.tokens == nil || actualTokens < 0 {
+		return
+	}
+	r.limiter.mu.Lock()
+	defer r.limiter.mu.Unlock()
+	r.state.tokens.refill(r.limiter.now())
+	r.state.tokens.available -= float64(actualTokens - r.tokens)
+	r.state.tokens.clamp()
+	r.tokens = actualTokens
+	r.state.wakeHead()
+}
+
+type keyState struct {
+	requests *bucket
+	tokens   *bucket
+	waiters  []*waiter
+}
+
+type waiter struct {
+	priority int
+	seq      uint64
+	wake     chan struct{}
+}
+
+// Acquire takes one request and the estimated tokens from the budget of
+// provider/model. Higher priorities are served first; equal priorities are
+// served in arrival order. It blocks until the budget allows the request or
+// ctx is done, unless the limiter is in fail-fast mode.
+func (l *Limiter) Acquire(ctx context.Context, provider, model string, tokens, priority int) (*Reservation, error) {
+	if l == nil {
+		return nil, nil
+	}
+	key := provider + "/" + model
+	limit := l.limitFor(provider, key)
+	if limit.unlimited() {
+		return nil, nil
+	}
+	if tokens < 0 {
+		tokens = 0
+	}
+
+	l.mu.Lock()
+	state := l.state(key, limit)
+	// A request larger than the whole budget would never fit; let it through
+	// once the bucket is full instead.
+	if state.tokens != nil && float64(tokens) > state.tokens.capacity {
+		tokens = int(state.tokens.capacity)
+	}
+	l.seq++
+	w := &waiter{priority: priority, seq: l.seq, wake: make(chan struct{}, 1)}
+	state.enqueue(w)
+	for {
+		now := l.now()
+		wait := state.wait(tokens, now)
+		if state.waiters[0] == w && wait <= 0 {
+			state.take(tokens, now)
+			state.remove(w)
+			state.wakeHead()
+			l.mu.Unlock()
+			return &Reservation{limiter: l, state: state, tokens: tokens}, nil
+		}
+		if l.cfg.FailFast {
+			state.remove(w)
+			state.wakeHead()
+			l.mu.Unlock()
+			if wait <= 0 {
+				wait = time.Millisecond
+			}
+			return nil, &Error{Key: key, RetryAfter: wait}
+		}
+		// Only the head waits for the budget to refill; the others wait to
+		// be woken when the head changes.
+		var timer *time.Timer
+		var refilled <-chan time.Time
+		if state.waiters[0] == w {
+			timer = time.NewTimer(wait)
+			refilled = timer.C
+		}
+		l.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			if timer != nil {
+				timer.Stop()
+			}
+			l.mu.Lock()
+			state.remove(w)
+			state.wakeHead()
+			l.mu.Unlock()
+			return nil, ctx.Err()
+		case <-refilled:
+		case <-w.wake:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		l.mu.Lock()
+	}
+}
+
+func (l *Limiter) limitFor(provider, key string) Limit {
+	if limit, ok := l.cfg.Limits[key]; ok {
+		return limit
+	}
+	if limit, ok := l.cfg.Limits[provider]; ok {
+		return limit
+	}
+	return l.cfg.Default
+}
+
+func (l *Limiter) state(key string, limit Limit) *keyState {
+	if state, ok := l.keys[key]; ok {
+		return state
+	}
+	now := l.now()
+	state := &keyState{
+		requests: newBucket(limit.RequestsPerMinute, now),
+		tokens:   newBucket(limit.TokensPerMinute, now),
+	}
+	l.keys[key] = state
+	return state
+}
+
+func (s *keyState) enqueue(w *waiter) {
+	s.waiters = append(s.waiters, w)
+	sort.SliceStable(s.waiters, func(i, j int) bool {
+		if s.waiters[i].priority != s.waiters[j].priority {
+			return s.waiters[i].priority > s.waiters[j].priority
+		}
+		return s.waiters[i].seq < s.waiters[j].seq
+	})
+	if s.waiters[0] == w && len(s.waiters) > 1 {
+		// The previous head is now waiting on its wake channel only.
+		s.waiters[1].notify()
+	}
+}
+
+func (s *keyState) remove(w *waiter) {
+	for i, candidate := range s.waiters {
+		if candidate == w {
+			s.waiters = append(s.waiters[:i], s.waiters[i+1:]...)
+			return
+		}
+	}
+}
+
+func (s *keyState) wakeHead() {
+	if len(s.waiters) > 0 {
+		s.waiters[0].notify()
+	}
+}
+
+func (w *waiter) notify() {
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *keyState) wait(tokens int, now time.Time) time.Duration {
+	return max(s.requests.wait(1, now), s.tokens.wait(tokens, now))
+}
+
+func (s *keyState) take(tokens int, now time.Time) {
+	s.requests.take(1, now)
+	s.tokens.take(tokens, now)
+}
+
+// bucket is a token bucket refilled continuously at capacity per minute. A
+// nil bucket is unlimited.
+type bucket struct {
+	capacity  float64
+	available float64
+	last      time.Time
+}
+
+func newBucket(perMinute int, now time.Time) *bucket {
+	if perMinute <= 0 {
+		return nil
+	}
+	return &bucket{capacity: float64(perMinute), available: float64(perMinute), last: now}
+}
+
+func (b *bucket) refill(now time.Time) {
+	if b == nil {
+		return
+	}
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.available += b.capacity * elapsed.Minutes()
+		b.last = now
+	}
+	b.clamp()
+}
+
+func (b *bucket) clamp() {
+	if b.available > b.capacity {
+		b.available = b.capacity
+	}
+}
+
+func (b *bucket) wait(n int, now time.Time) time.Duration {
+	if b == nil {
+		return 0
+	}
+	b.refill(now)
+	missing := float64(n) - b.available
+	if missing <= 0 {
+		return 0
+	}
+	return time.Duration(missing / b.capacity * float64(time.Minute))
+}
+
+func (b *bucket) take(n int, now time.Time) {
+	if b == nil {
+		return
+	}
+	b.refill(now)
+	b.available -= float64(n)
+}
@@ -0,0 +1,137 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAcquireFailFast(t *testing.T) {
+	l := New(Config{
+		Limits:   map[string]Limit{"openai/gpt-4.1": {RequestsPerMinute: 2}},
+		FailFast: true,
+	})
+	for i := 0; i < 2; i++ {
+		if _, err := l.Acquire(context.Background(), "openai", "gpt-4.1", 10, 0); err != nil {
+			t.Fatalf("acquire %d: %v", i, err)
+		}
+	}
+	_, err := l.Acquire(context.Background(), "openai", "gpt-4.1", 10, 0)
+	var limitErr *Error
+	if !errors.Is(err, ErrLimited) || !errors.As(err, &limitErr) {
+		t.Fatalf("expected limit error, got %v", err)
+	}
+	if limitErr.Key != "openai/gpt-4.1" || limitErr.RetryAfter <= 0 || limitErr.RetryAfter > 30*time.Second {
+		t.Fatalf("unexpected limit error: %#v", limitErr)
+	}
+
+	// Other models and unconfigured providers have their own budgets.
+	if _, err := l.Acquire(context.Background(), "openai", "gpt-4.1-mini", 10, 0); err != nil {
+		t.Fatalf("expected unconfigured model to be unlimited, got %v", err)
+	}
+}
+
+func TestAcquireUsesMostSpecificLimit(t *testing.T) {
+	l := New(Config{
+		Limits: map[string]Limit{
+			"anthropic":                 {TokensPerMinute: 100},
+			"anthropic/claude-big-test": {TokensPerMinute: 1000},
+		},
+		Default:  Limit{RequestsPerMinute: 1},
+		FailFast: true,
+	})
+	if _, err := l.Acquire(context.Background(), "anthropic", "claude-big-test", 500, 0); err != nil {
+		t.Fatalf("expected model limit to apply: %v", err)
+	}
+	if _, err := l.Acquire(context.Background(), "anthropic", "claude-small-test", 500, 0); err != nil {
+		t.Fatalf("expected oversized request to pass on a full bucket: %v", err)
+	}
+	if _, err := l.Acquire(context.Background(), "anthropic", "claude-small-test", 1, 0); !errors.Is(err, ErrLimited) {
+		t.Fatalf("expected provider limit to apply, got %v", err)
+	}
+	if _, err := l.Acquire(context.Background(), "gemini", "gemini-test", 1, 0); err != nil {
+		t.Fatalf("default acquire: %v", err)
+	}
+	if _, err := l.Acquire(context.Background(), "gemini", "gemini-test", 1, 0); !errors.Is(err, ErrLimited) {
+		t.Fatalf("expected default limit to apply, got %v", err)
+	}
+}
+
+func TestReservationReconcile(t *testing.T) {
+	l := New(Config{Default: Limit{TokensPerMinute: 1000}, FailFast: true})
+	now := time.Unix(0, 0)
+	l.now = func() time.Time { return now }
+
+	res, err := l.Acquire(context.Background(), "openai", "m", 900, 0)
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	if _, err := l.Acquire(context.Background(), "openai", "m", 200, 0); !errors.Is(err, ErrLimited) {
+		t.Fatalf("expected estimate to exhaust budget, got %v", err)
+	}
+	res.Reconcile(100)
+	if _, err := l.Acquire(context.Background(), "openai", "m", 800, 0); err != nil {
+		t.Fatalf("expected refunded tokens to be available: %v", err)
+	}
+	res.Reconcile(400)
+	if _, err := l.Acquire(context.Background(), "openai", "m", 1, 0); !errors.Is(err, ErrLimited) {
+		t.Fatalf("expected extra usage to be charged, got %v", err)
+	}
+}
+
+func TestAcquireBlocksAndHonorsContext(t *testing.T) {
+	l := New(Config{Default: Limit{TokensPerMinute: 6000}})
+	ctx := context.Background()
+	if _, err := l.Acquire(ctx, "openai", "m", 6000, 0); err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+
+	start := time.Now()
+	if _, err := l.Acquire(ctx, "openai", "m", 5, 0); err != nil {
+		t.Fatalf("blocking acquire: %v", err)
+	}
+	if waited := time.Since(start); waited < 40*time.Millisecond {
+		t.Fatalf("expected to wait for refill, waited %v", waited)
+	}
+
+	short, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if _, err := l.Acquire(short, "openai", "m", 6000, 0); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestAcquirePriority(t *testing.T) {
+	l := New(Config{Default: Limit{TokensPerMinute: 6000}})
+	ctx := context.Background()
+	if _, err := l.Acquire(ctx, "openai", "m", 6000, 0); err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+
+	var (
+		mu    sync.Mutex
+		order []string
+		wg    sync.WaitGroup
+	)
+	run := func(name string, priority int) {
+		defer wg.Done()
+		if _, err := l.Acquire(ctx, "openai", "m", 5, priority); err != nil {
+			t.Errorf("%s: %v", name, err)
+			return
+		}
+		mu.Lock()
+		order = append(order, name)
+		mu.Unlock()
+	}
+	wg.Add(2)
+	go run("batch", -1)
+	time.Sleep(10 * time.Millisecond)
+	go run("interactive", 1)
+	wg.Wait()
+
+	if len(order) != 2 || order[0] != "interactive" {
+		t.Fatalf("expected interactive first, got %v", order)
+	}
+}
@@ -0,0 +1,173 @@
+// Package retry implements the client-side retry policy shared by chat and
+// the embedding/image/rerank/classify/audio clients.
+package retry
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net"
+	"time"
+
+	"github.com/quailyquaily/uniai/internal/apierror"
+)
+
+const (
+	DefaultBaseBackoff = 500 * time.Millisecond
+	DefaultMaxBackoff  = 30 * time.Second
+)
+
+// DefaultRetryOn lists the categories retried when Policy.RetryOn is empty.
+var DefaultRetryOn = []error{apierror.ErrRateLimited, apierror.ErrServerError}
+
+// Policy configures retries. The zero value makes a single attempt.
+type Policy struct {
+	// MaxAttempts is the total number of attempts, including the first one.
+	// Values <= 1 disable retries.
+	MaxAttempts int
+	// BaseBackoff is the delay before the first retry; it doubles on every
+	// further attempt. Defaults to DefaultBaseBackoff.
+	BaseBackoff time.Duration
+	// MaxBackoff caps the computed delay. A provider Retry-After longer than
+	// MaxBackoff ends retrying instead of waiting. Defaults to DefaultMaxBackoff.
+	MaxBackoff time.Duration
+	// Jitter randomly shortens each computed delay by up to this fraction
+	// (0..1) to spread out concurrent retries.
+	Jitter float64
+	// RetryOn lists the error categories (uniai.ErrRateLimited,
+	// uniai.ErrServerError, ...) that are retried. Transport errors such as
+	// timeouts and connection resets count as ErrServerError.
+	// Defaults to DefaultRetryOn.
+	RetryOn []error
+}
+
+// Enabled reports whether the policy allows more than one attempt.
+func (p Policy) Enabled() bool {
+	return p.MaxAttempts > 1
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err as not retryable regardless of its category.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// Do calls fn until it succeeds, returns an error that is not retryable, or
+// the policy runs out of attempts. It returns the error of every failed
+// attempt (in order) along with the final error.
+func Do(ctx context.Context, p Policy, fn func() error) ([]error, error) {
+	maxAttempts := p.MaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	var attemptErrs []error
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil {
+			return attemptErrs, nil
+		}
+		var permanent *permanentError
+		if errors.As(err, &permanent) {
+			err = permanent.err
+			return append(attemptErrs, err), err
+		}
+		attemptErrs = append(attemptErrs, err)
+		if attempt >= maxAttempts || !p.Retryable(err) {
+			return attemptErrs, err
+		}
+		delay, ok := p.Delay(attempt, err)
+		if !ok {
+			return attemptErrs, err
+		}
+		if err := sleep(ctx, delay); err != nil {
+			return attemptErrs, errors.Join(attemptErrs[len(attemptErrs)-1], err)
+		}
+	}
+}
+
+// Retryable reports whether err falls into one of the policy categories.
+func (p Policy) Retryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	retryOn := p.RetryOn
+	if len(retryOn) == 0 {
+		retryOn = DefaultRetryOn
+	}
+	var apiErr *apierror.Error
+	isAPIErr := errors.As(err, &apiErr)
+	for _, category := range retryOn {
+		if errors.Is(err, category) {
+			return true
+		}
+		if !isAPIErr && category == apierror.ErrServerError && isTransportError(err) {
+			return true
+		}
+	}
+	return false
+}
+
+// Delay returns how long to wait after the given failed attempt (1-based).
+// It returns false when the provider asked for a longer wait than MaxBackoff.
+func (p Policy) Delay(attempt int, err error) (time.Duration, bool) {
+	base := p.BaseBackoff
+	if base <= 0 {
+		base = DefaultBaseBackoff
+	}
+	maxBackoff := p.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = DefaultMaxBackoff
+	}
+
+	var apiErr *apierror.Error
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+		if apiErr.RetryAfter > maxBackoff {
+			return 0, false
+		}
+		return apiErr.RetryAfter, true
+	}
+
+	delay := base
+	for i := 1; i < attempt && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > maxBackoff {
+		delay = maxBackoff
+	}
+	if jitter := min(max(p.Jitter, 0), 1); jitter > 0 {
+		delay -= time.Duration(rand.Float64() * jitter * float64(delay))
+	}
+	return delay, true
+}
+
+func isTransportError(err error) bool {
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr)
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
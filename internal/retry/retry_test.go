@@ -0,0 +1,127 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/quailyquaily/uniai/internal/apierror"
+)
+
+func TestDoRetriesRetryableCategories(t *testing.T) {
+	policy := Policy{MaxAttempts: 3, BaseBackoff: time.Millisecond}
+	calls := 0
+	attemptErrs, err := Do(context.Background(), policy, func() error {
+		calls++
+		if calls < 3 {
+			return apierror.New("openai", http.StatusServiceUnavailable, "", "", "busy")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != 3 || len(attemptErrs) != 2 {
+		t.Fatalf("expected 3 calls and 2 attempt errors, got %d and %d", calls, len(attemptErrs))
+	}
+}
+
+func TestDoStopsOnNonRetryableError(t *testing.T) {
+	policy := Policy{MaxAttempts: 5, BaseBackoff: time.Millisecond}
+	calls := 0
+	_, err := Do(context.Background(), policy, func() error {
+		calls++
+		return apierror.New("openai", http.StatusBadRequest, "", "", "bad")
+	})
+	if !errors.Is(err, apierror.ErrInvalidRequest) || calls != 1 {
+		t.Fatalf("expected a single invalid request attempt, got %d calls: %v", calls, err)
+	}
+}
+
+func TestDoHonorsRetryOn(t *testing.T) {
+	policy := Policy{MaxAttempts: 2, BaseBackoff: time.Millisecond, RetryOn: []error{apierror.ErrServerError}}
+	calls := 0
+	_, err := Do(context.Background(), policy, func() error {
+		calls++
+		return apierror.New("openai", http.StatusTooManyRequests, "", "", "slow down")
+	})
+	if !errors.Is(err, apierror.ErrRateLimited) || calls != 1 {
+		t.Fatalf("expected rate limit not to be retried, got %d calls: %v", calls, err)
+	}
+}
+
+func TestDoPermanentErrorStopsRetrying(t *testing.T) {
+	policy := Policy{MaxAttempts: 3, BaseBackoff: time.Millisecond}
+	cause := apierror.New("openai", http.StatusBadGateway, "", "", "bad gateway")
+	calls := 0
+	attemptErrs, err := Do(context.Background(), policy, func() error {
+		calls++
+		return Permanent(cause)
+	})
+	if err != cause || calls != 1 || len(attemptErrs) != 1 {
+		t.Fatalf("unexpected result: calls=%d attempts=%d err=%v", calls, len(attemptErrs), err)
+	}
+}
+
+func TestDoRespectsContextWhileWaiting(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	policy := Policy{MaxAttempts: 3, BaseBackoff: time.Hour, MaxBackoff: time.Hour}
+	_, err := Do(ctx, policy, func() error {
+		cancel()
+		return apierror.New("openai", http.StatusInternalServerError, "", "", "boom")
+	})
+	if !errors.Is(err, context.Canceled) || !errors.Is(err, apierror.ErrServerError) {
+		t.Fatalf("expected canceled server error, got %v", err)
+	}
+}
+
+func TestDelay(t *testing.T) {
+	policy := Policy{BaseBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+	plain := errors.New("boom")
+	for attempt, want := range map[int]time.Duration{1: 100 * time.Millisecond, 2: 200 * time.Millisecond, 3: 400 * time.Millisecond, 5: time.Second} {
+		if got, ok := policy.Delay(attempt, plain); !ok || got != want {
+			t.Fatalf("attempt %d: expected %v, got %v", attempt, want, got)
+		}
+	}
+
+	apiErr := apierror.New("anthropic", http.StatusTooManyRequests, "", "", "slow down")
+	apiErr.RetryAfter = 750 * time.Millisecond
+	if got, ok := policy.Delay(1, fmt.Errorf("wrapped: %w", apiErr)); !ok || got != 750*time.Millisecond {
+		t.Fatalf("expected Retry-After delay, got %v", got)
+	}
+	apiErr.RetryAfter = time.Minute
+	if _, ok := policy.Delay(1, apiErr); ok {
+		t.Fatalf("expected Retry-After above MaxBackoff to stop retrying")
+	}
+
+	jittered := Policy{BaseBackoff: 100 * time.Millisecond, Jitter: 0.5}
+	for i := 0; i < 20; i++ {
+		got, _ := jittered.Delay(1, plain)
+		if got < 50*time.Millisecond || got > 100*time.Millisecond {
+			t.Fatalf("jittered delay out of range: %v", got)
+		}
+	}
+}
+
+func TestRetryableTransportErrors(t *testing.T) {
+	policy := Policy{}
+	var netErr error = &timeoutError{}
+	if !policy.Retryable(fmt.Errorf("post: %w", netErr)) {
+		t.Fatalf("expected transport error to be retryable")
+	}
+	if policy.Retryable(context.DeadlineExceeded) {
+		t.Fatalf("expected context deadline not to be retryable")
+	}
+	if (Policy{RetryOn: []error{apierror.ErrRateLimited}}).Retryable(netErr) {
+		t.Fatalf("expected transport error to follow ErrServerError")
+	}
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
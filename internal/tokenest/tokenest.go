@@ -0,0 +1,139 @@
+// Package tokenest provides a rough, provider-agnostic token estimate used
+// where an exact count is unavailable or too expensive to fetch.
+package tokenest
+
+import (
+	"encoding/json"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/quailyquaily/uniai/chat"
+)
+
+const (
+	// messageOverhead approximates the role and separator tokens added per
+	// message by chat templates.
+	messageOverhead = 4
+	// imageTokens is a flat estimate for an image part.
+	imageTokens = 85
+	// audioTokens is a flat estimate for an audio part, about half a minute
+	// of speech.
+	audioTokens = 750
+	// documentTokens is a flat estimate for a document file part, a few
+	// pages of a PDF. Plain-text documents count by their text.
+	documentTokens = 3000
+)
+
+// Text estimates the token count of s: about four bytes per token for ASCII
+// text and one token per rune for other scripts.
+func Text(s string) int {
+	ascii, other := 0, 0
+	for i := 0; i < len(s); {
+		if s[i] < utf8.RuneSelf {
+			ascii++
+			i++
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(s[i:])
+		other++
+		i += size
+	}
+	return (ascii+3)/4 + other
+}
+
+// Request estimates the input tokens of a chat request, including messages,
+// tool calls and tool definitions.
+func Request(req *chat.Request) int {
+	if req == nil {
+		return 0
+	}
+	total := 0
+	for _, msg := range req.Messages {
+		total += messageOverhead + Text(msg.Content) + Text(msg.ReasoningContent)
+		for _, part := range msg.Parts {
+			switch part.Type {
+			case chat.PartTypeText:
+				total += Text(part.Text)
+			case chat.PartTypeImageURL, chat.PartTypeImageBase64:
+				total += imageTokens
+			case chat.PartTypeAudioURL, chat.PartTypeAudioBase64:
+				total += audioTokens
+			case chat.PartTypeDocumentText:
+				total += Text(part.Title) + Text(part.Text)
+			case chat.PartTypeDocumentURL, chat.PartTypeDocumentBase64:
+				total += documentTokens
+			}
+		}
+		for _, call := range msg.ToolCalls {
+			total += Text(call.Function.Name) + Text(call.Function.Arguments)
+		}
+	}
+	for _, tool := range req.Tools {
+		total += Text(tool.Function.Name) + Text(tool.Function.Description) + Text(string(tool.Function.ParametersJSONSchema))
+	}
+	return total
+}
+
+// Payload estimates the input tokens of a provider request body as sent on
+// the wire: the text of every string value and object key, so system prompts,
+// tool schemas and replayed tool calls count as the provider builder laid
+// them out. Image blocks and data URLs count as one flat image each, and
+// input_audio blocks and file documents as one flat audio clip or document,
+// instead of by their encoded size.
+func Payload(body any) (int, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return 0, err
+	}
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return 0, err
+	}
+	return payloadValue(value), nil
+}
+
+func payloadValue(value any) int {
+	switch v := value.(type) {
+	case string:
+		if strings.HasPrefix(v, "data:") {
+			return imageTokens
+		}
+		return Text(v)
+	case []any:
+		total := 0
+		for _, item := range v {
+			total += payloadValue(item)
+		}
+		return total
+	case map[string]any:
+		typ, _ := v["type"].(string)
+		if imageBlockTypes[typ] {
+			return imageTokens
+		}
+		if typ == "input_audio" {
+			return audioTokens
+		}
+		if typ == "input_file" {
+			return documentTokens
+		}
+		if source, _ := v["source"].(map[string]any); typ == "document" && source["type"] != "text" {
+			return documentTokens
+		}
+		total := 0
+		for key, item := range v {
+			total += Text(key) + payloadValue(item)
+		}
+		return total
+	}
+	return 0
+}
+
+// imageBlockTypes are the content block types used for images by the
+// OpenAI Chat Completions, Responses and Anthropic request formats, including
+// generated images replayed to the Responses API.
+var imageBlockTypes = map[string]bool{
+	"image":                 true,
+	"image_url":             true,
+	"input_image":           true,
+	"image_generation_call": true,
+}
@@ -0,0 +1,73 @@
+package tokenest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/quailyquaily/uniai/chat"
+)
+
+func TestText(t *testing.T) {
+	cases := map[string]int{
+		"":          0,
+		"abcd":      1,
+		"abcde":     2,
+		"你好":        2,
+		"hi 世界":     3,
+		"hello wor": 3,
+	}
+	for in, want := range cases {
+		if got := Text(in); got != want {
+			t.Fatalf("Text(%q) = %d, want %d", in, got, want)
+		}
+	}
+}
+
+func TestRequest(t *testing.T) {
+	req := &chat.Request{
+		Messages: []chat.Message{
+			chat.System("abcdefgh"),
+			chat.UserParts(chat.Part{Type: chat.PartTypeText, Text: "abcd"}, chat.Part{Type: chat.PartTypeImageURL, URL: "https://example.com/a.png"}),
+		},
+		Tools: []chat.Tool{{Type: "function", Function: chat.ToolFunction{Name: "lookup", ParametersJSONSchema: []byte(`{}`)}}},
+	}
+	// 2 messages * 4 overhead + 2 + 1 + 85 + tool name (2) + schema (1)
+	if got, want := Request(req), 99; got != want {
+		t.Fatalf("Request() = %d, want %d", got, want)
+	}
+	if Request(nil) != 0 {
+		t.Fatalf("expected 0 for nil request")
+	}
+}
+
+func TestPayload(t *testing.T) {
+	body := map[string]any{
+		"model": "abcd",
+		"messages": []map[string]any{
+			{"role": "user", "content": []map[string]any{
+				{"type": "text", "text": "abcdefgh"},
+				{"type": "image_url", "image_url": map[string]string{"url": "data:image/png;base64," + strings.Repeat("A", 4000)}},
+			}},
+		},
+		"max_tokens": 100,
+	}
+	got, err := Payload(body)
+	if err != nil {
+		t.Fatalf("payload: %v", err)
+	}
+	// keys: model 2, messages 2, max_tokens 3, role 1, content 2, type 1, text 1
+	// values: abcd 1, user 1, text 1, abcdefgh 2, image 85
+	if want := 102; got != want {
+		t.Fatalf("Payload() = %d, want %d", got, want)
+	}
+
+	audio := map[string]any{"type": "input_audio", "input_audio": map[string]string{"data": strings.Repeat("A", 4000), "format": "wav"}}
+	if got, err := Payload(audio); err != nil || got != audioTokens {
+		t.Fatalf("Payload(input_audio) = %d, %v, want %d", got, err, audioTokens)
+	}
+
+	image := map[string]any{"type": "image_generation_call", "id": "ig_1", "result": strings.Repeat("A", 4000)}
+	if got, err := Payload(image); err != nil || got != imageTokens {
+		t.Fatalf("Payload(image_generation_call) = %d, %v, want %d", got, err, imageTokens)
+	}
+}
@@ -0,0 +1,45 @@
+package uniai
+
+import (
+	"context"
+
+	"github.com/quailyquaily/uniai/audio"
+	"github.com/quailyquaily/uniai/chat"
+	"github.com/quailyquaily/uniai/classify"
+	"github.com/quailyquaily/uniai/embedding"
+	"github.com/quailyquaily/uniai/image"
+	"github.com/quailyquaily/uniai/rerank"
+)
+
+// ChatHandler executes a chat request whose provider has already been
+// resolved. Middleware may mutate req (messages, options, OnStream) before
+// calling the next handler, or return a result without calling it.
+type ChatHandler func(ctx context.Context, req *chat.Request) (*chat.Result, error)
+
+// EmbeddingHandler executes an embedding request with a resolved provider.
+type EmbeddingHandler func(ctx context.Context, req *embedding.Request) (*embedding.Result, error)
+
+// ImageHandler executes an image generation request with a resolved provider.
+type ImageHandler func(ctx context.Context, req *image.Request) (*image.Result, error)
+
+// ImageEditHandler executes an image edit request with a resolved provider.
+type ImageEditHandler func(ctx context.Context, req *image.EditRequest) (*image.Result, error)
+
+// RerankHandler executes a rerank request with a resolved provider.
+type RerankHandler func(ctx context.Context, req *rerank.Request) (*rerank.Result, error)
+
+// ClassifyHandler executes a classify request with a resolved provider.
+type ClassifyHandler func(ctx context.Context, req *classify.Request) (*classify.Result, error)
+
+// AudioHandler executes an audio transcription request with a resolved provider.
+type AudioHandler func(ctx context.Context, req *audio.Request) (*audio.Result, error)
+
+// chain wraps h with middleware so that middleware[0] runs first.
+func chain[H any](h H, middleware []func(next H) H) H {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		if middleware[i] != nil {
+			h = middleware[i](h)
+		}
+	}
+	return h
+}
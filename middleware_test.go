@@ -0,0 +1,198 @@
+package uniai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/quailyquaily/uniai/chat"
+	"github.com/quailyquaily/uniai/embedding"
+)
+
+func TestChatMiddlewareOrderAndMutation(t *testing.T) {
+	var gotBody map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&gotBody)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"content":[{"type":"text","text":"ok"}],"model":"claude-sonnet-test","usage":{"input_tokens":1,"output_tokens":1}}`)
+	}))
+	defer server.Close()
+
+	var order []string
+	named := func(name string) func(ChatHandler) ChatHandler {
+		return func(next ChatHandler) ChatHandler {
+			return func(ctx context.Context, req *chat.Request) (*chat.Result, error) {
+				order = append(order, name+":"+req.Provider)
+				resp, err := next(ctx, req)
+				order = append(order, name+":done")
+				return resp, err
+			}
+		}
+	}
+	redact := func(next ChatHandler) ChatHandler {
+		return func(ctx context.Context, req *chat.Request) (*chat.Result, error) {
+			for i := range req.Messages {
+				req.Messages[i].Content = strings.ReplaceAll(req.Messages[i].Content, "secret", "[redacted]")
+			}
+			return next(ctx, req)
+		}
+	}
+
+	client := New(Config{
+		Provider:         "anthropic",
+		AnthropicAPIKey:  "test-key",
+		AnthropicAPIBase: server.URL,
+		AnthropicModel:   "claude-sonnet-test",
+		ChatMiddleware:   []func(ChatHandler) ChatHandler{named("outer"), named("inner"), redact},
+	})
+
+	resp, err := client.Chat(context.Background(), chat.WithMessages(chat.User("my secret")))
+	if err != nil {
+		t.Fatalf("chat: %v", err)
+	}
+	if resp.Text != "ok" || len(resp.Attempts) != 1 {
+		t.Fatalf("unexpected result: %#v", resp)
+	}
+	want := []string{"outer:anthropic", "inner:anthropic", "inner:done", "outer:done"}
+	if strings.Join(order, ",") != strings.Join(want, ",") {
+		t.Fatalf("unexpected order: %v", order)
+	}
+	raw, _ := json.Marshal(gotBody["messages"])
+	if strings.Contains(string(raw), "secret") || !strings.Contains(string(raw), "[redacted]") {
+		t.Fatalf("expected redacted messages, got %s", raw)
+	}
+}
+
+func TestChatMiddlewareShortCircuitAndStreamWrap(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, "event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\"Hello\"}}\n\n"+
+			"event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n")
+	}))
+	defer server.Close()
+
+	var wrapped atomic.Int32
+	cache := func(next ChatHandler) ChatHandler {
+		return func(ctx context.Context, req *chat.Request) (*chat.Result, error) {
+			if req.Messages[0].Content == "cached" {
+				return &chat.Result{Text: "from cache"}, nil
+			}
+			if onStream := req.Options.OnStream; onStream != nil {
+				req.Options.OnStream = func(ev chat.StreamEvent) error {
+					wrapped.Add(1)
+					return onStream(ev)
+				}
+			}
+			return next(ctx, req)
+		}
+	}
+	client := New(Config{
+		Provider:         "anthropic",
+		AnthropicAPIKey:  "test-key",
+		AnthropicAPIBase: server.URL,
+		AnthropicModel:   "claude-sonnet-test",
+		ChatMiddleware:   []func(ChatHandler) ChatHandler{cache},
+	})
+
+	resp, err := client.Chat(context.Background(), chat.WithMessages(chat.User("cached")))
+	if err != nil || resp.Text != "from cache" || calls.Load() != 0 {
+		t.Fatalf("expected cached result without requests, got %v, %#v, %d calls", err, resp, calls.Load())
+	}
+
+	var delivered int
+	resp, err = client.Chat(context.Background(),
+		chat.WithMessages(chat.User("hello")),
+		chat.WithOnStream(func(chat.StreamEvent) error { delivered++; return nil }),
+	)
+	if err != nil || resp.Text != "Hello" {
+		t.Fatalf("chat: %v %#v", err, resp)
+	}
+	if delivered == 0 || int(wrapped.Load()) != delivered {
+		t.Fatalf("expected middleware to see every stream event, got %d of %d", wrapped.Load(), delivered)
+	}
+}
+
+func TestEmbeddingMiddlewareSeesResolvedProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"object":"list","data":[{"object":"embedding","index":0,"embedding":"AAAA"}],"model":"jina-embeddings-v3","usage":{"total_tokens":2,"prompt_tokens":2}}`)
+	}))
+	defer server.Close()
+
+	var provider string
+	client := New(Config{
+		JinaAPIKey:  "test-key",
+		JinaAPIBase: server.URL,
+		EmbeddingMiddleware: []func(EmbeddingHandler) EmbeddingHandler{
+			func(next EmbeddingHandler) EmbeddingHandler {
+				return func(ctx context.Context, req *embedding.Request) (*embedding.Result, error) {
+					provider = req.Provider
+					return next(ctx, req)
+				}
+			},
+		},
+	})
+
+	resp, err := client.Embedding(context.Background(), embedding.Embedding("jina-embeddings-v3", "hello"))
+	if err != nil || len(resp.Data) != 1 {
+		t.Fatalf("embedding: %v %#v", err, resp)
+	}
+	if provider != "jina" {
+		t.Fatalf("expected resolved provider jina, got %q", provider)
+	}
+}
+
+func TestChatCallMiddlewareSeesEveryUpstreamRequest(t *testing.T) {
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer primary.Close()
+	secondary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"id":"1","object":"chat.completion","model":"gpt-test","choices":[{"index":0,"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}]}`)
+	}))
+	defer secondary.Close()
+
+	var chats atomic.Int32
+	var calls []string
+	client := New(Config{
+		Provider:         "anthropic",
+		AnthropicAPIKey:  "test-key",
+		AnthropicAPIBase: primary.URL,
+		AnthropicModel:   "claude-sonnet-test",
+		OpenAIAPIKey:     "test-key",
+		OpenAIAPIBase:    secondary.URL + "/v1",
+		ChatMiddleware: []func(ChatHandler) ChatHandler{func(next ChatHandler) ChatHandler {
+			return func(ctx context.Context, req *chat.Request) (*chat.Result, error) {
+				chats.Add(1)
+				return next(ctx, req)
+			}
+		}},
+		ChatCallMiddleware: []func(ChatHandler) ChatHandler{func(next ChatHandler) ChatHandler {
+			return func(ctx context.Context, req *chat.Request) (*chat.Result, error) {
+				resp, err := next(ctx, req)
+				calls = append(calls, fmt.Sprintf("%s:%v", req.Provider, err == nil))
+				return resp, err
+			}
+		}},
+	})
+
+	_, err := client.Chat(context.Background(),
+		chat.WithMessages(chat.User("hello")),
+		chat.WithFallbacks(chat.Fallback{Provider: "openai", Model: "gpt-test"}),
+	)
+	if err != nil {
+		t.Fatalf("chat: %v", err)
+	}
+	if chats.Load() != 1 || strings.Join(calls, ",") != "anthropic:false,openai:true" {
+		t.Fatalf("unexpected calls: chat=%d upstream=%v", chats.Load(), calls)
+	}
+}
@@ -0,0 +1,108 @@
+package uniai
+
+import (
+	"fmt"
+
+	"github.com/quailyquaily/uniai/chat"
+	"github.com/quailyquaily/uniai/internal/tokenest"
+)
+
+// MaxTokensPolicy selects what Client.Chat does with a WithMaxTokens value
+// above the model's MaxOutputTokens.
+type MaxTokensPolicy string
+
+const (
+	// MaxTokensClamp lowers the value to the limit and adds a result warning.
+	// It is the default.
+	MaxTokensClamp MaxTokensPolicy = "clamp"
+	// MaxTokensReject fails the call with a MaxTokensError.
+	MaxTokensReject MaxTokensPolicy = "reject"
+)
+
+// contextWindowSlack is how far the local input estimate must exceed the
+// context window before a request is rejected without calling the provider.
+// tokenest is a rough estimate, so borderline requests are left to the
+// provider.
+const contextWindowSlack = 1.1
+
+// ContextTooLongError is returned before calling the provider when the
+// estimated input of a chat request clearly exceeds the model's context
+// window. It matches ErrContextLengthExceeded, like the provider errors for
+// the same condition, so it can trigger a fallback to a larger model.
+type ContextTooLongError struct {
+	Provider string
+	Model    string
+	// EstimatedInputTokens is the local estimate of the request input.
+	EstimatedInputTokens int
+	ContextWindow        int
+}
+
+func (e *ContextTooLongError) Error() string {
+	return fmt.Sprintf("%s model %q: estimated %d input tokens exceed the %d token context window", e.Provider, e.Model, e.EstimatedInputTokens, e.ContextWindow)
+}
+
+func (e *ContextTooLongError) Unwrap() error {
+	return ErrContextLengthExceeded
+}
+
+// MaxTokensError is returned under MaxTokensReject when WithMaxTokens
+// exceeds the model's output limit. It matches ErrInvalidRequest.
+type MaxTokensError struct {
+	Provider  string
+	Model     string
+	MaxTokens int
+	Limit     int
+}
+
+func (e *MaxTokensError) Error() string {
+	return fmt.Sprintf("%s model %q: max tokens %d exceed the %d token output limit", e.Provider, e.Model, e.MaxTokens, e.Limit)
+}
+
+func (e *MaxTokensError) Unwrap() error {
+	return ErrInvalidRequest
+}
+
+// applyModelLimits checks req against the model catalog entry for the target
+// model. It clamps MaxTokens in place and returns the warning to attach to
+// the result, or fails when a limit is clearly exceeded.
+func (c *Client) applyModelLimits(providerName string, req *chat.Request) (string, error) {
+	if c.cfg.Models == nil {
+		return "", nil
+	}
+	model := c.resolveChatRequestedModel(providerName, req)
+	inferenceProvider := req.InferenceProvider
+	if inferenceProvider == "" {
+		inferenceProvider = providerName
+	}
+	info, ok := c.cfg.Models.Lookup(inferenceProvider, model)
+	if !ok {
+		return "", nil
+	}
+
+	if info.ContextWindow > 0 {
+		if estimate := tokenest.Request(req); float64(estimate) > float64(info.ContextWindow)*contextWindowSlack {
+			return "", &ContextTooLongError{
+				Provider:             providerName,
+				Model:                model,
+				EstimatedInputTokens: estimate,
+				ContextWindow:        info.ContextWindow,
+			}
+		}
+	}
+
+	if info.MaxOutputTokens <= 0 || req.Options.MaxTokens == nil || *req.Options.MaxTokens <= info.MaxOutputTokens {
+		return "", nil
+	}
+	requested := *req.Options.MaxTokens
+	if c.cfg.MaxTokensPolicy == MaxTokensReject {
+		return "", &MaxTokensError{
+			Provider:  providerName,
+			Model:     model,
+			MaxTokens: requested,
+			Limit:     info.MaxOutputTokens,
+		}
+	}
+	limit := info.MaxOutputTokens
+	req.Options.MaxTokens = &limit
+	return fmt.Sprintf("max tokens %d clamped to the %d token output limit of %s", requested, limit, model), nil
+}
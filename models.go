@@ -0,0 +1,141 @@
+package uniai
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ModelCatalog lists model limits and capabilities. Client.Chat uses it to
+// clamp or reject WithMaxTokens values above a model's output limit and to
+// fail fast with a ContextTooLongError when a request clearly does not fit
+// the context window.
+type ModelCatalog struct {
+	Chat []ModelInfo `json:"chat,omitempty" yaml:"chat,omitempty"`
+}
+
+// ModelInfo describes one chat model.
+//
+// Matching follows ChatPricingRule: exact on normalized model strings,
+// optional Aliases, and InferenceProvider as an explicit runtime hint. Zero
+// limits are unknown and not enforced.
+type ModelInfo struct {
+	InferenceProvider string   `json:"inference_provider,omitempty" yaml:"inference_provider,omitempty"`
+	Model             string   `json:"model" yaml:"model"`
+	Aliases           []string `json:"aliases,omitempty" yaml:"aliases,omitempty"`
+
+	// ContextWindow is the maximum number of input plus output tokens.
+	ContextWindow int `json:"context_window,omitempty" yaml:"context_window,omitempty"`
+	// MaxOutputTokens is the largest accepted max_tokens value.
+	MaxOutputTokens int `json:"max_output_tokens,omitempty" yaml:"max_output_tokens,omitempty"`
+
+	SupportsVision    bool `json:"supports_vision,omitempty" yaml:"supports_vision,omitempty"`
+	SupportsTools     bool `json:"supports_tools,omitempty" yaml:"supports_tools,omitempty"`
+	SupportsReasoning bool `json:"supports_reasoning,omitempty" yaml:"supports_reasoning,omitempty"`
+	SupportsCaching   bool `json:"supports_caching,omitempty" yaml:"supports_caching,omitempty"`
+}
+
+// ParseModelsYAML decodes a model catalog YAML document and validates it.
+func ParseModelsYAML(data []byte) (*ModelCatalog, error) {
+	var catalog ModelCatalog
+	if err := yaml.Unmarshal(data, &catalog); err != nil {
+		return nil, err
+	}
+	if err := catalog.Validate(); err != nil {
+		return nil, err
+	}
+	return &catalog, nil
+}
+
+// Clone returns a deep copy of the model catalog.
+func (c *ModelCatalog) Clone() *ModelCatalog {
+	if c == nil {
+		return nil
+	}
+	out := &ModelCatalog{}
+	if len(c.Chat) > 0 {
+		out.Chat = make([]ModelInfo, len(c.Chat))
+		for i, info := range c.Chat {
+			info.Aliases = append([]string(nil), info.Aliases...)
+			out.Chat[i] = info
+		}
+	}
+	return out
+}
+
+// Validate checks that limits are non-negative and model names are not
+// ambiguous within the same inference provider.
+func (c *ModelCatalog) Validate() error {
+	if c == nil {
+		return nil
+	}
+	seen := make(map[string]int, len(c.Chat))
+	for i, info := range c.Chat {
+		if strings.TrimSpace(info.Model) == "" {
+			return fmt.Errorf("chat[%d]: model is required", i)
+		}
+		if info.ContextWindow < 0 || info.MaxOutputTokens < 0 {
+			return fmt.Errorf("chat[%d]: limits must be non-negative", i)
+		}
+		if info.ContextWindow > 0 && info.MaxOutputTokens > info.ContextWindow {
+			return fmt.Errorf("chat[%d]: max_output_tokens %d exceeds context_window %d", i, info.MaxOutputTokens, info.ContextWindow)
+		}
+		inferenceProvider := normalizeInferenceProvider(info.InferenceProvider)
+		for _, name := range append([]string{info.Model}, info.Aliases...) {
+			normalized := normalizeModel(name)
+			if normalized == "" {
+				continue
+			}
+			key := inferenceProvider + "\x00" + normalized
+			if prev, ok := seen[key]; ok && prev != i {
+				return fmt.Errorf("chat[%d]: model or alias %q conflicts with chat[%d]", i, name, prev)
+			}
+			seen[key] = i
+		}
+	}
+	return nil
+}
+
+// Lookup returns the entry for model. If inferenceProvider is empty, or the
+// catalog has no entry for that inference provider, lookup falls back to
+// model-only matching; otherwise it stays within that provider.
+func (c *ModelCatalog) Lookup(inferenceProvider, model string) (ModelInfo, bool) {
+	if c == nil {
+		return ModelInfo{}, false
+	}
+	candidates := normalizeModelCandidates(model)
+	inferenceProvider = normalizeInferenceProvider(inferenceProvider)
+	scoped := false
+	if inferenceProvider != "" {
+		for i := range c.Chat {
+			if normalizeInferenceProvider(c.Chat[i].InferenceProvider) == inferenceProvider {
+				scoped = true
+				break
+			}
+		}
+	}
+	for _, candidate := range candidates {
+		for _, info := range c.Chat {
+			if scoped && normalizeInferenceProvider(info.InferenceProvider) != inferenceProvider {
+				continue
+			}
+			if modelInfoMatches(info, candidate) {
+				return info, true
+			}
+		}
+	}
+	return ModelInfo{}, false
+}
+
+func modelInfoMatches(info ModelInfo, model string) bool {
+	if normalizeModel(info.Model) == model {
+		return true
+	}
+	for _, alias := range info.Aliases {
+		if normalizeModel(alias) == model {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,128 @@
+package uniai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/quailyquaily/uniai/chat"
+)
+
+func TestParseModelsYAML(t *testing.T) {
+	catalog, err := ParseModelsYAML([]byte(`
+chat:
+  - inference_provider: openai
+    model: gpt-test
+    aliases:
+      - gpt-test-2026
+    context_window: 1000
+    max_output_tokens: 100
+    supports_tools: true
+  - inference_provider: groq
+    model: gpt-test
+    context_window: 500
+`))
+	if err != nil {
+		t.Fatalf("parse models yaml: %v", err)
+	}
+	info, ok := catalog.Lookup("", "GPT-Test-2026")
+	if !ok || info.Model != "gpt-test" || info.MaxOutputTokens != 100 || !info.SupportsTools {
+		t.Fatalf("unexpected alias lookup: %#v %v", info, ok)
+	}
+	if info, ok := catalog.Lookup("groq", "gpt-test"); !ok || info.ContextWindow != 500 {
+		t.Fatalf("expected the groq entry, got %#v %v", info, ok)
+	}
+	if _, ok := catalog.Lookup("groq", "gpt-test-2026"); ok {
+		t.Fatalf("expected lookup to stay within the hinted provider")
+	}
+	if info, ok := catalog.Lookup("azure", "gpt-test"); !ok || info.ContextWindow != 1000 {
+		t.Fatalf("expected a model-only fallback, got %#v %v", info, ok)
+	}
+
+	for name, doc := range map[string]string{
+		"missing model": "chat:\n  - context_window: 10\n",
+		"negative":      "chat:\n  - model: a\n    context_window: -1\n",
+		"output":        "chat:\n  - model: a\n    context_window: 10\n    max_output_tokens: 20\n",
+		"duplicate":     "chat:\n  - model: a\n  - model: b\n    aliases: [A]\n",
+	} {
+		if _, err := ParseModelsYAML([]byte(doc)); err == nil {
+			t.Fatalf("%s: expected a validation error", name)
+		}
+	}
+}
+
+func TestDefaultModelCatalog(t *testing.T) {
+	catalog := DefaultModelCatalog()
+	info, ok := catalog.Lookup("anthropic", "claude-sonnet-4.5")
+	if !ok || info.ContextWindow == 0 || info.MaxOutputTokens == 0 {
+		t.Fatalf("expected a default entry for claude-sonnet-4-5, got %#v %v", info, ok)
+	}
+	catalog.Chat[0].Model = "changed"
+	if DefaultModelCatalog().Chat[0].Model == "changed" {
+		t.Fatalf("expected a cloned catalog")
+	}
+}
+
+func TestChatAppliesModelLimits(t *testing.T) {
+	var calls atomic.Int32
+	var lastMaxTokens atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		var body struct {
+			MaxTokens int64 `json:"max_tokens"`
+		}
+		data, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(data, &body)
+		lastMaxTokens.Store(body.MaxTokens)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"content":[{"type":"text","text":"ok"}],"model":"claude-test","usage":{"input_tokens":1,"output_tokens":1}}`)
+	}))
+	defer server.Close()
+
+	models := &ModelCatalog{Chat: []ModelInfo{{InferenceProvider: "anthropic", Model: "claude-test", ContextWindow: 1000, MaxOutputTokens: 100}}}
+	cfg := Config{
+		Provider:         "anthropic",
+		AnthropicAPIKey:  "test-key",
+		AnthropicAPIBase: server.URL,
+		AnthropicModel:   "claude-test",
+		Models:           models,
+	}
+	ctx := context.Background()
+
+	resp, err := New(cfg).Chat(ctx, chat.WithMessages(chat.User("hello")), chat.WithMaxTokens(500))
+	if err != nil {
+		t.Fatalf("chat: %v", err)
+	}
+	if lastMaxTokens.Load() != 100 || len(resp.Warnings) != 1 || !strings.Contains(resp.Warnings[0], "clamped to the 100 token output limit") {
+		t.Fatalf("expected a clamped request, got max_tokens %d and warnings %v", lastMaxTokens.Load(), resp.Warnings)
+	}
+
+	rejecting := cfg
+	rejecting.MaxTokensPolicy = MaxTokensReject
+	_, err = New(rejecting).Chat(ctx, chat.WithMessages(chat.User("hello")), chat.WithMaxTokens(500))
+	var maxErr *MaxTokensError
+	if !errors.As(err, &maxErr) || maxErr.Limit != 100 || !errors.Is(err, ErrInvalidRequest) {
+		t.Fatalf("expected a MaxTokensError, got %v", err)
+	}
+
+	_, err = New(cfg).Chat(ctx, chat.WithMessages(chat.User(strings.Repeat("word ", 2000))))
+	var tooLong *ContextTooLongError
+	if !errors.As(err, &tooLong) || tooLong.ContextWindow != 1000 || !errors.Is(err, ErrContextLengthExceeded) {
+		t.Fatalf("expected a ContextTooLongError, got %v", err)
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("expected rejected requests to skip the provider, got %d calls", calls.Load())
+	}
+
+	disabled := cfg
+	disabled.Models = &ModelCatalog{}
+	if _, err := New(disabled).Chat(ctx, chat.WithMessages(chat.User("hello")), chat.WithMaxTokens(500)); err != nil || lastMaxTokens.Load() != 500 {
+		t.Fatalf("expected an empty catalog to disable checks, got %v and max_tokens %d", err, lastMaxTokens.Load())
+	}
+}
@@ -0,0 +1,334 @@
+// Package otel traces uniai calls with OpenTelemetry spans that follow the
+// GenAI semantic conventions.
+//
+// It lives in its own module so that the core uniai module does not depend
+// on OpenTelemetry:
+//
+//	cfg := uniai.Config{OpenAIAPIKey: "..."}
+//	otel.Instrument(&cfg)
+//	client := uniai.New(cfg)
+package otel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/quailyquaily/uniai"
+	"github.com/quailyquaily/uniai/chat"
+	"github.com/quailyquaily/uniai/embedding"
+	"github.com/quailyquaily/uniai/image"
+	"github.com/quailyquaily/uniai/rerank"
+	gootel "go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.39.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/quailyquaily/uniai/otel"
+
+// Attributes not (yet) covered by the GenAI semantic conventions.
+const (
+	UsageCacheReadInputTokensKey     = attribute.Key("gen_ai.usage.cache_read.input_tokens")
+	UsageCacheCreationInputTokensKey = attribute.Key("gen_ai.usage.cache_creation.input_tokens")
+	UsageCostKey                     = attribute.Key("uniai.usage.cost")
+	UsageCostCurrencyKey             = attribute.Key("uniai.usage.cost.currency")
+	TimeToFirstTokenKey              = attribute.Key("uniai.response.time_to_first_token")
+	AttemptsKey                      = attribute.Key("uniai.attempts")
+	WarningsKey                      = attribute.Key("uniai.warnings")
+)
+
+// Operation names for calls without a GenAI convention.
+const (
+	OperationGenerateImage = "generate_image"
+	OperationEditImage     = "edit_image"
+	OperationRerank        = "rerank"
+)
+
+// Option configures the tracer.
+type Option func(*Tracer)
+
+// WithTracerProvider sets the provider spans are created with. Defaults to
+// the global provider.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(t *Tracer) { t.provider = tp }
+}
+
+// Tracer creates spans for uniai calls. Its methods are middleware for the
+// matching uniai.Config fields.
+type Tracer struct {
+	provider trace.TracerProvider
+	tracer   trace.Tracer
+}
+
+// New returns a Tracer.
+func New(opts ...Option) *Tracer {
+	t := &Tracer{}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(t)
+		}
+	}
+	if t.provider == nil {
+		t.provider = gootel.GetTracerProvider()
+	}
+	t.tracer = t.provider.Tracer(instrumentationName)
+	return t
+}
+
+// Instrument adds tracing middleware to cfg, outside any middleware already
+// configured. Call it before uniai.New.
+//
+// Every Client.Chat gets a span carrying the totals of the call, with a child
+// span for each upstream request (fallback targets and tool emulation
+// rounds). Embedding, image and rerank calls get one span each.
+func Instrument(cfg *uniai.Config, opts ...Option) *Tracer {
+	t := New(opts...)
+	cfg.ChatMiddleware = append([]func(uniai.ChatHandler) uniai.ChatHandler{t.Chat}, cfg.ChatMiddleware...)
+	cfg.ChatCallMiddleware = append([]func(uniai.ChatHandler) uniai.ChatHandler{t.ChatCall}, cfg.ChatCallMiddleware...)
+	cfg.EmbeddingMiddleware = append([]func(uniai.EmbeddingHandler) uniai.EmbeddingHandler{t.Embedding}, cfg.EmbeddingMiddleware...)
+	cfg.ImageMiddleware = append([]func(uniai.ImageHandler) uniai.ImageHandler{t.Image}, cfg.ImageMiddleware...)
+	cfg.ImageEditMiddleware = append([]func(uniai.ImageEditHandler) uniai.ImageEditHandler{t.ImageEdit}, cfg.ImageEditMiddleware...)
+	cfg.RerankMiddleware = append([]func(uniai.RerankHandler) uniai.RerankHandler{t.Rerank}, cfg.RerankMiddleware...)
+	return t
+}
+
+// Chat traces a whole Client.Chat call (uniai.Config.ChatMiddleware).
+func (t *Tracer) Chat(next uniai.ChatHandler) uniai.ChatHandler {
+	return t.chat(next, true)
+}
+
+// ChatCall traces one upstream chat request (uniai.Config.ChatCallMiddleware).
+func (t *Tracer) ChatCall(next uniai.ChatHandler) uniai.ChatHandler {
+	return t.chat(next, false)
+}
+
+func (t *Tracer) chat(next uniai.ChatHandler, top bool) uniai.ChatHandler {
+	return func(ctx context.Context, req *chat.Request) (*chat.Result, error) {
+		ctx, span := t.start(ctx, semconv.GenAIOperationNameChat.Value.AsString(), req.Provider, req.Model, chatRequestAttributes(req)...)
+		defer span.End()
+
+		start := time.Now()
+		var firstToken sync.Once
+		if onStream := req.Options.OnStream; onStream != nil {
+			req.Options.OnStream = func(ev chat.StreamEvent) error {
+				if ev.Delta != "" || ev.ReasoningDelta != nil || ev.ToolCallDelta != nil || ev.Part != nil {
+					firstToken.Do(func() {
+						span.SetAttributes(TimeToFirstTokenKey.Float64(time.Since(start).Seconds()))
+					})
+				}
+				return onStream(ev)
+			}
+		}
+
+		resp, err := next(ctx, req)
+		if resp != nil {
+			span.SetAttributes(chatResultAttributes(resp, top)...)
+		}
+		endWithError(span, err)
+		return resp, err
+	}
+}
+
+// Embedding traces an embedding call (uniai.Config.EmbeddingMiddleware).
+func (t *Tracer) Embedding(next uniai.EmbeddingHandler) uniai.EmbeddingHandler {
+	return func(ctx context.Context, req *embedding.Request) (*embedding.Result, error) {
+		ctx, span := t.start(ctx, semconv.GenAIOperationNameEmbeddings.Value.AsString(), req.Provider, req.Model)
+		defer span.End()
+		resp, err := next(ctx, req)
+		if resp != nil {
+			span.SetAttributes(responseModel(resp.Model)...)
+			span.SetAttributes(semconv.GenAIUsageInputTokens(resp.Usage.PromptTokens))
+		}
+		endWithError(span, err)
+		return resp, err
+	}
+}
+
+// Image traces an image generation call (uniai.Config.ImageMiddleware).
+func (t *Tracer) Image(next uniai.ImageHandler) uniai.ImageHandler {
+	return func(ctx context.Context, req *image.Request) (*image.Result, error) {
+		ctx, span := t.start(ctx, OperationGenerateImage, req.Provider, req.Model)
+		defer span.End()
+		resp, err := next(ctx, req)
+		if resp != nil {
+			span.SetAttributes(imageUsageAttributes(resp.Usage)...)
+		}
+		endWithError(span, err)
+		return resp, err
+	}
+}
+
+// ImageEdit traces an image edit call (uniai.Config.ImageEditMiddleware).
+func (t *Tracer) ImageEdit(next uniai.ImageEditHandler) uniai.ImageEditHandler {
+	return func(ctx context.Context, req *image.EditRequest) (*image.Result, error) {
+		ctx, span := t.start(ctx, OperationEditImage, req.Provider, req.Model)
+		defer span.End()
+		resp, err := next(ctx, req)
+		if resp != nil {
+			span.SetAttributes(imageUsageAttributes(resp.Usage)...)
+		}
+		endWithError(span, err)
+		return resp, err
+	}
+}
+
+// Rerank traces a rerank call (uniai.Config.RerankMiddleware).
+func (t *Tracer) Rerank(next uniai.RerankHandler) uniai.RerankHandler {
+	return func(ctx context.Context, req *rerank.Request) (*rerank.Result, error) {
+		ctx, span := t.start(ctx, OperationRerank, req.Provider, req.Model)
+		defer span.End()
+		resp, err := next(ctx, req)
+		if resp != nil {
+			span.SetAttributes(responseModel(resp.Model)...)
+			span.SetAttributes(semconv.GenAIUsageInputTokens(resp.Usage.TotalTokens))
+		}
+		endWithError(span, err)
+		return resp, err
+	}
+}
+
+// start opens a client span named "{operation} {model}".
+func (t *Tracer) start(ctx context.Context, operation, provider, model string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	name := operation
+	if model != "" {
+		name += " " + model
+	}
+	attrs = append([]attribute.KeyValue{
+		semconv.GenAIOperationNameKey.String(operation),
+		semconv.GenAIProviderNameKey.String(ProviderName(provider)),
+	}, attrs...)
+	if model != "" {
+		attrs = append(attrs, semconv.GenAIRequestModel(model))
+	}
+	return t.tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+}
+
+// ProviderName maps a uniai provider name to the gen_ai.provider.name value.
+func ProviderName(provider string) string {
+	switch provider {
+	case "openai", "openai_resp", "openai_codex":
+		return semconv.GenAIProviderNameOpenAI.Value.AsString()
+	case "azure":
+		return semconv.GenAIProviderNameAzureAIOpenAI.Value.AsString()
+	case "anthropic":
+		return semconv.GenAIProviderNameAnthropic.Value.AsString()
+	case "gemini":
+		return semconv.GenAIProviderNameGCPGemini.Value.AsString()
+	case "bedrock":
+		return semconv.GenAIProviderNameAWSBedrock.Value.AsString()
+	case "deepseek":
+		return semconv.GenAIProviderNameDeepseek.Value.AsString()
+	case "xai":
+		return semconv.GenAIProviderNameXAI.Value.AsString()
+	case "groq":
+		return semconv.GenAIProviderNameGroq.Value.AsString()
+	default:
+		return provider
+	}
+}
+
+func chatRequestAttributes(req *chat.Request) []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	opts := req.Options
+	if opts.MaxTokens != nil {
+		attrs = append(attrs, semconv.GenAIRequestMaxTokens(*opts.MaxTokens))
+	}
+	if opts.Temperature != nil {
+		attrs = append(attrs, semconv.GenAIRequestTemperature(*opts.Temperature))
+	}
+	if opts.TopP != nil {
+		attrs = append(attrs, semconv.GenAIRequestTopP(*opts.TopP))
+	}
+	if opts.PresencePenalty != nil {
+		attrs = append(attrs, semconv.GenAIRequestPresencePenalty(*opts.PresencePenalty))
+	}
+	if opts.FrequencyPenalty != nil {
+		attrs = append(attrs, semconv.GenAIRequestFrequencyPenalty(*opts.FrequencyPenalty))
+	}
+	if len(opts.Stop) > 0 {
+		attrs = append(attrs, semconv.GenAIRequestStopSequences(opts.Stop...))
+	}
+	return attrs
+}
+
+func chatResultAttributes(resp *chat.Result, top bool) []attribute.KeyValue {
+	attrs := responseModel(resp.Model)
+	if resp.ID != "" {
+		attrs = append(attrs, semconv.GenAIResponseID(resp.ID))
+	}
+	if resp.FinishReason != "" {
+		attrs = append(attrs, semconv.GenAIResponseFinishReasons(string(resp.FinishReason)))
+	}
+	attrs = append(attrs,
+		semconv.GenAIUsageInputTokens(resp.Usage.InputTokens),
+		semconv.GenAIUsageOutputTokens(resp.Usage.OutputTokens),
+	)
+	if n := resp.Usage.Cache.CachedInputTokens; n > 0 {
+		attrs = append(attrs, UsageCacheReadInputTokensKey.Int(n))
+	}
+	if n := resp.Usage.Cache.CacheCreationInputTokens; n > 0 {
+		attrs = append(attrs, UsageCacheCreationInputTokensKey.Int(n))
+	}
+	if cost := resp.Usage.Cost; cost != nil {
+		attrs = append(attrs, UsageCostKey.Float64(cost.Total), UsageCostCurrencyKey.String(cost.Currency))
+	}
+	if top && len(resp.Attempts) > 0 {
+		attrs = append(attrs, AttemptsKey.Int(len(resp.Attempts)))
+	}
+	if len(resp.Warnings) > 0 {
+		attrs = append(attrs, WarningsKey.StringSlice(resp.Warnings))
+	}
+	return attrs
+}
+
+func imageUsageAttributes(usage image.CreateImageUsage) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		semconv.GenAIUsageInputTokens(usage.InputTokens),
+		semconv.GenAIUsageOutputTokens(usage.OutputTokens),
+	}
+	if usage.Cost != nil {
+		attrs = append(attrs, UsageCostKey.Float64(usage.Cost.Total), UsageCostCurrencyKey.String(usage.Cost.Currency))
+	}
+	return attrs
+}
+
+func responseModel(model string) []attribute.KeyValue {
+	if model == "" {
+		return nil
+	}
+	return []attribute.KeyValue{semconv.GenAIResponseModel(model)}
+}
+
+func endWithError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+	span.SetAttributes(semconv.ErrorTypeKey.String(errorType(err)))
+}
+
+// errorType returns a low-cardinality error.type value: the HTTP status of
+// provider errors, otherwise the error category.
+func errorType(err error) string {
+	var apiErr *uniai.APIError
+	switch {
+	case errors.As(err, &apiErr) && apiErr.StatusCode > 0:
+		return strconv.Itoa(apiErr.StatusCode)
+	case errors.Is(err, uniai.ErrCircuitOpen):
+		return "circuit_open"
+	case errors.Is(err, uniai.ErrClientRateLimited):
+		return "client_rate_limited"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	default:
+		return fmt.Sprintf("%T", err)
+	}
+}
@@ -0,0 +1,207 @@
+package otel
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/quailyquaily/uniai"
+	"github.com/quailyquaily/uniai/chat"
+	"github.com/quailyquaily/uniai/embedding"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func newTracedClient(t *testing.T, cfg uniai.Config) (*uniai.Client, *tracetest.InMemoryExporter) {
+	t.Helper()
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	t.Cleanup(func() { _ = tp.Shutdown(context.Background()) })
+	Instrument(&cfg, WithTracerProvider(tp))
+	return uniai.New(cfg), exporter
+}
+
+func attrs(span tracetest.SpanStub) map[attribute.Key]attribute.Value {
+	out := make(map[attribute.Key]attribute.Value, len(span.Attributes))
+	for _, kv := range span.Attributes {
+		out[kv.Key] = kv.Value
+	}
+	return out
+}
+
+func TestChatSpans(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"id":"msg_1","content":[{"type":"text","text":"ok"}],"model":"claude-sonnet-test","stop_reason":"end_turn","usage":{"input_tokens":100,"output_tokens":20,"cache_read_input_tokens":40}}`)
+	}))
+	defer server.Close()
+
+	pricing, err := uniai.ParsePricingYAML([]byte(`
+chat:
+  - inference_provider: anthropic
+    model: claude-sonnet-test
+    input_usd_per_million: 3
+    output_usd_per_million: 15
+    cached_input_usd_per_million: 0.3
+`))
+	if err != nil {
+		t.Fatalf("parse pricing: %v", err)
+	}
+	client, exporter := newTracedClient(t, uniai.Config{
+		Provider:         "anthropic",
+		AnthropicAPIKey:  "test-key",
+		AnthropicAPIBase: server.URL,
+		Pricing:          pricing,
+	})
+
+	_, err = client.Chat(context.Background(),
+		chat.WithModel("claude-sonnet-test"),
+		chat.WithMessages(chat.User("hello")),
+		chat.WithMaxTokens(256),
+		chat.WithTemperature(0.5),
+	)
+	if err != nil {
+		t.Fatalf("chat: %v", err)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("expected a call span and a chat span, got %d", len(spans))
+	}
+	call, top := spans[0], spans[1]
+	if call.Parent.SpanID() != top.SpanContext.SpanID() {
+		t.Fatalf("expected the call span to be a child of the chat span")
+	}
+	for _, span := range spans {
+		if span.Name != "chat claude-sonnet-test" || span.SpanKind != trace.SpanKindClient {
+			t.Fatalf("unexpected span %q kind %v", span.Name, span.SpanKind)
+		}
+		got := attrs(span)
+		want := map[attribute.Key]attribute.Value{
+			"gen_ai.operation.name":                attribute.StringValue("chat"),
+			"gen_ai.provider.name":                 attribute.StringValue("anthropic"),
+			"gen_ai.request.model":                 attribute.StringValue("claude-sonnet-test"),
+			"gen_ai.request.max_tokens":            attribute.IntValue(256),
+			"gen_ai.request.temperature":           attribute.Float64Value(0.5),
+			"gen_ai.response.model":                attribute.StringValue("claude-sonnet-test"),
+			"gen_ai.usage.input_tokens":            attribute.IntValue(100),
+			"gen_ai.usage.output_tokens":           attribute.IntValue(20),
+			"gen_ai.usage.cache_read.input_tokens": attribute.IntValue(40),
+			"gen_ai.response.finish_reasons":       attribute.StringSliceValue([]string{"stop"}),
+		}
+		for key, value := range want {
+			if got[key] != value {
+				t.Fatalf("%s: %s = %v, want %v", span.Name, key, got[key].Emit(), value.Emit())
+			}
+		}
+	}
+	// Cost is priced once for the whole Chat call.
+	got := attrs(top)
+	if got[UsageCostKey].AsFloat64() <= 0 || got[UsageCostCurrencyKey].AsString() != "USD" {
+		t.Fatalf("expected a cost attribute, got %v %v", got[UsageCostKey].Emit(), got[UsageCostCurrencyKey].Emit())
+	}
+	if _, ok := got[AttemptsKey]; !ok {
+		t.Fatalf("expected attempts on the chat span")
+	}
+}
+
+func TestChatSpansFallbackAndStreaming(t *testing.T) {
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = io.WriteString(w, `{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`)
+	}))
+	defer primary.Close()
+	secondary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, "data: {\"id\":\"c1\",\"object\":\"chat.completion.chunk\",\"model\":\"gpt-test\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"ok\"}}]}\n\n"+
+			"data: {\"id\":\"c1\",\"object\":\"chat.completion.chunk\",\"model\":\"gpt-test\",\"choices\":[{\"index\":0,\"delta\":{},\"finish_reason\":\"stop\"}]}\n\n"+
+			"data: [DONE]\n\n")
+	}))
+	defer secondary.Close()
+
+	client, exporter := newTracedClient(t, uniai.Config{
+		Provider:         "anthropic",
+		AnthropicAPIKey:  "test-key",
+		AnthropicAPIBase: primary.URL,
+		AnthropicModel:   "claude-sonnet-test",
+		OpenAIAPIKey:     "test-key",
+		OpenAIAPIBase:    secondary.URL + "/v1",
+	})
+
+	resp, err := client.Chat(context.Background(),
+		chat.WithMessages(chat.User("hello")),
+		chat.WithFallbacks(chat.Fallback{Provider: "openai", Model: "gpt-test"}),
+		chat.WithOnStream(func(chat.StreamEvent) error { return nil }),
+	)
+	if err != nil || resp.Text != "ok" {
+		t.Fatalf("chat: %v %#v", err, resp)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 3 {
+		t.Fatalf("expected two call spans and a chat span, got %d", len(spans))
+	}
+	failed, served, top := spans[0], spans[1], spans[2]
+	if failed.Status.Code != codes.Error || attrs(failed)["error.type"].AsString() != "503" {
+		t.Fatalf("expected failed call span, got %v %v", failed.Status, attrs(failed)["error.type"].Emit())
+	}
+	if got := attrs(served)["gen_ai.provider.name"].AsString(); got != "openai" || served.Name != "chat gpt-test" {
+		t.Fatalf("unexpected fallback span %q provider %q", served.Name, got)
+	}
+	for _, span := range []tracetest.SpanStub{served, top} {
+		if _, ok := attrs(span)[TimeToFirstTokenKey]; !ok {
+			t.Fatalf("%s: expected time to first token", span.Name)
+		}
+	}
+	if top.Status.Code == codes.Error {
+		t.Fatalf("expected the chat span to succeed")
+	}
+	if got := attrs(top)[WarningsKey].AsStringSlice(); len(got) != 1 {
+		t.Fatalf("expected fallback warning, got %v", got)
+	}
+}
+
+func TestEmbeddingSpanRecordsErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = io.WriteString(w, `{"detail":"bad key"}`)
+	}))
+	defer server.Close()
+
+	client, exporter := newTracedClient(t, uniai.Config{JinaAPIKey: "test-key", JinaAPIBase: server.URL})
+	_, err := client.Embedding(context.Background(), embedding.Embedding("jina-embeddings-v3", "hello"))
+	if !errors.Is(err, uniai.ErrAuth) {
+		t.Fatalf("expected auth error, got %v", err)
+	}
+	spans := exporter.GetSpans()
+	if len(spans) != 1 || spans[0].Name != "embeddings jina-embeddings-v3" {
+		t.Fatalf("unexpected spans: %#v", spans)
+	}
+	got := attrs(spans[0])
+	if got["gen_ai.operation.name"].AsString() != "embeddings" || got["error.type"].AsString() != "401" || spans[0].Status.Code != codes.Error {
+		t.Fatalf("unexpected embedding span: %v %v", spans[0].Status, spans[0].Attributes)
+	}
+}
+
+func TestProviderName(t *testing.T) {
+	for provider, want := range map[string]string{
+		"openai_resp": "openai",
+		"gemini":      "gcp.gemini",
+		"bedrock":     "aws.bedrock",
+		"azure":       "azure.ai.openai",
+		"xai":         "x_ai",
+		"cloudflare":  "cloudflare",
+	} {
+		if got := ProviderName(provider); got != want {
+			t.Fatalf("%s: got %q, want %q", provider, got, want)
+		}
+	}
+}
@@ -387,7 +387,7 @@ func resolveChatPricingRates(rule ChatPricingRule, usage Usage) (chatPricingRate
 }
 
 func estimateChatCostForRates(rates chatPricingRates, usage Usage) (*UsageCost, bool) {
-	if !hasPricableUsage(usage) {
+	if !usage.Pricable() {
 		return nil, false
 	}
 
@@ -481,13 +481,6 @@ func estimateImageCostForRates(rates imagePricingRates, usage image.CreateImageU
 	}, true
 }
 
-func hasPricableUsage(usage Usage) bool {
-	return usage.InputTokens > 0 ||
-		usage.OutputTokens > 0 ||
-		usage.Cache.CachedInputTokens > 0 ||
-		usage.Cache.CacheCreationInputTokens > 0
-}
-
 func hasPricableImageUsage(usage image.CreateImageUsage) bool {
 	return usage.InputTokens > 0 ||
 		usage.InputTextTokens > 0 ||
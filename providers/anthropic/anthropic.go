@@ -2,7 +2,6 @@ package anthropic
 
 import (
 	"bufio"
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -12,7 +11,9 @@ import (
 
 	"github.com/lyricat/goutils/structs"
 	"github.com/quailyquaily/uniai/chat"
+	"github.com/quailyquaily/uniai/internal/anthropicapi"
 	"github.com/quailyquaily/uniai/internal/anthropicstream"
+	"github.com/quailyquaily/uniai/internal/apierror"
 	"github.com/quailyquaily/uniai/internal/diag"
 	"github.com/quailyquaily/uniai/internal/httputil"
 	"github.com/quailyquaily/uniai/internal/modelcompat"
@@ -24,6 +25,7 @@ type Config struct {
 	DefaultModel string
 	Headers      map[string]string
 	Debug        bool
+	HTTPClient   *http.Client
 }
 
 type Provider struct {
@@ -33,109 +35,37 @@ type Provider struct {
 // DefaultAPIBase is the official Anthropic Messages API base URL.
 const DefaultAPIBase = "https://api.anthropic.com/v1"
 
+// maxDocumentBytes is the Messages API request size limit, checked against
+// the documents of a request together: base64 data counts encoded and text
+// sources as is.
+const maxDocumentBytes = 32 << 20
+
 func New(cfg Config) *Provider {
 	cfg.Headers = httputil.CloneHeaders(cfg.Headers)
 	return &Provider{cfg: cfg}
 }
 
-type anthropicMessage struct {
-	Role    string                 `json:"role"`
-	Content []anthropicContentPart `json:"content,omitempty"`
-}
-
-type anthropicContentPart struct {
-	Type         string                 `json:"type"`
-	Text         string                 `json:"text,omitempty"`
-	Thinking     string                 `json:"thinking,omitempty"`
-	Signature    string                 `json:"signature,omitempty"`
-	Data         string                 `json:"data,omitempty"`
-	Source       *anthropicImageSource  `json:"source,omitempty"`
-	ID           string                 `json:"id,omitempty"`
-	Name         string                 `json:"name,omitempty"`
-	Input        any                    `json:"input,omitempty"`
-	ToolUseID    string                 `json:"tool_use_id,omitempty"`
-	Content      any                    `json:"content,omitempty"`
-	IsError      *bool                  `json:"is_error,omitempty"`
-	CacheControl *anthropicCacheControl `json:"cache_control,omitempty"`
-}
-
-type anthropicImageSource struct {
-	Type      string `json:"type"`
-	MediaType string `json:"media_type,omitempty"`
-	Data      string `json:"data,omitempty"`
-	URL       string `json:"url,omitempty"`
-}
-
 type anthropicRequest struct {
-	Model         string                 `json:"model"`
-	System        any                    `json:"system,omitempty"`
-	Messages      []anthropicMessage     `json:"messages"`
-	MaxTokens     int                    `json:"max_tokens"`
-	Temperature   *float64               `json:"temperature,omitempty"`
-	TopP          *float64               `json:"top_p,omitempty"`
-	TopK          *int                   `json:"top_k,omitempty"`
-	StopSequences []string               `json:"stop_sequences,omitempty"`
-	Metadata      *anthropicMetadata     `json:"metadata,omitempty"`
-	Tools         []anthropicTool        `json:"tools,omitempty"`
-	ToolChoice    *anthropicToolChoice   `json:"tool_choice,omitempty"`
-	Thinking      *anthropicThinking     `json:"thinking,omitempty"`
-	OutputConfig  *anthropicOutputConfig `json:"output_config,omitempty"`
-	Stream        bool                   `json:"stream,omitempty"`
-}
-
-type anthropicResponse struct {
-	Content    []anthropicContentPart `json:"content"`
-	Model      string                 `json:"model"`
-	StopReason string                 `json:"stop_reason,omitempty"`
-	Usage      anthropicUsage         `json:"usage"`
-}
-
-type anthropicMetadata struct {
-	UserID string `json:"user_id,omitempty"`
-}
-
-type anthropicTool struct {
-	Name         string                 `json:"name"`
-	Description  string                 `json:"description,omitempty"`
-	InputSchema  any                    `json:"input_schema"`
-	CacheControl *anthropicCacheControl `json:"cache_control,omitempty"`
-}
-
-type anthropicToolChoice struct {
-	Type                   string `json:"type"`
-	Name                   string `json:"name,omitempty"`
-	DisableParallelToolUse *bool  `json:"disable_parallel_tool_use,omitempty"`
-}
-
-type anthropicThinking struct {
-	Type         string `json:"type"`
-	BudgetTokens *int   `json:"budget_tokens,omitempty"`
-	Display      string `json:"display,omitempty"`
+	Model         string                   `json:"model"`
+	System        any                      `json:"system,omitempty"`
+	Messages      []anthropicapi.Message   `json:"messages"`
+	MaxTokens     int                      `json:"max_tokens"`
+	Temperature   *float64                 `json:"temperature,omitempty"`
+	TopP          *float64                 `json:"top_p,omitempty"`
+	TopK          *int                     `json:"top_k,omitempty"`
+	StopSequences []string                 `json:"stop_sequences,omitempty"`
+	Metadata      *anthropicapi.Metadata   `json:"metadata,omitempty"`
+	Tools         []anthropicapi.Tool      `json:"tools,omitempty"`
+	ToolChoice    *anthropicapi.ToolChoice `json:"tool_choice,omitempty"`
+	Thinking      *anthropicapi.Thinking   `json:"thinking,omitempty"`
+	OutputConfig  *anthropicOutputConfig   `json:"output_config,omitempty"`
+	Stream        bool                     `json:"stream,omitempty"`
 }
 
 type anthropicOutputConfig struct {
 	Effort string `json:"effort,omitempty"`
 }
 
-type anthropicCacheControl struct {
-	Type string `json:"type"`
-	TTL  string `json:"ttl,omitempty"`
-}
-
-type anthropicSystemPart struct {
-	Type         string                 `json:"type"`
-	Text         string                 `json:"text,omitempty"`
-	CacheControl *anthropicCacheControl `json:"cache_control,omitempty"`
-}
-
-type anthropicUsage struct {
-	InputTokens              int            `json:"input_tokens,omitempty"`
-	OutputTokens             int            `json:"output_tokens,omitempty"`
-	CacheReadInputTokens     int            `json:"cache_read_input_tokens,omitempty"`
-	CacheCreationInputTokens int            `json:"cache_creation_input_tokens,omitempty"`
-	CacheCreation            map[string]int `json:"cache_creation,omitempty"`
-}
-
 func (p *Provider) Chat(ctx context.Context, req *chat.Request) (*chat.Result, error) {
 	debugFn := req.Options.DebugFn
 	if p.cfg.APIKey == "" {
@@ -164,16 +94,12 @@ func (p *Provider) Chat(ctx context.Context, req *chat.Request) (*chat.Result, e
 	}
 	diag.LogText(p.cfg.Debug, debugFn, "anthropic.chat.request", string(data))
 
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, messagesURL(p.cfg.APIBase), bytes.NewReader(data))
+	httpReq, err := p.newRequest(ctx, messagesURL(p.cfg.APIBase), data)
 	if err != nil {
 		return nil, err
 	}
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("x-api-key", p.cfg.APIKey)
-	httpReq.Header.Set("anthropic-version", "2023-06-01")
-	httputil.ApplyHeaders(httpReq.Header, p.cfg.Headers)
 
-	resp, err := httputil.ClientForContext(ctx).Do(httpReq)
+	resp, err := httputil.ClientForContext(httputil.WithClient(ctx, p.cfg.HTTPClient)).Do(httpReq)
 	if err != nil {
 		diag.LogError(p.cfg.Debug, debugFn, "anthropic.chat.response", err)
 		return nil, err
@@ -187,9 +113,9 @@ func (p *Provider) Chat(ctx context.Context, req *chat.Request) (*chat.Result, e
 				return nil, err
 			}
 			diag.LogText(p.cfg.Debug, debugFn, "anthropic.chat.response", string(respData))
-			return nil, fmt.Errorf("anthropic api error: status %d: %s", resp.StatusCode, strings.TrimSpace(string(respData)))
+			return nil, apierror.FromResponse("anthropic", resp.StatusCode, resp.Header, respData)
 		}
-		result, err := p.chatStream(resp.Body, req.Options.ReasoningDetails, req.Options.OnStream)
+		result, err := p.chatStream(resp.Body, req.Options.ReasoningDetails, outputToolName(req), req.Options.OnStream)
 		if err != nil {
 			diag.LogError(p.cfg.Debug, debugFn, "anthropic.chat.response", err)
 			return nil, err
@@ -203,15 +129,15 @@ func (p *Provider) Chat(ctx context.Context, req *chat.Request) (*chat.Result, e
 	}
 	diag.LogText(p.cfg.Debug, debugFn, "anthropic.chat.response", string(respData))
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("anthropic api error: status %d: %s", resp.StatusCode, strings.TrimSpace(string(respData)))
+		return nil, apierror.FromResponse("anthropic", resp.StatusCode, resp.Header, respData)
 	}
 
-	var out anthropicResponse
+	var out anthropicapi.Response
 	if err := json.Unmarshal(respData, &out); err != nil {
 		return nil, err
 	}
 
-	result, err := toResult(&out, req.Options.ReasoningDetails)
+	result, err := toResult(&out, req.Options.ReasoningDetails, outputToolName(req))
 	if err != nil {
 		return nil, err
 	}
@@ -255,11 +181,14 @@ func isASCIIDigit(ch byte) bool {
 }
 
 func buildRequest(req *chat.Request, model string) (*anthropicRequest, error) {
+	if err := chat.ValidateRequestDocumentSize(req.Messages, "anthropic", maxDocumentBytes, documentRequestSize); err != nil {
+		return nil, err
+	}
 	modelKey := normalizeAnthropicModel(model)
 	systemTextParts := make([]string, 0, 1)
-	systemParts := make([]anthropicSystemPart, 0, 1)
+	systemParts := make([]anthropicapi.ContentBlock, 0, 1)
 	structuredSystem := false
-	messages := make([]anthropicMessage, 0, len(req.Messages))
+	messages := make([]anthropicapi.Message, 0, len(req.Messages))
 
 	for _, m := range req.Messages {
 		switch m.Role {
@@ -279,7 +208,7 @@ func buildRequest(req *chat.Request, model string) (*anthropicRequest, error) {
 				systemParts = append(systemParts, systemPart)
 			}
 		case chat.RoleUser:
-			msg := anthropicMessage{Role: "user"}
+			msg := anthropicapi.Message{Role: "user"}
 			for _, part := range chat.NormalizeMessageParts(m) {
 				contentPart, ok, err := toAnthropicContentPart(part)
 				if err != nil {
@@ -294,7 +223,7 @@ func buildRequest(req *chat.Request, model string) (*anthropicRequest, error) {
 				messages = append(messages, msg)
 			}
 		case chat.RoleAssistant:
-			msg := anthropicMessage{Role: "assistant"}
+			msg := anthropicapi.Message{Role: "assistant"}
 			for _, part := range chat.NormalizeMessageParts(m) {
 				contentPart, ok, err := toAnthropicContentPart(part)
 				if err != nil {
@@ -326,12 +255,12 @@ func buildRequest(req *chat.Request, model string) (*anthropicRequest, error) {
 			if err != nil {
 				return nil, fmt.Errorf("role %q: %w", m.Role, err)
 			}
-			messages = append(messages, anthropicMessage{
+			messages = append(messages, anthropicapi.Message{
 				Role: "user",
-				Content: []anthropicContentPart{{
+				Content: []anthropicapi.ContentBlock{{
 					Type:      "tool_result",
 					ToolUseID: m.ToolCallID,
-					Content:   text,
+					Content:   toolResultContent(text),
 				}},
 			})
 		default:
@@ -382,6 +311,9 @@ func buildRequest(req *chat.Request, model string) (*anthropicRequest, error) {
 	if err := applyAnthropicReasoningOptions(body, modelKey, req.Options); err != nil {
 		return nil, err
 	}
+	if err := applyAnthropicResponseFormat(body, req.Options.ResponseFormat); err != nil {
+		return nil, err
+	}
 	applyAnthropicOptions(body, req.Options.Anthropic)
 	applyAnthropicModelOverlay(body, modelKey)
 	return body, nil
@@ -403,9 +335,9 @@ func applyAnthropicReasoningOptions(body *anthropicRequest, model string, opts c
 		if modelcompat.AnthropicPrefersReasoningEffort(model) {
 			return fmt.Errorf("anthropic model %q prefers reasoning effort; reasoning budget tokens are not supported in this path", model)
 		}
-		body.Thinking = &anthropicThinking{
+		body.Thinking = &anthropicapi.Thinking{
 			Type:         "enabled",
-			BudgetTokens: opts.ReasoningBudget,
+			BudgetTokens: budget,
 		}
 	}
 
@@ -419,7 +351,7 @@ func applyAnthropicReasoningOptions(body *anthropicRequest, model string, opts c
 	if opts.ReasoningDetails {
 		switch {
 		case modelcompat.AnthropicPrefersReasoningEffort(model):
-			body.Thinking = &anthropicThinking{Type: "adaptive"}
+			body.Thinking = &anthropicapi.Thinking{Type: "adaptive"}
 			if modelcompat.AnthropicSummarizesThinkingDetails(model) {
 				body.Thinking.Display = "summarized"
 			}
@@ -433,6 +365,46 @@ func applyAnthropicReasoningOptions(body *anthropicRequest, model string, opts c
 	return nil
 }
 
+// applyAnthropicResponseFormat implements structured output as a forced call
+// of a single tool whose input schema is the requested schema. toResult and
+// chatStream turn that call back into text.
+func applyAnthropicResponseFormat(body *anthropicRequest, format *chat.ResponseFormat) error {
+	if body == nil || format == nil {
+		return nil
+	}
+	if len(body.Tools) > 0 || body.ToolChoice != nil {
+		return fmt.Errorf("structured output cannot be combined with tools")
+	}
+	if body.Thinking != nil {
+		return fmt.Errorf("structured output forces a tool call, which extended thinking does not allow")
+	}
+	schema, err := format.SchemaMap()
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return err
+	}
+	name := format.ToolName()
+	body.Tools = []anthropicapi.Tool{{
+		Name:        name,
+		Description: "Respond with the structured output.",
+		InputSchema: data,
+	}}
+	body.ToolChoice = &anthropicapi.ToolChoice{Type: "tool", Name: name}
+	return nil
+}
+
+// outputToolName returns the forced tool name used for structured output, or
+// "" when the request has no response format.
+func outputToolName(req *chat.Request) string {
+	if req.Options.ResponseFormat == nil {
+		return ""
+	}
+	return req.Options.ResponseFormat.ToolName()
+}
+
 func applyAnthropicModelOverlay(body *anthropicRequest, model string) {
 	if body == nil || !modelcompat.AnthropicDropsSamplingParameters(model) {
 		return
@@ -453,12 +425,12 @@ func applyAnthropicOptions(body *anthropicRequest, opts structs.JSONMap) {
 		}
 	}
 	if userID := readUserID(opt); userID != "" {
-		body.Metadata = &anthropicMetadata{UserID: userID}
+		body.Metadata = &anthropicapi.Metadata{UserID: userID}
 	}
 }
 
-func toAnthropicTools(tools []chat.Tool) ([]anthropicTool, error) {
-	out := make([]anthropicTool, 0, len(tools))
+func toAnthropicTools(tools []chat.Tool) ([]anthropicapi.Tool, error) {
+	out := make([]anthropicapi.Tool, 0, len(tools))
 	for _, tool := range tools {
 		if tool.Type != "function" {
 			continue
@@ -466,15 +438,15 @@ func toAnthropicTools(tools []chat.Tool) ([]anthropicTool, error) {
 		if tool.Function.Name == "" {
 			continue
 		}
-		var schema any
+		schema := json.RawMessage(`{"type":"object"}`)
 		if len(tool.Function.ParametersJSONSchema) > 0 {
-			if err := json.Unmarshal(tool.Function.ParametersJSONSchema, &schema); err != nil {
+			var decoded any
+			if err := json.Unmarshal(tool.Function.ParametersJSONSchema, &decoded); err != nil {
 				return nil, err
 			}
-		} else {
-			schema = map[string]any{"type": "object"}
+			schema = json.RawMessage(tool.Function.ParametersJSONSchema)
 		}
-		at := anthropicTool{
+		at := anthropicapi.Tool{
 			Name:         tool.Function.Name,
 			Description:  tool.Function.Description,
 			InputSchema:  schema,
@@ -485,29 +457,29 @@ func toAnthropicTools(tools []chat.Tool) ([]anthropicTool, error) {
 	return out, nil
 }
 
-func toAnthropicToolChoice(choice *chat.ToolChoice) (*anthropicToolChoice, error) {
+func toAnthropicToolChoice(choice *chat.ToolChoice) (*anthropicapi.ToolChoice, error) {
 	if choice == nil {
 		return nil, nil
 	}
 	switch choice.Mode {
 	case "auto":
-		return &anthropicToolChoice{Type: "auto"}, nil
+		return &anthropicapi.ToolChoice{Type: "auto"}, nil
 	case "none":
-		return &anthropicToolChoice{Type: "none"}, nil
+		return &anthropicapi.ToolChoice{Type: "none"}, nil
 	case "required":
-		return &anthropicToolChoice{Type: "any"}, nil
+		return &anthropicapi.ToolChoice{Type: "any"}, nil
 	case "function":
 		if strings.TrimSpace(choice.FunctionName) == "" {
 			return nil, fmt.Errorf("tool_choice function_name is required")
 		}
-		return &anthropicToolChoice{Type: "tool", Name: choice.FunctionName}, nil
+		return &anthropicapi.ToolChoice{Type: "tool", Name: choice.FunctionName}, nil
 	default:
 		return nil, nil
 	}
 }
 
-func toAnthropicToolUses(calls []chat.ToolCall) ([]anthropicContentPart, error) {
-	out := make([]anthropicContentPart, 0, len(calls))
+func toAnthropicToolUses(calls []chat.ToolCall) ([]anthropicapi.ContentBlock, error) {
+	out := make([]anthropicapi.ContentBlock, 0, len(calls))
 	for _, call := range calls {
 		if call.Function.Name == "" {
 			continue
@@ -524,22 +496,22 @@ func toAnthropicToolUses(calls []chat.ToolCall) ([]anthropicContentPart, error)
 		if err := json.Unmarshal([]byte(args), &input); err != nil {
 			return nil, fmt.Errorf("invalid tool call arguments: %w", err)
 		}
-		out = append(out, anthropicContentPart{
+		out = append(out, anthropicapi.ContentBlock{
 			Type:  "tool_use",
 			ID:    id,
 			Name:  call.Function.Name,
-			Input: input,
+			Input: json.RawMessage(args),
 		})
 	}
 	return out, nil
 }
 
-func fromAnthropicToolUse(part anthropicContentPart) (chat.ToolCall, error) {
+func fromAnthropicToolUse(part anthropicapi.ContentBlock) (chat.ToolCall, error) {
 	if strings.TrimSpace(part.ID) == "" || strings.TrimSpace(part.Name) == "" {
 		return chat.ToolCall{}, fmt.Errorf("anthropic tool_use missing id or name")
 	}
 	args := "{}"
-	if part.Input != nil {
+	if len(part.Input) > 0 && string(part.Input) != "null" {
 		data, err := json.Marshal(part.Input)
 		if err != nil {
 			return chat.ToolCall{}, err
@@ -556,7 +528,9 @@ func fromAnthropicToolUse(part anthropicContentPart) (chat.ToolCall, error) {
 	}, nil
 }
 
-func toResult(out *anthropicResponse, reasoningDetails bool) (*chat.Result, error) {
+// toResult converts a Messages response. A tool_use block named outputTool
+// is structured output and becomes the result text.
+func toResult(out *anthropicapi.Response, reasoningDetails bool, outputTool string) (*chat.Result, error) {
 	if out == nil {
 		return &chat.Result{}, nil
 	}
@@ -570,6 +544,14 @@ func toResult(out *anthropicResponse, reasoningDetails bool) (*chat.Result, erro
 				textParts = append(textParts, part.Text)
 			}
 		case "tool_use":
+			if outputTool != "" && part.Name == outputTool {
+				data, err := json.Marshal(part.Input)
+				if err != nil {
+					return nil, err
+				}
+				textParts = append(textParts, string(data))
+				continue
+			}
 			call, err := fromAnthropicToolUse(part)
 			if err != nil {
 				return nil, err
@@ -599,6 +581,7 @@ func toResult(out *anthropicResponse, reasoningDetails bool) (*chat.Result, erro
 	if text != "" {
 		result.Parts = append(result.Parts, chat.TextPart(text))
 	}
+	anthropicapi.SetFinishReason(result, out.StopReason, outputTool)
 	return result, nil
 }
 
@@ -624,52 +607,25 @@ func appendAnthropicReasoning(reasoning *chat.ReasoningResult, typ, text, signat
 	return reasoning
 }
 
-// SSE event data types for streaming.
-
-type sseMessageStart struct {
-	Message struct {
-		Model string         `json:"model"`
-		Usage anthropicUsage `json:"usage"`
-	} `json:"message"`
-}
-
-type sseContentBlockStart struct {
-	Index        int `json:"index"`
-	ContentBlock struct {
-		Type string `json:"type"`
-		ID   string `json:"id,omitempty"`
-		Name string `json:"name,omitempty"`
-	} `json:"content_block"`
-}
-
-type sseContentBlockDelta struct {
-	Index int `json:"index"`
-	Delta struct {
-		Type        string `json:"type"`
-		Text        string `json:"text,omitempty"`
-		PartialJSON string `json:"partial_json,omitempty"`
-	} `json:"delta"`
-}
-
-type sseMessageDelta struct {
-	Usage anthropicUsage `json:"usage"`
-}
-
-func (p *Provider) chatStream(body io.Reader, reasoningDetails bool, onStream chat.OnStreamFunc) (*chat.Result, error) {
+// chatStream reads a Messages SSE stream. Argument deltas of the tool named
+// outputTool are structured output and are streamed as text deltas.
+func (p *Provider) chatStream(body io.Reader, reasoningDetails bool, outputTool string, onStream chat.OnStreamFunc) (*chat.Result, error) {
 	scanner := bufio.NewScanner(body)
 	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024) // allow lines up to 1 MB
 
 	var (
-		model     string
-		usage     chat.Usage
-		textParts []string
-		toolCalls []chat.ToolCall
+		model      string
+		stopReason string
+		usage      chat.Usage
+		textParts  []string
+		toolCalls  []chat.ToolCall
 
 		// per-tool-call accumulator
 		currentToolIndex int = -1
 		currentToolID    string
 		currentToolName  string
 		currentToolArgs  strings.Builder
+		outputIndex      = -1
 		reasoningState   anthropicstream.ReasoningState
 	)
 
@@ -714,16 +670,19 @@ func (p *Provider) chatStream(body io.Reader, reasoningDetails bool, onStream ch
 
 		switch eventType {
 		case "message_start":
-			var ev sseMessageStart
+			var ev anthropicapi.MessageStartEvent
 			if err := json.Unmarshal([]byte(data), &ev); err == nil {
 				model = ev.Message.Model
 				applyAnthropicUsage(&usage, ev.Message.Usage)
 			}
 
 		case "content_block_start":
-			var ev sseContentBlockStart
+			var ev anthropicapi.ContentBlockStartEvent
 			if err := json.Unmarshal([]byte(data), &ev); err == nil {
-				if ev.ContentBlock.Type == "tool_use" {
+				if ev.ContentBlock.Type == "tool_use" && outputTool != "" && ev.ContentBlock.Name == outputTool {
+					flushToolCall()
+					outputIndex = ev.Index
+				} else if ev.ContentBlock.Type == "tool_use" {
 					flushToolCall()
 					currentToolIndex = ev.Index
 					currentToolID = ev.ContentBlock.ID
@@ -742,7 +701,7 @@ func (p *Provider) chatStream(body io.Reader, reasoningDetails bool, onStream ch
 			}
 
 		case "content_block_delta":
-			var ev sseContentBlockDelta
+			var ev anthropicapi.ContentBlockDeltaEvent
 			if err := json.Unmarshal([]byte(data), &ev); err == nil {
 				switch ev.Delta.Type {
 				case "text_delta":
@@ -754,6 +713,16 @@ func (p *Provider) chatStream(body io.Reader, reasoningDetails bool, onStream ch
 						return nil, err
 					}
 				case "input_json_delta":
+					if ev.Index == outputIndex {
+						textParts = append(textParts, ev.Delta.PartialJSON)
+						if err := onStream(chat.StreamEvent{
+							Delta: ev.Delta.PartialJSON,
+							Raw:   raw,
+						}); err != nil {
+							return nil, err
+						}
+						break
+					}
 					currentToolArgs.WriteString(ev.Delta.PartialJSON)
 					if err := onStream(chat.StreamEvent{
 						ToolCallDelta: &chat.ToolCallDelta{
@@ -771,13 +740,19 @@ func (p *Provider) chatStream(body io.Reader, reasoningDetails bool, onStream ch
 			flushToolCall()
 
 		case "message_delta":
-			var ev sseMessageDelta
+			var ev anthropicapi.MessageDeltaEvent
 			if err := json.Unmarshal([]byte(data), &ev); err == nil {
 				applyAnthropicUsage(&usage, ev.Usage)
+				if ev.Delta.StopReason != "" {
+					stopReason = ev.Delta.StopReason
+				}
 			}
 
 		case "message_stop":
 			// handled after the loop
+
+		case "error":
+			return nil, apierror.FromResponse("anthropic", 0, nil, []byte(data))
 		}
 		eventType = ""
 	}
@@ -788,56 +763,65 @@ func (p *Provider) chatStream(body io.Reader, reasoningDetails bool, onStream ch
 	flushToolCall()
 
 	usage.TotalTokens = usage.InputTokens + usage.OutputTokens
+	text := strings.Join(textParts, "")
+	result := &chat.Result{
+		Text:      text,
+		Model:     model,
+		ToolCalls: toolCalls,
+		Reasoning: reasoningState.Result(),
+		Usage:     usage,
+	}
+	if text != "" {
+		result.Parts = []chat.Part{chat.TextPart(text)}
+	}
+	anthropicapi.SetFinishReason(result, stopReason, outputTool)
 	if err := onStream(chat.StreamEvent{
-		Done:  true,
-		Usage: &usage,
+		Done:            true,
+		Usage:           &usage,
+		FinishReason:    result.FinishReason,
+		RawFinishReason: result.RawFinishReason,
 	}); err != nil {
 		return nil, err
 	}
+	return result, nil
+}
 
-	return &chat.Result{
-		Text:  strings.Join(textParts, ""),
-		Model: model,
-		Parts: func() []chat.Part {
-			text := strings.Join(textParts, "")
-			if text == "" {
-				return nil
-			}
-			return []chat.Part{chat.TextPart(text)}
-		}(),
-		ToolCalls: toolCalls,
-		Reasoning: reasoningState.Result(),
-		Usage:     usage,
-	}, nil
+// toolResultContent returns the tool_result content for text, which is
+// omitted when empty since the API rejects empty text blocks.
+func toolResultContent(text string) anthropicapi.Content {
+	if text == "" {
+		return nil
+	}
+	return anthropicapi.Content{{Type: "text", Text: text}}
 }
 
-func toAnthropicSystemPart(part chat.Part) (anthropicSystemPart, bool, error) {
+func toAnthropicSystemPart(part chat.Part) (anthropicapi.ContentBlock, bool, error) {
 	if err := chat.ValidatePart(part); err != nil {
-		return anthropicSystemPart{}, false, err
+		return anthropicapi.ContentBlock{}, false, err
 	}
 	if part.Type != chat.PartTypeText {
-		return anthropicSystemPart{}, false, fmt.Errorf("unsupported part type %q", part.Type)
+		return anthropicapi.ContentBlock{}, false, fmt.Errorf("unsupported part type %q", part.Type)
 	}
 	if strings.TrimSpace(part.Text) == "" && part.CacheControl == nil {
-		return anthropicSystemPart{}, false, nil
+		return anthropicapi.ContentBlock{}, false, nil
 	}
-	return anthropicSystemPart{
+	return anthropicapi.ContentBlock{
 		Type:         "text",
 		Text:         part.Text,
 		CacheControl: toAnthropicCacheControl(part.CacheControl),
 	}, true, nil
 }
 
-func toAnthropicContentPart(part chat.Part) (anthropicContentPart, bool, error) {
+func toAnthropicContentPart(part chat.Part) (anthropicapi.ContentBlock, bool, error) {
 	if err := chat.ValidatePart(part); err != nil {
-		return anthropicContentPart{}, false, err
+		return anthropicapi.ContentBlock{}, false, err
 	}
 	switch part.Type {
 	case chat.PartTypeText:
 		if strings.TrimSpace(part.Text) == "" && part.CacheControl == nil {
-			return anthropicContentPart{}, false, nil
+			return anthropicapi.ContentBlock{}, false, nil
 		}
-		return anthropicContentPart{
+		return anthropicapi.ContentBlock{
 			Type:         "text",
 			Text:         part.Text,
 			CacheControl: toAnthropicCacheControl(part.CacheControl),
@@ -847,9 +831,9 @@ func toAnthropicContentPart(part chat.Part) (anthropicContentPart, bool, error)
 		if mimeType == "" {
 			mimeType = "image/png"
 		}
-		return anthropicContentPart{
+		return anthropicapi.ContentBlock{
 			Type: "image",
-			Source: &anthropicImageSource{
+			Source: &anthropicapi.Source{
 				Type:      "base64",
 				MediaType: mimeType,
 				Data:      strings.TrimSpace(part.DataBase64),
@@ -857,31 +841,63 @@ func toAnthropicContentPart(part chat.Part) (anthropicContentPart, bool, error)
 			CacheControl: toAnthropicCacheControl(part.CacheControl),
 		}, true, nil
 	case chat.PartTypeImageURL:
-		return anthropicContentPart{
+		return anthropicapi.ContentBlock{
 			Type: "image",
-			Source: &anthropicImageSource{
+			Source: &anthropicapi.Source{
 				Type: "url",
 				URL:  strings.TrimSpace(part.URL),
 			},
 			CacheControl: toAnthropicCacheControl(part.CacheControl),
 		}, true, nil
+	case chat.PartTypeDocumentURL, chat.PartTypeDocumentBase64, chat.PartTypeDocumentText:
+		block := anthropicapi.ContentBlock{
+			Type:         "document",
+			Source:       toAnthropicDocumentSource(part),
+			Title:        strings.TrimSpace(part.Title),
+			CacheControl: toAnthropicCacheControl(part.CacheControl),
+		}
+		if part.Citations {
+			block.Citations = anthropicapi.CitationsEnabled()
+		}
+		return block, true, nil
+	case chat.PartTypeAudioURL, chat.PartTypeAudioBase64:
+		return anthropicapi.ContentBlock{}, false, fmt.Errorf("anthropic provider does not support %q parts", part.Type)
+	default:
+		return anthropicapi.ContentBlock{}, false, fmt.Errorf("unsupported part type %q", part.Type)
+	}
+}
+
+// documentRequestSize is the size a document part adds to the request body.
+func documentRequestSize(part chat.Part) int {
+	if part.Type == chat.PartTypeDocumentText {
+		return chat.DocumentSize(part)
+	}
+	return chat.EncodedDocumentSize(part)
+}
+
+func toAnthropicDocumentSource(part chat.Part) *anthropicapi.Source {
+	switch part.Type {
+	case chat.PartTypeDocumentURL:
+		return &anthropicapi.Source{Type: "url", URL: strings.TrimSpace(part.URL)}
+	case chat.PartTypeDocumentText:
+		return &anthropicapi.Source{Type: "text", MediaType: "text/plain", Data: part.Text}
 	default:
-		return anthropicContentPart{}, false, fmt.Errorf("unsupported part type %q", part.Type)
+		return &anthropicapi.Source{Type: "base64", MediaType: chat.DocumentMIMEType(part), Data: strings.TrimSpace(part.DataBase64)}
 	}
 }
 
-func toAnthropicCacheControl(ctrl *chat.CacheControl) *anthropicCacheControl {
+func toAnthropicCacheControl(ctrl *chat.CacheControl) *anthropicapi.CacheControl {
 	if ctrl == nil {
 		return nil
 	}
-	out := &anthropicCacheControl{Type: "ephemeral"}
+	out := &anthropicapi.CacheControl{Type: "ephemeral"}
 	if ttl := strings.TrimSpace(ctrl.TTL); ttl != "" {
 		out.TTL = ttl
 	}
 	return out
 }
 
-func usageFromAnthropicUsage(src anthropicUsage) chat.Usage {
+func usageFromAnthropicUsage(src anthropicapi.Usage) chat.Usage {
 	usage := chat.Usage{
 		InputTokens:  src.InputTokens,
 		OutputTokens: src.OutputTokens,
@@ -894,7 +910,7 @@ func usageFromAnthropicUsage(src anthropicUsage) chat.Usage {
 	return usage
 }
 
-func applyAnthropicUsage(dst *chat.Usage, src anthropicUsage) {
+func applyAnthropicUsage(dst *chat.Usage, src anthropicapi.Usage) {
 	if dst == nil {
 		return
 	}
@@ -2,14 +2,19 @@ package anthropic
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/lyricat/goutils/structs"
 	"github.com/quailyquaily/uniai/chat"
+	"github.com/quailyquaily/uniai/internal/anthropicapi"
+	"github.com/quailyquaily/uniai/internal/apierror"
 	"github.com/quailyquaily/uniai/internal/httputil"
 )
 
@@ -118,6 +123,80 @@ func TestBuildRequestRejectsNonTextPartForSystemRole(t *testing.T) {
 	}
 }
 
+func TestBuildRequestMapsDocumentParts(t *testing.T) {
+	pdf := chat.WithPartCacheControl(chat.DocumentBase64Part("", "JVBERi0="), chat.CacheTTL5m())
+	pdf.Title = "report.pdf"
+	pdf.Citations = true
+	req := &chat.Request{
+		Model: "claude-sonnet-4-20250514",
+		Messages: []chat.Message{
+			chat.UserParts(
+				pdf,
+				chat.DocumentURLPart("https://example.com/a.pdf"),
+				chat.DocumentTextPart("notes", "hello"),
+				chat.TextPart("summarize"),
+			),
+		},
+	}
+
+	body, err := buildRequest(req, req.Model)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, _ := json.Marshal(body.Messages[0].Content[:3])
+	want := `[{"type":"document","source":{"type":"base64","media_type":"application/pdf","data":"JVBERi0="},"title":"report.pdf","citations":{"enabled":true},"cache_control":{"type":"ephemeral","ttl":"5m"}},` +
+		`{"type":"document","source":{"type":"url","url":"https://example.com/a.pdf"}},` +
+		`{"type":"document","source":{"type":"text","media_type":"text/plain","data":"hello"},"title":"notes"}]`
+	if string(data) != want {
+		t.Fatalf("unexpected document blocks:\n got %s\nwant %s", data, want)
+	}
+}
+
+func TestBuildRequestLimitsEncodedDocumentSize(t *testing.T) {
+	build := func(parts ...chat.Part) error {
+		_, err := buildRequest(&chat.Request{
+			Model:    "claude-sonnet-4-20250514",
+			Messages: []chat.Message{chat.UserParts(parts...)},
+		}, "claude-sonnet-4-20250514")
+		return err
+	}
+
+	// The limit applies to the base64 data, not to the decoded document.
+	if err := build(chat.DocumentBase64Part("", strings.Repeat("A", maxDocumentBytes))); err != nil {
+		t.Fatalf("unexpected error at the limit: %v", err)
+	}
+	err := build(chat.DocumentBase64Part("", strings.Repeat("A", maxDocumentBytes+4)))
+	if err == nil || !strings.Contains(err.Error(), "anthropic provider accepts up to 33554432 bytes of documents per request") {
+		t.Fatalf("expected size limit error, got %v", err)
+	}
+	// Text sources are sent as is.
+	if err := build(chat.DocumentTextPart("", strings.Repeat("a", maxDocumentBytes))); err != nil {
+		t.Fatalf("unexpected error for text at the limit: %v", err)
+	}
+	if err := build(chat.DocumentTextPart("", strings.Repeat("a", maxDocumentBytes+1))); err == nil {
+		t.Fatal("expected size limit error for text over the limit")
+	}
+	// The limit covers all documents of the request.
+	half := strings.Repeat("a", maxDocumentBytes/2)
+	if err := build(chat.DocumentTextPart("", half), chat.DocumentTextPart("", half+"a")); err == nil {
+		t.Fatal("expected size limit error for documents over the limit together")
+	}
+}
+
+func TestBuildRequestRejectsAudioParts(t *testing.T) {
+	req := &chat.Request{
+		Model: "claude-sonnet-4-20250514",
+		Messages: []chat.Message{
+			chat.UserParts(chat.AudioBase64Part("audio/wav", "UklGRg==")),
+		},
+	}
+
+	_, err := buildRequest(req, req.Model)
+	if err == nil || !strings.Contains(err.Error(), `anthropic provider does not support "audio_base64" parts`) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
 func TestBuildRequestMapsReasoningBudget(t *testing.T) {
 	budget := 4096
 	req := &chat.Request{
@@ -134,11 +213,70 @@ func TestBuildRequestMapsReasoningBudget(t *testing.T) {
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if body.Thinking == nil || body.Thinking.Type != "enabled" || body.Thinking.BudgetTokens == nil || *body.Thinking.BudgetTokens != budget {
+	if body.Thinking == nil || body.Thinking.Type != "enabled" || body.Thinking.BudgetTokens != budget {
 		t.Fatalf("unexpected thinking config: %#v", body.Thinking)
 	}
 }
 
+func TestBuildRequestForcesToolForResponseFormat(t *testing.T) {
+	req, err := chat.BuildRequest(
+		chat.WithModel("claude-sonnet-4-5"),
+		chat.WithMessages(chat.User("hello")),
+		chat.WithJSONSchema("person", []byte(`{"type":"object","properties":{"name":{"type":"string"}}}`), true),
+	)
+	if err != nil {
+		t.Fatalf("build request: %v", err)
+	}
+
+	body, err := buildRequest(req, req.Model)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(body.Tools) != 1 || body.Tools[0].Name != "person" {
+		t.Fatalf("unexpected tools: %#v", body.Tools)
+	}
+	if body.ToolChoice == nil || body.ToolChoice.Type != "tool" || body.ToolChoice.Name != "person" {
+		t.Fatalf("unexpected tool choice: %#v", body.ToolChoice)
+	}
+
+	req.Tools = []chat.Tool{chat.FunctionTool("lookup", "", nil)}
+	if _, err := buildRequest(req, req.Model); err == nil || !strings.Contains(err.Error(), "cannot be combined with tools") {
+		t.Fatalf("expected tools conflict, got %v", err)
+	}
+}
+
+func TestToResultTurnsOutputToolIntoText(t *testing.T) {
+	out, err := toResult(&anthropicapi.Response{
+		Content: []anthropicapi.ContentBlock{
+			{Type: "tool_use", ID: "toolu_1", Name: "person", Input: json.RawMessage(`{"name":"Ada"}`)},
+		},
+		StopReason: "tool_use",
+	}, false, "person")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.Text != `{"name":"Ada"}` || len(out.ToolCalls) != 0 {
+		t.Fatalf("unexpected result: text=%q tool_calls=%#v", out.Text, out.ToolCalls)
+	}
+	if out.FinishReason != chat.FinishReasonStop {
+		t.Fatalf("unexpected finish reason: %q", out.FinishReason)
+	}
+}
+
+func TestToResultDefaultsMissingToolInput(t *testing.T) {
+	var out anthropicapi.Response
+	if err := json.Unmarshal([]byte(`{"content":[{"type":"tool_use","id":"toolu_1","name":"now","input":null},{"type":"tool_use","id":"toolu_2","name":"now"}],"stop_reason":"tool_use"}`), &out); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	result, err := toResult(&out, false, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.ToolCalls) != 2 || result.ToolCalls[0].Function.Arguments != "{}" || result.ToolCalls[1].Function.Arguments != "{}" {
+		t.Fatalf("unexpected tool calls: %#v", result.ToolCalls)
+	}
+}
+
 func TestBuildRequestMapsCacheControl(t *testing.T) {
 	req := &chat.Request{
 		Model: "claude-sonnet-4-20250514",
@@ -156,7 +294,7 @@ func TestBuildRequestMapsCacheControl(t *testing.T) {
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	system, ok := body.System.([]anthropicSystemPart)
+	system, ok := body.System.([]anthropicapi.ContentBlock)
 	if !ok || len(system) != 1 {
 		t.Fatalf("expected structured system blocks, got %#v", body.System)
 	}
@@ -396,14 +534,14 @@ func TestBuildRequestRejectsReasoningBudgetOnEffortModels(t *testing.T) {
 }
 
 func TestToResultParsesReasoningDetails(t *testing.T) {
-	out, err := toResult(&anthropicResponse{
+	out, err := toResult(&anthropicapi.Response{
 		Model: "claude-opus-4-5-20250929",
-		Content: []anthropicContentPart{
+		Content: []anthropicapi.ContentBlock{
 			{Type: "thinking", Thinking: "I should inspect the file", Signature: "sig1"},
 			{Type: "redacted_thinking", Data: "opaque"},
 			{Type: "text", Text: "done"},
 		},
-	}, true)
+	}, true, "")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -419,12 +557,12 @@ func TestToResultParsesReasoningDetails(t *testing.T) {
 }
 
 func TestToResultParsesCacheUsage(t *testing.T) {
-	out, err := toResult(&anthropicResponse{
+	out, err := toResult(&anthropicapi.Response{
 		Model: "claude-sonnet-4-20250514",
-		Content: []anthropicContentPart{
+		Content: []anthropicapi.ContentBlock{
 			{Type: "text", Text: "ok"},
 		},
-		Usage: anthropicUsage{
+		Usage: anthropicapi.Usage{
 			InputTokens:              100,
 			OutputTokens:             20,
 			CacheReadInputTokens:     80,
@@ -433,7 +571,7 @@ func TestToResultParsesCacheUsage(t *testing.T) {
 				"ephemeral_5m_input_tokens": 40,
 			},
 		},
-	}, false)
+	}, false, "")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -527,6 +665,39 @@ func TestChatUsesCustomAPIBase(t *testing.T) {
 	}
 }
 
+func TestChatReturnsTypedAPIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("request-id", "req_abc")
+		w.Header().Set("retry-after", "7")
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = io.WriteString(w, `{"type":"error","error":{"type":"rate_limit_error","message":"Number of request tokens has exceeded your per-minute rate limit"}}`)
+	}))
+	defer server.Close()
+
+	p := New(Config{
+		APIKey:       "test-key",
+		APIBase:      server.URL,
+		DefaultModel: "claude-sonnet-test",
+	})
+	_, err := p.Chat(context.Background(), &chat.Request{
+		Messages: []chat.Message{chat.User("hello")},
+	})
+	if !errors.Is(err, apierror.ErrRateLimited) {
+		t.Fatalf("expected rate limited error, got %v", err)
+	}
+	var apiErr *apierror.Error
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected *apierror.Error, got %T", err)
+	}
+	if apiErr.Provider != "anthropic" || apiErr.StatusCode != http.StatusTooManyRequests || apiErr.Type != "rate_limit_error" {
+		t.Fatalf("unexpected api error: %#v", apiErr)
+	}
+	if apiErr.RequestID != "req_abc" || apiErr.RetryAfter != 7*time.Second {
+		t.Fatalf("unexpected request id / retry after: %q %v", apiErr.RequestID, apiErr.RetryAfter)
+	}
+}
+
 func TestNormalizeAPIBase(t *testing.T) {
 	tests := []struct {
 		name string
@@ -0,0 +1,96 @@
+package anthropic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/quailyquaily/uniai/chat"
+	"github.com/quailyquaily/uniai/internal/anthropicapi"
+	"github.com/quailyquaily/uniai/internal/apierror"
+	"github.com/quailyquaily/uniai/internal/diag"
+	"github.com/quailyquaily/uniai/internal/httputil"
+)
+
+// anthropicCountTokensRequest is the subset of anthropicRequest accepted by
+// the /messages/count_tokens endpoint.
+type anthropicCountTokensRequest struct {
+	Model      string                   `json:"model"`
+	System     any                      `json:"system,omitempty"`
+	Messages   []anthropicapi.Message   `json:"messages"`
+	Tools      []anthropicapi.Tool      `json:"tools,omitempty"`
+	ToolChoice *anthropicapi.ToolChoice `json:"tool_choice,omitempty"`
+	Thinking   *anthropicapi.Thinking   `json:"thinking,omitempty"`
+}
+
+// CountTokens returns the input token count of req as reported by the
+// Messages count_tokens endpoint. The request is built as Chat builds it.
+func (p *Provider) CountTokens(ctx context.Context, req *chat.Request) (int, error) {
+	debugFn := req.Options.DebugFn
+	if p.cfg.APIKey == "" {
+		return 0, fmt.Errorf("anthropic api key is required")
+	}
+	model := req.Model
+	if model == "" {
+		model = p.cfg.DefaultModel
+	}
+	if model == "" {
+		return 0, fmt.Errorf("model is required")
+	}
+	body, err := buildRequest(req, model)
+	if err != nil {
+		return 0, fmt.Errorf("anthropic provider model %q: %w", model, err)
+	}
+	data, err := json.Marshal(anthropicCountTokensRequest{
+		Model:      body.Model,
+		System:     body.System,
+		Messages:   body.Messages,
+		Tools:      body.Tools,
+		ToolChoice: body.ToolChoice,
+		Thinking:   body.Thinking,
+	})
+	if err != nil {
+		return 0, err
+	}
+	diag.LogText(p.cfg.Debug, debugFn, "anthropic.count_tokens.request", string(data))
+
+	httpReq, err := p.newRequest(ctx, normalizeAPIBase(p.cfg.APIBase)+"/messages/count_tokens", data)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := httputil.ClientForContext(httputil.WithClient(ctx, p.cfg.HTTPClient)).Do(httpReq)
+	if err != nil {
+		diag.LogError(p.cfg.Debug, debugFn, "anthropic.count_tokens.response", err)
+		return 0, err
+	}
+	defer resp.Body.Close()
+	respData, err := httputil.ReadBody(resp.Body)
+	if err != nil {
+		return 0, err
+	}
+	diag.LogText(p.cfg.Debug, debugFn, "anthropic.count_tokens.response", string(respData))
+	if resp.StatusCode != http.StatusOK {
+		return 0, apierror.FromResponse("anthropic", resp.StatusCode, resp.Header, respData)
+	}
+	var out struct {
+		InputTokens int `json:"input_tokens"`
+	}
+	if err := json.Unmarshal(respData, &out); err != nil {
+		return 0, err
+	}
+	return out.InputTokens, nil
+}
+
+func (p *Provider) newRequest(ctx context.Context, endpoint string, data []byte) (*http.Request, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("x-api-key", p.cfg.APIKey)
+	httpReq.Header.Set("anthropic-version", "2023-06-01")
+	httputil.ApplyHeaders(httpReq.Header, p.cfg.Headers)
+	return httpReq, nil
+}
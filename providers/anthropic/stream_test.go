@@ -1,11 +1,13 @@
 package anthropic
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 	"testing"
 
 	"github.com/quailyquaily/uniai/chat"
+	"github.com/quailyquaily/uniai/internal/apierror"
 )
 
 func sseEvent(event, data string) string {
@@ -26,12 +28,14 @@ func TestChatStreamText(t *testing.T) {
 	var deltas []string
 	var gotDone bool
 	var gotUsage *chat.Usage
+	var gotFinish chat.FinishReason
 
 	p := &Provider{}
-	result, err := p.chatStream(strings.NewReader(sse), false, func(ev chat.StreamEvent) error {
+	result, err := p.chatStream(strings.NewReader(sse), false, "", func(ev chat.StreamEvent) error {
 		if ev.Done {
 			gotDone = true
 			gotUsage = ev.Usage
+			gotFinish = ev.FinishReason
 			return nil
 		}
 		if ev.Delta != "" {
@@ -60,6 +64,9 @@ func TestChatStreamText(t *testing.T) {
 	if result.Usage.InputTokens != 10 || result.Usage.OutputTokens != 5 {
 		t.Fatalf("result usage mismatch: %+v", result.Usage)
 	}
+	if gotFinish != chat.FinishReasonStop || result.FinishReason != chat.FinishReasonStop || result.RawFinishReason != "end_turn" {
+		t.Fatalf("finish reason mismatch: done=%q result=%q raw=%q", gotFinish, result.FinishReason, result.RawFinishReason)
+	}
 }
 
 func TestChatStreamToolCall(t *testing.T) {
@@ -75,7 +82,7 @@ func TestChatStreamToolCall(t *testing.T) {
 
 	var toolDeltas []chat.ToolCallDelta
 	p := &Provider{}
-	result, err := p.chatStream(strings.NewReader(sse), false, func(ev chat.StreamEvent) error {
+	result, err := p.chatStream(strings.NewReader(sse), false, "", func(ev chat.StreamEvent) error {
 		if ev.ToolCallDelta != nil {
 			toolDeltas = append(toolDeltas, *ev.ToolCallDelta)
 		}
@@ -94,6 +101,9 @@ func TestChatStreamToolCall(t *testing.T) {
 	if tc.Function.Arguments != `{"city":"Tokyo"}` {
 		t.Fatalf("tool args mismatch: %q", tc.Function.Arguments)
 	}
+	if result.FinishReason != chat.FinishReasonToolCalls {
+		t.Fatalf("finish reason mismatch: %q", result.FinishReason)
+	}
 
 	// First delta should carry ID and Name
 	if len(toolDeltas) < 1 || toolDeltas[0].ID != "toolu_01" || toolDeltas[0].Name != "get_weather" {
@@ -108,6 +118,39 @@ func TestChatStreamToolCall(t *testing.T) {
 	}
 }
 
+func TestChatStreamOutputToolAsText(t *testing.T) {
+	sse := strings.Join([]string{
+		sseEvent("message_start", `{"type":"message_start","message":{"model":"claude-sonnet-4-20250514","usage":{"input_tokens":20}}}`),
+		sseEvent("content_block_start", `{"type":"content_block_start","index":0,"content_block":{"type":"tool_use","id":"toolu_01","name":"json_output"}}`),
+		sseEvent("content_block_delta", `{"type":"content_block_delta","index":0,"delta":{"type":"input_json_delta","partial_json":"{\"ok\""}}`),
+		sseEvent("content_block_delta", `{"type":"content_block_delta","index":0,"delta":{"type":"input_json_delta","partial_json":":true}"}}`),
+		sseEvent("content_block_stop", `{"type":"content_block_stop","index":0}`),
+		sseEvent("message_delta", `{"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":8}}`),
+		sseEvent("message_stop", `{"type":"message_stop"}`),
+	}, "")
+
+	var deltas []string
+	p := &Provider{}
+	result, err := p.chatStream(strings.NewReader(sse), false, chat.DefaultResponseFormatName, func(ev chat.StreamEvent) error {
+		if ev.ToolCallDelta != nil {
+			t.Fatalf("unexpected tool call delta: %+v", ev.ToolCallDelta)
+		}
+		if ev.Delta != "" {
+			deltas = append(deltas, ev.Delta)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Text != `{"ok":true}` || len(result.ToolCalls) != 0 || len(deltas) != 2 {
+		t.Fatalf("unexpected result: text=%q tool_calls=%#v deltas=%v", result.Text, result.ToolCalls, deltas)
+	}
+	if result.FinishReason != chat.FinishReasonStop {
+		t.Fatalf("unexpected finish reason: %q", result.FinishReason)
+	}
+}
+
 func TestChatStreamCacheUsage(t *testing.T) {
 	sse := strings.Join([]string{
 		sseEvent("message_start", `{"type":"message_start","message":{"model":"claude-sonnet-4-20250514","usage":{"input_tokens":100,"cache_read_input_tokens":80,"cache_creation_input_tokens":40,"cache_creation":{"ephemeral_5m_input_tokens":40}}}}`),
@@ -120,7 +163,7 @@ func TestChatStreamCacheUsage(t *testing.T) {
 
 	var gotUsage *chat.Usage
 	p := &Provider{}
-	result, err := p.chatStream(strings.NewReader(sse), false, func(ev chat.StreamEvent) error {
+	result, err := p.chatStream(strings.NewReader(sse), false, "", func(ev chat.StreamEvent) error {
 		if ev.Done {
 			gotUsage = ev.Usage
 		}
@@ -155,7 +198,7 @@ func TestChatStreamCallbackError(t *testing.T) {
 
 	cancelErr := fmt.Errorf("cancelled")
 	p := &Provider{}
-	_, err := p.chatStream(strings.NewReader(sse), false, func(ev chat.StreamEvent) error {
+	_, err := p.chatStream(strings.NewReader(sse), false, "", func(ev chat.StreamEvent) error {
 		if ev.Delta != "" {
 			return cancelErr
 		}
@@ -178,7 +221,7 @@ func TestChatStreamDoneError(t *testing.T) {
 
 	doneErr := fmt.Errorf("done error")
 	p := &Provider{}
-	_, err := p.chatStream(strings.NewReader(sse), false, func(ev chat.StreamEvent) error {
+	_, err := p.chatStream(strings.NewReader(sse), false, "", func(ev chat.StreamEvent) error {
 		if ev.Done {
 			return doneErr
 		}
@@ -207,7 +250,7 @@ func TestChatStreamReasoningDetails(t *testing.T) {
 
 	var contentEvents []string
 	p := &Provider{}
-	result, err := p.chatStream(strings.NewReader(sse), true, func(ev chat.StreamEvent) error {
+	result, err := p.chatStream(strings.NewReader(sse), true, "", func(ev chat.StreamEvent) error {
 		if ev.ReasoningDelta != nil {
 			contentEvents = append(contentEvents, "reasoning:"+ev.ReasoningDelta.Delta)
 			if ev.Raw == nil {
@@ -239,3 +282,23 @@ func TestChatStreamReasoningDetails(t *testing.T) {
 		t.Fatalf("unexpected redacted block: %#v", block)
 	}
 }
+
+func TestChatStreamErrorEvent(t *testing.T) {
+	sse := strings.Join([]string{
+		sseEvent("message_start", `{"type":"message_start","message":{"model":"claude-sonnet-4-20250514","usage":{"input_tokens":10}}}`),
+		sseEvent("error", `{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`),
+	}, "")
+
+	gotDone := false
+	p := &Provider{}
+	_, err := p.chatStream(strings.NewReader(sse), false, "", func(ev chat.StreamEvent) error {
+		gotDone = gotDone || ev.Done
+		return nil
+	})
+	if !errors.Is(err, apierror.ErrServerError) {
+		t.Fatalf("expected server error, got %v", err)
+	}
+	if gotDone {
+		t.Fatal("failed stream emitted Done")
+	}
+}
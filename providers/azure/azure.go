@@ -3,6 +3,7 @@ package azure
 import (
 	"context"
 	"fmt"
+	"net/http"
 
 	"github.com/lyricat/goutils/structs"
 	openai "github.com/openai/openai-go/v3"
@@ -21,6 +22,11 @@ type Config struct {
 	APIVersion string
 	Headers    map[string]string
 	Debug      bool
+	// DisableRetries turns off the openai-go built-in retries.
+	DisableRetries bool
+	// HTTPClient is passed to openai-go, which uses its own default client
+	// when it is nil.
+	HTTPClient *http.Client
 }
 
 type Provider struct {
@@ -49,6 +55,12 @@ func New(cfg Config) (*Provider, error) {
 	for key, value := range httputil.CloneHeaders(cfg.Headers) {
 		opts = append(opts, option.WithHeader(key, value))
 	}
+	if cfg.DisableRetries {
+		opts = append(opts, option.WithMaxRetries(0))
+	}
+	if cfg.HTTPClient != nil {
+		opts = append(opts, option.WithHTTPClient(cfg.HTTPClient))
+	}
 	client := openai.NewClient(opts...)
 	return &Provider{
 		client:     client,
@@ -62,9 +74,38 @@ func (p *Provider) Chat(ctx context.Context, req *chat.Request) (*chat.Result, e
 	if err := chat.ValidateNoScopedCacheControl(req, "azure"); err != nil {
 		return nil, err
 	}
+	params, err := p.buildParams(req)
+	if err != nil {
+		return nil, err
+	}
+	diag.LogJSON(p.debug, debugFn, "azure.chat.request", params)
+
+	if req.Options.OnStream != nil {
+		result, err := oaicompat.ChatStream(ctx, &p.client, params, false, req.Options.OnStream)
+		if err != nil {
+			diag.LogError(p.debug, debugFn, "azure.chat.response", err)
+			return nil, oaicompat.WrapError("azure", err)
+		}
+		return result, nil
+	}
+
+	resp, err := p.client.Chat.Completions.New(ctx, params)
+	if err != nil {
+		diag.LogError(p.debug, debugFn, "azure.chat.response", err)
+		return nil, oaicompat.WrapError("azure", err)
+	}
+	if raw := resp.RawJSON(); raw != "" {
+		diag.LogText(p.debug, debugFn, "azure.chat.response", raw)
+	} else {
+		diag.LogJSON(p.debug, debugFn, "azure.chat.response", resp)
+	}
+	return toResult(resp), nil
+}
+
+func (p *Provider) buildParams(req *chat.Request) (openai.ChatCompletionNewParams, error) {
 	messages, err := oaicompat.ToMessages(req.Messages, p.deployment)
 	if err != nil {
-		return nil, fmt.Errorf("azure provider model %q: %w", p.deployment, err)
+		return openai.ChatCompletionNewParams{}, fmt.Errorf("azure provider model %q: %w", p.deployment, err)
 	}
 
 	params := openai.ChatCompletionNewParams{
@@ -97,7 +138,7 @@ func (p *Provider) Chat(ctx context.Context, req *chat.Request) (*chat.Result, e
 	if len(req.Tools) > 0 {
 		tools, err := oaicompat.ToToolParams(req.Tools)
 		if err != nil {
-			return nil, err
+			return params, err
 		}
 		if len(tools) > 0 {
 			params.Tools = tools
@@ -108,31 +149,13 @@ func (p *Provider) Chat(ctx context.Context, req *chat.Request) (*chat.Result, e
 		params.ToolChoice = oaicompat.ToToolChoice(req.ToolChoice)
 	}
 
-	if err := applyAzureOptions(&params, req.Options.Azure, req.Options.OpenAI); err != nil {
-		return nil, err
-	}
-	diag.LogJSON(p.debug, debugFn, "azure.chat.request", params)
-
-	if req.Options.OnStream != nil {
-		result, err := oaicompat.ChatStream(ctx, &p.client, params, false, req.Options.OnStream)
-		if err != nil {
-			diag.LogError(p.debug, debugFn, "azure.chat.response", err)
-			return nil, err
-		}
-		return result, nil
+	if err := oaicompat.ApplyChatResponseFormat(&params, req.Options.ResponseFormat); err != nil {
+		return params, err
 	}
-
-	resp, err := p.client.Chat.Completions.New(ctx, params)
-	if err != nil {
-		diag.LogError(p.debug, debugFn, "azure.chat.response", err)
-		return nil, err
-	}
-	if raw := resp.RawJSON(); raw != "" {
-		diag.LogText(p.debug, debugFn, "azure.chat.response", raw)
-	} else {
-		diag.LogJSON(p.debug, debugFn, "azure.chat.response", resp)
+	if err := applyAzureOptions(&params, req.Options.Azure, req.Options.OpenAI); err != nil {
+		return params, err
 	}
-	return toResult(resp), nil
+	return params, nil
 }
 
 func applyAzureOptions(params *openai.ChatCompletionNewParams, azureOpts, openaiOpts structs.JSONMap) error {
@@ -0,0 +1,16 @@
+package azure
+
+import (
+	"github.com/quailyquaily/uniai/chat"
+	"github.com/quailyquaily/uniai/internal/tokenest"
+)
+
+// EstimateTokens estimates the input tokens of req from the Chat Completions
+// request body sent to the deployment.
+func (p *Provider) EstimateTokens(req *chat.Request) (int, error) {
+	params, err := p.buildParams(req)
+	if err != nil {
+		return 0, err
+	}
+	return tokenest.Payload(params)
+}
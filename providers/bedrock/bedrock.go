@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -13,6 +14,7 @@ import (
 	smithyhttp "github.com/aws/smithy-go/transport/http"
 	"github.com/lyricat/goutils/structs"
 	"github.com/quailyquaily/uniai/chat"
+	"github.com/quailyquaily/uniai/internal/anthropicapi"
 	"github.com/quailyquaily/uniai/internal/anthropicstream"
 	"github.com/quailyquaily/uniai/internal/diag"
 	"github.com/quailyquaily/uniai/internal/httputil"
@@ -27,6 +29,11 @@ type Config struct {
 	ModelArn        string
 	Headers         map[string]string
 	Debug           bool
+	// DisableRetries turns off the AWS SDK retryer.
+	DisableRetries bool
+	// HTTPClient is passed to the AWS SDK, which uses its own default client
+	// when it is nil.
+	HTTPClient *http.Client
 }
 
 type Provider struct {
@@ -72,6 +79,12 @@ func New(cfg Config) *Provider {
 		Region:      region,
 		Credentials: credentials.NewStaticCredentialsProvider(cfg.AwsKey, cfg.AwsSecret, cfg.AwsSessionToken),
 	}
+	if cfg.DisableRetries {
+		awsCfg.Retryer = func() aws.Retryer { return aws.NopRetryer{} }
+	}
+	if cfg.HTTPClient != nil {
+		awsCfg.HTTPClient = cfg.HTTPClient
+	}
 	return &Provider{
 		client:   bedrockRuntimeClientAdapter{client: bedrockruntime.NewFromConfig(awsCfg)},
 		modelArn: cfg.ModelArn,
@@ -80,28 +93,15 @@ func New(cfg Config) *Provider {
 	}
 }
 
-type bedrockMessage struct {
-	Role    string              `json:"role"`
-	Content []bedrockMsgContent `json:"content"`
-}
-
-type bedrockMsgContent struct {
-	Type         string               `json:"type"`
-	Text         string               `json:"text,omitempty"`
-	Thinking     string               `json:"thinking,omitempty"`
-	Signature    string               `json:"signature,omitempty"`
-	Data         string               `json:"data,omitempty"`
-	CacheControl *bedrockCacheControl `json:"cache_control,omitempty"`
-}
+// maxDocumentBytes is the Bedrock limit for one document.
+const maxDocumentBytes = 4_500_000
 
+// bedrockResponse is an InvokeModel response body: an Anthropic Messages
+// response with Bedrock's usage fields.
 type bedrockResponse struct {
-	Content []bedrockMsgContent `json:"content"`
-	Usage   bedrockUsage        `json:"usage"`
-}
-
-type bedrockCacheControl struct {
-	Type string `json:"type"`
-	TTL  string `json:"ttl,omitempty"`
+	Content    []anthropicapi.ContentBlock `json:"content"`
+	StopReason string                      `json:"stop_reason,omitempty"`
+	Usage      bedrockUsage                `json:"usage"`
 }
 
 type bedrockUsage struct {
@@ -123,56 +123,10 @@ func (p *Provider) Chat(ctx context.Context, req *chat.Request) (*chat.Result, e
 		return nil, err
 	}
 
-	systemParts := make([]string, 0, 1)
-	messages := make([]bedrockMessage, 0, len(req.Messages))
-	for _, m := range req.Messages {
-		switch m.Role {
-		case chat.RoleSystem:
-			text, err := chat.MessageText(m)
-			if err != nil {
-				return nil, fmt.Errorf("bedrock provider model %q: role %q: %w", p.modelArn, m.Role, err)
-			}
-			if text != "" {
-				systemParts = append(systemParts, text)
-			}
-		case chat.RoleUser, chat.RoleAssistant:
-			content, err := toBedrockContent(m)
-			if err != nil {
-				return nil, fmt.Errorf("bedrock provider model %q: role %q: %w", p.modelArn, m.Role, err)
-			}
-			if len(content) == 0 {
-				continue
-			}
-			messages = append(messages, bedrockMessage{
-				Role:    m.Role,
-				Content: content,
-			})
-		default:
-			return nil, fmt.Errorf("bedrock provider does not support role %q", m.Role)
-		}
-	}
-	if len(messages) == 0 {
-		return nil, fmt.Errorf("at least one user or assistant message is required")
-	}
-
-	maxTokens := 10000
-	if req.Options.MaxTokens != nil {
-		maxTokens = *req.Options.MaxTokens
-	}
-
-	payload := map[string]any{
-		"anthropic_version": "bedrock-2023-05-31",
-		"max_tokens":        maxTokens,
-		"messages":          messages,
-	}
-	if len(systemParts) > 0 {
-		payload["system"] = strings.Join(systemParts, "\n")
-	}
-	if err := applyBedrockReasoningOptions(payload, p.modelArn, req.Options); err != nil {
+	payload, outputTool, err := p.buildPayload(req)
+	if err != nil {
 		return nil, err
 	}
-	applyBedrockOptions(payload, req.Options.Bedrock)
-	applyBedrockModelOverlay(payload, p.modelArn)
 
 	body, err := json.Marshal(payload)
 	if err != nil {
@@ -181,7 +135,7 @@ func (p *Provider) Chat(ctx context.Context, req *chat.Request) (*chat.Result, e
 	diag.LogText(p.debug, debugFn, "bedrock.chat.request", string(body))
 
 	if req.Options.OnStream != nil {
-		result, err := p.chatStream(ctx, body, req.Options.ReasoningDetails, req.Options.OnStream, req.Tools)
+		result, err := p.chatStream(ctx, body, req.Options.ReasoningDetails, outputTool, req.Options.OnStream, req.Tools)
 		if err != nil {
 			diag.LogError(p.debug, debugFn, "bedrock.chat.response", err)
 			return nil, err
@@ -197,7 +151,7 @@ func (p *Provider) Chat(ctx context.Context, req *chat.Request) (*chat.Result, e
 	}, p.requestOptions()...)
 	if err != nil {
 		diag.LogError(p.debug, debugFn, "bedrock.chat.response", err)
-		return nil, err
+		return nil, wrapError(err)
 	}
 	diag.LogText(p.debug, debugFn, "bedrock.chat.response", string(resp.Body))
 
@@ -209,8 +163,15 @@ func (p *Provider) Chat(ctx context.Context, req *chat.Request) (*chat.Result, e
 
 	var textParts []string
 	for _, c := range out.Content {
-		if c.Type == "text" && c.Text != "" {
+		switch {
+		case c.Type == "text" && c.Text != "":
 			textParts = append(textParts, c.Text)
+		case c.Type == "tool_use" && outputTool != "" && c.Name == outputTool:
+			data, err := json.Marshal(c.Input)
+			if err != nil {
+				return nil, err
+			}
+			textParts = append(textParts, string(data))
 		}
 	}
 	text := strings.Join(textParts, "")
@@ -227,13 +188,74 @@ func (p *Provider) Chat(ctx context.Context, req *chat.Request) (*chat.Result, e
 		Usage: usage,
 		Raw:   out,
 	}
+	anthropicapi.SetFinishReason(result, out.StopReason, outputTool)
 	if len(req.Tools) > 0 {
 		result.Warnings = append(result.Warnings, "tools not supported for bedrock provider yet")
 	}
 	return result, nil
 }
 
-func bedrockReasoningResult(content []bedrockMsgContent, enabled bool) *chat.ReasoningResult {
+// buildPayload builds the InvokeModel body for req and returns the name of
+// the tool used for structured output, if any.
+func (p *Provider) buildPayload(req *chat.Request) (map[string]any, string, error) {
+	systemParts := make([]string, 0, 1)
+	messages := make([]anthropicapi.Message, 0, len(req.Messages))
+	for _, m := range req.Messages {
+		switch m.Role {
+		case chat.RoleSystem:
+			text, err := chat.MessageText(m)
+			if err != nil {
+				return nil, "", fmt.Errorf("bedrock provider model %q: role %q: %w", p.modelArn, m.Role, err)
+			}
+			if text != "" {
+				systemParts = append(systemParts, text)
+			}
+		case chat.RoleUser, chat.RoleAssistant:
+			content, err := toBedrockContent(m)
+			if err != nil {
+				return nil, "", fmt.Errorf("bedrock provider model %q: role %q: %w", p.modelArn, m.Role, err)
+			}
+			if len(content) == 0 {
+				continue
+			}
+			messages = append(messages, anthropicapi.Message{
+				Role:    m.Role,
+				Content: content,
+			})
+		default:
+			return nil, "", fmt.Errorf("bedrock provider does not support role %q", m.Role)
+		}
+	}
+	if len(messages) == 0 {
+		return nil, "", fmt.Errorf("at least one user or assistant message is required")
+	}
+
+	maxTokens := 10000
+	if req.Options.MaxTokens != nil {
+		maxTokens = *req.Options.MaxTokens
+	}
+
+	payload := map[string]any{
+		"anthropic_version": "bedrock-2023-05-31",
+		"max_tokens":        maxTokens,
+		"messages":          messages,
+	}
+	if len(systemParts) > 0 {
+		payload["system"] = strings.Join(systemParts, "\n")
+	}
+	if err := applyBedrockReasoningOptions(payload, p.modelArn, req.Options); err != nil {
+		return nil, "", err
+	}
+	outputTool, err := applyBedrockResponseFormat(payload, req)
+	if err != nil {
+		return nil, "", fmt.Errorf("bedrock provider model %q: %w", p.modelArn, err)
+	}
+	applyBedrockOptions(payload, req.Options.Bedrock)
+	applyBedrockModelOverlay(payload, p.modelArn)
+	return payload, outputTool, nil
+}
+
+func bedrockReasoningResult(content []anthropicapi.ContentBlock, enabled bool) *chat.ReasoningResult {
 	if !enabled {
 		return nil
 	}
@@ -259,10 +281,13 @@ type bedrockStreamEvent struct {
 	Index        int    `json:"index,omitempty"`
 	ContentBlock *struct {
 		Type string `json:"type"`
+		Name string `json:"name,omitempty"`
 	} `json:"content_block,omitempty"`
 	Delta *struct {
-		Type string `json:"type"`
-		Text string `json:"text,omitempty"`
+		Type        string `json:"type"`
+		Text        string `json:"text,omitempty"`
+		PartialJSON string `json:"partial_json,omitempty"`
+		StopReason  string `json:"stop_reason,omitempty"`
 	} `json:"delta,omitempty"`
 	Message *struct {
 		Model string        `json:"model,omitempty"`
@@ -271,7 +296,10 @@ type bedrockStreamEvent struct {
 	Usage *bedrockUsage `json:"usage,omitempty"`
 }
 
-func (p *Provider) chatStream(ctx context.Context, body []byte, reasoningDetails bool, onStream chat.OnStreamFunc, tools []chat.Tool) (*chat.Result, error) {
+// chatStream reads an InvokeModelWithResponseStream response. Argument deltas
+// of the tool named outputTool are structured output and are streamed as text
+// deltas.
+func (p *Provider) chatStream(ctx context.Context, body []byte, reasoningDetails bool, outputTool string, onStream chat.OnStreamFunc, tools []chat.Tool) (*chat.Result, error) {
 	stream, err := p.client.InvokeModelWithResponseStream(ctx, &bedrockruntime.InvokeModelWithResponseStreamInput{
 		ModelId:     aws.String(p.modelArn),
 		Body:        body,
@@ -279,15 +307,17 @@ func (p *Provider) chatStream(ctx context.Context, body []byte, reasoningDetails
 		ContentType: aws.String("application/json"),
 	}, p.requestOptions()...)
 	if err != nil {
-		return nil, err
+		return nil, wrapError(err)
 	}
 	defer stream.Close()
 
 	var (
-		textParts []string
-		model     string
-		usage     chat.Usage
-		reasoning anthropicstream.ReasoningState
+		textParts   []string
+		model       string
+		stopReason  string
+		usage       chat.Usage
+		reasoning   anthropicstream.ReasoningState
+		outputIndex = -1
 	)
 
 	for event := range stream.Events() {
@@ -316,11 +346,25 @@ func (p *Provider) chatStream(ctx context.Context, body []byte, reasoningDetails
 					applyBedrockUsage(&usage, *ev.Message.Usage)
 				}
 			}
+		case "content_block_start":
+			if ev.ContentBlock != nil && ev.ContentBlock.Type == "tool_use" && outputTool != "" && ev.ContentBlock.Name == outputTool {
+				outputIndex = ev.Index
+			}
 		case "content_block_delta":
-			if ev.Delta != nil && ev.Delta.Type == "text_delta" && ev.Delta.Text != "" {
-				textParts = append(textParts, ev.Delta.Text)
+			if ev.Delta == nil {
+				continue
+			}
+			delta := ""
+			switch {
+			case ev.Delta.Type == "text_delta":
+				delta = ev.Delta.Text
+			case ev.Delta.Type == "input_json_delta" && ev.Index == outputIndex:
+				delta = ev.Delta.PartialJSON
+			}
+			if delta != "" {
+				textParts = append(textParts, delta)
 				if err := onStream(chat.StreamEvent{
-					Delta: ev.Delta.Text,
+					Delta: delta,
 					Raw:   json.RawMessage(append([]byte(nil), chunk.Value.Bytes...)),
 				}); err != nil {
 					return nil, err
@@ -330,37 +374,39 @@ func (p *Provider) chatStream(ctx context.Context, body []byte, reasoningDetails
 			if ev.Usage != nil {
 				applyBedrockUsage(&usage, *ev.Usage)
 			}
+			if ev.Delta != nil && ev.Delta.StopReason != "" {
+				stopReason = ev.Delta.StopReason
+			}
 		}
 	}
 
 	if err := stream.Err(); err != nil {
-		return nil, err
+		return nil, wrapError(err)
 	}
 
 	usage.TotalTokens = usage.InputTokens + usage.OutputTokens
-	if err := onStream(chat.StreamEvent{
-		Done:  true,
-		Usage: &usage,
-	}); err != nil {
-		return nil, err
-	}
-
+	text := strings.Join(textParts, "")
 	result := &chat.Result{
-		Text:  strings.Join(textParts, ""),
-		Model: model,
-		Parts: func() []chat.Part {
-			text := strings.Join(textParts, "")
-			if text == "" {
-				return nil
-			}
-			return []chat.Part{chat.TextPart(text)}
-		}(),
+		Text:      text,
+		Model:     model,
 		Reasoning: reasoning.Result(),
 		Usage:     usage,
 	}
+	if text != "" {
+		result.Parts = []chat.Part{chat.TextPart(text)}
+	}
+	anthropicapi.SetFinishReason(result, stopReason, outputTool)
 	if len(tools) > 0 {
 		result.Warnings = append(result.Warnings, "tools not supported for bedrock provider yet")
 	}
+	if err := onStream(chat.StreamEvent{
+		Done:            true,
+		Usage:           &usage,
+		FinishReason:    result.FinishReason,
+		RawFinishReason: result.RawFinishReason,
+	}); err != nil {
+		return nil, err
+	}
 	return result, nil
 }
 
@@ -408,6 +454,34 @@ func applyBedrockReasoningOptions(payload map[string]any, model string, opts cha
 	return nil
 }
 
+// applyBedrockResponseFormat implements structured output as a forced call of
+// a single tool whose input schema is the requested schema. It returns the
+// tool name, or "" when the request has no response format.
+func applyBedrockResponseFormat(payload map[string]any, req *chat.Request) (string, error) {
+	format := req.Options.ResponseFormat
+	if payload == nil || format == nil {
+		return "", nil
+	}
+	if len(req.Tools) > 0 || req.ToolChoice != nil {
+		return "", fmt.Errorf("structured output cannot be combined with tools")
+	}
+	if payload["thinking"] != nil {
+		return "", fmt.Errorf("structured output forces a tool call, which extended thinking does not allow")
+	}
+	schema, err := format.SchemaMap()
+	if err != nil {
+		return "", err
+	}
+	name := format.ToolName()
+	payload["tools"] = []map[string]any{{
+		"name":         name,
+		"description":  "Respond with the structured output.",
+		"input_schema": schema,
+	}}
+	payload["tool_choice"] = map[string]any{"type": "tool", "name": name}
+	return name, nil
+}
+
 func applyBedrockOptions(payload map[string]any, opts structs.JSONMap) {
 	if payload == nil || len(opts) == 0 {
 		return
@@ -494,36 +568,70 @@ func validateBedrockCacheControl(req *chat.Request, modelArn string) error {
 	return nil
 }
 
-func toBedrockContent(msg chat.Message) ([]bedrockMsgContent, error) {
+func toBedrockContent(msg chat.Message) (anthropicapi.Content, error) {
 	parts := chat.NormalizeMessageParts(msg)
 	if len(parts) == 0 {
 		return nil, nil
 	}
-	out := make([]bedrockMsgContent, 0, len(parts))
+	out := make(anthropicapi.Content, 0, len(parts))
 	for _, part := range parts {
 		if err := chat.ValidatePart(part); err != nil {
 			return nil, err
 		}
-		if part.Type != chat.PartTypeText {
+		switch part.Type {
+		case chat.PartTypeText:
+			if strings.TrimSpace(part.Text) == "" && part.CacheControl == nil {
+				continue
+			}
+			out = append(out, anthropicapi.ContentBlock{
+				Type:         "text",
+				Text:         part.Text,
+				CacheControl: toBedrockCacheControl(part.CacheControl),
+			})
+		case chat.PartTypeDocumentURL, chat.PartTypeDocumentBase64, chat.PartTypeDocumentText:
+			block, err := toBedrockDocument(part)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, block)
+		case chat.PartTypeAudioURL, chat.PartTypeAudioBase64:
+			return nil, fmt.Errorf("bedrock provider does not support %q parts", part.Type)
+		default:
 			return nil, fmt.Errorf("unsupported part type %q", part.Type)
 		}
-		if strings.TrimSpace(part.Text) == "" && part.CacheControl == nil {
-			continue
-		}
-		out = append(out, bedrockMsgContent{
-			Type:         "text",
-			Text:         part.Text,
-			CacheControl: toBedrockCacheControl(part.CacheControl),
-		})
 	}
 	return out, nil
 }
 
-func toBedrockCacheControl(ctrl *chat.CacheControl) *bedrockCacheControl {
+// toBedrockDocument returns the Anthropic document block of a document
+// part. Bedrock takes document data inline only.
+func toBedrockDocument(part chat.Part) (anthropicapi.ContentBlock, error) {
+	if part.Type == chat.PartTypeDocumentURL {
+		return anthropicapi.ContentBlock{}, fmt.Errorf("bedrock provider does not support %q parts; send the file as %q", part.Type, chat.PartTypeDocumentBase64)
+	}
+	if err := chat.ValidateDocumentSize(part, "bedrock", maxDocumentBytes); err != nil {
+		return anthropicapi.ContentBlock{}, err
+	}
+	block := anthropicapi.ContentBlock{
+		Type:         "document",
+		Source:       &anthropicapi.Source{Type: "base64", MediaType: chat.DocumentMIMEType(part), Data: strings.TrimSpace(part.DataBase64)},
+		Title:        strings.TrimSpace(part.Title),
+		CacheControl: toBedrockCacheControl(part.CacheControl),
+	}
+	if part.Type == chat.PartTypeDocumentText {
+		block.Source = &anthropicapi.Source{Type: "text", MediaType: "text/plain", Data: part.Text}
+	}
+	if part.Citations {
+		block.Citations = anthropicapi.CitationsEnabled()
+	}
+	return block, nil
+}
+
+func toBedrockCacheControl(ctrl *chat.CacheControl) *anthropicapi.CacheControl {
 	if ctrl == nil {
 		return nil
 	}
-	out := &bedrockCacheControl{Type: "ephemeral"}
+	out := &anthropicapi.CacheControl{Type: "ephemeral"}
 	if ttl := strings.TrimSpace(ctrl.TTL); ttl != "" {
 		out.TTL = ttl
 	}
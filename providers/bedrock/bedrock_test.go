@@ -3,13 +3,22 @@ package bedrock
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
 	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
 	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
+	"github.com/aws/smithy-go"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
 	"github.com/lyricat/goutils/structs"
 	"github.com/quailyquaily/uniai/chat"
+	"github.com/quailyquaily/uniai/internal/apierror"
 )
 
 func TestToBedrockContentMapsCacheControl(t *testing.T) {
@@ -31,6 +40,41 @@ func TestToBedrockContentMapsCacheControl(t *testing.T) {
 	}
 }
 
+func TestToBedrockContentMapsDocumentParts(t *testing.T) {
+	pdf := chat.DocumentBase64Part("application/pdf", "JVBERi0=")
+	pdf.Citations = true
+	content, err := toBedrockContent(chat.UserParts(pdf, chat.DocumentTextPart("notes", "hello")))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, _ := json.Marshal(content)
+	want := `[{"type":"document","source":{"type":"base64","media_type":"application/pdf","data":"JVBERi0="},"citations":{"enabled":true}},` +
+		`{"type":"document","source":{"type":"text","media_type":"text/plain","data":"hello"},"title":"notes"}]`
+	if string(data) != want {
+		t.Fatalf("unexpected document blocks:\n got %s\nwant %s", data, want)
+	}
+
+	if _, err := toBedrockContent(chat.UserParts(chat.DocumentURLPart("https://example.com/a.pdf"))); err == nil {
+		t.Fatalf("expected document_url to be rejected")
+	}
+	large := chat.DocumentTextPart("", strings.Repeat("a", maxDocumentBytes+1))
+	if _, err := toBedrockContent(chat.UserParts(large)); err == nil || !strings.Contains(err.Error(), "bedrock provider accepts documents up to") {
+		t.Fatalf("expected size limit error, got %v", err)
+	}
+}
+
+func TestToBedrockContentRejectsAudioParts(t *testing.T) {
+	for _, part := range []chat.Part{
+		chat.AudioBase64Part("audio/wav", "UklGRg=="),
+		chat.AudioURLPart("audio/wav", "https://example.com/a.wav"),
+	} {
+		_, err := toBedrockContent(chat.UserParts(part))
+		if err == nil || !strings.Contains(err.Error(), fmt.Sprintf("bedrock provider does not support %q parts", part.Type)) {
+			t.Fatalf("expected %s to be rejected, got %v", part.Type, err)
+		}
+	}
+}
+
 func TestValidateBedrockCacheControl(t *testing.T) {
 	req := &chat.Request{
 		Messages: []chat.Message{
@@ -310,6 +354,63 @@ func TestChatReasoningDetailsRequiresBudgetForManualModel(t *testing.T) {
 	}
 }
 
+func TestChatWrapsSmithyErrors(t *testing.T) {
+	header := http.Header{}
+	header.Set("Retry-After", "2")
+	p := &Provider{
+		client: &fakeBedrockRuntimeClient{
+			invokeModelErr: &smithy.OperationError{
+				ServiceID:     "Bedrock Runtime",
+				OperationName: "InvokeModel",
+				Err: &awshttp.ResponseError{
+					ResponseError: &smithyhttp.ResponseError{
+						Response: &smithyhttp.Response{Response: &http.Response{StatusCode: http.StatusTooManyRequests, Header: header}},
+						Err:      &types.ThrottlingException{Message: aws.String("Too many requests, please wait before trying again.")},
+					},
+					RequestID: "req-bedrock",
+				},
+			},
+		},
+		modelArn: "anthropic.claude-sonnet-4-20250514-v1:0",
+	}
+
+	_, err := p.Chat(context.Background(), &chat.Request{
+		Messages: []chat.Message{chat.User("hi")},
+	})
+	if !errors.Is(err, apierror.ErrRateLimited) {
+		t.Fatalf("expected rate limited error, got %v", err)
+	}
+	var apiErr *apierror.Error
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected *apierror.Error, got %T", err)
+	}
+	if apiErr.Provider != "bedrock" || apiErr.StatusCode != http.StatusTooManyRequests || apiErr.Code != "ThrottlingException" {
+		t.Fatalf("unexpected api error: %#v", apiErr)
+	}
+	if apiErr.RequestID != "req-bedrock" || apiErr.RetryAfter != 2*time.Second {
+		t.Fatalf("unexpected request id / retry after: %q %v", apiErr.RequestID, apiErr.RetryAfter)
+	}
+}
+
+func TestChatStreamWrapsStreamException(t *testing.T) {
+	stream := newFakeBedrockResponseStream()
+	stream.err = &types.ValidationException{Message: aws.String("Input is too long for requested model.")}
+	p := &Provider{
+		client:   &fakeBedrockRuntimeClient{stream: stream},
+		modelArn: "anthropic.claude-sonnet-4-20250514-v1:0",
+	}
+
+	_, err := p.Chat(context.Background(), &chat.Request{
+		Messages: []chat.Message{chat.User("hi")},
+		Options: chat.Options{
+			OnStream: func(chat.StreamEvent) error { return nil },
+		},
+	})
+	if !errors.Is(err, apierror.ErrContextLengthExceeded) {
+		t.Fatalf("expected context length error, got %v", err)
+	}
+}
+
 type fakeBedrockRuntimeClient struct {
 	invokeModelInput  *bedrockruntime.InvokeModelInput
 	invokeModelOptFns int
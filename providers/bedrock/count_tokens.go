@@ -0,0 +1,16 @@
+package bedrock
+
+import (
+	"github.com/quailyquaily/uniai/chat"
+	"github.com/quailyquaily/uniai/internal/tokenest"
+)
+
+// EstimateTokens estimates the input tokens of req from the InvokeModel
+// request body.
+func (p *Provider) EstimateTokens(req *chat.Request) (int, error) {
+	payload, _, err := p.buildPayload(req)
+	if err != nil {
+		return 0, err
+	}
+	return tokenest.Payload(payload)
+}
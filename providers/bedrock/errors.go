@@ -0,0 +1,67 @@
+package bedrock
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/smithy-go"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+	"github.com/quailyquaily/uniai/internal/apierror"
+)
+
+// wrapError converts AWS smithy API and HTTP response errors into an
+// *apierror.Error. Other errors (context cancellation, encoding) are
+// returned unchanged.
+func wrapError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var existing *apierror.Error
+	if errors.As(err, &existing) {
+		return err
+	}
+
+	var (
+		apiErr  smithy.APIError
+		respErr *smithyhttp.ResponseError
+	)
+	hasAPIErr := errors.As(err, &apiErr)
+	hasRespErr := errors.As(err, &respErr)
+	if !hasAPIErr && !hasRespErr {
+		return err
+	}
+
+	out := &apierror.Error{
+		Provider: "bedrock",
+		Err:      err,
+	}
+	if hasAPIErr {
+		out.Code = apiErr.ErrorCode()
+		out.Message = strings.TrimSpace(apiErr.ErrorMessage())
+		if fault := apiErr.ErrorFault(); fault != smithy.FaultUnknown {
+			out.Type = fault.String()
+		}
+	}
+	var header http.Header
+	if hasRespErr {
+		out.StatusCode = respErr.HTTPStatusCode()
+		if respErr.Response != nil && respErr.Response.Response != nil {
+			header = respErr.Response.Header
+		}
+	}
+	var withRequestID interface{ ServiceRequestID() string }
+	if errors.As(err, &withRequestID) {
+		out.RequestID = withRequestID.ServiceRequestID()
+	}
+	if out.RequestID == "" {
+		out.RequestID = apierror.RequestID(header)
+	}
+	out.RetryAfter = apierror.RetryAfter(header, time.Now())
+	if out.Message == "" {
+		out.Message = err.Error()
+	}
+	out.Category = apierror.Classify(out.StatusCode, out.Code, out.Type, out.Message)
+	return out
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"strings"
 
 	"github.com/lyricat/goutils/structs"
@@ -15,11 +16,12 @@ import (
 )
 
 type Config struct {
-	AccountID string
-	APIToken  string
-	APIBase   string
-	Headers   map[string]string
-	Debug     bool
+	AccountID  string
+	APIToken   string
+	APIBase    string
+	Headers    map[string]string
+	Debug      bool
+	HTTPClient *http.Client
 }
 
 type Provider struct {
@@ -58,7 +60,7 @@ func (p *Provider) Chat(ctx context.Context, req *chat.Request) (*chat.Result, e
 	}
 	diag.LogText(p.cfg.Debug, debugFn, "cloudflare.chat.request", string(reqBody))
 
-	resultRaw, err := cf.RunJSONWithHeaders(ctx, p.cfg.APIToken, p.cfg.APIBase, p.cfg.AccountID, model, p.cfg.Headers, payload)
+	resultRaw, err := cf.RunJSONWithHeaders(httputil.WithClient(ctx, p.cfg.HTTPClient), p.cfg.APIToken, p.cfg.APIBase, p.cfg.AccountID, model, p.cfg.Headers, payload)
 	if err != nil {
 		diag.LogError(p.cfg.Debug, debugFn, "cloudflare.chat.response", err)
 		return nil, err
@@ -117,10 +119,49 @@ func buildPayload(req *chat.Request, model string) (structs.JSONMap, error) {
 		}
 	}
 
+	if err := applyResponseFormat(&payload, req.Options.ResponseFormat, responsesCompatible); err != nil {
+		return nil, err
+	}
+
 	applyCommonOptions(&payload, req.Options)
 	return payload, nil
 }
 
+// applyResponseFormat maps the provider-neutral response format to Workers AI
+// JSON mode, or to Responses text.format for gpt-oss models, unless raw
+// options already set one.
+func applyResponseFormat(payload *structs.JSONMap, format *chat.ResponseFormat, responsesCompatible bool) error {
+	if format == nil || payload.HasKey("response_format") || payload.HasKey("text") {
+		return nil
+	}
+	if format.Type == chat.ResponseFormatJSONObject {
+		if responsesCompatible {
+			payload.SetValue("text", map[string]any{"format": map[string]any{"type": "json_object"}})
+		} else {
+			payload.SetValue("response_format", map[string]any{"type": "json_object"})
+		}
+		return nil
+	}
+	schema, err := format.SchemaMap()
+	if err != nil {
+		return err
+	}
+	if responsesCompatible {
+		payload.SetValue("text", map[string]any{"format": map[string]any{
+			"type":   "json_schema",
+			"name":   format.ToolName(),
+			"schema": schema,
+			"strict": format.Strict,
+		}})
+		return nil
+	}
+	payload.SetValue("response_format", map[string]any{
+		"type":        "json_schema",
+		"json_schema": schema,
+	})
+	return nil
+}
+
 func applyCommonOptions(payload *structs.JSONMap, opts chat.Options) {
 	if opts.Temperature != nil && !payload.HasKey("temperature") {
 		payload.SetValue("temperature", *opts.Temperature)
@@ -299,6 +340,8 @@ func toScopedUserPart(part chat.Part) (map[string]any, bool, error) {
 				"url": fmt.Sprintf("data:%s;base64,%s", mimeType, strings.TrimSpace(part.DataBase64)),
 			},
 		}, true, nil
+	case chat.PartTypeAudioURL, chat.PartTypeAudioBase64, chat.PartTypeDocumentURL, chat.PartTypeDocumentBase64, chat.PartTypeDocumentText:
+		return nil, false, fmt.Errorf("cloudflare provider does not support %q parts", part.Type)
 	default:
 		return nil, false, fmt.Errorf("unsupported part type %q", part.Type)
 	}
@@ -544,6 +587,7 @@ func toChatResult(resultRaw []byte, fallbackModel string) *chat.Result {
 		if usage := extractUsage(m); usage != nil {
 			result.Usage = *usage
 		}
+		result.SetFinishReason(extractFinishReason(m))
 	}
 	if result.Model == "" {
 		result.Model = fallbackModel
@@ -623,6 +667,29 @@ func extractChoicesText(choices []any) string {
 	return ""
 }
 
+// extractFinishReason reads the finish reason from the Workers AI, Chat
+// Completions or Responses shaped output.
+func extractFinishReason(m map[string]any) string {
+	if reason := extractString(m, "finish_reason"); reason != "" {
+		return reason
+	}
+	if choices, ok := m["choices"].([]any); ok {
+		for _, choice := range choices {
+			if choiceMap, ok := choice.(map[string]any); ok {
+				if reason := extractString(choiceMap, "finish_reason"); reason != "" {
+					return reason
+				}
+			}
+		}
+	}
+	if details, ok := m["incomplete_details"].(map[string]any); ok {
+		if reason := extractString(details, "reason"); reason != "" {
+			return reason
+		}
+	}
+	return extractString(m, "status")
+}
+
 func extractToolCalls(m map[string]any) []chat.ToolCall {
 	if raw := m["tool_calls"]; raw != nil {
 		if calls := parseToolCalls(raw); len(calls) > 0 {
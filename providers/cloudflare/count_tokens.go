@@ -0,0 +1,21 @@
+package cloudflare
+
+import (
+	"fmt"
+
+	"github.com/quailyquaily/uniai/chat"
+	"github.com/quailyquaily/uniai/internal/tokenest"
+)
+
+// EstimateTokens estimates the input tokens of req from the Workers AI
+// request payload. Workers AI has no counting endpoint.
+func (p *Provider) EstimateTokens(req *chat.Request) (int, error) {
+	if req.Model == "" {
+		return 0, fmt.Errorf("model is required")
+	}
+	payload, err := buildPayload(req, req.Model)
+	if err != nil {
+		return 0, fmt.Errorf("cloudflare provider model %q: %w", req.Model, err)
+	}
+	return tokenest.Payload(payload)
+}
@@ -0,0 +1,88 @@
+package gemini
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/quailyquaily/uniai/chat"
+	"github.com/quailyquaily/uniai/internal/apierror"
+	"github.com/quailyquaily/uniai/internal/diag"
+	"github.com/quailyquaily/uniai/internal/httputil"
+)
+
+// geminiCountTokensRequest wraps a generateContent request for the
+// countTokens endpoint, which then counts the system instruction and tools
+// as well as the contents.
+type geminiCountTokensRequest struct {
+	GenerateContentRequest geminiGenerateContentRequest `json:"generateContentRequest"`
+}
+
+type geminiGenerateContentRequest struct {
+	Model string `json:"model"`
+	*geminiRequest
+}
+
+// CountTokens returns the input token count of req as reported by the
+// countTokens endpoint. The request is built as Chat builds it.
+func (p *Provider) CountTokens(ctx context.Context, req *chat.Request) (int, error) {
+	debugFn := req.Options.DebugFn
+	if err := chat.ValidateNoScopedCacheControl(req, "gemini"); err != nil {
+		return 0, err
+	}
+	model := strings.TrimSpace(req.Model)
+	if model == "" {
+		model = strings.TrimSpace(p.cfg.DefaultModel)
+	}
+	if model == "" {
+		return 0, fmt.Errorf("model is required")
+	}
+	payload, err := buildRequest(req, model)
+	if err != nil {
+		return 0, fmt.Errorf("gemini provider model %q: %w", model, err)
+	}
+	reqBody, err := json.Marshal(geminiCountTokensRequest{
+		GenerateContentRequest: geminiGenerateContentRequest{
+			Model:         "models/" + normalizeGeminiModel(model),
+			geminiRequest: payload,
+		},
+	})
+	if err != nil {
+		return 0, err
+	}
+	diag.LogText(p.cfg.Debug, debugFn, "gemini.count_tokens.request", string(reqBody))
+
+	endpoint := modelURL(p.cfg.BaseURL, model, "countTokens", url.Values{"key": []string{p.cfg.APIKey}})
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(reqBody))
+	if err != nil {
+		return 0, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httputil.ApplyHeaders(httpReq.Header, p.cfg.Headers)
+
+	resp, err := httputil.ClientForContext(httputil.WithClient(ctx, p.cfg.HTTPClient)).Do(httpReq)
+	if err != nil {
+		diag.LogError(p.cfg.Debug, debugFn, "gemini.count_tokens.response", err)
+		return 0, err
+	}
+	defer resp.Body.Close()
+	respData, err := httputil.ReadBody(resp.Body)
+	if err != nil {
+		return 0, err
+	}
+	diag.LogText(p.cfg.Debug, debugFn, "gemini.count_tokens.response", string(respData))
+	if resp.StatusCode != http.StatusOK {
+		return 0, apierror.FromResponse("gemini", resp.StatusCode, resp.Header, respData)
+	}
+	var out struct {
+		TotalTokens int `json:"totalTokens"`
+	}
+	if err := json.Unmarshal(respData, &out); err != nil {
+		return 0, err
+	}
+	return out.TotalTokens, nil
+}
@@ -14,6 +14,7 @@ import (
 
 	"github.com/lyricat/goutils/structs"
 	"github.com/quailyquaily/uniai/chat"
+	"github.com/quailyquaily/uniai/internal/apierror"
 	"github.com/quailyquaily/uniai/internal/diag"
 	"github.com/quailyquaily/uniai/internal/httputil"
 	"github.com/quailyquaily/uniai/internal/toolschema"
@@ -21,12 +22,18 @@ import (
 
 const defaultGeminiAPIBase = "https://generativelanguage.googleapis.com"
 
+// maxInlineDocumentBytes is the inline request data limit of generateContent,
+// checked against the base64-encoded documents of a request together. Larger
+// files go through the Files API and document_url parts.
+const maxInlineDocumentBytes = 20 << 20
+
 type Config struct {
 	APIKey       string
 	BaseURL      string
 	DefaultModel string
 	Headers      map[string]string
 	Debug        bool
+	HTTPClient   *http.Client
 }
 
 type Provider struct {
@@ -58,6 +65,7 @@ type geminiPart struct {
 	Text             string                  `json:"text,omitempty"`
 	Thought          bool                    `json:"thought,omitempty"`
 	InlineData       *geminiInlineData       `json:"inlineData,omitempty"`
+	FileData         *geminiFileData         `json:"fileData,omitempty"`
 	FunctionCall     *geminiFunctionCall     `json:"functionCall,omitempty"`
 	FunctionResponse *geminiFunctionResponse `json:"functionResponse,omitempty"`
 	ThoughtSignature string                  `json:"thoughtSignature,omitempty"`
@@ -68,6 +76,11 @@ type geminiInlineData struct {
 	Data     string `json:"data,omitempty"`
 }
 
+type geminiFileData struct {
+	MimeType string `json:"mimeType,omitempty"`
+	FileURI  string `json:"fileUri,omitempty"`
+}
+
 type geminiFunctionCall struct {
 	Name string `json:"name,omitempty"`
 	Args any    `json:"args,omitempty"`
@@ -116,10 +129,17 @@ type geminiThinkingConfig struct {
 }
 
 type geminiResponse struct {
-	Candidates []geminiCandidate `json:"candidates,omitempty"`
-	Usage      geminiUsage       `json:"usageMetadata,omitempty"`
-	Model      string            `json:"modelVersion,omitempty"`
-	Error      *geminiError      `json:"error,omitempty"`
+	Candidates     []geminiCandidate     `json:"candidates,omitempty"`
+	PromptFeedback *geminiPromptFeedback `json:"promptFeedback,omitempty"`
+	Usage          geminiUsage           `json:"usageMetadata,omitempty"`
+	Model          string                `json:"modelVersion,omitempty"`
+	Error          *geminiError          `json:"error,omitempty"`
+}
+
+// geminiPromptFeedback is set instead of candidates when the prompt itself
+// was blocked.
+type geminiPromptFeedback struct {
+	BlockReason string `json:"blockReason,omitempty"`
 }
 
 type geminiCandidate struct {
@@ -138,10 +158,6 @@ type geminiError struct {
 	Message string `json:"message,omitempty"`
 }
 
-type geminiErrorEnvelope struct {
-	Error geminiError `json:"error"`
-}
-
 func (p *Provider) Chat(ctx context.Context, req *chat.Request) (*chat.Result, error) {
 	debugFn := req.Options.DebugFn
 	if err := chat.ValidateNoScopedCacheControl(req, "gemini"); err != nil {
@@ -167,19 +183,13 @@ func (p *Provider) Chat(ctx context.Context, req *chat.Request) (*chat.Result, e
 	}
 	diag.LogText(p.cfg.Debug, debugFn, "gemini.chat.request", string(reqBody))
 
-	base := normalizeGeminiBase(p.cfg.BaseURL)
 	operation := "generateContent"
 	query := url.Values{"key": []string{p.cfg.APIKey}}
 	if req.Options.OnStream != nil {
 		operation = "streamGenerateContent"
 		query.Set("alt", "sse")
 	}
-	endpoint := fmt.Sprintf("%s/v1beta/models/%s:%s?%s",
-		base,
-		url.PathEscape(normalizeGeminiModel(model)),
-		operation,
-		query.Encode(),
-	)
+	endpoint := modelURL(p.cfg.BaseURL, model, operation, query)
 
 	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(reqBody))
 	if err != nil {
@@ -188,7 +198,7 @@ func (p *Provider) Chat(ctx context.Context, req *chat.Request) (*chat.Result, e
 	httpReq.Header.Set("Content-Type", "application/json")
 	httputil.ApplyHeaders(httpReq.Header, p.cfg.Headers)
 
-	resp, err := httputil.ClientForContext(ctx).Do(httpReq)
+	resp, err := httputil.ClientForContext(httputil.WithClient(ctx, p.cfg.HTTPClient)).Do(httpReq)
 	if err != nil {
 		diag.LogError(p.cfg.Debug, debugFn, "gemini.chat.response", err)
 		return nil, err
@@ -201,7 +211,7 @@ func (p *Provider) Chat(ctx context.Context, req *chat.Request) (*chat.Result, e
 				return nil, err
 			}
 			diag.LogText(p.cfg.Debug, debugFn, "gemini.chat.response", string(respData))
-			return nil, fmt.Errorf("gemini api error: status %d: %s", resp.StatusCode, parseGeminiError(respData))
+			return nil, apierror.FromResponse("gemini", resp.StatusCode, resp.Header, respData)
 		}
 		result, err := p.chatStream(resp.Body, model, req.Options.ReasoningDetails, req.Options.OnStream)
 		if err != nil {
@@ -218,7 +228,7 @@ func (p *Provider) Chat(ctx context.Context, req *chat.Request) (*chat.Result, e
 	diag.LogText(p.cfg.Debug, debugFn, "gemini.chat.response", string(respData))
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("gemini api error: status %d: %s", resp.StatusCode, parseGeminiError(respData))
+		return nil, apierror.FromResponse("gemini", resp.StatusCode, resp.Header, respData)
 	}
 
 	var out geminiResponse
@@ -242,6 +252,8 @@ func (p *Provider) chatStream(body io.Reader, fallbackModel string, reasoningDet
 		parts                []geminiPart
 		usage                geminiUsage
 		model                string
+		finishReason         string
+		promptFeedback       *geminiPromptFeedback
 		rawChunks            []json.RawMessage
 		toolIndex            int
 		activeThoughtPart    = -1
@@ -265,19 +277,22 @@ func (p *Provider) chatStream(body io.Reader, fallbackModel string, reasoningDet
 			return nil, fmt.Errorf("gemini stream response: %w", err)
 		}
 		if chunk.Error != nil {
-			if message := strings.TrimSpace(chunk.Error.Message); message != "" {
-				return nil, fmt.Errorf("gemini stream error: %s", message)
-			}
-			return nil, fmt.Errorf("gemini stream error")
+			return nil, apierror.FromResponse("gemini", 0, nil, raw)
 		}
 		rawChunks = append(rawChunks, raw)
 		if chunk.Model != "" {
 			model = chunk.Model
 		}
 		mergeGeminiUsage(&usage, chunk.Usage)
+		if chunk.PromptFeedback != nil {
+			promptFeedback = chunk.PromptFeedback
+		}
 		if len(chunk.Candidates) == 0 {
 			continue
 		}
+		if reason := chunk.Candidates[0].FinishReason; reason != "" {
+			finishReason = reason
+		}
 
 		for _, part := range chunk.Candidates[0].Content.Parts {
 			partIndex, merged := appendGeminiStreamPart(&parts, part)
@@ -309,6 +324,14 @@ func (p *Provider) chatStream(body io.Reader, fallbackModel string, reasoningDet
 				}
 			}
 
+			if image, ok := toGeminiImagePart(part); ok {
+				activeThoughtPart = -1
+				activeReasoningIndex = -1
+				if err := onStream(chat.StreamEvent{Part: &image, Raw: raw}); err != nil {
+					return nil, err
+				}
+			}
+
 			if part.FunctionCall != nil {
 				activeThoughtPart = -1
 				activeReasoningIndex = -1
@@ -340,9 +363,10 @@ func (p *Provider) chatStream(body io.Reader, fallbackModel string, reasoningDet
 	}
 
 	accumulated := &geminiResponse{
-		Candidates: []geminiCandidate{{Content: geminiContent{Parts: parts}}},
-		Usage:      usage,
-		Model:      model,
+		Candidates:     []geminiCandidate{{Content: geminiContent{Parts: parts}, FinishReason: finishReason}},
+		PromptFeedback: promptFeedback,
+		Usage:          usage,
+		Model:          model,
 	}
 	result, err := toChatResult(accumulated, fallbackModel, reasoningDetails)
 	if err != nil {
@@ -350,9 +374,11 @@ func (p *Provider) chatStream(body io.Reader, fallbackModel string, reasoningDet
 	}
 	result.Raw = rawChunks
 	if err := onStream(chat.StreamEvent{
-		Done:  true,
-		Usage: &result.Usage,
-		Raw:   rawChunks,
+		Done:            true,
+		Usage:           &result.Usage,
+		Raw:             rawChunks,
+		FinishReason:    result.FinishReason,
+		RawFinishReason: result.RawFinishReason,
 	}); err != nil {
 		return nil, err
 	}
@@ -397,6 +423,9 @@ func mergeGeminiUsage(dst *geminiUsage, src geminiUsage) {
 }
 
 func buildRequest(req *chat.Request, model string) (*geminiRequest, error) {
+	if err := chat.ValidateRequestDocumentSize(req.Messages, "gemini", maxInlineDocumentBytes, chat.EncodedDocumentSize); err != nil {
+		return nil, err
+	}
 	out := &geminiRequest{}
 
 	systemParts := make([]geminiPart, 0, 1)
@@ -439,6 +468,42 @@ func buildRequest(req *chat.Request, model string) (*geminiRequest, error) {
 							Data:     strings.TrimSpace(part.DataBase64),
 						},
 					})
+				case chat.PartTypeAudioBase64:
+					appendContent(&contents, "user", geminiPart{
+						InlineData: &geminiInlineData{
+							MimeType: strings.TrimSpace(part.MIMEType),
+							Data:     strings.TrimSpace(part.DataBase64),
+						},
+					})
+				case chat.PartTypeAudioURL:
+					mimeType := strings.TrimSpace(part.MIMEType)
+					if mimeType == "" {
+						return nil, fmt.Errorf("role %q: part type %q requires mime_type for gemini", msg.Role, part.Type)
+					}
+					appendContent(&contents, "user", geminiPart{
+						FileData: &geminiFileData{
+							MimeType: mimeType,
+							FileURI:  strings.TrimSpace(part.URL),
+						},
+					})
+				case chat.PartTypeDocumentBase64, chat.PartTypeDocumentText:
+					data := strings.TrimSpace(part.DataBase64)
+					if part.Type == chat.PartTypeDocumentText {
+						data = base64.StdEncoding.EncodeToString([]byte(part.Text))
+					}
+					appendContent(&contents, "user", geminiPart{
+						InlineData: &geminiInlineData{
+							MimeType: chat.DocumentMIMEType(part),
+							Data:     data,
+						},
+					})
+				case chat.PartTypeDocumentURL:
+					appendContent(&contents, "user", geminiPart{
+						FileData: &geminiFileData{
+							MimeType: chat.DocumentMIMEType(part),
+							FileURI:  strings.TrimSpace(part.URL),
+						},
+					})
 				case chat.PartTypeImageURL:
 					return nil, fmt.Errorf("role %q: unsupported part type %q", msg.Role, part.Type)
 				default:
@@ -451,12 +516,26 @@ func buildRequest(req *chat.Request, model string) (*geminiRequest, error) {
 				if err := chat.ValidatePart(part); err != nil {
 					return nil, fmt.Errorf("role %q: %w", msg.Role, err)
 				}
-				if part.Type != chat.PartTypeText {
+				switch part.Type {
+				case chat.PartTypeText:
+					if strings.TrimSpace(part.Text) != "" {
+						assistantParts = append(assistantParts, geminiPart{Text: part.Text, ThoughtSignature: part.ThoughtSignature})
+					}
+				case chat.PartTypeImageBase64:
+					mimeType := strings.TrimSpace(part.MIMEType)
+					if mimeType == "" {
+						mimeType = "image/png"
+					}
+					assistantParts = append(assistantParts, geminiPart{
+						InlineData: &geminiInlineData{
+							MimeType: mimeType,
+							Data:     strings.TrimSpace(part.DataBase64),
+						},
+						ThoughtSignature: part.ThoughtSignature,
+					})
+				default:
 					return nil, fmt.Errorf("role %q: unsupported part type %q", msg.Role, part.Type)
 				}
-				if strings.TrimSpace(part.Text) != "" {
-					assistantParts = append(assistantParts, geminiPart{Text: part.Text})
-				}
 			}
 			seenFunctionCall := false
 			for _, call := range msg.ToolCalls {
@@ -786,6 +865,9 @@ func toGenerationConfig(model string, opts chat.Options) (*geminiGenerationConfi
 	applyGeminiOptions(cfg, opts.OpenAI)
 	applyGeminiOptions(cfg, opts.Azure)
 	applyGeminiOptions(cfg, opts.Cloudflare)
+	if err := applyGeminiResponseFormat(cfg, opts.ResponseFormat); err != nil {
+		return nil, err
+	}
 	if err := applyGeminiReasoningOptions(model, cfg, opts); err != nil {
 		return nil, err
 	}
@@ -961,6 +1043,28 @@ func applyGeminiOptions(cfg *geminiGenerationConfig, opts structs.JSONMap) {
 	}
 }
 
+// applyGeminiResponseFormat maps the provider-neutral response format to
+// responseMimeType and a downgraded responseSchema, unless raw options
+// already set them.
+func applyGeminiResponseFormat(cfg *geminiGenerationConfig, format *chat.ResponseFormat) error {
+	if cfg == nil || format == nil {
+		return nil
+	}
+	if cfg.ResponseMIMEType == "" {
+		cfg.ResponseMIMEType = "application/json"
+	}
+	if format.Type != chat.ResponseFormatJSONSchema || cfg.ResponseSchema != nil {
+		return nil
+	}
+	schema, err := format.SchemaMap()
+	if err != nil {
+		return err
+	}
+	toolschema.Normalize(schema)
+	cfg.ResponseSchema = toGeminiSchema(schema)
+	return nil
+}
+
 func toChatResult(in *geminiResponse, fallbackModel string, reasoningDetails bool) (*chat.Result, error) {
 	if in == nil {
 		return &chat.Result{Model: fallbackModel}, nil
@@ -978,6 +1082,7 @@ func toChatResult(in *geminiResponse, fallbackModel string, reasoningDetails boo
 	}
 
 	if len(in.Candidates) == 0 {
+		applyGeminiPromptFeedback(result, in.PromptFeedback)
 		return result, nil
 	}
 
@@ -993,9 +1098,14 @@ func toChatResult(in *geminiResponse, fallbackModel string, reasoningDetails boo
 				}
 			} else {
 				text = append(text, part.Text)
-				outParts = append(outParts, chat.TextPart(part.Text))
+				textPart := chat.TextPart(part.Text)
+				textPart.ThoughtSignature = part.ThoughtSignature
+				outParts = append(outParts, textPart)
 			}
 		}
+		if image, ok := toGeminiImagePart(part); ok {
+			outParts = append(outParts, image)
+		}
 		if part.FunctionCall != nil {
 			args, err := json.Marshal(part.FunctionCall.Args)
 			if err != nil {
@@ -1019,9 +1129,33 @@ func toChatResult(in *geminiResponse, fallbackModel string, reasoningDetails boo
 	result.Text = strings.Join(text, "")
 	result.Parts = outParts
 	result.ToolCalls = calls
+	result.SetFinishReason(in.Candidates[0].FinishReason)
+	applyGeminiPromptFeedback(result, in.PromptFeedback)
 	return result, nil
 }
 
+// toGeminiImagePart returns the image_base64 part of an image returned by an
+// image-capable model. Thought images, drafts the model shows while
+// reasoning, are skipped.
+func toGeminiImagePart(part geminiPart) (chat.Part, bool) {
+	if part.InlineData == nil || part.Thought || !strings.HasPrefix(part.InlineData.MimeType, "image/") {
+		return chat.Part{}, false
+	}
+	image := chat.ImageBase64Part(part.InlineData.MimeType, part.InlineData.Data)
+	image.ThoughtSignature = part.ThoughtSignature
+	return image, true
+}
+
+// applyGeminiPromptFeedback reports a blocked prompt as a content filter stop
+// when no candidate finish reason is available.
+func applyGeminiPromptFeedback(result *chat.Result, feedback *geminiPromptFeedback) {
+	if result.FinishReason != "" || feedback == nil || feedback.BlockReason == "" {
+		return
+	}
+	result.RawFinishReason = feedback.BlockReason
+	result.FinishReason = chat.FinishReasonContentFilter
+}
+
 func appendGeminiReasoningDetail(reasoning *chat.ReasoningResult, text string) *chat.ReasoningResult {
 	text = strings.TrimSpace(text)
 	if text == "" {
@@ -1068,19 +1202,6 @@ func splitToolCallIDAndThoughtSignature(callID string) (string, string) {
 	return baseID, string(decoded)
 }
 
-func parseGeminiError(data []byte) string {
-	if len(data) == 0 {
-		return ""
-	}
-	var env geminiErrorEnvelope
-	if err := json.Unmarshal(data, &env); err == nil {
-		if msg := strings.TrimSpace(env.Error.Message); msg != "" {
-			return msg
-		}
-	}
-	return strings.TrimSpace(string(data))
-}
-
 func normalizeGeminiBase(base string) string {
 	trimmed := strings.TrimRight(strings.TrimSpace(base), "/")
 	if trimmed == "" {
@@ -1097,6 +1218,15 @@ func normalizeGeminiBase(base string) string {
 	return trimmed
 }
 
+func modelURL(base, model, operation string, query url.Values) string {
+	return fmt.Sprintf("%s/v1beta/models/%s:%s?%s",
+		normalizeGeminiBase(base),
+		url.PathEscape(normalizeGeminiModel(model)),
+		operation,
+		query.Encode(),
+	)
+}
+
 func normalizeGeminiModel(model string) string {
 	model = strings.TrimSpace(model)
 	model = strings.TrimPrefix(model, "models/")
@@ -138,6 +138,125 @@ func TestBuildRequestMapsUserImageBase64Part(t *testing.T) {
 	}
 }
 
+func TestBuildRequestMapsUserAudioParts(t *testing.T) {
+	req := &chat.Request{
+		Messages: []chat.Message{
+			chat.UserParts(
+				chat.AudioBase64Part("audio/ogg", "T2dn"),
+				chat.AudioURLPart("audio/mpeg", "https://example.com/a.mp3"),
+			),
+		},
+	}
+
+	out, err := buildRequest(req, req.Model)
+	if err != nil {
+		t.Fatalf("build request: %v", err)
+	}
+	parts := out.Contents[0].Parts
+	if len(parts) != 2 {
+		t.Fatalf("expected 2 parts, got %d", len(parts))
+	}
+	if parts[0].InlineData == nil || parts[0].InlineData.MimeType != "audio/ogg" || parts[0].InlineData.Data != "T2dn" {
+		t.Fatalf("unexpected inlineData part: %#v", parts[0])
+	}
+	if parts[1].FileData == nil || parts[1].FileData.MimeType != "audio/mpeg" || parts[1].FileData.FileURI != "https://example.com/a.mp3" {
+		t.Fatalf("unexpected fileData part: %#v", parts[1])
+	}
+
+	req.Messages = []chat.Message{chat.UserParts(chat.AudioURLPart("", "https://example.com/a.mp3"))}
+	if _, err := buildRequest(req, req.Model); err == nil || !strings.Contains(err.Error(), "mime_type") {
+		t.Fatalf("expected missing mime_type error, got %v", err)
+	}
+}
+
+func TestBuildRequestMapsUserDocumentParts(t *testing.T) {
+	req := &chat.Request{
+		Messages: []chat.Message{
+			chat.UserParts(
+				chat.DocumentBase64Part("", "JVBERi0="),
+				chat.DocumentURLPart("https://generativelanguage.googleapis.com/v1beta/files/abc"),
+				chat.DocumentTextPart("notes", "hello"),
+			),
+		},
+	}
+
+	out, err := buildRequest(req, req.Model)
+	if err != nil {
+		t.Fatalf("build request: %v", err)
+	}
+	parts := out.Contents[0].Parts
+	if len(parts) != 3 {
+		t.Fatalf("expected 3 parts, got %d", len(parts))
+	}
+	if parts[0].InlineData == nil || parts[0].InlineData.MimeType != "application/pdf" || parts[0].InlineData.Data != "JVBERi0=" {
+		t.Fatalf("unexpected pdf part: %#v", parts[0])
+	}
+	if parts[1].FileData == nil || parts[1].FileData.MimeType != "application/pdf" {
+		t.Fatalf("unexpected fileData part: %#v", parts[1])
+	}
+	if parts[2].InlineData == nil || parts[2].InlineData.MimeType != "text/plain" || parts[2].InlineData.Data != "aGVsbG8=" {
+		t.Fatalf("unexpected text document part: %#v", parts[2])
+	}
+}
+
+func TestBuildRequestLimitsEncodedDocumentSize(t *testing.T) {
+	build := func(parts ...chat.Part) error {
+		_, err := buildRequest(&chat.Request{Messages: []chat.Message{chat.UserParts(parts...)}}, "")
+		return err
+	}
+
+	// The inline limit applies to the base64 data, not to the decoded
+	// document; text is base64-encoded before it is sent.
+	if err := build(chat.DocumentBase64Part("", strings.Repeat("A", maxInlineDocumentBytes))); err != nil {
+		t.Fatalf("unexpected error at the limit: %v", err)
+	}
+	if err := build(chat.DocumentTextPart("", strings.Repeat("a", maxInlineDocumentBytes/4*3))); err != nil {
+		t.Fatalf("unexpected error for text at the limit: %v", err)
+	}
+	for _, part := range []chat.Part{
+		chat.DocumentBase64Part("", strings.Repeat("A", maxInlineDocumentBytes+4)),
+		chat.DocumentTextPart("", strings.Repeat("a", maxInlineDocumentBytes/4*3+1)),
+	} {
+		if err := build(part); err == nil || !strings.Contains(err.Error(), "gemini provider accepts up to 20971520 bytes of documents per request") {
+			t.Fatalf("expected size limit error for %s, got %v", part.Type, err)
+		}
+	}
+	// The limit covers all inline documents of the request.
+	half := strings.Repeat("A", maxInlineDocumentBytes/2)
+	if err := build(chat.DocumentBase64Part("", half), chat.DocumentBase64Part("", half+"AAAA")); err == nil {
+		t.Fatal("expected size limit error for documents over the limit together")
+	}
+}
+
+func TestBuildRequestReplaysAssistantImageParts(t *testing.T) {
+	text := chat.TextPart("Here it is.")
+	text.ThoughtSignature = "sig_text"
+	image := chat.ImageBase64Part("image/png", "QUJD")
+	image.ThoughtSignature = "sig_image"
+	req := &chat.Request{
+		Messages: []chat.Message{
+			chat.User("draw a cat"),
+			chat.AssistantParts(text, image),
+			chat.User("make it blue"),
+		},
+	}
+
+	out, err := buildRequest(req, req.Model)
+	if err != nil {
+		t.Fatalf("build request: %v", err)
+	}
+	model := out.Contents[1]
+	if model.Role != "model" || len(model.Parts) != 2 {
+		t.Fatalf("unexpected model content: %#v", model)
+	}
+	if model.Parts[0].Text != "Here it is." || model.Parts[0].ThoughtSignature != "sig_text" {
+		t.Fatalf("unexpected text part: %#v", model.Parts[0])
+	}
+	if got := model.Parts[1]; got.InlineData == nil || got.InlineData.Data != "QUJD" || got.ThoughtSignature != "sig_image" {
+		t.Fatalf("unexpected image part: %#v", got)
+	}
+}
+
 func TestBuildRequestRejectsUserImageURLPart(t *testing.T) {
 	req := &chat.Request{
 		Messages: []chat.Message{
@@ -255,6 +374,37 @@ func TestBuildRequestRejectsEffortAndBudgetTogether(t *testing.T) {
 	}
 }
 
+func TestBuildRequestMapsJSONSchemaResponseFormat(t *testing.T) {
+	req, err := chat.BuildRequest(
+		chat.WithModel("gemini-2.5-flash"),
+		chat.WithMessages(chat.User("hello")),
+		chat.WithJSONSchema("person", []byte(`{"type":"object","properties":{"name":{"type":["string","null"]}},"additionalProperties":false}`), true),
+	)
+	if err != nil {
+		t.Fatalf("build request: %v", err)
+	}
+
+	out, err := buildRequest(req, req.Model)
+	if err != nil {
+		t.Fatalf("buildRequest: %v", err)
+	}
+	cfg := out.GenerationConfig
+	if cfg == nil || cfg.ResponseMIMEType != "application/json" {
+		t.Fatalf("unexpected generation config: %#v", cfg)
+	}
+	schema, ok := cfg.ResponseSchema.(map[string]any)
+	if !ok || schema["type"] != "OBJECT" {
+		t.Fatalf("unexpected response schema: %#v", cfg.ResponseSchema)
+	}
+	if _, ok := schema["additionalProperties"]; ok {
+		t.Fatalf("expected additionalProperties to be dropped: %#v", schema)
+	}
+	name := schema["properties"].(map[string]any)["name"].(map[string]any)
+	if name["type"] != "STRING" || name["nullable"] != true {
+		t.Fatalf("unexpected downgraded property: %#v", name)
+	}
+}
+
 func TestToChatResultParsesFunctionCallAndSignature(t *testing.T) {
 	in := &geminiResponse{
 		Model: "gemini-2.5-pro",
@@ -376,6 +526,95 @@ func TestToChatResultSeparatesThoughtSummary(t *testing.T) {
 	}
 }
 
+func TestToChatResultReturnsImageParts(t *testing.T) {
+	out, err := toChatResult(&geminiResponse{
+		Candidates: []geminiCandidate{{Content: geminiContent{Parts: []geminiPart{
+			{Thought: true, InlineData: &geminiInlineData{MimeType: "image/png", Data: "draft"}},
+			{Text: "Here it is.", ThoughtSignature: "sig_text"},
+			{InlineData: &geminiInlineData{MimeType: "image/png", Data: "QUJD"}, ThoughtSignature: "sig_image"},
+		}}}},
+	}, "gemini-2.5-flash-image", false)
+	if err != nil {
+		t.Fatalf("toChatResult: %v", err)
+	}
+	if out.Text != "Here it is." || len(out.Parts) != 2 {
+		t.Fatalf("unexpected result: %#v", out)
+	}
+	if out.Parts[0].ThoughtSignature != "sig_text" {
+		t.Fatalf("unexpected text part: %#v", out.Parts[0])
+	}
+	image := out.Parts[1]
+	if image.Type != chat.PartTypeImageBase64 || image.MIMEType != "image/png" || image.DataBase64 != "QUJD" || image.ThoughtSignature != "sig_image" {
+		t.Fatalf("unexpected image part: %#v", image)
+	}
+}
+
+func TestChatStreamsImageParts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		writeGeminiSSE(t, w, map[string]any{
+			"candidates": []any{map[string]any{"content": map[string]any{"parts": []any{
+				map[string]any{"text": "Here it is."},
+			}}}},
+		})
+		writeGeminiSSE(t, w, map[string]any{
+			"candidates": []any{map[string]any{"content": map[string]any{"parts": []any{
+				map[string]any{"inlineData": map[string]any{"mimeType": "image/png", "data": "QUJD"}, "thoughtSignature": "sig_image"},
+			}}, "finishReason": "STOP"}},
+		})
+	}))
+	defer server.Close()
+
+	p, err := New(Config{APIKey: "test-key", BaseURL: server.URL, DefaultModel: "gemini-2.5-flash-image"})
+	if err != nil {
+		t.Fatalf("new provider: %v", err)
+	}
+	var images []chat.Part
+	result, err := p.Chat(context.Background(), &chat.Request{
+		Messages: []chat.Message{chat.User("draw a cat")},
+		Options: chat.Options{
+			OnStream: func(event chat.StreamEvent) error {
+				if event.Part != nil {
+					images = append(images, *event.Part)
+				}
+				return nil
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("chat: %v", err)
+	}
+	if len(images) != 1 || images[0].DataBase64 != "QUJD" || images[0].ThoughtSignature != "sig_image" {
+		t.Fatalf("unexpected image events: %#v", images)
+	}
+	if len(result.Parts) != 2 || result.Parts[1].Type != chat.PartTypeImageBase64 || result.Parts[1].DataBase64 != "QUJD" {
+		t.Fatalf("unexpected result parts: %#v", result.Parts)
+	}
+}
+
+func TestToChatResultNormalizesFinishReason(t *testing.T) {
+	out, err := toChatResult(&geminiResponse{
+		Candidates: []geminiCandidate{{
+			Content:      geminiContent{Parts: []geminiPart{{Text: "truncated"}}},
+			FinishReason: "MAX_TOKENS",
+		}},
+	}, "gemini-2.5-pro", false)
+	if err != nil {
+		t.Fatalf("toChatResult: %v", err)
+	}
+	if out.FinishReason != chat.FinishReasonLength || out.RawFinishReason != "MAX_TOKENS" {
+		t.Fatalf("unexpected finish reason: %q (%q)", out.FinishReason, out.RawFinishReason)
+	}
+
+	out, err = toChatResult(&geminiResponse{PromptFeedback: &geminiPromptFeedback{BlockReason: "SAFETY"}}, "gemini-2.5-pro", false)
+	if err != nil {
+		t.Fatalf("toChatResult: %v", err)
+	}
+	if out.FinishReason != chat.FinishReasonContentFilter || out.RawFinishReason != "SAFETY" {
+		t.Fatalf("unexpected blocked finish reason: %q (%q)", out.FinishReason, out.RawFinishReason)
+	}
+}
+
 func TestChatStreamsThoughtTextAndToolCalls(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path != "/v1beta/models/gemini-2.5-pro:streamGenerateContent" {
@@ -0,0 +1,16 @@
+package openai
+
+import (
+	"github.com/quailyquaily/uniai/chat"
+	"github.com/quailyquaily/uniai/internal/tokenest"
+)
+
+// EstimateTokens estimates the input tokens of req from the Chat Completions
+// request body. OpenAI-compatible APIs have no counting endpoint.
+func (p *Provider) EstimateTokens(req *chat.Request) (int, error) {
+	params, err := buildParams(req, p.defaultModel)
+	if err != nil {
+		return 0, err
+	}
+	return tokenest.Payload(params)
+}
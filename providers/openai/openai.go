@@ -19,12 +19,19 @@ import (
 	"github.com/quailyquaily/uniai/internal/oaicompat"
 )
 
+const providerName = "openai"
+
 type Config struct {
 	APIKey       string
 	BaseURL      string
 	DefaultModel string
 	Headers      map[string]string
 	Debug        bool
+	// DisableRetries turns off the openai-go built-in retries.
+	DisableRetries bool
+	// HTTPClient is passed to openai-go, which uses its own default client
+	// when it is nil.
+	HTTPClient *http.Client
 }
 
 type Provider struct {
@@ -45,6 +52,12 @@ func New(cfg Config) (*Provider, error) {
 	for key, value := range httputil.CloneHeaders(cfg.Headers) {
 		opts = append(opts, option.WithHeader(key, value))
 	}
+	if cfg.DisableRetries {
+		opts = append(opts, option.WithMaxRetries(0))
+	}
+	if cfg.HTTPClient != nil {
+		opts = append(opts, option.WithHTTPClient(cfg.HTTPClient))
+	}
 	return &Provider{
 		client:       openai.NewClient(opts...),
 		defaultModel: cfg.DefaultModel,
@@ -69,6 +82,7 @@ func (p *Provider) Chat(ctx context.Context, req *chat.Request) (*chat.Result, e
 		result, err := oaicompat.ChatStream(ctx, &p.client, params, req.Options.ReasoningDetails, req.Options.OnStream, opts...)
 		if err != nil {
 			streamDebug.Emit(err)
+			err = oaicompat.WrapError(providerName, err)
 			diag.LogError(p.debug, debugFn, "openai.chat.response", err)
 			return nil, err
 		}
@@ -94,7 +108,7 @@ func (p *Provider) Chat(ctx context.Context, req *chat.Request) (*chat.Result, e
 func (p *Provider) chatCompletion(ctx context.Context, params openai.ChatCompletionNewParams) (*chat.Result, string, error) {
 	var rawResp *http.Response
 	if err := p.client.Execute(ctx, http.MethodPost, "chat/completions", params, &rawResp); err != nil {
-		return nil, "", err
+		return nil, "", oaicompat.WrapError(providerName, err)
 	}
 	if rawResp == nil || rawResp.Body == nil {
 		return nil, "", fmt.Errorf("openai chat response is empty")
@@ -103,7 +117,7 @@ func (p *Provider) chatCompletion(ctx context.Context, params openai.ChatComplet
 
 	if oaicompat.IsEventStreamContentType(rawResp.Header.Get("Content-Type")) {
 		result, err := oaicompat.ChatStreamFromResponse(rawResp, false, nil)
-		return result, "", err
+		return result, "", oaicompat.WrapError(providerName, err)
 	}
 
 	data, err := io.ReadAll(rawResp.Body)
@@ -195,6 +209,10 @@ func buildParams(req *chat.Request, defaultModel string) (openai.ChatCompletionN
 		params.ToolChoice = oaicompat.ToToolChoice(req.ToolChoice)
 	}
 
+	if err := oaicompat.ApplyChatResponseFormat(&params, req.Options.ResponseFormat); err != nil {
+		return openai.ChatCompletionNewParams{}, err
+	}
+
 	openAIOptions := req.Options.OpenAI
 	if err := oaicompat.ApplyOptions(&params, openAIOptions); err != nil {
 		return openai.ChatCompletionNewParams{}, err
@@ -3,6 +3,7 @@ package openai
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -11,6 +12,7 @@ import (
 	"github.com/lyricat/goutils/structs"
 	openai "github.com/openai/openai-go/v3"
 	"github.com/quailyquaily/uniai/chat"
+	"github.com/quailyquaily/uniai/internal/apierror"
 )
 
 func TestBuildRequestMapping(t *testing.T) {
@@ -121,6 +123,38 @@ func TestBuildParamsMapsReasoningEffort(t *testing.T) {
 	}
 }
 
+func TestBuildParamsMapsJSONSchemaResponseFormat(t *testing.T) {
+	req, err := chat.BuildRequest(
+		chat.WithModel("gpt-4.1-mini"),
+		chat.WithMessages(chat.User("hello")),
+		chat.WithJSONSchema("person", []byte(`{"type":"object","properties":{"name":{"type":"string"}},"required":["name"],"additionalProperties":false}`), true),
+	)
+	if err != nil {
+		t.Fatalf("build request: %v", err)
+	}
+
+	params, err := buildParams(req, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	format := params.ResponseFormat.OfJSONSchema
+	if format == nil || format.JSONSchema.Name != "person" || !format.JSONSchema.Strict.Value {
+		t.Fatalf("unexpected response format: %#v", params.ResponseFormat)
+	}
+	if schema, ok := format.JSONSchema.Schema.(map[string]any); !ok || schema["type"] != "object" {
+		t.Fatalf("unexpected schema: %#v", format.JSONSchema.Schema)
+	}
+
+	req.Options.OpenAI = structs.JSONMap{"response_format": "json_object"}
+	params, err = buildParams(req, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if params.ResponseFormat.OfJSONObject == nil {
+		t.Fatalf("expected raw response_format to win, got %#v", params.ResponseFormat)
+	}
+}
+
 func TestBuildParamsDropsKimiK26FixedSamplingParams(t *testing.T) {
 	temp := 0.2
 	topP := 0.9
@@ -763,6 +797,41 @@ func TestBuildRequestMapsUserImageBase64Part(t *testing.T) {
 	}
 }
 
+func TestBuildRequestMapsUserAudioBase64Part(t *testing.T) {
+	req := &chat.Request{
+		Model: "gpt-4o-audio-preview",
+		Messages: []chat.Message{
+			chat.UserParts(
+				chat.TextPart("transcribe this"),
+				chat.AudioBase64Part("audio/mpeg", "SUQz"),
+			),
+		},
+	}
+
+	params, err := buildParams(req, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	parts := params.Messages[0].OfUser.Content.OfArrayOfContentParts
+	if len(parts) != 2 || parts[1].OfInputAudio == nil {
+		t.Fatalf("expected an input_audio part, got %#v", parts)
+	}
+	if audio := parts[1].OfInputAudio.InputAudio; audio.Data != "SUQz" || audio.Format != "mp3" {
+		t.Fatalf("unexpected input_audio payload: %#v", audio)
+	}
+
+	for _, part := range []chat.Part{
+		chat.AudioBase64Part("audio/ogg", "T2dn"),
+		chat.AudioURLPart("audio/mpeg", "https://example.com/a.mp3"),
+		chat.DocumentBase64Part("application/pdf", "JVBERi0="),
+	} {
+		req.Messages = []chat.Message{chat.UserParts(part)}
+		if _, err := buildParams(req, ""); err == nil {
+			t.Fatalf("expected %s %s to be rejected", part.Type, part.MIMEType)
+		}
+	}
+}
+
 func TestToResultAddsTextPart(t *testing.T) {
 	resp := &openai.ChatCompletion{
 		Model: "gpt-5.2",
@@ -1032,6 +1101,43 @@ func TestChatAppliesCustomHeaders(t *testing.T) {
 	}
 }
 
+func TestChatConvertsSDKErrorToAPIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("x-request-id", "req_openai")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":{"message":"This model's maximum context length is 8192 tokens.","type":"invalid_request_error","param":"messages","code":"context_length_exceeded"}}`))
+	}))
+	defer server.Close()
+
+	p, err := New(Config{
+		APIKey:       "test-key",
+		BaseURL:      server.URL + "/v1",
+		DefaultModel: "gpt-4.1-mini",
+	})
+	if err != nil {
+		t.Fatalf("new provider: %v", err)
+	}
+
+	_, err = p.Chat(context.Background(), &chat.Request{
+		Messages: []chat.Message{chat.User("hello")},
+	})
+	if !errors.Is(err, apierror.ErrContextLengthExceeded) {
+		t.Fatalf("expected context length error, got %v", err)
+	}
+	var apiErr *apierror.Error
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected *apierror.Error, got %T", err)
+	}
+	if apiErr.StatusCode != http.StatusBadRequest || apiErr.Code != "context_length_exceeded" || apiErr.RequestID != "req_openai" {
+		t.Fatalf("unexpected api error: %#v", apiErr)
+	}
+	var sdkErr *openai.Error
+	if !errors.As(err, &sdkErr) {
+		t.Fatalf("expected SDK error to stay reachable, got %T", err)
+	}
+}
+
 func TestChatAggregatesEventStreamOnNonStreamingRequest(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path != "/v1/chat/completions" {
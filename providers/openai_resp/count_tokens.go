@@ -0,0 +1,74 @@
+package openairesp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/quailyquaily/uniai/chat"
+	"github.com/quailyquaily/uniai/internal/diag"
+	"github.com/quailyquaily/uniai/internal/oaicompat"
+	"github.com/quailyquaily/uniai/internal/tokenest"
+)
+
+// inputTokenCountKeys are the request fields accepted by the
+// responses/input_tokens endpoint.
+var inputTokenCountKeys = map[string]struct{}{
+	"conversation":         {},
+	"input":                {},
+	"instructions":         {},
+	"model":                {},
+	"parallel_tool_calls":  {},
+	"previous_response_id": {},
+	"reasoning":            {},
+	"text":                 {},
+	"tool_choice":          {},
+	"tools":                {},
+	"truncation":           {},
+}
+
+// CountTokens returns the input token count of req as reported by the
+// Responses input token counting endpoint. The request is built as Chat
+// builds it; fields the endpoint does not accept are dropped.
+func (p *Provider) CountTokens(ctx context.Context, req *chat.Request) (int, error) {
+	debugFn := req.Options.DebugFn
+	params, err := buildParams(req, p.defaultModel, p.openAICodex)
+	if err != nil {
+		return 0, err
+	}
+	data, err := json.Marshal(params)
+	if err != nil {
+		return 0, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return 0, err
+	}
+	for key := range fields {
+		if _, ok := inputTokenCountKeys[key]; !ok {
+			delete(fields, key)
+		}
+	}
+	diag.LogJSON(p.debug, debugFn, "openai.responses.input_tokens.request", fields)
+
+	var out struct {
+		InputTokens int `json:"input_tokens"`
+	}
+	if err := p.client.Execute(ctx, http.MethodPost, "responses/input_tokens", fields, &out); err != nil {
+		err = oaicompat.WrapError(providerName, err)
+		diag.LogError(p.debug, debugFn, "openai.responses.input_tokens.response", err)
+		return 0, err
+	}
+	diag.LogJSON(p.debug, debugFn, "openai.responses.input_tokens.response", out)
+	return out.InputTokens, nil
+}
+
+// EstimateTokens estimates the input tokens of req from the Responses
+// request body, for deployments without the input token counting endpoint.
+func (p *Provider) EstimateTokens(req *chat.Request) (int, error) {
+	params, err := buildParams(req, p.defaultModel, p.openAICodex)
+	if err != nil {
+		return 0, err
+	}
+	return tokenest.Payload(params)
+}
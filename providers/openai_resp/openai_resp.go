@@ -18,6 +18,7 @@ import (
 	"github.com/openai/openai-go/v3/responses"
 	"github.com/openai/openai-go/v3/shared"
 	"github.com/quailyquaily/uniai/chat"
+	"github.com/quailyquaily/uniai/internal/apierror"
 	"github.com/quailyquaily/uniai/internal/diag"
 	"github.com/quailyquaily/uniai/internal/httputil"
 	"github.com/quailyquaily/uniai/internal/modelcompat"
@@ -25,6 +26,11 @@ import (
 	"github.com/quailyquaily/uniai/internal/toolschema"
 )
 
+const providerName = "openai_resp"
+
+// maxDocumentBytes is the Responses API limit for one input_file.
+const maxDocumentBytes = 50 << 20
+
 type Config struct {
 	APIKey       string
 	BaseURL      string
@@ -32,6 +38,11 @@ type Config struct {
 	Headers      map[string]string
 	Debug        bool
 	OpenAICodex  bool
+	// DisableRetries turns off the openai-go built-in retries.
+	DisableRetries bool
+	// HTTPClient is passed to openai-go, which uses its own default client
+	// when it is nil.
+	HTTPClient *http.Client
 }
 
 type Provider struct {
@@ -53,6 +64,12 @@ func New(cfg Config) (*Provider, error) {
 	for key, value := range httputil.CloneHeaders(cfg.Headers) {
 		opts = append(opts, option.WithHeader(key, value))
 	}
+	if cfg.DisableRetries {
+		opts = append(opts, option.WithMaxRetries(0))
+	}
+	if cfg.HTTPClient != nil {
+		opts = append(opts, option.WithHTTPClient(cfg.HTTPClient))
+	}
 
 	return &Provider{
 		client:       openai.NewClient(opts...),
@@ -96,7 +113,7 @@ func (p *Provider) Chat(ctx context.Context, req *chat.Request) (*chat.Result, e
 func (p *Provider) response(ctx context.Context, params responses.ResponseNewParams, reasoningDetails bool) (*chat.Result, string, error) {
 	var rawResp *http.Response
 	if err := p.client.Execute(ctx, http.MethodPost, "responses", params, &rawResp); err != nil {
-		return nil, "", err
+		return nil, "", oaicompat.WrapError(providerName, err)
 	}
 	if rawResp == nil || rawResp.Body == nil {
 		return nil, "", fmt.Errorf("openai responses response is empty")
@@ -256,6 +273,12 @@ func buildParams(req *chat.Request, defaultModel string, openAICodex bool) (resp
 		if err != nil {
 			return responses.ResponseNewParams{}, err
 		}
+		if !opts.HasKey("response_format") && req.Options.ResponseFormat != nil {
+			if err := applyChatResponseFormat(&text, req.Options.ResponseFormat); err != nil {
+				return responses.ResponseNewParams{}, err
+			}
+			hasText = true
+		}
 		if hasText {
 			params.Text = text
 		}
@@ -811,6 +834,29 @@ func setResponseFormatByType(cfg *responses.ResponseTextConfigParam, typeName st
 	}
 }
 
+// applyChatResponseFormat maps the provider-neutral response format to
+// text.format.
+func applyChatResponseFormat(cfg *responses.ResponseTextConfigParam, format *chat.ResponseFormat) error {
+	if format.Type == chat.ResponseFormatJSONObject {
+		cfg.Format = responses.ResponseFormatTextConfigUnionParam{
+			OfJSONObject: &shared.ResponseFormatJSONObjectParam{Type: "json_object"},
+		}
+		return nil
+	}
+	schema, err := format.SchemaMap()
+	if err != nil {
+		return err
+	}
+	cfg.Format = responses.ResponseFormatTextConfigUnionParam{
+		OfJSONSchema: &responses.ResponseFormatTextJSONSchemaConfigParam{
+			Name:   format.ToolName(),
+			Schema: schema,
+			Strict: openai.Bool(format.Strict),
+		},
+	}
+	return nil
+}
+
 func decodeRawTools(opts structs.JSONMap) ([]responses.ToolUnionParam, error) {
 	if !opts.HasKey("tools") {
 		return nil, nil
@@ -997,13 +1043,11 @@ func buildInputFromMessages(messages []chat.Message, promptCacheBreakpoints bool
 			}
 			items = append(items, responses.ResponseInputItemParamOfMessage(content, responses.EasyInputMessageRoleUser))
 		case chat.RoleAssistant:
-			text, err := chat.MessageText(chat.Message{Role: msg.Role, Content: msg.Content, Parts: msg.Parts})
+			content, err := buildAssistantInputItems(msg)
 			if err != nil {
 				return responses.ResponseNewParamsInputUnion{}, fmt.Errorf("role %q: %w", msg.Role, err)
 			}
-			if strings.TrimSpace(text) != "" {
-				items = append(items, responses.ResponseInputItemParamOfMessage(text, responses.EasyInputMessageRoleAssistant))
-			}
+			items = append(items, content...)
 			for _, call := range msg.ToolCalls {
 				name := strings.TrimSpace(call.Function.Name)
 				if name == "" {
@@ -1051,6 +1095,43 @@ func buildInputFromMessages(messages []chat.Message, promptCacheBreakpoints bool
 	}, nil
 }
 
+// buildAssistantInputItems returns the assistant message items of msg in
+// part order. Text parts in a row are joined into one message; generated
+// images are replayed as image_generation_call items, which need the id of
+// the call that returned them.
+func buildAssistantInputItems(msg chat.Message) ([]responses.ResponseInputItemUnionParam, error) {
+	var (
+		items []responses.ResponseInputItemUnionParam
+		text  strings.Builder
+	)
+	flushText := func() {
+		if strings.TrimSpace(text.String()) != "" {
+			items = append(items, responses.ResponseInputItemParamOfMessage(text.String(), responses.EasyInputMessageRoleAssistant))
+		}
+		text.Reset()
+	}
+	for _, part := range chat.NormalizeMessageParts(msg) {
+		if err := chat.ValidatePart(part); err != nil {
+			return nil, err
+		}
+		switch part.Type {
+		case chat.PartTypeText:
+			text.WriteString(part.Text)
+		case chat.PartTypeImageBase64:
+			id := strings.TrimSpace(part.ID)
+			if id == "" {
+				return nil, fmt.Errorf("image_base64 parts need the id of the image_generation_call that returned them")
+			}
+			flushText()
+			items = append(items, responses.ResponseInputItemParamOfImageGenerationCall(id, strings.TrimSpace(part.DataBase64), "completed"))
+		default:
+			return nil, fmt.Errorf("unsupported part type %q", part.Type)
+		}
+	}
+	flushText()
+	return items, nil
+}
+
 func buildSystemInputContent(msg chat.Message) (responses.ResponseInputMessageContentListParam, bool, error) {
 	parts := chat.NormalizeMessageParts(msg)
 	hasCacheControl := false
@@ -1115,6 +1196,41 @@ func buildUserInputContent(msg chat.Message) (responses.ResponseInputMessageCont
 					ImageURL: openai.String(fmt.Sprintf("data:%s;base64,%s", mimeType, part.DataBase64)),
 				},
 			})
+		case chat.PartTypeAudioBase64:
+			format, err := oaicompat.AudioFormat(part.MIMEType)
+			if err != nil {
+				return nil, false, err
+			}
+			out = append(out, inputAudioContent(strings.TrimSpace(part.DataBase64), format))
+		case chat.PartTypeAudioURL:
+			return nil, false, fmt.Errorf("openai_resp input_audio requires %q parts; %q is not supported", chat.PartTypeAudioBase64, chat.PartTypeAudioURL)
+		case chat.PartTypeDocumentURL:
+			out = append(out, responses.ResponseInputContentUnionParam{
+				OfInputFile: &responses.ResponseInputFileParam{FileURL: openai.String(strings.TrimSpace(part.URL))},
+			})
+		case chat.PartTypeDocumentBase64:
+			if err := chat.ValidateDocumentSize(part, providerName, maxDocumentBytes); err != nil {
+				return nil, false, err
+			}
+			out = append(out, responses.ResponseInputContentUnionParam{
+				OfInputFile: &responses.ResponseInputFileParam{
+					FileData: openai.String(fmt.Sprintf("data:%s;base64,%s", chat.DocumentMIMEType(part), strings.TrimSpace(part.DataBase64))),
+					Filename: openai.String(documentFilename(part)),
+				},
+			})
+		case chat.PartTypeDocumentText:
+			// Plain-text documents are sent as text, headed by their title;
+			// input_file takes files.
+			if err := chat.ValidateDocumentSize(part, providerName, maxDocumentBytes); err != nil {
+				return nil, false, err
+			}
+			text := part.Text
+			if title := strings.TrimSpace(part.Title); title != "" {
+				text = title + "\n\n" + text
+			}
+			out = append(out, responses.ResponseInputContentUnionParam{
+				OfInputText: &responses.ResponseInputTextParam{Text: text},
+			})
 		default:
 			return nil, false, fmt.Errorf("unsupported part type %q", part.Type)
 		}
@@ -1123,6 +1239,29 @@ func buildUserInputContent(msg chat.Message) (responses.ResponseInputMessageCont
 	return out, true, nil
 }
 
+// documentFilename returns the filename sent with input_file data, which the
+// API requires: the part title, or "document" with an extension for PDFs.
+func documentFilename(part chat.Part) string {
+	if title := strings.TrimSpace(part.Title); title != "" {
+		return title
+	}
+	if chat.DocumentMIMEType(part) == "application/pdf" {
+		return "document.pdf"
+	}
+	return "document"
+}
+
+// inputAudioContent returns an input_audio content part. openai-go has the
+// input_audio param but not its content union variant, so the part is sent
+// as raw JSON in the input_text slot.
+func inputAudioContent(data, format string) responses.ResponseInputContentUnionParam {
+	raw, _ := json.Marshal(responses.ResponseInputAudioParam{
+		InputAudio: responses.ResponseInputAudioInputAudioParam{Data: data, Format: format},
+	})
+	text := param.Override[responses.ResponseInputTextParam](json.RawMessage(raw))
+	return responses.ResponseInputContentUnionParam{OfInputText: &text}
+}
+
 func toResult(resp *responses.Response) *chat.Result {
 	if resp == nil {
 		return &chat.Result{Warnings: []string{"openai responses response is nil"}}
@@ -1155,6 +1294,16 @@ func toResult(resp *responses.Response) *chat.Result {
 					Parts:   parts,
 				})
 			}
+		case responses.ResponseOutputItemImageGenerationCall:
+			image, ok := imageGenerationPart(out)
+			if !ok {
+				continue
+			}
+			result.Parts = append(result.Parts, image)
+			textMessages = append(textMessages, chat.Message{
+				Role:  chat.RoleAssistant,
+				Parts: []chat.Part{image},
+			})
 		case responses.ResponseFunctionToolCall:
 			toolCalls = append(toolCalls, chat.ToolCall{
 				ID:   out.CallID,
@@ -1203,10 +1352,36 @@ func toResult(resp *responses.Response) *chat.Result {
 	if reasoning != nil && (len(reasoning.Summary) > 0 || len(reasoning.Blocks) > 0) {
 		result.Reasoning = reasoning
 	}
+	applyResponseFinishReason(result, resp)
 	chat.EnsureResultParts(result)
 	return result
 }
 
+// applyResponseFinishReason derives the finish reason from the response
+// status, or from the incomplete reason when the output was cut short.
+func applyResponseFinishReason(result *chat.Result, resp *responses.Response) {
+	raw := string(resp.Status)
+	if resp.Status == responses.ResponseStatusIncomplete && resp.IncompleteDetails.Reason != "" {
+		raw = resp.IncompleteDetails.Reason
+	}
+	result.SetFinishReason(raw)
+	if result.FinishReason != chat.FinishReasonStop {
+		return
+	}
+	for _, item := range resp.Output {
+		msg, ok := item.AsAny().(responses.ResponseOutputMessage)
+		if !ok {
+			continue
+		}
+		for _, content := range msg.Content {
+			if content.Type == "refusal" {
+				result.FinishReason = chat.FinishReasonRefusal
+				return
+			}
+		}
+	}
+}
+
 func responseUsageToChatUsage(usage responses.ResponseUsage) chat.Usage {
 	inputTokens := int(usage.InputTokens)
 	outputTokens := int(usage.OutputTokens)
@@ -1248,6 +1423,27 @@ func responseUsageToChatUsage(usage responses.ResponseUsage) chat.Usage {
 	}
 }
 
+// imageGenerationPart returns the image_base64 part of an
+// image_generation_call item, or false when it has no image. The MIME type
+// follows the output_format of the call, which defaults to png.
+func imageGenerationPart(call responses.ResponseOutputItemImageGenerationCall) (chat.Part, bool) {
+	if call.Result == "" {
+		return chat.Part{}, false
+	}
+	var extra struct {
+		OutputFormat string `json:"output_format"`
+	}
+	_ = json.Unmarshal([]byte(call.RawJSON()), &extra)
+	mimeType := "image/png"
+	switch extra.OutputFormat {
+	case "jpeg", "webp":
+		mimeType = "image/" + extra.OutputFormat
+	}
+	image := chat.ImageBase64Part(mimeType, call.Result)
+	image.ID = call.ID
+	return image, true
+}
+
 func extractOutputMessage(msg responses.ResponseOutputMessage) (string, []chat.Part) {
 	var text strings.Builder
 	parts := make([]chat.Part, 0, len(msg.Content))
@@ -1270,12 +1466,20 @@ func responseStatusError(resp *responses.Response) error {
 	}
 	switch resp.Status {
 	case responses.ResponseStatusFailed:
+		message := "openai responses failed"
 		if strings.TrimSpace(resp.Error.Message) != "" {
-			return fmt.Errorf("openai responses failed: %s", resp.Error.Message)
+			message = "openai responses failed: " + resp.Error.Message
 		}
-		return fmt.Errorf("openai responses failed")
+		return apierror.New(providerName, 0, string(resp.Error.Code), "", message)
 	case responses.ResponseStatusIncomplete:
 		reason := strings.TrimSpace(resp.IncompleteDetails.Reason)
+		if reason == "max_output_tokens" {
+			// Truncated output is returned with FinishReasonLength.
+			return nil
+		}
+		if reason == "content_filter" {
+			return apierror.New(providerName, 0, reason, "", "openai responses incomplete: "+reason)
+		}
 		if reason != "" {
 			return fmt.Errorf("openai responses incomplete: %s", reason)
 		}
@@ -1378,7 +1582,7 @@ func consumeResponseStream(stream *ssestream.Stream[responses.ResponseStreamEven
 		}
 	}
 	if err := stream.Err(); err != nil {
-		return nil, err
+		return nil, oaicompat.WrapError(providerName, err)
 	}
 	result, err := finalizeStreamResult(state)
 	if err != nil {
@@ -1386,9 +1590,11 @@ func consumeResponseStream(stream *ssestream.Stream[responses.ResponseStreamEven
 	}
 	if onStream != nil {
 		if err := onStream(chat.StreamEvent{
-			Done:  true,
-			Usage: &result.Usage,
-			Raw:   state.completed,
+			Done:            true,
+			Usage:           &result.Usage,
+			Raw:             state.completed,
+			FinishReason:    result.FinishReason,
+			RawFinishReason: result.RawFinishReason,
 		}); err != nil {
 			return nil, err
 		}
@@ -1402,6 +1608,13 @@ func processStreamEvent(ev responses.ResponseStreamEventUnion, state *responseSt
 		registerStreamOutputItem(event.Item, int(event.OutputIndex), state)
 	case responses.ResponseOutputItemDoneEvent:
 		registerStreamOutputItem(event.Item, int(event.OutputIndex), state)
+		call, ok := event.Item.AsAny().(responses.ResponseOutputItemImageGenerationCall)
+		if !ok || onStream == nil {
+			return nil
+		}
+		if image, ok := imageGenerationPart(call); ok {
+			return onStream(chat.StreamEvent{Part: &image, Raw: ev})
+		}
 	case responses.ResponseTextDeltaEvent:
 		if event.Delta == "" {
 			return nil
@@ -1513,6 +1726,8 @@ func processStreamEvent(ev responses.ResponseStreamEventUnion, state *responseSt
 		state.completed = &event.Response
 	case responses.ResponseFailedEvent:
 		state.completed = &event.Response
+	case responses.ResponseErrorEvent:
+		return apierror.New(providerName, 0, event.Code, "", event.Message)
 	}
 	return nil
 }
@@ -1541,6 +1756,7 @@ func finalizeStreamResult(state *responseStreamState) (*chat.Result, error) {
 	if fallback := accumulatedStreamToolCalls(state); len(fallback) > 0 {
 		result.ToolCalls = mergeStreamToolCalls(result.ToolCalls, fallback)
 		ensureResultToolCallMessage(result)
+		applyResponseFinishReason(result, state.completed)
 	}
 	return result, nil
 }
@@ -102,6 +102,101 @@ func TestBuildParamsMapsResponsesRequest(t *testing.T) {
 	}
 }
 
+func TestBuildParamsMapsUserAudioBase64Part(t *testing.T) {
+	req := &chat.Request{
+		Model: "gpt-audio",
+		Messages: []chat.Message{
+			chat.UserParts(chat.AudioBase64Part("audio/wav", "UklGRg==")),
+		},
+	}
+
+	params, err := buildParams(req, "", false)
+	if err != nil {
+		t.Fatalf("buildParams: %v", err)
+	}
+	data, err := json.Marshal(params.Input)
+	if err != nil {
+		t.Fatalf("marshal input: %v", err)
+	}
+	want := `"content":[{"input_audio":{"data":"UklGRg==","format":"wav"},"type":"input_audio"}]`
+	if !strings.Contains(string(data), want) {
+		t.Fatalf("expected input_audio content, got %s", data)
+	}
+
+	req.Messages = []chat.Message{chat.UserParts(chat.AudioURLPart("audio/wav", "https://example.com/a.wav"))}
+	if _, err := buildParams(req, "", false); err == nil {
+		t.Fatalf("expected audio_url to be rejected")
+	}
+}
+
+func TestBuildParamsMapsUserDocumentParts(t *testing.T) {
+	pdf := chat.DocumentBase64Part("", "JVBERi0=")
+	pdf.Title = "report.pdf"
+	req := &chat.Request{
+		Model: "gpt-5.4",
+		Messages: []chat.Message{
+			chat.UserParts(
+				pdf,
+				chat.DocumentURLPart("https://example.com/a.pdf"),
+				chat.DocumentTextPart("notes", "hello"),
+				chat.DocumentTextPart("", "bye"),
+			),
+		},
+	}
+
+	params, err := buildParams(req, "", false)
+	if err != nil {
+		t.Fatalf("buildParams: %v", err)
+	}
+	content := params.Input.OfInputItemList[0].OfMessage.Content.OfInputItemContentList
+	if len(content) != 4 {
+		t.Fatalf("expected 4 content items, got %d", len(content))
+	}
+	if file := content[0].OfInputFile; file == nil || file.FileData.Value != "data:application/pdf;base64,JVBERi0=" || file.Filename.Value != "report.pdf" {
+		t.Fatalf("unexpected input_file data item: %#v", content[0])
+	}
+	if file := content[1].OfInputFile; file == nil || file.FileURL.Value != "https://example.com/a.pdf" {
+		t.Fatalf("unexpected input_file url item: %#v", content[1])
+	}
+	if content[2].OfInputText == nil || content[2].OfInputText.Text != "notes\n\nhello" {
+		t.Fatalf("unexpected text document item: %#v", content[2])
+	}
+	if content[3].OfInputText == nil || content[3].OfInputText.Text != "bye" {
+		t.Fatalf("unexpected untitled text document item: %#v", content[3])
+	}
+}
+
+func TestBuildParamsReplaysAssistantImageParts(t *testing.T) {
+	image := chat.ImageBase64Part("image/png", "QUJD")
+	image.ID = "ig_1"
+	req := &chat.Request{
+		Model: "gpt-5.4",
+		Messages: []chat.Message{
+			chat.User("draw a cat"),
+			chat.AssistantParts(image, chat.TextPart("Here it is.")),
+			chat.User("make it blue"),
+		},
+	}
+
+	params, err := buildParams(req, "", false)
+	if err != nil {
+		t.Fatalf("buildParams: %v", err)
+	}
+	data, err := json.Marshal(params.Input.OfInputItemList[1:3])
+	if err != nil {
+		t.Fatalf("marshal input: %v", err)
+	}
+	want := `[{"result":"QUJD","id":"ig_1","status":"completed","type":"image_generation_call"},{"content":"Here it is.","role":"assistant"}]`
+	if string(data) != want {
+		t.Fatalf("unexpected replayed items:\n got %s\nwant %s", data, want)
+	}
+
+	req.Messages[1] = chat.AssistantParts(chat.ImageBase64Part("image/png", "QUJD"))
+	if _, err := buildParams(req, "", false); err == nil || !strings.Contains(err.Error(), "image_generation_call") {
+		t.Fatalf("expected missing id error, got %v", err)
+	}
+}
+
 func TestChatAggregatesEventStreamOnNonStreamingRequest(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path != "/v1/responses" {
@@ -389,6 +484,26 @@ func TestBuildParamsAllowsRawInputWithoutMessages(t *testing.T) {
 	}
 }
 
+func TestBuildParamsMapsJSONSchemaResponseFormat(t *testing.T) {
+	req, err := chat.BuildRequest(
+		chat.WithModel("gpt-5.4"),
+		chat.WithMessages(chat.User("hello")),
+		chat.WithJSONSchema("person", []byte(`{"type":"object","properties":{"name":{"type":"string"}}}`), false),
+	)
+	if err != nil {
+		t.Fatalf("build request: %v", err)
+	}
+
+	params, err := buildParams(req, "", false)
+	if err != nil {
+		t.Fatalf("buildParams: %v", err)
+	}
+	format := params.Text.Format.OfJSONSchema
+	if format == nil || format.Name != "person" || format.Schema["type"] != "object" || format.Strict.Value {
+		t.Fatalf("unexpected text format: %#v", params.Text.Format)
+	}
+}
+
 func TestBuildParamsMapsPromptCacheRetention(t *testing.T) {
 	req := &chat.Request{
 		Model: "gpt-5.4",
@@ -1373,6 +1488,68 @@ func TestToResultParsesResponsesOutput(t *testing.T) {
 	}
 }
 
+func TestToResultParsesImageGenerationCall(t *testing.T) {
+	resp := mustDecodeResponse(t, map[string]any{
+		"id":                  "resp_img",
+		"model":               "gpt-5.4",
+		"object":              "response",
+		"parallel_tool_calls": true,
+		"tool_choice":         "auto",
+		"tools":               []any{},
+		"status":              "completed",
+		"output": []any{
+			map[string]any{
+				"id":            "ig_1",
+				"type":          "image_generation_call",
+				"status":        "completed",
+				"output_format": "webp",
+				"result":        "QUJD",
+			},
+			map[string]any{
+				"id":     "msg_1",
+				"type":   "message",
+				"role":   "assistant",
+				"status": "completed",
+				"content": []any{
+					map[string]any{"type": "output_text", "text": "Here it is.", "annotations": []any{}},
+				},
+			},
+		},
+	})
+
+	result := toResult(resp)
+	if result.Text != "Here it is." || len(result.Parts) != 2 {
+		t.Fatalf("unexpected result: %#v", result)
+	}
+	image := result.Parts[0]
+	if image.Type != chat.PartTypeImageBase64 || image.MIMEType != "image/webp" || image.DataBase64 != "QUJD" || image.ID != "ig_1" {
+		t.Fatalf("unexpected image part: %#v", image)
+	}
+	if len(result.Messages) != 2 || result.Messages[0].Parts[0].ID != "ig_1" || result.Messages[1].Content != "Here it is." {
+		t.Fatalf("unexpected messages: %#v", result.Messages)
+	}
+
+	var streamed []chat.Part
+	state := &responseStreamState{toolCalls: map[int]streamToolCallState{}}
+	ev := mustDecodeStreamEvent(t, map[string]any{
+		"type":            "response.output_item.done",
+		"output_index":    0,
+		"sequence_number": 1,
+		"item":            map[string]any{"id": "ig_1", "type": "image_generation_call", "status": "completed", "result": "QUJD"},
+	})
+	if err := processStreamEvent(ev, state, false, func(event chat.StreamEvent) error {
+		if event.Part != nil {
+			streamed = append(streamed, *event.Part)
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("processStreamEvent: %v", err)
+	}
+	if len(streamed) != 1 || streamed[0].MIMEType != "image/png" || streamed[0].ID != "ig_1" {
+		t.Fatalf("unexpected streamed image parts: %#v", streamed)
+	}
+}
+
 func TestToResultIncludesSakanaOrchestrationTokens(t *testing.T) {
 	resp := mustDecodeResponse(t, map[string]any{
 		"id":                  "resp_sakana",
@@ -1456,10 +1633,10 @@ func TestResponseStatusError(t *testing.T) {
 				"model":  "gpt-5.4",
 				"status": "incomplete",
 				"incomplete_details": map[string]any{
-					"reason": "max_output_tokens",
+					"reason": "content_filter",
 				},
 			}),
-			want: "max_output_tokens",
+			want: "content_filter",
 		},
 	}
 
@@ -1473,6 +1650,62 @@ func TestResponseStatusError(t *testing.T) {
 	}
 }
 
+func TestToResultFinishReason(t *testing.T) {
+	message := func(content map[string]any) map[string]any {
+		return map[string]any{"id": "msg_1", "type": "message", "role": "assistant", "status": "completed", "content": []any{content}}
+	}
+	cases := []struct {
+		name    string
+		payload map[string]any
+		want    chat.FinishReason
+		wantRaw string
+	}{
+		{
+			name:    "completed",
+			payload: map[string]any{"status": "completed", "output": []any{message(map[string]any{"type": "output_text", "text": "hi", "annotations": []any{}})}},
+			want:    chat.FinishReasonStop,
+			wantRaw: "completed",
+		},
+		{
+			name: "truncated",
+			payload: map[string]any{
+				"status":             "incomplete",
+				"incomplete_details": map[string]any{"reason": "max_output_tokens"},
+				"output":             []any{message(map[string]any{"type": "output_text", "text": "partial", "annotations": []any{}})},
+			},
+			want:    chat.FinishReasonLength,
+			wantRaw: "max_output_tokens",
+		},
+		{
+			name:    "tool call",
+			payload: map[string]any{"status": "completed", "output": []any{map[string]any{"id": "fc_1", "type": "function_call", "call_id": "call_1", "name": "get_weather", "arguments": "{}"}}},
+			want:    chat.FinishReasonToolCalls,
+			wantRaw: "completed",
+		},
+		{
+			name:    "refusal",
+			payload: map[string]any{"status": "completed", "output": []any{message(map[string]any{"type": "refusal", "refusal": "no"})}},
+			want:    chat.FinishReasonRefusal,
+			wantRaw: "completed",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.payload["id"] = "resp_1"
+			tc.payload["object"] = "response"
+			tc.payload["model"] = "gpt-5.4"
+			resp := mustDecodeResponse(t, tc.payload)
+			if err := responseStatusError(resp); err != nil {
+				t.Fatalf("unexpected status error: %v", err)
+			}
+			result := toResult(resp)
+			if result.FinishReason != tc.want || result.RawFinishReason != tc.wantRaw {
+				t.Fatalf("got %q (%q), want %q (%q)", result.FinishReason, result.RawFinishReason, tc.want, tc.wantRaw)
+			}
+		})
+	}
+}
+
 func TestProcessStreamEventParsesDeltasAndCompletion(t *testing.T) {
 	events := []responses.ResponseStreamEventUnion{
 		mustDecodeStreamEvent(t, map[string]any{
@@ -0,0 +1,60 @@
+package uniai
+
+import (
+	"context"
+
+	"github.com/quailyquaily/uniai/chat"
+	"github.com/quailyquaily/uniai/internal/ratelimit"
+	"github.com/quailyquaily/uniai/internal/tokenest"
+)
+
+// RateLimiter enforces client-side requests-per-minute and tokens-per-minute
+// budgets per provider/model. It may be shared by several clients so batch
+// and interactive traffic draw from the same budget.
+type RateLimiter = ratelimit.Limiter
+
+// RateLimit is a per-minute budget. Zero fields are unlimited.
+type RateLimit = ratelimit.Limit
+
+// RateLimitConfig configures a RateLimiter.
+type RateLimitConfig = ratelimit.Config
+
+// RateLimitError is returned by a fail-fast RateLimiter when a request does
+// not fit the budget. It matches ErrClientRateLimited.
+type RateLimitError = ratelimit.Error
+
+// ErrClientRateLimited matches requests rejected by a fail-fast RateLimiter
+// before reaching the provider.
+var ErrClientRateLimited = ratelimit.ErrLimited
+
+// NewRateLimiter returns a RateLimiter for cfg.
+func NewRateLimiter(cfg RateLimitConfig) *RateLimiter {
+	return ratelimit.New(cfg)
+}
+
+// chatProviderLimited sends one provider request through the configured rate
+// limiter. The token estimate covers the input plus MaxTokens and is replaced
+// by the reported usage once the provider responds.
+func (c *Client) chatProviderLimited(ctx context.Context, providerName string, req *chat.Request) (*chat.Result, error) {
+	if c.cfg.RateLimiter == nil {
+		return c.chatProvider(ctx, providerName, req)
+	}
+	estimate := tokenest.Request(req)
+	if req.Options.MaxTokens != nil {
+		estimate += *req.Options.MaxTokens
+	}
+	model := c.resolveChatRequestedModel(providerName, req)
+	reservation, err := c.cfg.RateLimiter.Acquire(ctx, providerName, model, estimate, int(req.Options.Priority))
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.chatProvider(ctx, providerName, req)
+	if resp != nil {
+		if used := resp.Usage.TotalTokens; used > 0 {
+			reservation.Reconcile(used)
+		} else if used := resp.Usage.InputTokens + resp.Usage.OutputTokens; used > 0 {
+			reservation.Reconcile(used)
+		}
+	}
+	return resp, err
+}
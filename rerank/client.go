@@ -4,14 +4,20 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"strings"
 
+	"github.com/quailyquaily/uniai/internal/httputil"
 	"github.com/quailyquaily/uniai/internal/providers/jina"
+	"github.com/quailyquaily/uniai/internal/retry"
 )
 
 type Config struct {
 	JinaAPIKey  string
 	JinaAPIBase string
+
+	Retry      retry.Policy
+	HTTPClient *http.Client
 }
 
 type Client struct {
@@ -23,14 +29,16 @@ func New(cfg Config) *Client {
 }
 
 func (c *Client) Rerank(ctx context.Context, opts ...Option) (*Result, error) {
-	req := BuildRequest(opts...)
-	provider := req.Provider
-	if provider == "" {
-		provider = pickProviderByModel(req.Model)
-	}
-	if provider == "" {
-		return nil, fmt.Errorf("provider not set")
+	return c.Do(ctx, BuildRequest(opts...))
+}
+
+// Do runs a built request. An empty Provider is resolved from the model.
+func (c *Client) Do(ctx context.Context, req *Request) (*Result, error) {
+	if err := ResolveProvider(req); err != nil {
+		return nil, err
 	}
+	ctx = httputil.WithClient(ctx, c.cfg.HTTPClient)
+	provider := req.Provider
 
 	var (
 		respData []byte
@@ -38,7 +46,10 @@ func (c *Client) Rerank(ctx context.Context, opts ...Option) (*Result, error) {
 	)
 	switch provider {
 	case "jina":
-		respData, err = jina.Rerank(ctx, c.cfg.JinaAPIKey, c.cfg.JinaAPIBase, req.Model, req.Query, toJinaDocs(req.Documents), req.TopN, req.ReturnDocuments)
+		_, err = retry.Do(ctx, c.cfg.Retry, func() (err error) {
+			respData, err = jina.Rerank(ctx, c.cfg.JinaAPIKey, c.cfg.JinaAPIBase, req.Model, req.Query, toJinaDocs(req.Documents), req.TopN, req.ReturnDocuments)
+			return err
+		})
 	default:
 		return nil, fmt.Errorf("unknown provider: %s", provider)
 	}
@@ -53,6 +64,17 @@ func (c *Client) Rerank(ctx context.Context, opts ...Option) (*Result, error) {
 	return &out, nil
 }
 
+// ResolveProvider sets req.Provider from the model when it is empty.
+func ResolveProvider(req *Request) error {
+	if req.Provider == "" {
+		req.Provider = pickProviderByModel(req.Model)
+	}
+	if req.Provider == "" {
+		return fmt.Errorf("provider not set")
+	}
+	return nil
+}
+
 func pickProviderByModel(model string) string {
 	if strings.Contains(model, "jina") {
 		return "jina"
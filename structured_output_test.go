@@ -0,0 +1,58 @@
+package uniai
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/quailyquaily/uniai/chat"
+)
+
+func TestChatJSONSchemaRepairsOpenAIOutput(t *testing.T) {
+	var body map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"id":"c1","object":"chat.completion","model":"gpt-test","choices":[{"index":0,"finish_reason":"stop","message":{"role":"assistant","content":"Here you go: {\"name\":\"Ada\",\"tags\":[\"math\",],}"}}]}`)
+	}))
+	defer server.Close()
+
+	client := New(Config{Provider: "openai", OpenAIAPIKey: "test-key", OpenAIAPIBase: server.URL + "/v1", OpenAIModel: "gpt-test"})
+	resp, err := client.Chat(context.Background(),
+		chat.WithMessages(chat.User("who?")),
+		chat.WithJSONSchema("person", []byte(`{"type":"object","properties":{"name":{"type":"string"}}}`), false),
+	)
+	if err != nil {
+		t.Fatalf("chat: %v", err)
+	}
+	format, _ := body["response_format"].(map[string]any)
+	if format["type"] != "json_schema" {
+		t.Fatalf("unexpected response_format: %#v", body["response_format"])
+	}
+	if string(resp.JSON) != `{"name":"Ada","tags":["math"]}` {
+		t.Fatalf("unexpected JSON: %s", resp.JSON)
+	}
+}
+
+func TestChatJSONObjectFromAnthropicTool(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"content":[{"type":"tool_use","id":"toolu_1","name":"json_output","input":{"ok":true}}],"model":"claude-sonnet-test","stop_reason":"tool_use","usage":{"input_tokens":1,"output_tokens":1}}`)
+	}))
+	defer server.Close()
+
+	client := New(Config{Provider: "anthropic", AnthropicAPIKey: "test-key", AnthropicAPIBase: server.URL, AnthropicModel: "claude-sonnet-test"})
+	resp, err := client.Chat(context.Background(),
+		chat.WithMessages(chat.User("answer in JSON")),
+		chat.WithJSONObject(),
+	)
+	if err != nil {
+		t.Fatalf("chat: %v", err)
+	}
+	if string(resp.JSON) != `{"ok":true}` || resp.Text != `{"ok":true}` || resp.FinishReason != chat.FinishReasonStop {
+		t.Fatalf("unexpected result: json=%s text=%q finish=%q", resp.JSON, resp.Text, resp.FinishReason)
+	}
+}
@@ -16,7 +16,7 @@ import (
 
 func (c *Client) chatWithToolEmulation(ctx context.Context, providerName string, req *chat.Request, priorResp *chat.Result, userOnStream chat.OnStreamFunc) (*chat.Result, error) {
 	if len(req.Tools) == 0 {
-		return c.chatOnce(ctx, providerName, req)
+		return c.chatCall(ctx, providerName, req)
 	}
 	debugFn := req.Options.DebugFn
 	diag.LogJSON(c.cfg.Debug, debugFn, "tool_emulation.start", map[string]any{
@@ -38,13 +38,13 @@ func (c *Client) chatWithToolEmulation(ctx context.Context, providerName string,
 		if resp == nil {
 			return
 		}
-		addChatUsage(&usagePrefix, resp.Usage)
+		usagePrefix = usagePrefix.Add(resp.Usage)
 		cost, ok := c.estimateChatUsageCost(providerName, pricingReq, c.resolveChatCostModel(providerName, pricingReq, resp), resp.Usage)
 		accumulateChatUsageCost(&prefixCost, &prefixCostComplete, resp.Usage, cost, ok)
 	}
 	accumulatePrefix(req, priorResp)
 
-	decisionResp, err := c.chatOnce(ctx, providerName, decisionReq)
+	decisionResp, err := c.chatCall(ctx, providerName, decisionReq)
 	if err != nil {
 		return nil, err
 	}
@@ -69,15 +69,16 @@ func (c *Client) chatWithToolEmulation(ctx context.Context, providerName string,
 		finalReq := buildFinalRequest(req)
 		if userOnStream != nil {
 			prefixUsage := withAggregatedChatCost(usagePrefix, prefixCost, prefixCostComplete)
-			onStream := wrapPrefixedChatStreamUsage(prefixUsage, prefixCostComplete, userOnStream)
+			onStream := wrapPrefixedChatStreamUsage(prefixUsage, userOnStream)
 			finalReq.Options.OnStream = c.wrapChatStreamCost(providerName, finalReq, onStream)
 		}
-		resp, err := c.chatOnce(ctx, providerName, finalReq)
+		resp, err := c.chatCall(ctx, providerName, finalReq)
 		if resp != nil {
-			usage := mergeChatUsage(usagePrefix, resp.Usage)
+			usage := usagePrefix.Add(resp.Usage)
+			usage.Cost = nil
 			finalCost, ok := c.estimateChatUsageCost(providerName, finalReq, c.resolveChatCostModel(providerName, finalReq, resp), resp.Usage)
-			if prefixCostComplete && (finalCost != nil || !hasPricableUsage(resp.Usage)) && (ok || !hasPricableUsage(resp.Usage)) {
-				usage.Cost = mergeChatUsageCost(prefixCost, finalCost)
+			if prefixCostComplete && (finalCost != nil || !resp.Usage.Pricable()) && (ok || !resp.Usage.Pricable()) {
+				usage.Cost = prefixCost.Add(finalCost)
 			}
 			resp.Usage = usage
 			resp.Warnings = append(resp.Warnings, "tool calls emulated")
@@ -109,8 +110,12 @@ func (c *Client) chatWithToolEmulation(ctx context.Context, providerName string,
 		Model:     decisionResp.Model,
 		ToolCalls: calls,
 		Usage:     withAggregatedChatCost(usagePrefix, prefixCost, prefixCostComplete),
-		Raw:       decisionResp.Raw,
-		Warnings:  []string{"tool calls emulated"},
+		// The decision request stopped naturally; report it as a tool call
+		// stop like an upstream tool-calling response.
+		FinishReason:    chat.FinishReasonToolCalls,
+		RawFinishReason: decisionResp.RawFinishReason,
+		Raw:             decisionResp.Raw,
+		Warnings:        []string{"tool calls emulated"},
 	}
 	if dropped > 0 {
 		resp.Warnings = append(resp.Warnings, "unknown tool calls dropped")
@@ -119,7 +124,7 @@ func (c *Client) chatWithToolEmulation(ctx context.Context, providerName string,
 }
 
 func accumulateChatUsageCost(dst **chat.UsageCost, complete *bool, usage chat.Usage, cost *chat.UsageCost, ok bool) {
-	if complete == nil || !*complete || !hasPricableUsage(usage) {
+	if complete == nil || !*complete || !usage.Pricable() {
 		return
 	}
 	if !ok || cost == nil {
@@ -127,13 +132,13 @@ func accumulateChatUsageCost(dst **chat.UsageCost, complete *bool, usage chat.Us
 		*complete = false
 		return
 	}
-	*dst = mergeChatUsageCost(*dst, cost)
+	*dst = (*dst).Add(cost)
 }
 
 func withAggregatedChatCost(usage chat.Usage, cost *chat.UsageCost, complete bool) chat.Usage {
 	usage.Cost = nil
 	if complete {
-		usage.Cost = cloneChatUsageCost(cost)
+		usage.Cost = cost.Add(nil) // a copy, or nil
 	}
 	return usage
 }
@@ -148,6 +153,7 @@ func buildToolDecisionRequest(req *chat.Request) (*chat.Request, error) {
 	out.ToolChoice = nil
 	out.Options.ToolsEmulationMode = chat.ToolsEmulationOff
 	out.Options.OnStream = nil // decision output is JSON; must not be streamed
+	out.Options.ResponseFormat = nil
 	out.Messages = filterNonSystemMessages(out.Messages)
 	out.Messages = maskToolDecisionMessages(out.Messages)
 	out.Messages = append([]chat.Message{
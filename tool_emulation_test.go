@@ -99,50 +99,6 @@ func TestEnforceToolChoice(t *testing.T) {
 	}
 }
 
-func TestMergeChatUsageAggregatesFields(t *testing.T) {
-	usage := mergeChatUsage(
-		chat.Usage{
-			InputTokens:  10,
-			OutputTokens: 2,
-			TotalTokens:  12,
-			Cache: chat.UsageCache{
-				CachedInputTokens:        3,
-				CacheCreationInputTokens: 1,
-				Details: map[string]int{
-					"ephemeral_5m_input_tokens": 1,
-				},
-			},
-			Cost: &chat.UsageCost{Currency: "USD", Total: 1},
-		},
-		chat.Usage{
-			InputTokens:  7,
-			OutputTokens: 4,
-			TotalTokens:  11,
-			Cache: chat.UsageCache{
-				CachedInputTokens:        2,
-				CacheCreationInputTokens: 5,
-				Details: map[string]int{
-					"ephemeral_5m_input_tokens": 2,
-					"ephemeral_1h_input_tokens": 3,
-				},
-			},
-		},
-	)
-
-	if usage.InputTokens != 17 || usage.OutputTokens != 6 || usage.TotalTokens != 23 {
-		t.Fatalf("unexpected aggregate usage: %#v", usage)
-	}
-	if usage.Cache.CachedInputTokens != 5 || usage.Cache.CacheCreationInputTokens != 6 {
-		t.Fatalf("unexpected aggregate cache usage: %#v", usage.Cache)
-	}
-	if usage.Cache.Details["ephemeral_5m_input_tokens"] != 3 || usage.Cache.Details["ephemeral_1h_input_tokens"] != 3 {
-		t.Fatalf("unexpected aggregate cache details: %#v", usage.Cache.Details)
-	}
-	if usage.Cost != nil {
-		t.Fatalf("expected aggregate usage to clear cost, got %#v", usage.Cost)
-	}
-}
-
 func TestWrapPrefixedChatStreamUsageMergesPrefixCostAfterFinalRequestPricing(t *testing.T) {
 	client := New(Config{
 		Provider:    "openai",
@@ -179,7 +135,7 @@ func TestWrapPrefixedChatStreamUsageMergesPrefixCostAfterFinalRequestPricing(t *
 			Input:     0.5,
 			Total:     0.5,
 		},
-	}, true, func(ev chat.StreamEvent) error {
+	}, func(ev chat.StreamEvent) error {
 		got = ev.Usage
 		return nil
 	})